@@ -22,8 +22,6 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
-
-	"github.com/spf13/viper"
 )
 
 type Attrs struct {
@@ -82,7 +80,9 @@ type CredAttr interface {
 	updateInternalValue(*big.Int) error
 	setInternalValue() error
 	isKnown() bool
+	isHidden() bool
 	hasVal() bool
+	clone() CredAttr
 	Name() string
 	String() string
 
@@ -102,10 +102,11 @@ const (
 	greaterThan
 	greaterThanOrEqual
 	equal
+	in
 	none
 )
 
-var attrCondStr = []string{"lt", "lte", "gt", "gte", "equal", "none"}
+var attrCondStr = []string{"lt", "lte", "gt", "gte", "equal", "in", "none"}
 
 func (c AttrCond) String() string {
 	return attrCondStr[c]
@@ -126,8 +127,11 @@ func parseAttrCond(cond string) (AttrCond, error) {
 // access to some internet service (like electronic newspaper), attributes could be
 // Type (for example only news related to politics) of the service and Date of Expiration.
 type Attr struct {
-	name   string
-	Known  bool
+	name  string
+	Known bool
+	// Hidden attributes are known only to the credential receiver,
+	// the issuer never learns their values nor commitments to them.
+	Hidden bool
 	ValSet bool
 	Val    *big.Int
 	cond   AttrCond
@@ -154,6 +158,10 @@ func (a *Attr) isKnown() bool {
 	return a.Known
 }
 
+func (a *Attr) isHidden() bool {
+	return a.Hidden
+}
+
 func (a *Attr) internalValue() *big.Int {
 	return a.Val
 }
@@ -168,8 +176,10 @@ func (a *Attr) Name() string {
 
 func (a *Attr) String() string {
 	tag := "Known"
-	if !a.isKnown() {
-		tag = "revealed"
+	if a.isHidden() {
+		tag = "hidden"
+	} else if !a.isKnown() {
+		tag = "committed"
 	}
 	return fmt.Sprintf("%s (%s)", a.name, tag)
 }
@@ -199,17 +209,17 @@ func NewInt64Attr(name string, val int64, known bool) (*Int64Attr,
 }
 
 func (a *Int64Attr) setInternalValue() error {
-	a.Attr.Val = big.NewInt(int64(a.Val)) // FIXME
+	a.Attr.Val = encodeInt64(a.Val)
 	a.ValSet = true
 	return nil
 }
 
 func (a *Int64Attr) updateInternalValue(val *big.Int) error {
-	v, err := strconv.Atoi(val.String())
+	v, err := decodeInt64(val)
 	if err != nil {
-		return err
+		return fmt.Errorf("value of attribute %s: %s", a.Name(), err)
 	}
-	a.Val = int64(v)
+	a.Val = v
 	return nil
  }
 
@@ -223,6 +233,9 @@ func (a *Int64Attr) UpdateValue(n interface{}) error {
 		a.Val = int64(n.(int))
 	case int64:
 		a.Val = n.(int64)
+	default:
+		return fmt.Errorf("value of attribute %s is not an integer",
+			a.Name())
 	}
 	return a.setInternalValue()
 }
@@ -254,12 +267,26 @@ func (a *Int64Attr) ValidateAgainst(v interface{}) (bool, error) {
 	return res, nil
 }
 
+// clone returns a copy of the attribute that can be modified
+// independently of the original.
+func (a *Int64Attr) clone() CredAttr {
+	attr := *a.Attr
+	return &Int64Attr{
+		Val:  a.Val,
+		Attr: &attr,
+	}
+}
+
 func (a *Int64Attr) String() string {
 	return fmt.Sprintf("%s, type = %T", a.Attr.String(), a.Val)
 }
 
+// StrAttr is an attribute holding a string. With RawEncoding the
+// string has to fit into AttrBitLen bits, longer strings need
+// HashEncoding.
 type StrAttr struct {
-	Val string
+	Val      string
+	Encoding AttrEncoding
 	*Attr
 }
 
@@ -283,14 +310,19 @@ func NewStrAttr(name, val string, known bool) (*StrAttr,
 }
 
 func (a *StrAttr) setInternalValue() error {
-	a.Attr.Val = new(big.Int).SetBytes([]byte(a.Val)) // FIXME
+	a.Attr.Val = a.encode(a.Val)
 	a.ValSet = true
 	return nil
 }
 
+// updateInternalValue sets the internal value of the attribute to val.
+// With HashEncoding the value itself cannot be recovered, so only the
+// internal value is set.
 func (a *StrAttr) updateInternalValue(val *big.Int) error {
-	v := string(val.Bytes())
-	a.Val = v
+	a.Attr.Val = val
+	if a.Encoding == RawEncoding {
+		a.Val = string(val.Bytes())
+	}
 	return nil
 }
 
@@ -299,121 +331,171 @@ func (a *StrAttr) getValue() interface{} {
 }
 
 func (a *StrAttr) UpdateValue(s interface{}) error {
-	a.Val = s.(string)
+	v, ok := s.(string)
+	if !ok {
+		return fmt.Errorf("value of attribute %s is not string", a.Name())
+	}
+	a.Val = v
 	return a.setInternalValue()
 }
 
+// ValidateAgainst checks the attribute against v. For condition equal,
+// v has to be a string, for condition in, v has to be a list of strings
+// (see parseStrSet). Internal values are compared, so that attributes
+// with HashEncoding are compared by hash.
 func (a *StrAttr) ValidateAgainst(v interface{}) (bool, error) {
-	actual, ok := v.(string)
-	if !ok {
-		return false, fmt.Errorf("value provided for '%s' is not string",
-			a.Name())
+	switch a.cond {
+	case equal:
+		actual, ok := v.(string)
+		if !ok {
+			return false, fmt.Errorf("value provided for '%s' is not string",
+				a.Name())
+		}
+		return a.encode(actual).Cmp(a.internalValue()) == 0, nil
+	case in:
+		set, err := parseStrSet(v)
+		if err != nil {
+			return false, fmt.Errorf("value provided for '%s': %s", a.Name(),
+				err)
+		}
+		for _, s := range set {
+			if a.encode(s).Cmp(a.internalValue()) == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
 	}
 
-	if a.cond != equal {
-		return false, errors.New("invalid condition")
+	return false, errors.New("invalid condition")
+}
+
+// encode returns the internal value of the attribute for value s.
+func (a *StrAttr) encode(s string) *big.Int {
+	if a.Encoding == HashEncoding {
+		return hashValue([]byte(s))
+	}
+	return encodeStr(s)
+}
+
+// parseStrSet returns a list of strings from v, which is either
+// []string or []interface{} holding only strings.
+func parseStrSet(v interface{}) ([]string, error) {
+	switch set := v.(type) {
+	case []string:
+		return set, nil
+	case []interface{}:
+		res := make([]string, len(set))
+		for i, e := range set {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("set element %v is not string", e)
+			}
+			res[i] = s
+		}
+		return res, nil
 	}
 
-	return actual == a.Val, nil
+	return nil, fmt.Errorf("%v is not a set of strings", v)
 }
 
-func (a *StrAttr) String() string {
-	return fmt.Sprintf("%s, type = %T", a.Attr.String(), a.Val)
+// encodeStr returns the internal value of a string attribute with
+// RawEncoding.
+func encodeStr(s string) *big.Int {
+	return new(big.Int).SetBytes([]byte(s))
 }
 
-// FIXME make nicer
-// Hook to organization?
-func parseAttrs(v *viper.Viper) ([]CredAttr, *AttrCount, error) {
-	if !v.IsSet("attributes") {
-		return nil, nil, fmt.Errorf("missing attributes declaration")
-	}
-
-	specs := v.GetStringMap("attributes")
-	attrs := make([]CredAttr, len(specs))
-
-	var nKnown, nCommitted int
+// int64Offset is added to values of int64 attributes to get their
+// internal values, see encodeInt64.
+var int64Offset = new(big.Int).Lsh(big.NewInt(1), 63)
 
-	for name, val := range specs { // TODO enforce proper ordering with Index
-		data, ok := val.(map[string]interface{})
-		if !ok {
-			return nil, nil, fmt.Errorf("invalid configuration")
-		}
+// encodeInt64 returns the internal value of an int64 attribute. Since
+// attributes are exponents of at most AttrBitLen bits, negative values
+// cannot be used directly. Values are shifted by 2^63 instead, which
+// maps all int64 values to non-negative values of 64 bits and keeps
+// their order, so that predicates over them can still be proved.
+func encodeInt64(n int64) *big.Int {
+	return new(big.Int).Add(big.NewInt(n), int64Offset)
+}
 
-		index, ok := data["index"]
-		if !ok {
-			return nil, nil, fmt.Errorf("missing attribute index")
+// decodeInt64 returns the int64 value that internal value v encodes,
+// see encodeInt64.
+func decodeInt64(v *big.Int) (int64, error) {
+	n := new(big.Int).Sub(v, int64Offset)
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("%v is not an encoded int64 value", v)
+	}
+	return n.Int64(), nil
+}
+
+// encodeAttrValue returns the internal value that attribute a has
+// when its value is v. Integer attributes accept int and int64 values,
+// string attributes accept string values and date attributes accept
+// time.Time values and strings in the form of DateLayout. Boolean
+// attributes accept bool values and their string representations,
+// enumerated attributes accept their allowed values and bytes
+// attributes accept []byte values and base64 encoded strings.
+func encodeAttrValue(a CredAttr, v interface{}) (*big.Int, error) {
+	switch attr := a.(type) {
+	case *Int64Attr:
+		switch n := v.(type) {
+		case int:
+			return encodeInt64(int64(n)), nil
+		case int64:
+			return encodeInt64(n), nil
 		}
-		i, ok := index.(int)
-		if !ok {
-			return nil, nil, fmt.Errorf("Index must be an integer")
+	case *StrAttr:
+		if str, ok := v.(string); ok {
+			return attr.encode(str), nil
 		}
-		if i >= len(attrs) {
-			return nil, nil,
-			fmt.Errorf("Index too large for the provided number of attributes")
+	case *DateAttr:
+		if date, err := parseDate(v); err == nil {
+			return encodeInt64(daysOf(date)), nil
 		}
-		if attrs[i] != nil {
-			return nil, nil,
-				fmt.Errorf("duplicate index")
+	case *BoolAttr:
+		if b, err := parseBool(v); err == nil {
+			return encodeBool(b), nil
 		}
-
-
-		t, ok := data["type"]
-		if !ok {
-			return nil, nil, fmt.Errorf("missing type specifier")
+	case *EnumAttr:
+		if str, ok := v.(string); ok && attr.allows(str) {
+			return encodeStr(str), nil
 		}
-
-		known := true
-		k, ok := data["known"]
-		if ok {
-			res, err := strconv.ParseBool(k.(string))
-			if err != nil {
-				return nil, nil, fmt.Errorf("Known must be true or false")
-			}
-			known = res
+	case *BytesAttr:
+		if b, err := parseBytes(v); err == nil {
+			return attr.encode(b), nil
 		}
+	}
 
-		if known {
-			nKnown++
-		} else {
-			nCommitted++
-		}
+	return nil, fmt.Errorf("value %v is not valid for attribute %s", v,
+		a.Name())
+}
 
-		var condition AttrCond
-		cond, ok := data["cond"]
-		if !ok {
-			condition = none
-		} else {
-			c, err := parseAttrCond(cond.(string))
-			if err != nil {
-				return nil, nil, err
-			}
-			condition = c
-		}
+// clone returns a copy of the attribute that can be modified
+// independently of the original.
+func (a *StrAttr) clone() CredAttr {
+	attr := *a.Attr
+	return &StrAttr{
+		Val:      a.Val,
+		Encoding: a.Encoding,
+		Attr:     &attr,
+	}
+}
 
-		switch t {
-		case "string":
-			a, err := NewStrAttr(name, "", known) // FIXME
-			if err != nil {
-				return nil, nil, err
-			}
-			a.cond = condition
-			attrs[i] = a
-			a.Index = i
-		case "int64":
-			a, err := NewInt64Attr(name, 0, known) // FIXME
-			if err != nil {
-				return nil, nil, err
-			}
-			a.cond = condition
-			attrs[i] = a
-			a.Index = i
-		default:
-			return nil, nil, fmt.Errorf("unsupported attribute type: %s", t)
-		}
+func (a *StrAttr) String() string {
+	if a.Encoding == HashEncoding {
+		return fmt.Sprintf("%s, type = %T (%s)", a.Attr.String(), a.Val,
+			a.Encoding)
+	}
+	return fmt.Sprintf("%s, type = %T", a.Attr.String(), a.Val)
+}
 
-		i++
+// parseBool accepts either a boolean or its string representation.
+func parseBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return strconv.ParseBool(b)
 	}
 
-	// TODO Hidden params
-	return attrs, NewAttrCount(nKnown, nCommitted, 0), nil
+	return false, fmt.Errorf("not a boolean value: %v", v)
 }
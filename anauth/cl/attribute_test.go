@@ -18,10 +18,13 @@
 package cl
 
 import (
+	"math"
 	"math/big"
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseAttrCond(t *testing.T) {
@@ -40,7 +43,140 @@ func TestParseAttrCondInvalid(t *testing.T) {
 func TestNewIntAttribute(t *testing.T) {
 	a, err := NewInt64Attr("a", 100, true)
 	assert.NoError(t, err)
-	assert.Equal(t, big.NewInt(100).Cmp(a.internalValue()), 0)
+	assert.Equal(t, encodeInt64(100).Cmp(a.internalValue()), 0)
 	assert.True(t, a.isKnown())
 }
 
+func TestParseAttrs_Hidden(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"name": map[string]interface{}{
+			"index": 0,
+			"type":  "string",
+		},
+		"link_secret": map[string]interface{}{
+			"index":  1,
+			"type":   "int64",
+			"hidden": true,
+		},
+		"device": map[string]interface{}{
+			"index":  2,
+			"type":   "string",
+			"known":  "false",
+			"hidden": "true",
+		},
+	})
+
+	attrs, count, err := parseAttrs(v)
+	require.NoError(t, err)
+	assert.Equal(t, NewAttrCount(1, 0, 2), count)
+	assert.False(t, attrs[0].isHidden())
+	assert.True(t, attrs[1].isHidden())
+	assert.False(t, attrs[1].isKnown())
+	assert.True(t, attrs[2].isHidden())
+}
+
+func TestParseAttrs_HiddenInvalid(t *testing.T) {
+	tests := []struct {
+		desc string
+		attr map[string]interface{}
+	}{
+		{"Known", map[string]interface{}{
+			"index":  0,
+			"type":   "int64",
+			"known":  "true",
+			"hidden": "true",
+		}},
+		{"Condition", map[string]interface{}{
+			"index":  0,
+			"type":   "int64",
+			"hidden": "true",
+			"cond":   "gte",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			v := viper.New()
+			v.Set("attributes", map[string]interface{}{"a": tt.attr})
+			_, _, err := parseAttrs(v)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseAttrs_InvalidIn(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"a": map[string]interface{}{
+			"index": 0,
+			"type":  "int64",
+			"cond":  "in",
+		},
+	})
+	_, _, err := parseAttrs(v)
+	assert.Error(t, err)
+}
+
+func TestStrAttr_ValidateAgainstIn(t *testing.T) {
+	a, err := NewStrAttr("country", "Slovenia", true)
+	require.NoError(t, err)
+	a.cond = in
+
+	tests := []struct {
+		desc  string
+		set   interface{}
+		valid bool
+	}{
+		{"Strings", []string{"Croatia", "Slovenia"}, true},
+		{"Interfaces", []interface{}{"Croatia", "Slovenia"}, true},
+		{"NotInSet", []string{"Croatia", "Austria"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			valid, err := a.ValidateAgainst(tt.set)
+			require.NoError(t, err)
+			assert.Equal(t, tt.valid, valid)
+		})
+	}
+
+	_, err = a.ValidateAgainst("Slovenia")
+	assert.Error(t, err)
+	_, err = a.ValidateAgainst([]interface{}{"Slovenia", 1})
+	assert.Error(t, err)
+}
+
+func TestInt64Encoding(t *testing.T) {
+	vals := []int64{math.MinInt64, -1000, -1, 0, 1, 1000, math.MaxInt64}
+	for i, n := range vals {
+		enc := encodeInt64(n)
+		assert.True(t, enc.Sign() >= 0, "%d", n)
+		assert.True(t, enc.BitLen() <= 64, "%d", n)
+		if i > 0 {
+			assert.Equal(t, 1, enc.Cmp(encodeInt64(vals[i-1])),
+				"order of %d", n)
+		}
+
+		dec, err := decodeInt64(enc)
+		require.NoError(t, err)
+		assert.Equal(t, n, dec)
+	}
+
+	_, err := decodeInt64(big.NewInt(-1))
+	assert.Error(t, err)
+	_, err = decodeInt64(new(big.Int).Lsh(big.NewInt(1), 64))
+	assert.Error(t, err)
+}
+
+func TestInt64Attr_Negative(t *testing.T) {
+	a, err := NewInt64Attr("balance", -250, true)
+	require.NoError(t, err)
+	assert.True(t, a.internalValue().Sign() > 0)
+
+	b := NewEmptyInt64Attr("balance", true)
+	require.NoError(t, b.updateInternalValue(a.internalValue()))
+	assert.Equal(t, int64(-250), b.Val)
+
+	assert.Error(t, b.UpdateValue("-250"))
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BoolAttr is an attribute holding a boolean value, such as whether
+// the holder of a credential is a student. Its internal value is 1 for
+// true and 0 for false. The only supported condition is equal.
+type BoolAttr struct {
+	Val bool
+	*Attr
+}
+
+func NewEmptyBoolAttr(name string, known bool) *BoolAttr {
+	return &BoolAttr{
+		Attr: newAttr(name, known),
+	}
+}
+
+func NewBoolAttr(name string, val bool, known bool) (*BoolAttr, error) {
+	a := &BoolAttr{
+		Val:  val,
+		Attr: newAttr(name, known),
+	}
+	if err := a.setInternalValue(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *BoolAttr) setInternalValue() error {
+	a.Attr.Val = encodeBool(a.Val)
+	a.ValSet = true
+	return nil
+}
+
+func (a *BoolAttr) updateInternalValue(val *big.Int) error {
+	switch {
+	case val.Cmp(encodeBool(true)) == 0:
+		a.Val = true
+	case val.Cmp(encodeBool(false)) == 0:
+		a.Val = false
+	default:
+		return fmt.Errorf("invalid value of bool attribute %s", a.Name())
+	}
+	return nil
+}
+
+func (a *BoolAttr) getValue() interface{} {
+	return a.Val
+}
+
+// UpdateValue sets the attribute to b, which is either a bool or its
+// string representation.
+func (a *BoolAttr) UpdateValue(b interface{}) error {
+	v, err := parseBool(b)
+	if err != nil {
+		return fmt.Errorf("value of attribute %s: %s", a.Name(), err)
+	}
+	a.Val = v
+	return a.setInternalValue()
+}
+
+// ValidateAgainst checks the attribute against v, which is either
+// a bool or its string representation.
+func (a *BoolAttr) ValidateAgainst(v interface{}) (bool, error) {
+	actual, err := parseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("value provided for '%s': %s", a.Name(),
+			err)
+	}
+	if a.cond != equal {
+		return false, fmt.Errorf("invalid condition")
+	}
+
+	return actual == a.Val, nil
+}
+
+// clone returns a copy of the attribute that can be modified
+// independently of the original.
+func (a *BoolAttr) clone() CredAttr {
+	attr := *a.Attr
+	return &BoolAttr{
+		Val:  a.Val,
+		Attr: &attr,
+	}
+}
+
+func (a *BoolAttr) String() string {
+	return fmt.Sprintf("%s, type = bool", a.Attr.String())
+}
+
+// encodeBool returns the internal value of a bool attribute.
+func encodeBool(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return big.NewInt(0)
+}
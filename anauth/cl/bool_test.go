@@ -0,0 +1,81 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoolAttr(t *testing.T) {
+	a, err := NewBoolAttr("student", true, true)
+	require.NoError(t, err)
+
+	b := NewEmptyBoolAttr("student", true)
+	require.NoError(t, b.updateInternalValue(a.internalValue()))
+	assert.True(t, b.Val)
+
+	require.NoError(t, b.UpdateValue("false"))
+	assert.False(t, b.Val)
+	assert.Equal(t, 1, a.internalValue().Cmp(b.internalValue()))
+	v, err := encodeAttrValue(b, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, v.Cmp(b.internalValue()))
+
+	assert.Error(t, b.UpdateValue(1))
+	assert.Error(t, b.updateInternalValue(encodeStr("true")))
+}
+
+func TestBoolAttr_ValidateAgainst(t *testing.T) {
+	a, err := NewBoolAttr("student", true, true)
+	require.NoError(t, err)
+	a.cond = equal
+
+	ok, err := a.ValidateAgainst(true)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = a.ValidateAgainst("false")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = a.ValidateAgainst("maybe")
+	assert.Error(t, err)
+	assert.False(t, provable(a))
+}
+
+func TestParseAttrs_Bool(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"student": map[string]interface{}{
+			"index": 0,
+			"type":  "bool",
+			"cond":  "equal",
+		},
+	})
+
+	attrs, _, err := parseAttrs(v)
+	require.NoError(t, err)
+	require.IsType(t, &BoolAttr{}, attrs[0])
+
+	v.Set("attributes.student.cond", "gte")
+	_, _, err = parseAttrs(v)
+	assert.Error(t, err)
+}
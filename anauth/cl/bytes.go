@@ -0,0 +1,149 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// BytesAttr is an attribute holding arbitrary binary data, such as
+// a photo or a signed document, usually with HashEncoding. The only
+// supported condition is equal.
+//
+// Values given as strings, for example as registered values of
+// attributes or as reference values, are base64 encoded.
+type BytesAttr struct {
+	Val      []byte
+	Encoding AttrEncoding
+	*Attr
+}
+
+func NewEmptyBytesAttr(name string, enc AttrEncoding,
+	known bool) *BytesAttr {
+	return &BytesAttr{
+		Encoding: enc,
+		Attr:     newAttr(name, known),
+	}
+}
+
+func NewBytesAttr(name string, val []byte, enc AttrEncoding,
+	known bool) (*BytesAttr, error) {
+	a := NewEmptyBytesAttr(name, enc, known)
+	a.Val = val
+	if err := a.setInternalValue(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *BytesAttr) setInternalValue() error {
+	a.Attr.Val = a.encode(a.Val)
+	a.ValSet = true
+	return nil
+}
+
+// updateInternalValue sets the internal value of the attribute to val.
+// With HashEncoding the value itself cannot be recovered, so only the
+// internal value is set.
+func (a *BytesAttr) updateInternalValue(val *big.Int) error {
+	if a.Encoding == HashEncoding {
+		a.Attr.Val = val
+		return nil
+	}
+
+	b := val.Bytes()
+	if len(b) == 0 || b[0] != 1 {
+		return fmt.Errorf("invalid value of bytes attribute %s", a.Name())
+	}
+	a.Attr.Val = val
+	a.Val = b[1:]
+	return nil
+}
+
+func (a *BytesAttr) getValue() interface{} {
+	return a.Val
+}
+
+// UpdateValue sets the attribute to b, which is either a []byte or
+// a base64 encoded string.
+func (a *BytesAttr) UpdateValue(b interface{}) error {
+	v, err := parseBytes(b)
+	if err != nil {
+		return fmt.Errorf("value of attribute %s: %s", a.Name(), err)
+	}
+	a.Val = v
+	return a.setInternalValue()
+}
+
+// ValidateAgainst checks the attribute against v, which is either
+// a []byte or a base64 encoded string. Internal values are compared, so
+// that attributes with HashEncoding are compared by hash.
+func (a *BytesAttr) ValidateAgainst(v interface{}) (bool, error) {
+	actual, err := parseBytes(v)
+	if err != nil {
+		return false, fmt.Errorf("value provided for '%s': %s", a.Name(),
+			err)
+	}
+	if a.cond != equal {
+		return false, fmt.Errorf("invalid condition")
+	}
+
+	return a.encode(actual).Cmp(a.internalValue()) == 0, nil
+}
+
+// encode returns the internal value of the attribute for value v.
+// Raw values are prefixed with byte 1, so that leading zero bytes are
+// kept.
+func (a *BytesAttr) encode(v []byte) *big.Int {
+	if a.Encoding == HashEncoding {
+		return hashValue(v)
+	}
+	return new(big.Int).SetBytes(append([]byte{1}, v...))
+}
+
+// clone returns a copy of the attribute that can be modified
+// independently of the original.
+func (a *BytesAttr) clone() CredAttr {
+	attr := *a.Attr
+	return &BytesAttr{
+		Val:      append([]byte(nil), a.Val...),
+		Encoding: a.Encoding,
+		Attr:     &attr,
+	}
+}
+
+func (a *BytesAttr) String() string {
+	return fmt.Sprintf("%s, type = bytes (%s)", a.Attr.String(),
+		a.Encoding)
+}
+
+// parseBytes returns bytes from b, which is either a []byte or a base64
+// encoded string.
+func parseBytes(b interface{}) ([]byte, error) {
+	switch v := b.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return base64.StdEncoding.DecodeString(v)
+	}
+
+	return nil, fmt.Errorf("%v is neither bytes nor a base64 string", b)
+}
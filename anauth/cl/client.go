@@ -18,8 +18,10 @@
 package cl
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -31,12 +33,23 @@ import (
 
 	"github.com/emmyzkp/crypto/common"
 	"github.com/emmyzkp/crypto/qr"
+	"github.com/emmyzkp/crypto/df"
 	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
 	"google.golang.org/grpc"
 )
 
 type Client struct {
 	pb.AnonCredsClient // TODO fix my name
+
+	// VerifierID is the identity of the verifier that the client
+	// expects. If it is not empty, ProveCredential fails when
+	// the server identifies itself differently, so that a proof
+	// cannot be relayed to another verifier.
+	VerifierID string
+	// Consent is called with the proof request of the verifier before
+	// credentials are proved, so that the holder can decide whether to
+	// proceed. If it is nil, proofs are built without asking.
+	Consent ConsentFunc
 }
 
 func NewClient(conn *grpc.ClientConn) *Client {
@@ -56,38 +69,150 @@ func (c *Client) GetPublicParams() (*PubParams, error) {
 		return nil, err
 	}
 
-	pubKey := &PubKey{
-		N:           new(big.Int).SetBytes(p.PubKey.N),
-		S:           new(big.Int).SetBytes(p.PubKey.S),
-		Z:           new(big.Int).SetBytes(p.PubKey.Z),
-		RsKnown:     fromByteSlices(p.PubKey.RsKnown),
-		RsCommitted: fromByteSlices(p.PubKey.RsCommitted),
-		RsHidden:    fromByteSlices(p.PubKey.RsHidden),
-		PedersenParams: pedersen.NewParams(
-			schnorr.NewGroupFromParams(
-				new(big.Int).SetBytes(p.PubKey.PedersenParams.SchnorrGroup.P),
-				new(big.Int).SetBytes(p.PubKey.PedersenParams.SchnorrGroup.G),
-				new(big.Int).SetBytes(p.PubKey.PedersenParams.SchnorrGroup.Q),
-			),
-			new(big.Int).SetBytes(p.PubKey.PedersenParams.H), nil),
-		N1: new(big.Int).SetBytes(p.PubKey.N1),
-		G:  new(big.Int).SetBytes(p.PubKey.G),
-		H:  new(big.Int).SetBytes(p.PubKey.H),
+	trusted, err := fromPbTrustedIssuers(p.TrustedIssuers)
+	if err != nil {
+		return nil, err
+	}
+	// servers that only verify credentials have no keys of their own
+	if p.PubKey == nil {
+		return &PubParams{
+			TrustedIssuers: trusted,
+		}, nil
+	}
+
+	pubKey, err := fromPbPubKey(p.PubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	retiredKeys := make([]*RetiredKey, len(p.RetiredKeys))
+	for i, k := range p.RetiredKeys {
+		pk, err := fromPbPubKey(k.PubKey)
+		if err != nil {
+			return nil, err
+		}
+		retiredKeys[i] = &RetiredKey{
+			PubKey:  pk,
+			Expires: time.Unix(k.Expires, 0),
+		}
 	}
 
-	rc, err := c.parseCredStructure(p.CredStructure)
+	if p.CredStructure == nil {
+		return nil, fmt.Errorf("missing credential structure")
+	}
+	schemaHash, err := SchemaHash(p.CredStructure)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(schemaHash, p.SchemaHash) {
+		return nil, fmt.Errorf("schema hash does not match the" +
+			" credential structure")
+	}
+	// the hash is only authenticated by keys bound to a schema, whose
+	// proofs of correctness commit to it
+	if err := pubKey.CheckSchema(p.CredStructure); err != nil {
+		return nil, err
+	}
+	for _, k := range retiredKeys {
+		if err := k.PubKey.CheckSchema(p.CredStructure); err != nil {
+			return nil, fmt.Errorf("retired key: %s", err)
+		}
+	}
+	rc, err := NewRawCredFromStructure(p.CredStructure)
 	if err != nil {
 		return nil, err
 	}
 
 	return &PubParams{
-		PubKey:  pubKey,
-		Config:  p.Params,
-		RawCred: rc,
+		PubKey:         pubKey,
+		Config:         p.Params,
+		RawCred:        rc,
+		RetiredKeys:    retiredKeys,
+		SchemaName:     p.CredStructure.Name,
+		SchemaVersion:  int(p.CredStructure.Version),
+		SchemaHash:     schemaHash,
+		TrustedIssuers: trusted,
 	}, nil
 }
 
-func (c *Client) parseCredStructure(cs *pb.CredStructure) (*RawCred, error) {
+// fromPbTrustedIssuers converts the issuers trusted by the server,
+// checking the proofs of correctness of their public keys.
+func fromPbTrustedIssuers(issuers []*pb.TrustedIssuer) ([]*TrustedIssuer,
+	error) {
+	trusted := make([]*TrustedIssuer, len(issuers))
+	for i, issuer := range issuers {
+		pubKey, err := fromPbPubKey(issuer.PubKey)
+		if err != nil {
+			return nil, err
+		}
+		if issuer.CredStructure == nil {
+			return nil, fmt.Errorf("missing credential structure of" +
+				" trusted issuer")
+		}
+		// the structure is only authenticated by keys bound to a schema
+		if err := pubKey.CheckSchema(issuer.CredStructure); err != nil {
+			return nil, fmt.Errorf("trusted issuer %s: %s", issuer.Name, err)
+		}
+		schema, err := schemaFromCredStructure(issuer.CredStructure)
+		if err != nil {
+			return nil, err
+		}
+		trusted[i] = &TrustedIssuer{
+			Name:   issuer.Name,
+			Params: issuer.Params,
+			PubKey: pubKey,
+			Attrs:  schema.Attrs,
+			Schema: issuer.CredStructure,
+		}
+	}
+
+	return trusted, nil
+}
+
+// fromPbPubKey converts a public key of the issuer, and checks the
+// proof of its correctness.
+func fromPbPubKey(k *pb.PubKey) (*PubKey, error) {
+	if k == nil || k.PedersenParams == nil ||
+		k.PedersenParams.SchnorrGroup == nil {
+		return nil, fmt.Errorf("missing public key of the issuer")
+	}
+
+	pubKey := &PubKey{
+		N:           new(big.Int).SetBytes(k.N),
+		S:           new(big.Int).SetBytes(k.S),
+		Z:           new(big.Int).SetBytes(k.Z),
+		RsKnown:     fromByteSlices(k.RsKnown),
+		RsCommitted: fromByteSlices(k.RsCommitted),
+		RsHidden:    fromByteSlices(k.RsHidden),
+		PedersenParams: pedersen.NewParams(
+			schnorr.NewGroupFromParams(
+				new(big.Int).SetBytes(k.PedersenParams.SchnorrGroup.P),
+				new(big.Int).SetBytes(k.PedersenParams.SchnorrGroup.G),
+				new(big.Int).SetBytes(k.PedersenParams.SchnorrGroup.Q),
+			),
+			new(big.Int).SetBytes(k.PedersenParams.H), nil),
+		N1:      new(big.Int).SetBytes(k.N1),
+		G:       new(big.Int).SetBytes(k.G),
+		H:       new(big.Int).SetBytes(k.H),
+		AccInit: fromOptionalBytes(k.AccInit),
+		AccG:    fromOptionalBytes(k.AccG),
+		AccH:    fromOptionalBytes(k.AccH),
+		Proof:   fromPbKeyProof(k.Proof),
+	}
+	if len(k.SchemaHash) > 0 {
+		pubKey.SchemaHash = k.SchemaHash
+	}
+	if err := pubKey.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid public key of the issuer: %s", err)
+	}
+
+	return pubKey, nil
+}
+
+// NewRawCredFromStructure creates a RawCred with empty attributes
+// described by credential structure cs, such as the one of Schema or
+// the one clients receive with PubParams.
+func NewRawCredFromStructure(cs *pb.CredStructure) (*RawCred, error) {
 	count := NewAttrCount(
 		int(cs.NKnown),
 		int(cs.NCommitted),
@@ -100,22 +225,97 @@ func (c *Client) parseCredStructure(cs *pb.CredStructure) (*RawCred, error) {
 		switch a.Type.(type) { // TODO make more intuitive
 		case *pb.CredAttribute_StringAttr:
 			strA := a.GetStringAttr().Attr
-			err := rc.addEmptyStrAttr(strA.Name, int(strA.Index), strA.Known)
+			enc := pbAttrEncoding(a.GetStringAttr().Hashed)
+			var err error
+			if strA.Hidden {
+				err = rc.addEmptyHiddenStrAttr(strA.Name, int(strA.Index), enc)
+			} else {
+				err = rc.addEmptyStrAttr(strA.Name, int(strA.Index), strA.Known,
+					enc)
+			}
 			if err != nil {
 				return nil, err
 			}
 		case *pb.CredAttribute_IntAttr:
-			intA := a.GetIntAttr().Attr
-			err := rc.addEmptyInt64Attr(intA.Name, int(intA.Index), intA.Known)
+			return nil, fmt.Errorf("attribute %s uses an unsupported"+
+				" encoding of int64 values", a.GetIntAttr().Attr.Name)
+		case *pb.CredAttribute_SignedIntAttr:
+			intA := a.GetSignedIntAttr().Attr
+			var err error
+			if intA.Hidden {
+				err = rc.addEmptyHiddenInt64Attr(intA.Name, int(intA.Index))
+			} else {
+				err = rc.addEmptyInt64Attr(intA.Name, int(intA.Index), intA.Known)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case *pb.CredAttribute_DateAttr:
+			dateA := a.GetDateAttr().Attr
+			var err error
+			if dateA.Hidden {
+				err = rc.addEmptyHiddenDateAttr(dateA.Name, int(dateA.Index))
+			} else {
+				err = rc.addEmptyDateAttr(dateA.Name, int(dateA.Index), dateA.Known)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case *pb.CredAttribute_BoolAttr:
+			boolA := a.GetBoolAttr().Attr
+			var err error
+			if boolA.Hidden {
+				err = rc.addEmptyHiddenBoolAttr(boolA.Name, int(boolA.Index))
+			} else {
+				err = rc.addEmptyBoolAttr(boolA.Name, int(boolA.Index), boolA.Known)
+			}
 			if err != nil {
 				return nil, err
 			}
+		case *pb.CredAttribute_EnumAttr:
+			enumA := a.GetEnumAttr()
+			var err error
+			if enumA.Attr.Hidden {
+				err = rc.addEmptyHiddenEnumAttr(enumA.Attr.Name,
+					int(enumA.Attr.Index), enumA.Values)
+			} else {
+				err = rc.addEmptyEnumAttr(enumA.Attr.Name,
+					int(enumA.Attr.Index), enumA.Values, enumA.Attr.Known)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case *pb.CredAttribute_BytesAttr:
+			bytesA := a.GetBytesAttr().Attr
+			enc := pbAttrEncoding(a.GetBytesAttr().Hashed)
+			var err error
+			if bytesA.Hidden {
+				err = rc.addEmptyHiddenBytesAttr(bytesA.Name,
+					int(bytesA.Index), enc)
+			} else {
+				err = rc.addEmptyBytesAttr(bytesA.Name, int(bytesA.Index),
+					bytesA.Known, enc)
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported attribute type")
 		}
 	}
 
 	return rc, nil
 }
 
+// pbAttrEncoding returns the encoding of attributes described as hashed
+// or not in credential structures.
+func pbAttrEncoding(hashed bool) AttrEncoding {
+	if hashed {
+		return HashEncoding
+	}
+	return RawEncoding
+}
+
 func (c *Client) GetAcceptableCreds() (map[string][]string, error) {
 	if c.AnonCredsClient == nil {
 		return nil, fmt.Errorf("client is not connected")
@@ -182,18 +382,7 @@ func (c *Client) IssueCredential(cm *CredManager, regKey string) (*Cred,
 		ProofData:       toStringSlices(credReq.UProof.ProofData),
 	}
 
-	proofs := make([]*pb.FiatShamir, len(credReq.CommitmentsOfAttrsProofs))
-	for i, proof := range credReq.CommitmentsOfAttrsProofs {
-		pData = make([][]byte, 2)
-		pData[0] = proof.ProofData1.Bytes()
-		pData[1] = proof.ProofData2.Bytes()
-		fs := &pb.FiatShamir{
-			ProofRandomData: proof.ProofRandomData.Bytes(),
-			Challenge:       proof.Challenge.Bytes(),
-			ProofData:       pData,
-		}
-		proofs[i] = fs
-	}
+	proofs := toPbOpeningProofs(credReq.CommitmentsOfAttrsProofs)
 
 	if err := stream.Send(
 		&pb.Request{
@@ -230,6 +419,7 @@ func (c *Client) IssueCredential(cm *CredManager, regKey string) (*Cred,
 		new(big.Int).SetBytes(issuedCred.Cred.E),
 		new(big.Int).SetBytes(issuedCred.Cred.V11),
 	)
+	cred.Witness = fromPbWitness(issuedCred.Witness)
 	AProof := qr.NewRepresentationProof(
 		new(big.Int).SetBytes(issuedCred.AProof.ProofRandomData),
 		new(big.Int).SetBytes(issuedCred.AProof.Challenge),
@@ -256,28 +446,122 @@ func (c *Client) IssueCredential(cm *CredManager, regKey string) (*Cred,
 	return nil, fmt.Errorf("credential not valid")
 }
 
+// UpdateCredential obtains a credential with the Known and Committed
+// attributes of rawCred. Changed Committed attributes are committed to
+// anew, and the issuer learns only the new commitments. If the credential of cm was issued with keys that the issuer
+// has since retired, it is re-issued with the active keys, to which cm
+// switches once the new credential is verified.
 func (c *Client) UpdateCredential(cm *CredManager, rawCred *RawCred) (*Cred,
 	error) {
 	if c.AnonCredsClient == nil {
 		return nil, fmt.Errorf("client is not connected")
 	}
 
-	// refresh credManager with new credential values,
-	// works only for Known attributes
-	cm.Update(rawCred)
-	newKnownAttrs := rawCred.GetKnownVals()
+	params, err := c.GetPublicParams()
+	if err != nil {
+		return nil, err
+	}
+	var newPubKey *PubKey
+	if params.PubKey.ID() != cm.PubKey.ID() {
+		if !equalPedersenParams(params.PubKey, cm.PubKey) {
+			return nil, fmt.Errorf("nym is not valid with the new key" +
+				" of the issuer")
+		}
+		newPubKey = params.PubKey
+	}
+
+	// the credential manager is restored if the credential is not
+	// updated, so that the previous credential can still be used
+	state := *cm
+	cred, err := c.updateCredential(cm, rawCred, newPubKey)
+	if err != nil {
+		*cm = state
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// updateCredential updates the credential of cm, or re-issues it with
+// newPubKey unless it is nil.
+func (c *Client) updateCredential(cm *CredManager, rawCred *RawCred,
+	newPubKey *PubKey) (*Cred, error) {
+	stream, err := c.AnonCredsClient.Update(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.Send(emptyRequest()); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	// refresh credManager with new credential values, changed
+	// Committed attributes are committed to anew
+	if err := cm.Update(rawCred); err != nil {
+		return nil, err
+	}
+	nonceOrg := new(big.Int).SetBytes(resp.GetNonce())
+	var updateReq *CredUpdateRequest
+	if newPubKey != nil {
+		updateReq, err = cm.GetCredReissueRequest(nonceOrg, newPubKey)
+	} else {
+		updateReq, err = cm.GetCredUpdateRequest(nonceOrg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pbUpdateReq := &pb.CredUpdateRequest{
+		Nym:           updateReq.Nym.Bytes(),
+		Nonce:         updateReq.Nonce.Bytes(),
+		NewKnownAttrs: toByteSlices(updateReq.NewKnownAttrs),
+		NymProof: &pb.FiatShamir{
+			ProofRandomData: updateReq.NymProof.ProofRandomData.Bytes(),
+			Challenge:       updateReq.NymProof.Challenge.Bytes(),
+			ProofData:       toByteSlices(updateReq.NymProof.ProofData),
+		},
+		NewCommitmentsOfAttrs: toByteSlices(updateReq.NewCommitmentsOfAttrs),
+		CommitmentsOfAttrsProofs: toPbOpeningProofs(
+			updateReq.CommitmentsOfAttrsProofs),
+	}
+	if updateReq.U != nil {
+		pbUpdateReq.U = updateReq.U.Bytes()
+		pbUpdateReq.UProof = &pb.FiatShamirAlsoNeg{
+			ProofRandomData: updateReq.UProof.ProofRandomData.Bytes(),
+			Challenge:       updateReq.UProof.Challenge.Bytes(),
+			ProofData:       toStringSlices(updateReq.UProof.ProofData),
+		}
+		pbUpdateReq.PrevUProof = &pb.FiatShamirAlsoNeg{
+			ProofRandomData: updateReq.PrevUProof.ProofRandomData.Bytes(),
+			Challenge:       updateReq.PrevUProof.Challenge.Bytes(),
+			ProofData:       toStringSlices(updateReq.PrevUProof.ProofData),
+		}
+	}
 
-	req := &pb.CredUpdateRequest{
-		Nym:           cm.Nym.Bytes(),
-		Nonce:         cm.CredReqNonce.Bytes(),
-		NewKnownAttrs: toByteSlices(newKnownAttrs),
+	if err := stream.Send(
+		&pb.Request{
+			Type: &pb.Request_CredUpdate{
+				CredUpdate: pbUpdateReq,
+			},
+		}); err != nil {
+		return nil, err
 	}
 
-	updatedCred, err := c.AnonCredsClient.Update(context.Background(), req)
+	resp, err = stream.Recv()
 	if err != nil {
 		return nil, err
 	}
 
+	updatedCred := resp.GetIssuedCred()
+	if updatedCred == nil {
+		return nil, fmt.Errorf("expected updated credential")
+	}
+
 	si, success := new(big.Int).SetString(updatedCred.AProof.ProofData[0], 10)
 	if !success {
 		return nil, fmt.Errorf("error when initializing big.Int from string")
@@ -288,6 +572,7 @@ func (c *Client) UpdateCredential(cm *CredManager, rawCred *RawCred) (*Cred,
 		new(big.Int).SetBytes(updatedCred.Cred.E),
 		new(big.Int).SetBytes(updatedCred.Cred.V11),
 	)
+	cred.Witness = fromPbWitness(updatedCred.Witness)
 	AProof := qr.NewRepresentationProof(
 		new(big.Int).SetBytes(updatedCred.AProof.ProofRandomData),
 		new(big.Int).SetBytes(updatedCred.AProof.Challenge),
@@ -306,35 +591,72 @@ func (c *Client) UpdateCredential(cm *CredManager, rawCred *RawCred) (*Cred,
 	return nil, fmt.Errorf("cred not valid")
 }
 
+// UpdateWitness updates the witness of cred with the updates of
+// the accumulator published by the server, so that it can be used to
+// prove that cred was not revoked. ErrRevoked is returned if cred was
+// revoked.
+func (c *Client) UpdateWitness(cm *CredManager, cred *Cred) error {
+	return c.updateWitness(cm, cred, -1)
+}
+
+// updateWitness updates the witness of cred to the given version of
+// the accumulator, or to the latest version if version is negative.
+func (c *Client) updateWitness(cm *CredManager, cred *Cred,
+	version int64) error {
+	if c.AnonCredsClient == nil {
+		return fmt.Errorf("client is not connected")
+	}
+	if cred.Witness == nil {
+		return fmt.Errorf("credential has no witness")
+	}
+
+	resp, err := c.AnonCredsClient.GetAccumulatorUpdates(
+		context.Background(), &pb.AccumulatorUpdatesRequest{
+			Version: cred.Witness.Version,
+			KeyId:   cm.PubKey.ID(),
+		})
+	if err != nil {
+		return err
+	}
+
+	updates := make([]*AccumulatorUpdate, len(resp.Updates))
+	for i, u := range resp.Updates {
+		updates[i] = &AccumulatorUpdate{
+			Version: u.Version,
+			E:       new(big.Int).SetBytes(u.E),
+			Removed: u.Removed,
+			Value:   new(big.Int).SetBytes(u.Value),
+		}
+	}
+	if version < 0 {
+		version = cred.Witness.Version
+		if len(updates) > 0 {
+			version = updates[len(updates)-1].Version
+		}
+	}
+
+	return cred.Witness.Update(cm.PubKey, cred.E, updates, version)
+}
+
 // ProveCred proves the possession of a valid credential and reveals only the attributes the user desires
 // to reveal. Which knownAttrs and commitmentsOfAttrs are to be revealed are given by revealedKnownAttrsIndices and
 // revealedCommitmentsOfAttrsIndices parameters. All knownAttrs and commitmentsOfAttrs should be passed into
 // ProveCred - only those which are revealed are then passed to the server.
+//
+// If the server supports revocation, the witness of cred is updated
+// to the current version of the accumulator.
+//
+// Attributes that the proof request of the server requires are revealed
+// along with revealedAttrs, and the proof fails if cred does not satisfy
+// the request or Consent refuses it.
 func (c *Client) ProveCredential(cm *CredManager, cred *Cred,
 	revealedAttrs []string) (*string, error) {
 	if c.AnonCredsClient == nil {
 		return nil, fmt.Errorf("client is not connected")
 	}
 
-	var revealedKnownAttrsIndices []int
-	var revealedCommitmentsOfAttrsIndices []int
-	knownCount := 0
-	commCount := 0
-
-	for _, a := range revealedAttrs {
-		attr, err := cm.RawCred.GetAttr(a)
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument,
-				"unexpected attribute: %s", a)
-		}
-
-		if attr.isKnown() {
-			revealedKnownAttrsIndices = append(revealedKnownAttrsIndices, knownCount)
-			knownCount++
-		} else {
-			revealedCommitmentsOfAttrsIndices = append(revealedCommitmentsOfAttrsIndices, commCount)
-			commCount++
-		}
+	if _, _, err := cm.RawCred.revealedIndices(revealedAttrs); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	stream, err := c.AnonCredsClient.Prove(context.Background())
@@ -342,7 +664,12 @@ func (c *Client) ProveCredential(cm *CredManager, cred *Cred,
 		return nil, err
 	}
 
-	if err := stream.Send(emptyRequest()); err != nil {
+	// the server verifies the credential with the keys it was issued with
+	if err := stream.Send(&pb.Request{
+		Type: &pb.Request_KeyId{
+			KeyId: cm.PubKey.ID(),
+		},
+	}); err != nil {
 		return nil, err
 	}
 
@@ -350,10 +677,47 @@ func (c *Client) ProveCredential(cm *CredManager, cred *Cred,
 	if err != nil {
 		return nil, err
 	}
-	nonce := new(big.Int).SetBytes(resp.GetNonce())
+	proofParams := resp.GetProofParams()
+	if proofParams == nil {
+		return nil, fmt.Errorf("missing proof parameters")
+	}
+	nonce := new(big.Int).SetBytes(proofParams.Nonce)
+	if c.VerifierID != "" && proofParams.Verifier != c.VerifierID {
+		return nil, fmt.Errorf("unexpected verifier %q", proofParams.Verifier)
+	}
+
+	req, err := proofRequest(proofParams,
+		proofParams.Request.GetRevealedAttrs(), proofParams.Predicates)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.CheckSatisfiable(cm); err != nil {
+		return nil, fmt.Errorf("credential does not satisfy the proof"+
+			" request: %v", err)
+	}
+	if err := c.consent(req); err != nil {
+		return nil, err
+	}
 
-	randCred, proof, err := cm.BuildProof(cred, revealedKnownAttrsIndices,
-		revealedCommitmentsOfAttrsIndices, nonce)
+	revealedAttrs = union(revealedAttrs, req.RevealedAttrs)
+	revealedKnownAttrsIndices, revealedCommitmentsOfAttrsIndices, err :=
+		cm.RawCred.revealedIndices(revealedAttrs)
+	if err != nil {
+		return nil, err
+	}
+	predicates, err := proofPredicates(proofParams.Predicates, revealedAttrs)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := c.proofAccumulator(cm, cred, proofParams.Accumulator)
+	if err != nil {
+		return nil, err
+	}
+
+	randCred, proof, predicateProofs, nonRevProof, scopeProof,
+		err := cm.BuildProof(cred, revealedKnownAttrsIndices,
+		revealedCommitmentsOfAttrsIndices, predicates, acc,
+		proofParams.Scope, proofParams.Verifier, nonce)
 	if err != nil {
 		return nil, fmt.Errorf("error when building credential proof: %v", err)
 	}
@@ -362,36 +726,155 @@ func (c *Client) ProveCredential(cm *CredManager, cred *Cred,
 		revealedKnownAttrsIndices,
 		revealedCommitmentsOfAttrsIndices)
 
-	// TODO disappeared?
-	revealedKnownAttrs := make([]int32, len(revealedKnownAttrsIndices))
-	for i, a := range revealedKnownAttrsIndices {
-		revealedKnownAttrs[i] = int32(a)
+	pbProof := toPbCredProof(&CredProofPart{
+		A:                                 randCred.A,
+		Proof:                             proof,
+		RevealedKnownAttrsIndices:         revealedKnownAttrsIndices,
+		RevealedKnownAttrs:                filteredKnownAttrs,
+		RevealedCommitmentsOfAttrsIndices: revealedCommitmentsOfAttrsIndices,
+		RevealedCommitmentsOfAttrs:        filteredCommitmentsOfAttrs,
+		PredicateProofs:                   predicateProofs,
+		NonRevocationProof:                nonRevProof,
+	})
+	pbProof.ScopePseudonymProof = toPbScopePseudonymProof(scopeProof)
+	proveMsg := &pb.Request{
+		Type: &pb.Request_CredProve{
+			CredProve: pbProof,
+		},
 	}
 
-	// TODO disappeared?
-	revealedCommitmentsOfAttrs := make([]int32, len(revealedCommitmentsOfAttrsIndices))
-	for i, a := range revealedCommitmentsOfAttrsIndices {
-		revealedCommitmentsOfAttrs[i] = int32(a)
+	if err := stream.Send(proveMsg); err != nil {
+		return nil, err
 	}
 
-	proveMsg := &pb.Request{
-		Type: &pb.Request_CredProve{
-			CredProve: &pb.CredProof{
-				A: randCred.A.Bytes(),
-				Proof: &pb.FiatShamirAlsoNeg{
-					ProofRandomData: proof.ProofRandomData.Bytes(),
-					Challenge:       proof.Challenge.Bytes(),
-					ProofData:       toStringSlices(proof.ProofData),
-				},
-				KnownAttrs:                 toByteSlices(filteredKnownAttrs),
-				CommitmentsOfAttrs:         toByteSlices(filteredCommitmentsOfAttrs),
-				RevealedKnownAttrs:         revealedKnownAttrs,
-				RevealedCommitmentsOfAttrs: revealedCommitmentsOfAttrs,
+	resp, err = stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	sessKey := resp.GetSessionKey()
+
+	return &sessKey, nil
+}
+
+// ProveCredentials proves the possession of several credentials, which
+// can be issued by different issuers, to a verifier that accepts all of
+// the issuers (see MultiCredProof). The credentials have to share
+// the master secret, which is their first Hidden attribute.
+//
+// As with ProveCredential, attributes that the proof request of
+// the server requires from each of the credentials are revealed along
+// with the attributes of the presentations.
+func (c *Client) ProveCredentials(creds []*CredPresentation) (*string,
+	error) {
+	if c.AnonCredsClient == nil {
+		return nil, fmt.Errorf("client is not connected")
+	}
+
+	keyIDs := make([]string, len(creds))
+	for i, p := range creds {
+		keyIDs[i] = p.Manager.PubKey.ID()
+	}
+
+	stream, err := c.AnonCredsClient.Prove(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.Send(&pb.Request{
+		Type: &pb.Request_KeyIds{
+			KeyIds: &pb.KeyIds{
+				Ids: keyIDs,
 			},
 		},
+	}); err != nil {
+		return nil, err
 	}
 
-	if err := stream.Send(proveMsg); err != nil {
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	proofParams := resp.GetProofParams()
+	if proofParams == nil || len(proofParams.Creds) != len(creds) {
+		return nil, fmt.Errorf("missing proof parameters")
+	}
+	nonce := new(big.Int).SetBytes(proofParams.Nonce)
+	if c.VerifierID != "" && proofParams.Verifier != c.VerifierID {
+		return nil, fmt.Errorf("unexpected verifier %q", proofParams.Verifier)
+	}
+
+	req, err := proofRequest(proofParams,
+		proofParams.Request.GetRevealedAttrs(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range creds {
+		params := proofParams.Creds[i]
+		if params.KeyId != keyIDs[i] {
+			return nil, fmt.Errorf("unexpected key %s of credential",
+				params.KeyId)
+		}
+		credReq, err := proofRequest(proofParams, params.RevealedAttrs,
+			params.Predicates)
+		if err != nil {
+			return nil, err
+		}
+		if err := credReq.CheckSatisfiable(p.Manager); err != nil {
+			return nil, fmt.Errorf("credential %d does not satisfy the"+
+				" proof request: %v", i, err)
+		}
+		req.Predicates = append(req.Predicates, credReq.Predicates...)
+	}
+	if err := c.consent(req); err != nil {
+		return nil, err
+	}
+
+	// presentations of the caller are not modified
+	presented := make([]*CredPresentation, len(creds))
+	for i, p := range creds {
+		params := proofParams.Creds[i]
+		revealedAttrs := union(p.RevealedAttrs, params.RevealedAttrs)
+		predicates, err := proofPredicates(params.Predicates, revealedAttrs)
+		if err != nil {
+			return nil, err
+		}
+		acc, err := c.proofAccumulator(p.Manager, p.Cred, params.Accumulator)
+		if err != nil {
+			return nil, err
+		}
+		presented[i] = &CredPresentation{
+			Manager:       p.Manager,
+			Cred:          p.Cred,
+			RevealedAttrs: revealedAttrs,
+			Predicates:    predicates,
+			Accumulator:   acc,
+		}
+	}
+
+	proof, err := BuildMultiCredProof(presented, proofParams.Scope,
+		proofParams.Verifier, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("error when building credentials proof: %v",
+			err)
+	}
+
+	pbProof := &pb.MultiCredProof{
+		Creds:               make([]*pb.CredProof, len(proof.Parts)),
+		ScopePseudonymProof: toPbScopePseudonymProof(proof.ScopePseudonymProof),
+	}
+	for i, p := range proof.Parts {
+		pbProof.Creds[i] = toPbCredProof(p)
+	}
+	if err := stream.Send(&pb.Request{
+		Type: &pb.Request_MultiCredProve{
+			MultiCredProve: pbProof,
+		},
+	}); err != nil {
 		return nil, err
 	}
 
@@ -409,6 +892,130 @@ func (c *Client) ProveCredential(cm *CredManager, cred *Cred,
 	return &sessKey, nil
 }
 
+// proofRequest returns the proof request of the verifier from proof
+// parameters params, requiring revealedAttrs to be revealed and
+// predicates pbPreds to hold for the rest of the attributes.
+func proofRequest(params *pb.ProofParams, revealedAttrs []string,
+	pbPreds []*pb.Predicate) (*ProofRequest, error) {
+	predicates, err := proofPredicates(pbPreds, revealedAttrs)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ProofRequest{
+		Verifier:      params.Verifier,
+		Purpose:       params.Request.GetPurpose(),
+		RevealedAttrs: revealedAttrs,
+		Predicates:    predicates,
+		Scope:         params.Scope,
+	}
+	for _, i := range params.Request.GetAcceptedIssuers() {
+		req.AcceptedIssuers = append(req.AcceptedIssuers, &AcceptedIssuer{
+			Name:  i.Name,
+			KeyID: i.KeyId,
+		})
+	}
+
+	return req, nil
+}
+
+// consent asks for the consent of the holder to prove credentials
+// according to proof request req.
+func (c *Client) consent(req *ProofRequest) error {
+	if c.Consent == nil {
+		return nil
+	}
+	if err := c.Consent(req); err != nil {
+		return fmt.Errorf("proof request was refused: %v", err)
+	}
+
+	return nil
+}
+
+// proofPredicates returns predicates that the verifier requires to be
+// proved. Predicates over revealed attributes are checked by
+// the verifier, the rest have to be proved.
+func proofPredicates(pbPreds []*pb.Predicate,
+	revealedAttrs []string) ([]*Predicate, error) {
+	var predicates []*Predicate
+	for _, p := range pbPreds {
+		if contains(revealedAttrs, p.Attr) {
+			continue
+		}
+		cond, err := parseAttrCond(p.Cond)
+		if err != nil {
+			return nil, err
+		}
+		if cond == in {
+			predicates = append(predicates, NewSetPredicate(p.Attr, p.Set))
+			continue
+		}
+		predicates = append(predicates, NewPredicate(p.Attr, cond, p.Value))
+	}
+
+	return predicates, nil
+}
+
+// proofAccumulator returns the accumulator that cred has to be proved
+// to be in, updating the witness of cred to it if needed. It returns
+// nil if the verifier does not check revocation.
+func (c *Client) proofAccumulator(cm *CredManager, cred *Cred,
+	a *pb.Accumulator) (*Accumulator, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	acc := &Accumulator{
+		Version: a.Version,
+		Value:   new(big.Int).SetBytes(a.Value),
+	}
+	if cred.Witness == nil {
+		return nil, fmt.Errorf("credential has no witness")
+	}
+	if cred.Witness.Version < acc.Version {
+		if err := c.updateWitness(cm, cred, acc.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	return acc, nil
+}
+
+// toPbCredProof converts the proof of possession of a credential to its
+// protobuf representation.
+func toPbCredProof(p *CredProofPart) *pb.CredProof {
+	pbPredicateProofs := make([]*pb.PredicateProof, len(p.PredicateProofs))
+	for i, pp := range p.PredicateProofs {
+		pbPredicateProofs[i] = toPbPredicateProof(pp)
+	}
+
+	revealedKnownAttrs := make([]int32, len(p.RevealedKnownAttrsIndices))
+	for i, a := range p.RevealedKnownAttrsIndices {
+		revealedKnownAttrs[i] = int32(a)
+	}
+	revealedCommitmentsOfAttrs := make([]int32,
+		len(p.RevealedCommitmentsOfAttrsIndices))
+	for i, a := range p.RevealedCommitmentsOfAttrsIndices {
+		revealedCommitmentsOfAttrs[i] = int32(a)
+	}
+
+	return &pb.CredProof{
+		A: p.A.Bytes(),
+		Proof: &pb.FiatShamirAlsoNeg{
+			ProofRandomData: p.Proof.ProofRandomData.Bytes(),
+			Challenge:       p.Proof.Challenge.Bytes(),
+			ProofData:       toStringSlices(p.Proof.ProofData),
+		},
+		KnownAttrs:                 toByteSlices(p.RevealedKnownAttrs),
+		CommitmentsOfAttrs:         toByteSlices(p.RevealedCommitmentsOfAttrs),
+		RevealedKnownAttrs:         revealedKnownAttrs,
+		RevealedCommitmentsOfAttrs: revealedCommitmentsOfAttrs,
+		PredicateProofs:            pbPredicateProofs,
+		NonRevocationProof:         toPbNonRevocationProof(p.NonRevocationProof),
+		KeyId:                      p.KeyID,
+	}
+}
+
 func emptyRequest() *pb.Request {
 	return &pb.Request{
 		Type: &pb.Request_Empty{
@@ -428,6 +1035,29 @@ func filterSlice(s []*big.Int, revealed []int) []*big.Int {
 	return res
 }
 
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// union returns the elements of a, followed by the elements of b that
+// are not in a.
+func union(a, b []string) []string {
+	res := append([]string{}, a...)
+	for _, e := range b {
+		if !contains(res, e) {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
 func toByteSlices(s []*big.Int) [][]byte {
 	res := make([][]byte, len(s))
 	for i, si := range s {
@@ -437,6 +1067,24 @@ func toByteSlices(s []*big.Int) [][]byte {
 	return res
 }
 
+// toPbOpeningProofs converts proofs of openings of commitments to
+// protobuf messages.
+func toPbOpeningProofs(proofs []*df.OpeningProof) []*pb.FiatShamir {
+	res := make([]*pb.FiatShamir, len(proofs))
+	for i, proof := range proofs {
+		res[i] = &pb.FiatShamir{
+			ProofRandomData: proof.ProofRandomData.Bytes(),
+			Challenge:       proof.Challenge.Bytes(),
+			ProofData: [][]byte{
+				proof.ProofData1.Bytes(),
+				proof.ProofData2.Bytes(),
+			},
+		}
+	}
+
+	return res
+}
+
 func toStringSlices(s []*big.Int) []string {
 	res := make([]string, len(s))
 	for i, p := range s {
@@ -445,3 +1093,82 @@ func toStringSlices(s []*big.Int) []string {
 
 	return res
 }
+
+func toPbPredicateProof(p *PredicateProof) *pb.PredicateProof {
+	proof := &pb.PredicateProof{
+		KnownAttrIndex: int32(p.KnownAttrIndex),
+	}
+
+	if rp := p.Range; rp != nil {
+		proof.Type = &pb.PredicateProof_Range{
+			Range: &pb.RangeProof{
+				T:           toByteSlices(rp.T),
+				TDelta:      rp.TDelta.Bytes(),
+				TTilde:      toByteSlices(rp.TTilde),
+				TDeltaTilde: rp.TDeltaTilde.Bytes(),
+				QTilde:      rp.QTilde.Bytes(),
+				UHat:        toStringSlices(rp.UHat),
+				RHat:        toStringSlices(rp.RHat),
+				RDeltaHat:   rp.RDeltaHat.String(),
+				AlphaHat:    rp.AlphaHat.String(),
+			},
+		}
+	}
+	if sp := p.Set; sp != nil {
+		proof.Type = &pb.PredicateProof_Set{
+			Set: &pb.SetMembershipProof{
+				C:          sp.C.Bytes(),
+				CTilde:     sp.CTilde.Bytes(),
+				TTilde:     toByteSlices(sp.TTilde),
+				Challenges: toByteSlices(sp.Challenges),
+				RHat:       sp.RHat.String(),
+				RhoHat:     toStringSlices(sp.RhoHat),
+			},
+		}
+	}
+
+	return proof
+}
+
+// toPbNonRevocationProof converts p, which can be nil, to a protobuf
+// message.
+func toPbNonRevocationProof(p *NonRevocationProof) *pb.NonRevocationProof {
+	if p == nil {
+		return nil
+	}
+
+	return &pb.NonRevocationProof{
+		CU:        p.CU.Bytes(),
+		CR:        p.CR.Bytes(),
+		CRTilde:   p.CRTilde.Bytes(),
+		OneTilde:  p.OneTilde.Bytes(),
+		AccTilde:  p.AccTilde.Bytes(),
+		R2Hat:     p.R2Hat.String(),
+		R3Hat:     p.R3Hat.String(),
+		Delta1Hat: p.Delta1Hat.String(),
+		Delta2Hat: p.Delta2Hat.String(),
+	}
+}
+
+func toPbScopePseudonymProof(p *ScopePseudonymProof) *pb.ScopePseudonymProof {
+	if p == nil {
+		return nil
+	}
+
+	return &pb.ScopePseudonymProof{
+		Nym:      p.Nym.Bytes(),
+		NymTilde: p.NymTilde.Bytes(),
+	}
+}
+
+// fromPbWitness converts w, which can be nil, from a protobuf message.
+func fromPbWitness(w *pb.Witness) *Witness {
+	if w == nil {
+		return nil
+	}
+
+	return &Witness{
+		Version: w.Version,
+		Value:   new(big.Int).SetBytes(w.Value),
+	}
+}
@@ -1,46 +1,14 @@
-// Code generated by protoc-gen-go.
+// Code generated by protoc-gen-go. DO NOT EDIT.
 // source: anauth/cl/clpb/cl.proto
-// DO NOT EDIT!
-
-/*
-Package clpb is a generated protocol buffer package.
-
-It is generated from these files:
-	anauth/cl/clpb/cl.proto
-
-It has these top-level messages:
-	Request
-	Response
-	Empty
-	SchnorrGroup
-	PedersenParams
-	PubKey
-	Params
-	PublicParams
-	CredIssueRequest
-	Cred
-	IssuedCred
-	CredUpdateRequest
-	CredProof
-	FiatShamir
-	FiatShamirAlsoNeg
-	AcceptableCred
-	AcceptableCreds
-	Attribute
-	IntAttribute
-	StringAttribute
-	CredAttribute
-	CredStructure
-*/
-package clpb
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
+package clpb
 
 import (
-	context "golang.org/x/net/context"
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
+	math "math"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -52,7 +20,7 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type Request struct {
 	// Types that are valid to be assigned to Type:
@@ -60,36 +28,93 @@ type Request struct {
 	//	*Request_RegKey
 	//	*Request_CredIssue
 	//	*Request_CredProve
-	Type isRequest_Type `protobuf_oneof:"type"`
+	//	*Request_CredUpdate
+	//	*Request_KeyId
+	//	*Request_KeyIds
+	//	*Request_MultiCredProve
+	Type                 isRequest_Type `protobuf_oneof:"type"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+func (*Request) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{0}
+}
+
+func (m *Request) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Request.Unmarshal(m, b)
+}
+func (m *Request) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Request.Marshal(b, m, deterministic)
+}
+func (m *Request) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Request.Merge(m, src)
+}
+func (m *Request) XXX_Size() int {
+	return xxx_messageInfo_Request.Size(m)
+}
+func (m *Request) XXX_DiscardUnknown() {
+	xxx_messageInfo_Request.DiscardUnknown(m)
 }
 
-func (m *Request) Reset()                    { *m = Request{} }
-func (m *Request) String() string            { return proto.CompactTextString(m) }
-func (*Request) ProtoMessage()               {}
-func (*Request) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+var xxx_messageInfo_Request proto.InternalMessageInfo
 
 type isRequest_Type interface {
 	isRequest_Type()
 }
 
 type Request_Empty struct {
-	Empty *Empty `protobuf:"bytes,1,opt,name=empty,oneof"`
+	Empty *Empty `protobuf:"bytes,1,opt,name=empty,proto3,oneof"`
 }
+
 type Request_RegKey struct {
-	RegKey string `protobuf:"bytes,2,opt,name=regKey,oneof"`
+	RegKey string `protobuf:"bytes,2,opt,name=regKey,proto3,oneof"`
 }
+
 type Request_CredIssue struct {
-	CredIssue *CredIssueRequest `protobuf:"bytes,3,opt,name=credIssue,oneof"`
+	CredIssue *CredIssueRequest `protobuf:"bytes,3,opt,name=credIssue,proto3,oneof"`
 }
+
 type Request_CredProve struct {
-	CredProve *CredProof `protobuf:"bytes,4,opt,name=credProve,oneof"`
+	CredProve *CredProof `protobuf:"bytes,4,opt,name=credProve,proto3,oneof"`
+}
+
+type Request_CredUpdate struct {
+	CredUpdate *CredUpdateRequest `protobuf:"bytes,5,opt,name=credUpdate,proto3,oneof"`
+}
+
+type Request_KeyId struct {
+	KeyId string `protobuf:"bytes,6,opt,name=keyId,proto3,oneof"`
+}
+
+type Request_KeyIds struct {
+	KeyIds *KeyIds `protobuf:"bytes,7,opt,name=keyIds,proto3,oneof"`
+}
+
+type Request_MultiCredProve struct {
+	MultiCredProve *MultiCredProof `protobuf:"bytes,8,opt,name=multiCredProve,proto3,oneof"`
 }
 
-func (*Request_Empty) isRequest_Type()     {}
-func (*Request_RegKey) isRequest_Type()    {}
+func (*Request_Empty) isRequest_Type() {}
+
+func (*Request_RegKey) isRequest_Type() {}
+
 func (*Request_CredIssue) isRequest_Type() {}
+
 func (*Request_CredProve) isRequest_Type() {}
 
+func (*Request_CredUpdate) isRequest_Type() {}
+
+func (*Request_KeyId) isRequest_Type() {}
+
+func (*Request_KeyIds) isRequest_Type() {}
+
+func (*Request_MultiCredProve) isRequest_Type() {}
+
 func (m *Request) GetType() isRequest_Type {
 	if m != nil {
 		return m.Type
@@ -125,112 +150,85 @@ func (m *Request) GetCredProve() *CredProof {
 	return nil
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*Request) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _Request_OneofMarshaler, _Request_OneofUnmarshaler, _Request_OneofSizer, []interface{}{
+func (m *Request) GetCredUpdate() *CredUpdateRequest {
+	if x, ok := m.GetType().(*Request_CredUpdate); ok {
+		return x.CredUpdate
+	}
+	return nil
+}
+
+func (m *Request) GetKeyId() string {
+	if x, ok := m.GetType().(*Request_KeyId); ok {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (m *Request) GetKeyIds() *KeyIds {
+	if x, ok := m.GetType().(*Request_KeyIds); ok {
+		return x.KeyIds
+	}
+	return nil
+}
+
+func (m *Request) GetMultiCredProve() *MultiCredProof {
+	if x, ok := m.GetType().(*Request_MultiCredProve); ok {
+		return x.MultiCredProve
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Request) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
 		(*Request_Empty)(nil),
 		(*Request_RegKey)(nil),
 		(*Request_CredIssue)(nil),
 		(*Request_CredProve)(nil),
+		(*Request_CredUpdate)(nil),
+		(*Request_KeyId)(nil),
+		(*Request_KeyIds)(nil),
+		(*Request_MultiCredProve)(nil),
 	}
 }
 
-func _Request_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*Request)
-	// type
-	switch x := m.Type.(type) {
-	case *Request_Empty:
-		b.EncodeVarint(1<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.Empty); err != nil {
-			return err
-		}
-	case *Request_RegKey:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		b.EncodeStringBytes(x.RegKey)
-	case *Request_CredIssue:
-		b.EncodeVarint(3<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.CredIssue); err != nil {
-			return err
-		}
-	case *Request_CredProve:
-		b.EncodeVarint(4<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.CredProve); err != nil {
-			return err
-		}
-	case nil:
-	default:
-		return fmt.Errorf("Request.Type has unexpected type %T", x)
-	}
-	return nil
-}
-
-func _Request_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*Request)
-	switch tag {
-	case 1: // type.empty
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(Empty)
-		err := b.DecodeMessage(msg)
-		m.Type = &Request_Empty{msg}
-		return true, err
-	case 2: // type.regKey
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeStringBytes()
-		m.Type = &Request_RegKey{x}
-		return true, err
-	case 3: // type.credIssue
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(CredIssueRequest)
-		err := b.DecodeMessage(msg)
-		m.Type = &Request_CredIssue{msg}
-		return true, err
-	case 4: // type.credProve
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(CredProof)
-		err := b.DecodeMessage(msg)
-		m.Type = &Request_CredProve{msg}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _Request_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*Request)
-	// type
-	switch x := m.Type.(type) {
-	case *Request_Empty:
-		s := proto.Size(x.Empty)
-		n += proto.SizeVarint(1<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Request_RegKey:
-		n += proto.SizeVarint(2<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(len(x.RegKey)))
-		n += len(x.RegKey)
-	case *Request_CredIssue:
-		s := proto.Size(x.CredIssue)
-		n += proto.SizeVarint(3<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Request_CredProve:
-		s := proto.Size(x.CredProve)
-		n += proto.SizeVarint(4<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
+type KeyIds struct {
+	Ids                  []string `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyIds) Reset()         { *m = KeyIds{} }
+func (m *KeyIds) String() string { return proto.CompactTextString(m) }
+func (*KeyIds) ProtoMessage()    {}
+func (*KeyIds) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{1}
+}
+
+func (m *KeyIds) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyIds.Unmarshal(m, b)
+}
+func (m *KeyIds) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyIds.Marshal(b, m, deterministic)
+}
+func (m *KeyIds) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyIds.Merge(m, src)
+}
+func (m *KeyIds) XXX_Size() int {
+	return xxx_messageInfo_KeyIds.Size(m)
+}
+func (m *KeyIds) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyIds.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyIds proto.InternalMessageInfo
+
+func (m *KeyIds) GetIds() []string {
+	if m != nil {
+		return m.Ids
+	}
+	return nil
 }
 
 type Response struct {
@@ -238,13 +236,37 @@ type Response struct {
 	//	*Response_Nonce
 	//	*Response_IssuedCred
 	//	*Response_SessionKey
-	Type isResponse_Type `protobuf_oneof:"type"`
+	//	*Response_ProofParams
+	Type                 isResponse_Type `protobuf_oneof:"type"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+func (*Response) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{2}
+}
+
+func (m *Response) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Response.Unmarshal(m, b)
+}
+func (m *Response) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Response.Marshal(b, m, deterministic)
+}
+func (m *Response) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Response.Merge(m, src)
+}
+func (m *Response) XXX_Size() int {
+	return xxx_messageInfo_Response.Size(m)
+}
+func (m *Response) XXX_DiscardUnknown() {
+	xxx_messageInfo_Response.DiscardUnknown(m)
 }
 
-func (m *Response) Reset()                    { *m = Response{} }
-func (m *Response) String() string            { return proto.CompactTextString(m) }
-func (*Response) ProtoMessage()               {}
-func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+var xxx_messageInfo_Response proto.InternalMessageInfo
 
 type isResponse_Type interface {
 	isResponse_Type()
@@ -253,17 +275,27 @@ type isResponse_Type interface {
 type Response_Nonce struct {
 	Nonce []byte `protobuf:"bytes,1,opt,name=nonce,proto3,oneof"`
 }
+
 type Response_IssuedCred struct {
-	IssuedCred *IssuedCred `protobuf:"bytes,2,opt,name=issuedCred,oneof"`
+	IssuedCred *IssuedCred `protobuf:"bytes,2,opt,name=issuedCred,proto3,oneof"`
 }
+
 type Response_SessionKey struct {
-	SessionKey string `protobuf:"bytes,3,opt,name=sessionKey,oneof"`
+	SessionKey string `protobuf:"bytes,3,opt,name=sessionKey,proto3,oneof"`
 }
 
-func (*Response_Nonce) isResponse_Type()      {}
+type Response_ProofParams struct {
+	ProofParams *ProofParams `protobuf:"bytes,4,opt,name=proofParams,proto3,oneof"`
+}
+
+func (*Response_Nonce) isResponse_Type() {}
+
 func (*Response_IssuedCred) isResponse_Type() {}
+
 func (*Response_SessionKey) isResponse_Type() {}
 
+func (*Response_ProofParams) isResponse_Type() {}
+
 func (m *Response) GetType() isResponse_Type {
 	if m != nil {
 		return m.Type
@@ -292,782 +324,2747 @@ func (m *Response) GetSessionKey() string {
 	return ""
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*Response) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _Response_OneofMarshaler, _Response_OneofUnmarshaler, _Response_OneofSizer, []interface{}{
+func (m *Response) GetProofParams() *ProofParams {
+	if x, ok := m.GetType().(*Response_ProofParams); ok {
+		return x.ProofParams
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Response) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
 		(*Response_Nonce)(nil),
 		(*Response_IssuedCred)(nil),
 		(*Response_SessionKey)(nil),
+		(*Response_ProofParams)(nil),
 	}
 }
 
-func _Response_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*Response)
-	// type
-	switch x := m.Type.(type) {
-	case *Response_Nonce:
-		b.EncodeVarint(1<<3 | proto.WireBytes)
-		b.EncodeRawBytes(x.Nonce)
-	case *Response_IssuedCred:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.IssuedCred); err != nil {
-			return err
-		}
-	case *Response_SessionKey:
-		b.EncodeVarint(3<<3 | proto.WireBytes)
-		b.EncodeStringBytes(x.SessionKey)
-	case nil:
-	default:
-		return fmt.Errorf("Response.Type has unexpected type %T", x)
-	}
-	return nil
-}
-
-func _Response_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*Response)
-	switch tag {
-	case 1: // type.nonce
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeRawBytes(true)
-		m.Type = &Response_Nonce{x}
-		return true, err
-	case 2: // type.issuedCred
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(IssuedCred)
-		err := b.DecodeMessage(msg)
-		m.Type = &Response_IssuedCred{msg}
-		return true, err
-	case 3: // type.sessionKey
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeStringBytes()
-		m.Type = &Response_SessionKey{x}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _Response_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*Response)
-	// type
-	switch x := m.Type.(type) {
-	case *Response_Nonce:
-		n += proto.SizeVarint(1<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(len(x.Nonce)))
-		n += len(x.Nonce)
-	case *Response_IssuedCred:
-		s := proto.Size(x.IssuedCred)
-		n += proto.SizeVarint(2<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Response_SessionKey:
-		n += proto.SizeVarint(3<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(len(x.SessionKey)))
-		n += len(x.SessionKey)
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
+type ProofParams struct {
+	Nonce                []byte             `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Predicates           []*Predicate       `protobuf:"bytes,2,rep,name=predicates,proto3" json:"predicates,omitempty"`
+	Accumulator          *Accumulator       `protobuf:"bytes,3,opt,name=accumulator,proto3" json:"accumulator,omitempty"`
+	Scope                string             `protobuf:"bytes,4,opt,name=scope,proto3" json:"scope,omitempty"`
+	Verifier             string             `protobuf:"bytes,5,opt,name=verifier,proto3" json:"verifier,omitempty"`
+	Creds                []*CredProofParams `protobuf:"bytes,6,rep,name=creds,proto3" json:"creds,omitempty"`
+	Request              *ProofRequest      `protobuf:"bytes,7,opt,name=request,proto3" json:"request,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
-type Empty struct {
+func (m *ProofParams) Reset()         { *m = ProofParams{} }
+func (m *ProofParams) String() string { return proto.CompactTextString(m) }
+func (*ProofParams) ProtoMessage()    {}
+func (*ProofParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{3}
 }
 
-func (m *Empty) Reset()                    { *m = Empty{} }
-func (m *Empty) String() string            { return proto.CompactTextString(m) }
-func (*Empty) ProtoMessage()               {}
-func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
-
-type SchnorrGroup struct {
-	P []byte `protobuf:"bytes,2,opt,name=p,proto3" json:"p,omitempty"`
-	G []byte `protobuf:"bytes,3,opt,name=g,proto3" json:"g,omitempty"`
-	Q []byte `protobuf:"bytes,4,opt,name=q,proto3" json:"q,omitempty"`
+func (m *ProofParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProofParams.Unmarshal(m, b)
+}
+func (m *ProofParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProofParams.Marshal(b, m, deterministic)
+}
+func (m *ProofParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProofParams.Merge(m, src)
+}
+func (m *ProofParams) XXX_Size() int {
+	return xxx_messageInfo_ProofParams.Size(m)
+}
+func (m *ProofParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProofParams.DiscardUnknown(m)
 }
 
-func (m *SchnorrGroup) Reset()                    { *m = SchnorrGroup{} }
-func (m *SchnorrGroup) String() string            { return proto.CompactTextString(m) }
-func (*SchnorrGroup) ProtoMessage()               {}
-func (*SchnorrGroup) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+var xxx_messageInfo_ProofParams proto.InternalMessageInfo
 
-func (m *SchnorrGroup) GetP() []byte {
+func (m *ProofParams) GetNonce() []byte {
 	if m != nil {
-		return m.P
+		return m.Nonce
 	}
 	return nil
 }
 
-func (m *SchnorrGroup) GetG() []byte {
+func (m *ProofParams) GetPredicates() []*Predicate {
 	if m != nil {
-		return m.G
+		return m.Predicates
 	}
 	return nil
 }
 
-func (m *SchnorrGroup) GetQ() []byte {
+func (m *ProofParams) GetAccumulator() *Accumulator {
 	if m != nil {
-		return m.Q
+		return m.Accumulator
 	}
 	return nil
 }
 
-type PedersenParams struct {
-	SchnorrGroup *SchnorrGroup `protobuf:"bytes,1,opt,name=schnorrGroup" json:"schnorrGroup,omitempty"`
-	H            []byte        `protobuf:"bytes,2,opt,name=h,proto3" json:"h,omitempty"`
+func (m *ProofParams) GetScope() string {
+	if m != nil {
+		return m.Scope
+	}
+	return ""
 }
 
-func (m *PedersenParams) Reset()                    { *m = PedersenParams{} }
-func (m *PedersenParams) String() string            { return proto.CompactTextString(m) }
-func (*PedersenParams) ProtoMessage()               {}
-func (*PedersenParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
-
-func (m *PedersenParams) GetSchnorrGroup() *SchnorrGroup {
+func (m *ProofParams) GetVerifier() string {
 	if m != nil {
-		return m.SchnorrGroup
+		return m.Verifier
 	}
-	return nil
+	return ""
 }
 
-func (m *PedersenParams) GetH() []byte {
+func (m *ProofParams) GetCreds() []*CredProofParams {
 	if m != nil {
-		return m.H
+		return m.Creds
 	}
 	return nil
 }
 
-type PubKey struct {
-	N              []byte          `protobuf:"bytes,1,opt,name=n,proto3" json:"n,omitempty"`
-	S              []byte          `protobuf:"bytes,2,opt,name=s,proto3" json:"s,omitempty"`
-	Z              []byte          `protobuf:"bytes,3,opt,name=z,proto3" json:"z,omitempty"`
-	RsKnown        [][]byte        `protobuf:"bytes,4,rep,name=rsKnown,proto3" json:"rsKnown,omitempty"`
-	RsCommitted    [][]byte        `protobuf:"bytes,5,rep,name=rsCommitted,proto3" json:"rsCommitted,omitempty"`
-	RsHidden       [][]byte        `protobuf:"bytes,6,rep,name=rsHidden,proto3" json:"rsHidden,omitempty"`
-	PedersenParams *PedersenParams `protobuf:"bytes,7,opt,name=pedersenParams" json:"pedersenParams,omitempty"`
-	N1             []byte          `protobuf:"bytes,8,opt,name=n1,proto3" json:"n1,omitempty"`
-	G              []byte          `protobuf:"bytes,9,opt,name=g,proto3" json:"g,omitempty"`
-	H              []byte          `protobuf:"bytes,10,opt,name=h,proto3" json:"h,omitempty"`
-}
-
-func (m *PubKey) Reset()                    { *m = PubKey{} }
-func (m *PubKey) String() string            { return proto.CompactTextString(m) }
-func (*PubKey) ProtoMessage()               {}
-func (*PubKey) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
-
-func (m *PubKey) GetN() []byte {
+func (m *ProofParams) GetRequest() *ProofRequest {
 	if m != nil {
-		return m.N
+		return m.Request
 	}
 	return nil
 }
 
-func (m *PubKey) GetS() []byte {
+type ProofRequest struct {
+	RevealedAttrs        []string          `protobuf:"bytes,1,rep,name=revealedAttrs,proto3" json:"revealedAttrs,omitempty"`
+	AcceptedIssuers      []*AcceptedIssuer `protobuf:"bytes,2,rep,name=acceptedIssuers,proto3" json:"acceptedIssuers,omitempty"`
+	Purpose              string            `protobuf:"bytes,3,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ProofRequest) Reset()         { *m = ProofRequest{} }
+func (m *ProofRequest) String() string { return proto.CompactTextString(m) }
+func (*ProofRequest) ProtoMessage()    {}
+func (*ProofRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{4}
+}
+
+func (m *ProofRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProofRequest.Unmarshal(m, b)
+}
+func (m *ProofRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProofRequest.Marshal(b, m, deterministic)
+}
+func (m *ProofRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProofRequest.Merge(m, src)
+}
+func (m *ProofRequest) XXX_Size() int {
+	return xxx_messageInfo_ProofRequest.Size(m)
+}
+func (m *ProofRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProofRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProofRequest proto.InternalMessageInfo
+
+func (m *ProofRequest) GetRevealedAttrs() []string {
 	if m != nil {
-		return m.S
+		return m.RevealedAttrs
 	}
 	return nil
 }
 
-func (m *PubKey) GetZ() []byte {
+func (m *ProofRequest) GetAcceptedIssuers() []*AcceptedIssuer {
 	if m != nil {
-		return m.Z
+		return m.AcceptedIssuers
 	}
 	return nil
 }
 
-func (m *PubKey) GetRsKnown() [][]byte {
+func (m *ProofRequest) GetPurpose() string {
 	if m != nil {
-		return m.RsKnown
+		return m.Purpose
 	}
-	return nil
+	return ""
 }
 
-func (m *PubKey) GetRsCommitted() [][]byte {
+type AcceptedIssuer struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	KeyId                string   `protobuf:"bytes,2,opt,name=keyId,proto3" json:"keyId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcceptedIssuer) Reset()         { *m = AcceptedIssuer{} }
+func (m *AcceptedIssuer) String() string { return proto.CompactTextString(m) }
+func (*AcceptedIssuer) ProtoMessage()    {}
+func (*AcceptedIssuer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{5}
+}
+
+func (m *AcceptedIssuer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcceptedIssuer.Unmarshal(m, b)
+}
+func (m *AcceptedIssuer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcceptedIssuer.Marshal(b, m, deterministic)
+}
+func (m *AcceptedIssuer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcceptedIssuer.Merge(m, src)
+}
+func (m *AcceptedIssuer) XXX_Size() int {
+	return xxx_messageInfo_AcceptedIssuer.Size(m)
+}
+func (m *AcceptedIssuer) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcceptedIssuer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcceptedIssuer proto.InternalMessageInfo
+
+func (m *AcceptedIssuer) GetName() string {
 	if m != nil {
-		return m.RsCommitted
+		return m.Name
 	}
-	return nil
+	return ""
 }
 
-func (m *PubKey) GetRsHidden() [][]byte {
+func (m *AcceptedIssuer) GetKeyId() string {
 	if m != nil {
-		return m.RsHidden
+		return m.KeyId
 	}
-	return nil
+	return ""
 }
 
-func (m *PubKey) GetPedersenParams() *PedersenParams {
+type CredProofParams struct {
+	KeyId                string       `protobuf:"bytes,1,opt,name=keyId,proto3" json:"keyId,omitempty"`
+	Predicates           []*Predicate `protobuf:"bytes,2,rep,name=predicates,proto3" json:"predicates,omitempty"`
+	Accumulator          *Accumulator `protobuf:"bytes,3,opt,name=accumulator,proto3" json:"accumulator,omitempty"`
+	RevealedAttrs        []string     `protobuf:"bytes,4,rep,name=revealedAttrs,proto3" json:"revealedAttrs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CredProofParams) Reset()         { *m = CredProofParams{} }
+func (m *CredProofParams) String() string { return proto.CompactTextString(m) }
+func (*CredProofParams) ProtoMessage()    {}
+func (*CredProofParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{6}
+}
+
+func (m *CredProofParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CredProofParams.Unmarshal(m, b)
+}
+func (m *CredProofParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CredProofParams.Marshal(b, m, deterministic)
+}
+func (m *CredProofParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CredProofParams.Merge(m, src)
+}
+func (m *CredProofParams) XXX_Size() int {
+	return xxx_messageInfo_CredProofParams.Size(m)
+}
+func (m *CredProofParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_CredProofParams.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CredProofParams proto.InternalMessageInfo
+
+func (m *CredProofParams) GetKeyId() string {
 	if m != nil {
-		return m.PedersenParams
+		return m.KeyId
 	}
-	return nil
+	return ""
 }
 
-func (m *PubKey) GetN1() []byte {
+func (m *CredProofParams) GetPredicates() []*Predicate {
 	if m != nil {
-		return m.N1
+		return m.Predicates
 	}
 	return nil
 }
 
-func (m *PubKey) GetG() []byte {
+func (m *CredProofParams) GetAccumulator() *Accumulator {
 	if m != nil {
-		return m.G
+		return m.Accumulator
 	}
 	return nil
 }
 
-func (m *PubKey) GetH() []byte {
+func (m *CredProofParams) GetRevealedAttrs() []string {
 	if m != nil {
-		return m.H
+		return m.RevealedAttrs
 	}
 	return nil
 }
 
-type Params struct {
-	// There are only a few possibilities for RhoBitLen. 256 implies that the modulus
-	// bit length is 2048
-	RhoBitLen  int32 `protobuf:"varint,1,opt,name=RhoBitLen" json:"RhoBitLen,omitempty"`
-	NLength    int32 `protobuf:"varint,2,opt,name=NLength" json:"NLength,omitempty"`
-	AttrBitLen int32 `protobuf:"varint,6,opt,name=AttrBitLen" json:"AttrBitLen,omitempty"`
-	HashBitLen int32 `protobuf:"varint,7,opt,name=HashBitLen" json:"HashBitLen,omitempty"`
-	// Fiat-Shamir
-	SecParam int32 `protobuf:"varint,8,opt,name=SecParam" json:"SecParam,omitempty"`
-	EBitLen  int32 `protobuf:"varint,9,opt,name=EBitLen" json:"EBitLen,omitempty"`
-	E1BitLen int32 `protobuf:"varint,10,opt,name=E1BitLen" json:"E1BitLen,omitempty"`
-	// from
-	VBitLen        int32 `protobuf:"varint,11,opt,name=VBitLen" json:"VBitLen,omitempty"`
-	ChallengeSpace int32 `protobuf:"varint,12,opt,name=ChallengeSpace" json:"ChallengeSpace,omitempty"`
-}
-
-func (m *Params) Reset()                    { *m = Params{} }
-func (m *Params) String() string            { return proto.CompactTextString(m) }
-func (*Params) ProtoMessage()               {}
-func (*Params) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+type Predicate struct {
+	Attr                 string   `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	Cond                 string   `protobuf:"bytes,2,opt,name=cond,proto3" json:"cond,omitempty"`
+	Value                int64    `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	Set                  []string `protobuf:"bytes,4,rep,name=set,proto3" json:"set,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
 
-func (m *Params) GetRhoBitLen() int32 {
-	if m != nil {
-		return m.RhoBitLen
-	}
-	return 0
+func (m *Predicate) Reset()         { *m = Predicate{} }
+func (m *Predicate) String() string { return proto.CompactTextString(m) }
+func (*Predicate) ProtoMessage()    {}
+func (*Predicate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{7}
 }
 
-func (m *Params) GetNLength() int32 {
-	if m != nil {
-		return m.NLength
-	}
-	return 0
+func (m *Predicate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Predicate.Unmarshal(m, b)
+}
+func (m *Predicate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Predicate.Marshal(b, m, deterministic)
+}
+func (m *Predicate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Predicate.Merge(m, src)
+}
+func (m *Predicate) XXX_Size() int {
+	return xxx_messageInfo_Predicate.Size(m)
+}
+func (m *Predicate) XXX_DiscardUnknown() {
+	xxx_messageInfo_Predicate.DiscardUnknown(m)
 }
 
-func (m *Params) GetAttrBitLen() int32 {
+var xxx_messageInfo_Predicate proto.InternalMessageInfo
+
+func (m *Predicate) GetAttr() string {
 	if m != nil {
-		return m.AttrBitLen
+		return m.Attr
 	}
-	return 0
+	return ""
 }
 
-func (m *Params) GetHashBitLen() int32 {
+func (m *Predicate) GetCond() string {
 	if m != nil {
-		return m.HashBitLen
+		return m.Cond
 	}
-	return 0
+	return ""
 }
 
-func (m *Params) GetSecParam() int32 {
+func (m *Predicate) GetValue() int64 {
 	if m != nil {
-		return m.SecParam
+		return m.Value
 	}
 	return 0
 }
 
-func (m *Params) GetEBitLen() int32 {
+func (m *Predicate) GetSet() []string {
 	if m != nil {
-		return m.EBitLen
+		return m.Set
 	}
-	return 0
+	return nil
 }
 
-func (m *Params) GetE1BitLen() int32 {
-	if m != nil {
-		return m.E1BitLen
-	}
-	return 0
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *Params) GetVBitLen() int32 {
-	if m != nil {
-		return m.VBitLen
-	}
-	return 0
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{8}
 }
 
-func (m *Params) GetChallengeSpace() int32 {
-	if m != nil {
-		return m.ChallengeSpace
-	}
-	return 0
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
 }
 
-type PublicParams struct {
-	PubKey        *PubKey        `protobuf:"bytes,1,opt,name=pubKey" json:"pubKey,omitempty"`
-	Params        *Params        `protobuf:"bytes,2,opt,name=params" json:"params,omitempty"`
-	CredStructure *CredStructure `protobuf:"bytes,3,opt,name=credStructure" json:"credStructure,omitempty"`
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+type SchnorrGroup struct {
+	P                    []byte   `protobuf:"bytes,2,opt,name=p,proto3" json:"p,omitempty"`
+	G                    []byte   `protobuf:"bytes,3,opt,name=g,proto3" json:"g,omitempty"`
+	Q                    []byte   `protobuf:"bytes,4,opt,name=q,proto3" json:"q,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *PublicParams) Reset()                    { *m = PublicParams{} }
-func (m *PublicParams) String() string            { return proto.CompactTextString(m) }
-func (*PublicParams) ProtoMessage()               {}
-func (*PublicParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (m *SchnorrGroup) Reset()         { *m = SchnorrGroup{} }
+func (m *SchnorrGroup) String() string { return proto.CompactTextString(m) }
+func (*SchnorrGroup) ProtoMessage()    {}
+func (*SchnorrGroup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{9}
+}
 
-func (m *PublicParams) GetPubKey() *PubKey {
+func (m *SchnorrGroup) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SchnorrGroup.Unmarshal(m, b)
+}
+func (m *SchnorrGroup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SchnorrGroup.Marshal(b, m, deterministic)
+}
+func (m *SchnorrGroup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SchnorrGroup.Merge(m, src)
+}
+func (m *SchnorrGroup) XXX_Size() int {
+	return xxx_messageInfo_SchnorrGroup.Size(m)
+}
+func (m *SchnorrGroup) XXX_DiscardUnknown() {
+	xxx_messageInfo_SchnorrGroup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SchnorrGroup proto.InternalMessageInfo
+
+func (m *SchnorrGroup) GetP() []byte {
 	if m != nil {
-		return m.PubKey
+		return m.P
 	}
 	return nil
 }
 
-func (m *PublicParams) GetParams() *Params {
+func (m *SchnorrGroup) GetG() []byte {
 	if m != nil {
-		return m.Params
+		return m.G
 	}
 	return nil
 }
 
-func (m *PublicParams) GetCredStructure() *CredStructure {
+func (m *SchnorrGroup) GetQ() []byte {
 	if m != nil {
-		return m.CredStructure
+		return m.Q
 	}
 	return nil
 }
 
-type CredIssueRequest struct {
-	Nym                      []byte             `protobuf:"bytes,1,opt,name=Nym,proto3" json:"Nym,omitempty"`
-	KnownAttrs               [][]byte           `protobuf:"bytes,2,rep,name=KnownAttrs,proto3" json:"KnownAttrs,omitempty"`
-	CommitmentsOfAttrs       [][]byte           `protobuf:"bytes,3,rep,name=CommitmentsOfAttrs,proto3" json:"CommitmentsOfAttrs,omitempty"`
-	NymProof                 *FiatShamir        `protobuf:"bytes,4,opt,name=NymProof" json:"NymProof,omitempty"`
-	U                        []byte             `protobuf:"bytes,5,opt,name=U,proto3" json:"U,omitempty"`
-	UProof                   *FiatShamirAlsoNeg `protobuf:"bytes,6,opt,name=UProof" json:"UProof,omitempty"`
-	CommitmentsOfAttrsProofs []*FiatShamir      `protobuf:"bytes,7,rep,name=CommitmentsOfAttrsProofs" json:"CommitmentsOfAttrsProofs,omitempty"`
-	Nonce                    []byte             `protobuf:"bytes,8,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
-	RegKey                   string             `protobuf:"bytes,9,opt,name=RegKey" json:"RegKey,omitempty"`
+type PedersenParams struct {
+	SchnorrGroup         *SchnorrGroup `protobuf:"bytes,1,opt,name=schnorrGroup,proto3" json:"schnorrGroup,omitempty"`
+	H                    []byte        `protobuf:"bytes,2,opt,name=h,proto3" json:"h,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
-func (m *CredIssueRequest) Reset()                    { *m = CredIssueRequest{} }
-func (m *CredIssueRequest) String() string            { return proto.CompactTextString(m) }
-func (*CredIssueRequest) ProtoMessage()               {}
-func (*CredIssueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (m *PedersenParams) Reset()         { *m = PedersenParams{} }
+func (m *PedersenParams) String() string { return proto.CompactTextString(m) }
+func (*PedersenParams) ProtoMessage()    {}
+func (*PedersenParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{10}
+}
 
-func (m *CredIssueRequest) GetNym() []byte {
-	if m != nil {
-		return m.Nym
-	}
+func (m *PedersenParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PedersenParams.Unmarshal(m, b)
+}
+func (m *PedersenParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PedersenParams.Marshal(b, m, deterministic)
+}
+func (m *PedersenParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PedersenParams.Merge(m, src)
+}
+func (m *PedersenParams) XXX_Size() int {
+	return xxx_messageInfo_PedersenParams.Size(m)
+}
+func (m *PedersenParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_PedersenParams.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PedersenParams proto.InternalMessageInfo
+
+func (m *PedersenParams) GetSchnorrGroup() *SchnorrGroup {
+	if m != nil {
+		return m.SchnorrGroup
+	}
+	return nil
+}
+
+func (m *PedersenParams) GetH() []byte {
+	if m != nil {
+		return m.H
+	}
+	return nil
+}
+
+type PubKey struct {
+	N                    []byte          `protobuf:"bytes,1,opt,name=n,proto3" json:"n,omitempty"`
+	S                    []byte          `protobuf:"bytes,2,opt,name=s,proto3" json:"s,omitempty"`
+	Z                    []byte          `protobuf:"bytes,3,opt,name=z,proto3" json:"z,omitempty"`
+	RsKnown              [][]byte        `protobuf:"bytes,4,rep,name=rsKnown,proto3" json:"rsKnown,omitempty"`
+	RsCommitted          [][]byte        `protobuf:"bytes,5,rep,name=rsCommitted,proto3" json:"rsCommitted,omitempty"`
+	RsHidden             [][]byte        `protobuf:"bytes,6,rep,name=rsHidden,proto3" json:"rsHidden,omitempty"`
+	PedersenParams       *PedersenParams `protobuf:"bytes,7,opt,name=pedersenParams,proto3" json:"pedersenParams,omitempty"`
+	N1                   []byte          `protobuf:"bytes,8,opt,name=n1,proto3" json:"n1,omitempty"`
+	G                    []byte          `protobuf:"bytes,9,opt,name=g,proto3" json:"g,omitempty"`
+	H                    []byte          `protobuf:"bytes,10,opt,name=h,proto3" json:"h,omitempty"`
+	AccInit              []byte          `protobuf:"bytes,11,opt,name=accInit,proto3" json:"accInit,omitempty"`
+	AccG                 []byte          `protobuf:"bytes,12,opt,name=accG,proto3" json:"accG,omitempty"`
+	AccH                 []byte          `protobuf:"bytes,13,opt,name=accH,proto3" json:"accH,omitempty"`
+	Proof                *KeyProof       `protobuf:"bytes,14,opt,name=proof,proto3" json:"proof,omitempty"`
+	SchemaHash           []byte          `protobuf:"bytes,15,opt,name=schemaHash,proto3" json:"schemaHash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *PubKey) Reset()         { *m = PubKey{} }
+func (m *PubKey) String() string { return proto.CompactTextString(m) }
+func (*PubKey) ProtoMessage()    {}
+func (*PubKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{11}
+}
+
+func (m *PubKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PubKey.Unmarshal(m, b)
+}
+func (m *PubKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PubKey.Marshal(b, m, deterministic)
+}
+func (m *PubKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PubKey.Merge(m, src)
+}
+func (m *PubKey) XXX_Size() int {
+	return xxx_messageInfo_PubKey.Size(m)
+}
+func (m *PubKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_PubKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PubKey proto.InternalMessageInfo
+
+func (m *PubKey) GetN() []byte {
+	if m != nil {
+		return m.N
+	}
+	return nil
+}
+
+func (m *PubKey) GetS() []byte {
+	if m != nil {
+		return m.S
+	}
+	return nil
+}
+
+func (m *PubKey) GetZ() []byte {
+	if m != nil {
+		return m.Z
+	}
+	return nil
+}
+
+func (m *PubKey) GetRsKnown() [][]byte {
+	if m != nil {
+		return m.RsKnown
+	}
+	return nil
+}
+
+func (m *PubKey) GetRsCommitted() [][]byte {
+	if m != nil {
+		return m.RsCommitted
+	}
+	return nil
+}
+
+func (m *PubKey) GetRsHidden() [][]byte {
+	if m != nil {
+		return m.RsHidden
+	}
+	return nil
+}
+
+func (m *PubKey) GetPedersenParams() *PedersenParams {
+	if m != nil {
+		return m.PedersenParams
+	}
+	return nil
+}
+
+func (m *PubKey) GetN1() []byte {
+	if m != nil {
+		return m.N1
+	}
+	return nil
+}
+
+func (m *PubKey) GetG() []byte {
+	if m != nil {
+		return m.G
+	}
+	return nil
+}
+
+func (m *PubKey) GetH() []byte {
+	if m != nil {
+		return m.H
+	}
+	return nil
+}
+
+func (m *PubKey) GetAccInit() []byte {
+	if m != nil {
+		return m.AccInit
+	}
+	return nil
+}
+
+func (m *PubKey) GetAccG() []byte {
+	if m != nil {
+		return m.AccG
+	}
+	return nil
+}
+
+func (m *PubKey) GetAccH() []byte {
+	if m != nil {
+		return m.AccH
+	}
+	return nil
+}
+
+func (m *PubKey) GetProof() *KeyProof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *PubKey) GetSchemaHash() []byte {
+	if m != nil {
+		return m.SchemaHash
+	}
+	return nil
+}
+
+type KeyProof struct {
+	Challenge            []byte   `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	Responses            [][]byte `protobuf:"bytes,2,rep,name=responses,proto3" json:"responses,omitempty"`
+	CommitmentResponses  [][]byte `protobuf:"bytes,4,rep,name=commitmentResponses,proto3" json:"commitmentResponses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyProof) Reset()         { *m = KeyProof{} }
+func (m *KeyProof) String() string { return proto.CompactTextString(m) }
+func (*KeyProof) ProtoMessage()    {}
+func (*KeyProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{12}
+}
+
+func (m *KeyProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyProof.Unmarshal(m, b)
+}
+func (m *KeyProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyProof.Marshal(b, m, deterministic)
+}
+func (m *KeyProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyProof.Merge(m, src)
+}
+func (m *KeyProof) XXX_Size() int {
+	return xxx_messageInfo_KeyProof.Size(m)
+}
+func (m *KeyProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyProof proto.InternalMessageInfo
+
+func (m *KeyProof) GetChallenge() []byte {
+	if m != nil {
+		return m.Challenge
+	}
+	return nil
+}
+
+func (m *KeyProof) GetResponses() [][]byte {
+	if m != nil {
+		return m.Responses
+	}
+	return nil
+}
+
+func (m *KeyProof) GetCommitmentResponses() [][]byte {
+	if m != nil {
+		return m.CommitmentResponses
+	}
+	return nil
+}
+
+type Params struct {
+	RhoBitLen            int32    `protobuf:"varint,1,opt,name=RhoBitLen,proto3" json:"RhoBitLen,omitempty"`
+	NLength              int32    `protobuf:"varint,2,opt,name=NLength,proto3" json:"NLength,omitempty"`
+	AttrBitLen           int32    `protobuf:"varint,6,opt,name=AttrBitLen,proto3" json:"AttrBitLen,omitempty"`
+	HashBitLen           int32    `protobuf:"varint,7,opt,name=HashBitLen,proto3" json:"HashBitLen,omitempty"`
+	SecParam             int32    `protobuf:"varint,8,opt,name=SecParam,proto3" json:"SecParam,omitempty"`
+	EBitLen              int32    `protobuf:"varint,9,opt,name=EBitLen,proto3" json:"EBitLen,omitempty"`
+	E1BitLen             int32    `protobuf:"varint,10,opt,name=E1BitLen,proto3" json:"E1BitLen,omitempty"`
+	VBitLen              int32    `protobuf:"varint,11,opt,name=VBitLen,proto3" json:"VBitLen,omitempty"`
+	ChallengeSpace       int32    `protobuf:"varint,12,opt,name=ChallengeSpace,proto3" json:"ChallengeSpace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Params) Reset()         { *m = Params{} }
+func (m *Params) String() string { return proto.CompactTextString(m) }
+func (*Params) ProtoMessage()    {}
+func (*Params) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{13}
+}
+
+func (m *Params) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Params.Unmarshal(m, b)
+}
+func (m *Params) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Params.Marshal(b, m, deterministic)
+}
+func (m *Params) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Params.Merge(m, src)
+}
+func (m *Params) XXX_Size() int {
+	return xxx_messageInfo_Params.Size(m)
+}
+func (m *Params) XXX_DiscardUnknown() {
+	xxx_messageInfo_Params.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Params proto.InternalMessageInfo
+
+func (m *Params) GetRhoBitLen() int32 {
+	if m != nil {
+		return m.RhoBitLen
+	}
+	return 0
+}
+
+func (m *Params) GetNLength() int32 {
+	if m != nil {
+		return m.NLength
+	}
+	return 0
+}
+
+func (m *Params) GetAttrBitLen() int32 {
+	if m != nil {
+		return m.AttrBitLen
+	}
+	return 0
+}
+
+func (m *Params) GetHashBitLen() int32 {
+	if m != nil {
+		return m.HashBitLen
+	}
+	return 0
+}
+
+func (m *Params) GetSecParam() int32 {
+	if m != nil {
+		return m.SecParam
+	}
+	return 0
+}
+
+func (m *Params) GetEBitLen() int32 {
+	if m != nil {
+		return m.EBitLen
+	}
+	return 0
+}
+
+func (m *Params) GetE1BitLen() int32 {
+	if m != nil {
+		return m.E1BitLen
+	}
+	return 0
+}
+
+func (m *Params) GetVBitLen() int32 {
+	if m != nil {
+		return m.VBitLen
+	}
+	return 0
+}
+
+func (m *Params) GetChallengeSpace() int32 {
+	if m != nil {
+		return m.ChallengeSpace
+	}
+	return 0
+}
+
+type PublicParams struct {
+	PubKey               *PubKey          `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Params               *Params          `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	CredStructure        *CredStructure   `protobuf:"bytes,3,opt,name=credStructure,proto3" json:"credStructure,omitempty"`
+	RetiredKeys          []*RetiredPubKey `protobuf:"bytes,4,rep,name=retiredKeys,proto3" json:"retiredKeys,omitempty"`
+	SchemaHash           []byte           `protobuf:"bytes,5,opt,name=schemaHash,proto3" json:"schemaHash,omitempty"`
+	TrustedIssuers       []*TrustedIssuer `protobuf:"bytes,6,rep,name=trustedIssuers,proto3" json:"trustedIssuers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *PublicParams) Reset()         { *m = PublicParams{} }
+func (m *PublicParams) String() string { return proto.CompactTextString(m) }
+func (*PublicParams) ProtoMessage()    {}
+func (*PublicParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{14}
+}
+
+func (m *PublicParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PublicParams.Unmarshal(m, b)
+}
+func (m *PublicParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PublicParams.Marshal(b, m, deterministic)
+}
+func (m *PublicParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PublicParams.Merge(m, src)
+}
+func (m *PublicParams) XXX_Size() int {
+	return xxx_messageInfo_PublicParams.Size(m)
+}
+func (m *PublicParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_PublicParams.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PublicParams proto.InternalMessageInfo
+
+func (m *PublicParams) GetPubKey() *PubKey {
+	if m != nil {
+		return m.PubKey
+	}
+	return nil
+}
+
+func (m *PublicParams) GetParams() *Params {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+func (m *PublicParams) GetCredStructure() *CredStructure {
+	if m != nil {
+		return m.CredStructure
+	}
+	return nil
+}
+
+func (m *PublicParams) GetRetiredKeys() []*RetiredPubKey {
+	if m != nil {
+		return m.RetiredKeys
+	}
+	return nil
+}
+
+func (m *PublicParams) GetSchemaHash() []byte {
+	if m != nil {
+		return m.SchemaHash
+	}
+	return nil
+}
+
+func (m *PublicParams) GetTrustedIssuers() []*TrustedIssuer {
+	if m != nil {
+		return m.TrustedIssuers
+	}
+	return nil
+}
+
+type TrustedIssuer struct {
+	Name                 string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PubKey               *PubKey        `protobuf:"bytes,2,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Params               *Params        `protobuf:"bytes,3,opt,name=params,proto3" json:"params,omitempty"`
+	CredStructure        *CredStructure `protobuf:"bytes,4,opt,name=credStructure,proto3" json:"credStructure,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *TrustedIssuer) Reset()         { *m = TrustedIssuer{} }
+func (m *TrustedIssuer) String() string { return proto.CompactTextString(m) }
+func (*TrustedIssuer) ProtoMessage()    {}
+func (*TrustedIssuer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{15}
+}
+
+func (m *TrustedIssuer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrustedIssuer.Unmarshal(m, b)
+}
+func (m *TrustedIssuer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrustedIssuer.Marshal(b, m, deterministic)
+}
+func (m *TrustedIssuer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrustedIssuer.Merge(m, src)
+}
+func (m *TrustedIssuer) XXX_Size() int {
+	return xxx_messageInfo_TrustedIssuer.Size(m)
+}
+func (m *TrustedIssuer) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrustedIssuer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrustedIssuer proto.InternalMessageInfo
+
+func (m *TrustedIssuer) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TrustedIssuer) GetPubKey() *PubKey {
+	if m != nil {
+		return m.PubKey
+	}
+	return nil
+}
+
+func (m *TrustedIssuer) GetParams() *Params {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+func (m *TrustedIssuer) GetCredStructure() *CredStructure {
+	if m != nil {
+		return m.CredStructure
+	}
+	return nil
+}
+
+type RetiredPubKey struct {
+	PubKey               *PubKey  `protobuf:"bytes,1,opt,name=pubKey,proto3" json:"pubKey,omitempty"`
+	Expires              int64    `protobuf:"varint,2,opt,name=expires,proto3" json:"expires,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RetiredPubKey) Reset()         { *m = RetiredPubKey{} }
+func (m *RetiredPubKey) String() string { return proto.CompactTextString(m) }
+func (*RetiredPubKey) ProtoMessage()    {}
+func (*RetiredPubKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{16}
+}
+
+func (m *RetiredPubKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetiredPubKey.Unmarshal(m, b)
+}
+func (m *RetiredPubKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetiredPubKey.Marshal(b, m, deterministic)
+}
+func (m *RetiredPubKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetiredPubKey.Merge(m, src)
+}
+func (m *RetiredPubKey) XXX_Size() int {
+	return xxx_messageInfo_RetiredPubKey.Size(m)
+}
+func (m *RetiredPubKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetiredPubKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RetiredPubKey proto.InternalMessageInfo
+
+func (m *RetiredPubKey) GetPubKey() *PubKey {
+	if m != nil {
+		return m.PubKey
+	}
+	return nil
+}
+
+func (m *RetiredPubKey) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+type CredIssueRequest struct {
+	Nym                      []byte             `protobuf:"bytes,1,opt,name=Nym,proto3" json:"Nym,omitempty"`
+	KnownAttrs               [][]byte           `protobuf:"bytes,2,rep,name=KnownAttrs,proto3" json:"KnownAttrs,omitempty"`
+	CommitmentsOfAttrs       [][]byte           `protobuf:"bytes,3,rep,name=CommitmentsOfAttrs,proto3" json:"CommitmentsOfAttrs,omitempty"`
+	NymProof                 *FiatShamir        `protobuf:"bytes,4,opt,name=NymProof,proto3" json:"NymProof,omitempty"`
+	U                        []byte             `protobuf:"bytes,5,opt,name=U,proto3" json:"U,omitempty"`
+	UProof                   *FiatShamirAlsoNeg `protobuf:"bytes,6,opt,name=UProof,proto3" json:"UProof,omitempty"`
+	CommitmentsOfAttrsProofs []*FiatShamir      `protobuf:"bytes,7,rep,name=CommitmentsOfAttrsProofs,proto3" json:"CommitmentsOfAttrsProofs,omitempty"`
+	Nonce                    []byte             `protobuf:"bytes,8,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
+	RegKey                   string             `protobuf:"bytes,9,opt,name=RegKey,proto3" json:"RegKey,omitempty"`
+	XXX_NoUnkeyedLiteral     struct{}           `json:"-"`
+	XXX_unrecognized         []byte             `json:"-"`
+	XXX_sizecache            int32              `json:"-"`
+}
+
+func (m *CredIssueRequest) Reset()         { *m = CredIssueRequest{} }
+func (m *CredIssueRequest) String() string { return proto.CompactTextString(m) }
+func (*CredIssueRequest) ProtoMessage()    {}
+func (*CredIssueRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{17}
+}
+
+func (m *CredIssueRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CredIssueRequest.Unmarshal(m, b)
+}
+func (m *CredIssueRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CredIssueRequest.Marshal(b, m, deterministic)
+}
+func (m *CredIssueRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CredIssueRequest.Merge(m, src)
+}
+func (m *CredIssueRequest) XXX_Size() int {
+	return xxx_messageInfo_CredIssueRequest.Size(m)
+}
+func (m *CredIssueRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CredIssueRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CredIssueRequest proto.InternalMessageInfo
+
+func (m *CredIssueRequest) GetNym() []byte {
+	if m != nil {
+		return m.Nym
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetKnownAttrs() [][]byte {
+	if m != nil {
+		return m.KnownAttrs
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetCommitmentsOfAttrs() [][]byte {
+	if m != nil {
+		return m.CommitmentsOfAttrs
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetNymProof() *FiatShamir {
+	if m != nil {
+		return m.NymProof
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetU() []byte {
+	if m != nil {
+		return m.U
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetUProof() *FiatShamirAlsoNeg {
+	if m != nil {
+		return m.UProof
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetCommitmentsOfAttrsProofs() []*FiatShamir {
+	if m != nil {
+		return m.CommitmentsOfAttrsProofs
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *CredIssueRequest) GetRegKey() string {
+	if m != nil {
+		return m.RegKey
+	}
+	return ""
+}
+
+type Cred struct {
+	A                    []byte   `protobuf:"bytes,1,opt,name=A,proto3" json:"A,omitempty"`
+	E                    []byte   `protobuf:"bytes,2,opt,name=E,proto3" json:"E,omitempty"`
+	V11                  []byte   `protobuf:"bytes,3,opt,name=V11,proto3" json:"V11,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Cred) Reset()         { *m = Cred{} }
+func (m *Cred) String() string { return proto.CompactTextString(m) }
+func (*Cred) ProtoMessage()    {}
+func (*Cred) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{18}
+}
+
+func (m *Cred) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Cred.Unmarshal(m, b)
+}
+func (m *Cred) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Cred.Marshal(b, m, deterministic)
+}
+func (m *Cred) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Cred.Merge(m, src)
+}
+func (m *Cred) XXX_Size() int {
+	return xxx_messageInfo_Cred.Size(m)
+}
+func (m *Cred) XXX_DiscardUnknown() {
+	xxx_messageInfo_Cred.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Cred proto.InternalMessageInfo
+
+func (m *Cred) GetA() []byte {
+	if m != nil {
+		return m.A
+	}
+	return nil
+}
+
+func (m *Cred) GetE() []byte {
+	if m != nil {
+		return m.E
+	}
+	return nil
+}
+
+func (m *Cred) GetV11() []byte {
+	if m != nil {
+		return m.V11
+	}
+	return nil
+}
+
+type IssuedCred struct {
+	Cred                 *Cred              `protobuf:"bytes,1,opt,name=cred,proto3" json:"cred,omitempty"`
+	AProof               *FiatShamirAlsoNeg `protobuf:"bytes,2,opt,name=AProof,proto3" json:"AProof,omitempty"`
+	Witness              *Witness           `protobuf:"bytes,3,opt,name=witness,proto3" json:"witness,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *IssuedCred) Reset()         { *m = IssuedCred{} }
+func (m *IssuedCred) String() string { return proto.CompactTextString(m) }
+func (*IssuedCred) ProtoMessage()    {}
+func (*IssuedCred) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{19}
+}
+
+func (m *IssuedCred) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IssuedCred.Unmarshal(m, b)
+}
+func (m *IssuedCred) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IssuedCred.Marshal(b, m, deterministic)
+}
+func (m *IssuedCred) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IssuedCred.Merge(m, src)
+}
+func (m *IssuedCred) XXX_Size() int {
+	return xxx_messageInfo_IssuedCred.Size(m)
+}
+func (m *IssuedCred) XXX_DiscardUnknown() {
+	xxx_messageInfo_IssuedCred.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IssuedCred proto.InternalMessageInfo
+
+func (m *IssuedCred) GetCred() *Cred {
+	if m != nil {
+		return m.Cred
+	}
+	return nil
+}
+
+func (m *IssuedCred) GetAProof() *FiatShamirAlsoNeg {
+	if m != nil {
+		return m.AProof
+	}
+	return nil
+}
+
+func (m *IssuedCred) GetWitness() *Witness {
+	if m != nil {
+		return m.Witness
+	}
+	return nil
+}
+
+type Witness struct {
+	Version              int64    `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Witness) Reset()         { *m = Witness{} }
+func (m *Witness) String() string { return proto.CompactTextString(m) }
+func (*Witness) ProtoMessage()    {}
+func (*Witness) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{20}
+}
+
+func (m *Witness) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Witness.Unmarshal(m, b)
+}
+func (m *Witness) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Witness.Marshal(b, m, deterministic)
+}
+func (m *Witness) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Witness.Merge(m, src)
+}
+func (m *Witness) XXX_Size() int {
+	return xxx_messageInfo_Witness.Size(m)
+}
+func (m *Witness) XXX_DiscardUnknown() {
+	xxx_messageInfo_Witness.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Witness proto.InternalMessageInfo
+
+func (m *Witness) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Witness) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type Accumulator struct {
+	Version              int64    `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Accumulator) Reset()         { *m = Accumulator{} }
+func (m *Accumulator) String() string { return proto.CompactTextString(m) }
+func (*Accumulator) ProtoMessage()    {}
+func (*Accumulator) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{21}
+}
+
+func (m *Accumulator) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Accumulator.Unmarshal(m, b)
+}
+func (m *Accumulator) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Accumulator.Marshal(b, m, deterministic)
+}
+func (m *Accumulator) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Accumulator.Merge(m, src)
+}
+func (m *Accumulator) XXX_Size() int {
+	return xxx_messageInfo_Accumulator.Size(m)
+}
+func (m *Accumulator) XXX_DiscardUnknown() {
+	xxx_messageInfo_Accumulator.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Accumulator proto.InternalMessageInfo
+
+func (m *Accumulator) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *Accumulator) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type AccumulatorUpdatesRequest struct {
+	Version              int64    `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	KeyId                string   `protobuf:"bytes,2,opt,name=keyId,proto3" json:"keyId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AccumulatorUpdatesRequest) Reset()         { *m = AccumulatorUpdatesRequest{} }
+func (m *AccumulatorUpdatesRequest) String() string { return proto.CompactTextString(m) }
+func (*AccumulatorUpdatesRequest) ProtoMessage()    {}
+func (*AccumulatorUpdatesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{22}
+}
+
+func (m *AccumulatorUpdatesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccumulatorUpdatesRequest.Unmarshal(m, b)
+}
+func (m *AccumulatorUpdatesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccumulatorUpdatesRequest.Marshal(b, m, deterministic)
+}
+func (m *AccumulatorUpdatesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccumulatorUpdatesRequest.Merge(m, src)
+}
+func (m *AccumulatorUpdatesRequest) XXX_Size() int {
+	return xxx_messageInfo_AccumulatorUpdatesRequest.Size(m)
+}
+func (m *AccumulatorUpdatesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccumulatorUpdatesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccumulatorUpdatesRequest proto.InternalMessageInfo
+
+func (m *AccumulatorUpdatesRequest) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *AccumulatorUpdatesRequest) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+type AccumulatorUpdates struct {
+	Updates              []*AccumulatorUpdate `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AccumulatorUpdates) Reset()         { *m = AccumulatorUpdates{} }
+func (m *AccumulatorUpdates) String() string { return proto.CompactTextString(m) }
+func (*AccumulatorUpdates) ProtoMessage()    {}
+func (*AccumulatorUpdates) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{23}
+}
+
+func (m *AccumulatorUpdates) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccumulatorUpdates.Unmarshal(m, b)
+}
+func (m *AccumulatorUpdates) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccumulatorUpdates.Marshal(b, m, deterministic)
+}
+func (m *AccumulatorUpdates) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccumulatorUpdates.Merge(m, src)
+}
+func (m *AccumulatorUpdates) XXX_Size() int {
+	return xxx_messageInfo_AccumulatorUpdates.Size(m)
+}
+func (m *AccumulatorUpdates) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccumulatorUpdates.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccumulatorUpdates proto.InternalMessageInfo
+
+func (m *AccumulatorUpdates) GetUpdates() []*AccumulatorUpdate {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+type AccumulatorUpdate struct {
+	Version              int64    `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	E                    []byte   `protobuf:"bytes,2,opt,name=e,proto3" json:"e,omitempty"`
+	Removed              bool     `protobuf:"varint,3,opt,name=removed,proto3" json:"removed,omitempty"`
+	Value                []byte   `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AccumulatorUpdate) Reset()         { *m = AccumulatorUpdate{} }
+func (m *AccumulatorUpdate) String() string { return proto.CompactTextString(m) }
+func (*AccumulatorUpdate) ProtoMessage()    {}
+func (*AccumulatorUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{24}
+}
+
+func (m *AccumulatorUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccumulatorUpdate.Unmarshal(m, b)
+}
+func (m *AccumulatorUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccumulatorUpdate.Marshal(b, m, deterministic)
+}
+func (m *AccumulatorUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccumulatorUpdate.Merge(m, src)
+}
+func (m *AccumulatorUpdate) XXX_Size() int {
+	return xxx_messageInfo_AccumulatorUpdate.Size(m)
+}
+func (m *AccumulatorUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccumulatorUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccumulatorUpdate proto.InternalMessageInfo
+
+func (m *AccumulatorUpdate) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *AccumulatorUpdate) GetE() []byte {
+	if m != nil {
+		return m.E
+	}
+	return nil
+}
+
+func (m *AccumulatorUpdate) GetRemoved() bool {
+	if m != nil {
+		return m.Removed
+	}
+	return false
+}
+
+func (m *AccumulatorUpdate) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type CredUpdateRequest struct {
+	Nym                      []byte             `protobuf:"bytes,1,opt,name=Nym,proto3" json:"Nym,omitempty"`
+	Nonce                    []byte             `protobuf:"bytes,2,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
+	NewKnownAttrs            [][]byte           `protobuf:"bytes,3,rep,name=NewKnownAttrs,proto3" json:"NewKnownAttrs,omitempty"`
+	NymProof                 *FiatShamir        `protobuf:"bytes,4,opt,name=NymProof,proto3" json:"NymProof,omitempty"`
+	U                        []byte             `protobuf:"bytes,5,opt,name=U,proto3" json:"U,omitempty"`
+	UProof                   *FiatShamirAlsoNeg `protobuf:"bytes,6,opt,name=UProof,proto3" json:"UProof,omitempty"`
+	NewCommitmentsOfAttrs    [][]byte           `protobuf:"bytes,7,rep,name=NewCommitmentsOfAttrs,proto3" json:"NewCommitmentsOfAttrs,omitempty"`
+	CommitmentsOfAttrsProofs []*FiatShamir      `protobuf:"bytes,8,rep,name=CommitmentsOfAttrsProofs,proto3" json:"CommitmentsOfAttrsProofs,omitempty"`
+	PrevUProof               *FiatShamirAlsoNeg `protobuf:"bytes,9,opt,name=PrevUProof,proto3" json:"PrevUProof,omitempty"`
+	XXX_NoUnkeyedLiteral     struct{}           `json:"-"`
+	XXX_unrecognized         []byte             `json:"-"`
+	XXX_sizecache            int32              `json:"-"`
+}
+
+func (m *CredUpdateRequest) Reset()         { *m = CredUpdateRequest{} }
+func (m *CredUpdateRequest) String() string { return proto.CompactTextString(m) }
+func (*CredUpdateRequest) ProtoMessage()    {}
+func (*CredUpdateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{25}
+}
+
+func (m *CredUpdateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CredUpdateRequest.Unmarshal(m, b)
+}
+func (m *CredUpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CredUpdateRequest.Marshal(b, m, deterministic)
+}
+func (m *CredUpdateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CredUpdateRequest.Merge(m, src)
+}
+func (m *CredUpdateRequest) XXX_Size() int {
+	return xxx_messageInfo_CredUpdateRequest.Size(m)
+}
+func (m *CredUpdateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CredUpdateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CredUpdateRequest proto.InternalMessageInfo
+
+func (m *CredUpdateRequest) GetNym() []byte {
+	if m != nil {
+		return m.Nym
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetNewKnownAttrs() [][]byte {
+	if m != nil {
+		return m.NewKnownAttrs
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetNymProof() *FiatShamir {
+	if m != nil {
+		return m.NymProof
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetU() []byte {
+	if m != nil {
+		return m.U
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetUProof() *FiatShamirAlsoNeg {
+	if m != nil {
+		return m.UProof
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetNewCommitmentsOfAttrs() [][]byte {
+	if m != nil {
+		return m.NewCommitmentsOfAttrs
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetCommitmentsOfAttrsProofs() []*FiatShamir {
+	if m != nil {
+		return m.CommitmentsOfAttrsProofs
+	}
+	return nil
+}
+
+func (m *CredUpdateRequest) GetPrevUProof() *FiatShamirAlsoNeg {
+	if m != nil {
+		return m.PrevUProof
+	}
+	return nil
+}
+
+type CredProof struct {
+	A                          []byte               `protobuf:"bytes,1,opt,name=A,proto3" json:"A,omitempty"`
+	Proof                      *FiatShamirAlsoNeg   `protobuf:"bytes,2,opt,name=Proof,proto3" json:"Proof,omitempty"`
+	KnownAttrs                 [][]byte             `protobuf:"bytes,3,rep,name=KnownAttrs,proto3" json:"KnownAttrs,omitempty"`
+	CommitmentsOfAttrs         [][]byte             `protobuf:"bytes,4,rep,name=CommitmentsOfAttrs,proto3" json:"CommitmentsOfAttrs,omitempty"`
+	RevealedKnownAttrs         []int32              `protobuf:"varint,5,rep,packed,name=RevealedKnownAttrs,proto3" json:"RevealedKnownAttrs,omitempty"`
+	RevealedCommitmentsOfAttrs []int32              `protobuf:"varint,6,rep,packed,name=RevealedCommitmentsOfAttrs,proto3" json:"RevealedCommitmentsOfAttrs,omitempty"`
+	PredicateProofs            []*PredicateProof    `protobuf:"bytes,7,rep,name=PredicateProofs,proto3" json:"PredicateProofs,omitempty"`
+	NonRevocationProof         *NonRevocationProof  `protobuf:"bytes,8,opt,name=NonRevocationProof,proto3" json:"NonRevocationProof,omitempty"`
+	ScopePseudonymProof        *ScopePseudonymProof `protobuf:"bytes,9,opt,name=ScopePseudonymProof,proto3" json:"ScopePseudonymProof,omitempty"`
+	KeyId                      string               `protobuf:"bytes,10,opt,name=KeyId,proto3" json:"KeyId,omitempty"`
+	XXX_NoUnkeyedLiteral       struct{}             `json:"-"`
+	XXX_unrecognized           []byte               `json:"-"`
+	XXX_sizecache              int32                `json:"-"`
+}
+
+func (m *CredProof) Reset()         { *m = CredProof{} }
+func (m *CredProof) String() string { return proto.CompactTextString(m) }
+func (*CredProof) ProtoMessage()    {}
+func (*CredProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{26}
+}
+
+func (m *CredProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CredProof.Unmarshal(m, b)
+}
+func (m *CredProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CredProof.Marshal(b, m, deterministic)
+}
+func (m *CredProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CredProof.Merge(m, src)
+}
+func (m *CredProof) XXX_Size() int {
+	return xxx_messageInfo_CredProof.Size(m)
+}
+func (m *CredProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_CredProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CredProof proto.InternalMessageInfo
+
+func (m *CredProof) GetA() []byte {
+	if m != nil {
+		return m.A
+	}
+	return nil
+}
+
+func (m *CredProof) GetProof() *FiatShamirAlsoNeg {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *CredProof) GetKnownAttrs() [][]byte {
+	if m != nil {
+		return m.KnownAttrs
+	}
+	return nil
+}
+
+func (m *CredProof) GetCommitmentsOfAttrs() [][]byte {
+	if m != nil {
+		return m.CommitmentsOfAttrs
+	}
+	return nil
+}
+
+func (m *CredProof) GetRevealedKnownAttrs() []int32 {
+	if m != nil {
+		return m.RevealedKnownAttrs
+	}
+	return nil
+}
+
+func (m *CredProof) GetRevealedCommitmentsOfAttrs() []int32 {
+	if m != nil {
+		return m.RevealedCommitmentsOfAttrs
+	}
+	return nil
+}
+
+func (m *CredProof) GetPredicateProofs() []*PredicateProof {
+	if m != nil {
+		return m.PredicateProofs
+	}
+	return nil
+}
+
+func (m *CredProof) GetNonRevocationProof() *NonRevocationProof {
+	if m != nil {
+		return m.NonRevocationProof
+	}
+	return nil
+}
+
+func (m *CredProof) GetScopePseudonymProof() *ScopePseudonymProof {
+	if m != nil {
+		return m.ScopePseudonymProof
+	}
+	return nil
+}
+
+func (m *CredProof) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+type MultiCredProof struct {
+	Creds                []*CredProof         `protobuf:"bytes,1,rep,name=creds,proto3" json:"creds,omitempty"`
+	ScopePseudonymProof  *ScopePseudonymProof `protobuf:"bytes,2,opt,name=ScopePseudonymProof,proto3" json:"ScopePseudonymProof,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *MultiCredProof) Reset()         { *m = MultiCredProof{} }
+func (m *MultiCredProof) String() string { return proto.CompactTextString(m) }
+func (*MultiCredProof) ProtoMessage()    {}
+func (*MultiCredProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{27}
+}
+
+func (m *MultiCredProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultiCredProof.Unmarshal(m, b)
+}
+func (m *MultiCredProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultiCredProof.Marshal(b, m, deterministic)
+}
+func (m *MultiCredProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiCredProof.Merge(m, src)
+}
+func (m *MultiCredProof) XXX_Size() int {
+	return xxx_messageInfo_MultiCredProof.Size(m)
+}
+func (m *MultiCredProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiCredProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiCredProof proto.InternalMessageInfo
+
+func (m *MultiCredProof) GetCreds() []*CredProof {
+	if m != nil {
+		return m.Creds
+	}
+	return nil
+}
+
+func (m *MultiCredProof) GetScopePseudonymProof() *ScopePseudonymProof {
+	if m != nil {
+		return m.ScopePseudonymProof
+	}
+	return nil
+}
+
+type PredicateProof struct {
+	KnownAttrIndex int32 `protobuf:"varint,1,opt,name=KnownAttrIndex,proto3" json:"KnownAttrIndex,omitempty"`
+	// Types that are valid to be assigned to Type:
+	//	*PredicateProof_Range
+	//	*PredicateProof_Set
+	Type                 isPredicateProof_Type `protobuf_oneof:"type"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *PredicateProof) Reset()         { *m = PredicateProof{} }
+func (m *PredicateProof) String() string { return proto.CompactTextString(m) }
+func (*PredicateProof) ProtoMessage()    {}
+func (*PredicateProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{28}
+}
+
+func (m *PredicateProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PredicateProof.Unmarshal(m, b)
+}
+func (m *PredicateProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PredicateProof.Marshal(b, m, deterministic)
+}
+func (m *PredicateProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PredicateProof.Merge(m, src)
+}
+func (m *PredicateProof) XXX_Size() int {
+	return xxx_messageInfo_PredicateProof.Size(m)
+}
+func (m *PredicateProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_PredicateProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PredicateProof proto.InternalMessageInfo
+
+func (m *PredicateProof) GetKnownAttrIndex() int32 {
+	if m != nil {
+		return m.KnownAttrIndex
+	}
+	return 0
+}
+
+type isPredicateProof_Type interface {
+	isPredicateProof_Type()
+}
+
+type PredicateProof_Range struct {
+	Range *RangeProof `protobuf:"bytes,2,opt,name=range,proto3,oneof"`
+}
+
+type PredicateProof_Set struct {
+	Set *SetMembershipProof `protobuf:"bytes,3,opt,name=set,proto3,oneof"`
+}
+
+func (*PredicateProof_Range) isPredicateProof_Type() {}
+
+func (*PredicateProof_Set) isPredicateProof_Type() {}
+
+func (m *PredicateProof) GetType() isPredicateProof_Type {
+	if m != nil {
+		return m.Type
+	}
+	return nil
+}
+
+func (m *PredicateProof) GetRange() *RangeProof {
+	if x, ok := m.GetType().(*PredicateProof_Range); ok {
+		return x.Range
+	}
+	return nil
+}
+
+func (m *PredicateProof) GetSet() *SetMembershipProof {
+	if x, ok := m.GetType().(*PredicateProof_Set); ok {
+		return x.Set
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*PredicateProof) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*PredicateProof_Range)(nil),
+		(*PredicateProof_Set)(nil),
+	}
+}
+
+type RangeProof struct {
+	T                    [][]byte `protobuf:"bytes,1,rep,name=T,proto3" json:"T,omitempty"`
+	TDelta               []byte   `protobuf:"bytes,2,opt,name=TDelta,proto3" json:"TDelta,omitempty"`
+	TTilde               [][]byte `protobuf:"bytes,3,rep,name=TTilde,proto3" json:"TTilde,omitempty"`
+	TDeltaTilde          []byte   `protobuf:"bytes,4,opt,name=TDeltaTilde,proto3" json:"TDeltaTilde,omitempty"`
+	QTilde               []byte   `protobuf:"bytes,5,opt,name=QTilde,proto3" json:"QTilde,omitempty"`
+	UHat                 []string `protobuf:"bytes,6,rep,name=UHat,proto3" json:"UHat,omitempty"`
+	RHat                 []string `protobuf:"bytes,7,rep,name=RHat,proto3" json:"RHat,omitempty"`
+	RDeltaHat            string   `protobuf:"bytes,8,opt,name=RDeltaHat,proto3" json:"RDeltaHat,omitempty"`
+	AlphaHat             string   `protobuf:"bytes,9,opt,name=AlphaHat,proto3" json:"AlphaHat,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RangeProof) Reset()         { *m = RangeProof{} }
+func (m *RangeProof) String() string { return proto.CompactTextString(m) }
+func (*RangeProof) ProtoMessage()    {}
+func (*RangeProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{29}
+}
+
+func (m *RangeProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RangeProof.Unmarshal(m, b)
+}
+func (m *RangeProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RangeProof.Marshal(b, m, deterministic)
+}
+func (m *RangeProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RangeProof.Merge(m, src)
+}
+func (m *RangeProof) XXX_Size() int {
+	return xxx_messageInfo_RangeProof.Size(m)
+}
+func (m *RangeProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_RangeProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RangeProof proto.InternalMessageInfo
+
+func (m *RangeProof) GetT() [][]byte {
+	if m != nil {
+		return m.T
+	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetKnownAttrs() [][]byte {
+func (m *RangeProof) GetTDelta() []byte {
 	if m != nil {
-		return m.KnownAttrs
+		return m.TDelta
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetCommitmentsOfAttrs() [][]byte {
+func (m *RangeProof) GetTTilde() [][]byte {
 	if m != nil {
-		return m.CommitmentsOfAttrs
+		return m.TTilde
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetNymProof() *FiatShamir {
+func (m *RangeProof) GetTDeltaTilde() []byte {
 	if m != nil {
-		return m.NymProof
+		return m.TDeltaTilde
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetU() []byte {
+func (m *RangeProof) GetQTilde() []byte {
 	if m != nil {
-		return m.U
+		return m.QTilde
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetUProof() *FiatShamirAlsoNeg {
+func (m *RangeProof) GetUHat() []string {
 	if m != nil {
-		return m.UProof
+		return m.UHat
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetCommitmentsOfAttrsProofs() []*FiatShamir {
+func (m *RangeProof) GetRHat() []string {
 	if m != nil {
-		return m.CommitmentsOfAttrsProofs
+		return m.RHat
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetNonce() []byte {
+func (m *RangeProof) GetRDeltaHat() string {
 	if m != nil {
-		return m.Nonce
+		return m.RDeltaHat
+	}
+	return ""
+}
+
+func (m *RangeProof) GetAlphaHat() string {
+	if m != nil {
+		return m.AlphaHat
+	}
+	return ""
+}
+
+type NonRevocationProof struct {
+	CU                   []byte   `protobuf:"bytes,1,opt,name=CU,proto3" json:"CU,omitempty"`
+	CR                   []byte   `protobuf:"bytes,2,opt,name=CR,proto3" json:"CR,omitempty"`
+	CRTilde              []byte   `protobuf:"bytes,3,opt,name=CRTilde,proto3" json:"CRTilde,omitempty"`
+	OneTilde             []byte   `protobuf:"bytes,4,opt,name=OneTilde,proto3" json:"OneTilde,omitempty"`
+	AccTilde             []byte   `protobuf:"bytes,5,opt,name=AccTilde,proto3" json:"AccTilde,omitempty"`
+	R2Hat                string   `protobuf:"bytes,6,opt,name=R2Hat,proto3" json:"R2Hat,omitempty"`
+	R3Hat                string   `protobuf:"bytes,7,opt,name=R3Hat,proto3" json:"R3Hat,omitempty"`
+	Delta1Hat            string   `protobuf:"bytes,8,opt,name=Delta1Hat,proto3" json:"Delta1Hat,omitempty"`
+	Delta2Hat            string   `protobuf:"bytes,9,opt,name=Delta2Hat,proto3" json:"Delta2Hat,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NonRevocationProof) Reset()         { *m = NonRevocationProof{} }
+func (m *NonRevocationProof) String() string { return proto.CompactTextString(m) }
+func (*NonRevocationProof) ProtoMessage()    {}
+func (*NonRevocationProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{30}
+}
+
+func (m *NonRevocationProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NonRevocationProof.Unmarshal(m, b)
+}
+func (m *NonRevocationProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NonRevocationProof.Marshal(b, m, deterministic)
+}
+func (m *NonRevocationProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NonRevocationProof.Merge(m, src)
+}
+func (m *NonRevocationProof) XXX_Size() int {
+	return xxx_messageInfo_NonRevocationProof.Size(m)
+}
+func (m *NonRevocationProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_NonRevocationProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NonRevocationProof proto.InternalMessageInfo
+
+func (m *NonRevocationProof) GetCU() []byte {
+	if m != nil {
+		return m.CU
 	}
 	return nil
 }
 
-func (m *CredIssueRequest) GetRegKey() string {
+func (m *NonRevocationProof) GetCR() []byte {
 	if m != nil {
-		return m.RegKey
+		return m.CR
+	}
+	return nil
+}
+
+func (m *NonRevocationProof) GetCRTilde() []byte {
+	if m != nil {
+		return m.CRTilde
+	}
+	return nil
+}
+
+func (m *NonRevocationProof) GetOneTilde() []byte {
+	if m != nil {
+		return m.OneTilde
+	}
+	return nil
+}
+
+func (m *NonRevocationProof) GetAccTilde() []byte {
+	if m != nil {
+		return m.AccTilde
+	}
+	return nil
+}
+
+func (m *NonRevocationProof) GetR2Hat() string {
+	if m != nil {
+		return m.R2Hat
 	}
 	return ""
 }
 
-type Cred struct {
-	A   []byte `protobuf:"bytes,1,opt,name=A,proto3" json:"A,omitempty"`
-	E   []byte `protobuf:"bytes,2,opt,name=E,proto3" json:"E,omitempty"`
-	V11 []byte `protobuf:"bytes,3,opt,name=V11,proto3" json:"V11,omitempty"`
+func (m *NonRevocationProof) GetR3Hat() string {
+	if m != nil {
+		return m.R3Hat
+	}
+	return ""
 }
 
-func (m *Cred) Reset()                    { *m = Cred{} }
-func (m *Cred) String() string            { return proto.CompactTextString(m) }
-func (*Cred) ProtoMessage()               {}
-func (*Cred) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (m *NonRevocationProof) GetDelta1Hat() string {
+	if m != nil {
+		return m.Delta1Hat
+	}
+	return ""
+}
 
-func (m *Cred) GetA() []byte {
+func (m *NonRevocationProof) GetDelta2Hat() string {
 	if m != nil {
-		return m.A
+		return m.Delta2Hat
+	}
+	return ""
+}
+
+type ScopePseudonymProof struct {
+	Nym                  []byte   `protobuf:"bytes,1,opt,name=Nym,proto3" json:"Nym,omitempty"`
+	NymTilde             []byte   `protobuf:"bytes,2,opt,name=NymTilde,proto3" json:"NymTilde,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScopePseudonymProof) Reset()         { *m = ScopePseudonymProof{} }
+func (m *ScopePseudonymProof) String() string { return proto.CompactTextString(m) }
+func (*ScopePseudonymProof) ProtoMessage()    {}
+func (*ScopePseudonymProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{31}
+}
+
+func (m *ScopePseudonymProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScopePseudonymProof.Unmarshal(m, b)
+}
+func (m *ScopePseudonymProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScopePseudonymProof.Marshal(b, m, deterministic)
+}
+func (m *ScopePseudonymProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScopePseudonymProof.Merge(m, src)
+}
+func (m *ScopePseudonymProof) XXX_Size() int {
+	return xxx_messageInfo_ScopePseudonymProof.Size(m)
+}
+func (m *ScopePseudonymProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScopePseudonymProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScopePseudonymProof proto.InternalMessageInfo
+
+func (m *ScopePseudonymProof) GetNym() []byte {
+	if m != nil {
+		return m.Nym
 	}
 	return nil
 }
 
-func (m *Cred) GetE() []byte {
+func (m *ScopePseudonymProof) GetNymTilde() []byte {
 	if m != nil {
-		return m.E
+		return m.NymTilde
 	}
 	return nil
 }
 
-func (m *Cred) GetV11() []byte {
+type SetMembershipProof struct {
+	C                    []byte   `protobuf:"bytes,1,opt,name=C,proto3" json:"C,omitempty"`
+	CTilde               []byte   `protobuf:"bytes,2,opt,name=CTilde,proto3" json:"CTilde,omitempty"`
+	TTilde               [][]byte `protobuf:"bytes,3,rep,name=TTilde,proto3" json:"TTilde,omitempty"`
+	Challenges           [][]byte `protobuf:"bytes,4,rep,name=Challenges,proto3" json:"Challenges,omitempty"`
+	RHat                 string   `protobuf:"bytes,5,opt,name=RHat,proto3" json:"RHat,omitempty"`
+	RhoHat               []string `protobuf:"bytes,6,rep,name=RhoHat,proto3" json:"RhoHat,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetMembershipProof) Reset()         { *m = SetMembershipProof{} }
+func (m *SetMembershipProof) String() string { return proto.CompactTextString(m) }
+func (*SetMembershipProof) ProtoMessage()    {}
+func (*SetMembershipProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{32}
+}
+
+func (m *SetMembershipProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetMembershipProof.Unmarshal(m, b)
+}
+func (m *SetMembershipProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetMembershipProof.Marshal(b, m, deterministic)
+}
+func (m *SetMembershipProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetMembershipProof.Merge(m, src)
+}
+func (m *SetMembershipProof) XXX_Size() int {
+	return xxx_messageInfo_SetMembershipProof.Size(m)
+}
+func (m *SetMembershipProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetMembershipProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetMembershipProof proto.InternalMessageInfo
+
+func (m *SetMembershipProof) GetC() []byte {
 	if m != nil {
-		return m.V11
+		return m.C
 	}
 	return nil
 }
 
-type IssuedCred struct {
-	Cred   *Cred              `protobuf:"bytes,1,opt,name=cred" json:"cred,omitempty"`
-	AProof *FiatShamirAlsoNeg `protobuf:"bytes,2,opt,name=AProof" json:"AProof,omitempty"`
+func (m *SetMembershipProof) GetCTilde() []byte {
+	if m != nil {
+		return m.CTilde
+	}
+	return nil
 }
 
-func (m *IssuedCred) Reset()                    { *m = IssuedCred{} }
-func (m *IssuedCred) String() string            { return proto.CompactTextString(m) }
-func (*IssuedCred) ProtoMessage()               {}
-func (*IssuedCred) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (m *SetMembershipProof) GetTTilde() [][]byte {
+	if m != nil {
+		return m.TTilde
+	}
+	return nil
+}
 
-func (m *IssuedCred) GetCred() *Cred {
+func (m *SetMembershipProof) GetChallenges() [][]byte {
 	if m != nil {
-		return m.Cred
+		return m.Challenges
 	}
 	return nil
 }
 
-func (m *IssuedCred) GetAProof() *FiatShamirAlsoNeg {
+func (m *SetMembershipProof) GetRHat() string {
 	if m != nil {
-		return m.AProof
+		return m.RHat
+	}
+	return ""
+}
+
+func (m *SetMembershipProof) GetRhoHat() []string {
+	if m != nil {
+		return m.RhoHat
 	}
 	return nil
 }
 
-type CredUpdateRequest struct {
-	Nym           []byte   `protobuf:"bytes,1,opt,name=Nym,proto3" json:"Nym,omitempty"`
-	Nonce         []byte   `protobuf:"bytes,2,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
-	NewKnownAttrs [][]byte `protobuf:"bytes,3,rep,name=NewKnownAttrs,proto3" json:"NewKnownAttrs,omitempty"`
+type FiatShamir struct {
+	ProofRandomData      []byte   `protobuf:"bytes,1,opt,name=ProofRandomData,proto3" json:"ProofRandomData,omitempty"`
+	Challenge            []byte   `protobuf:"bytes,2,opt,name=Challenge,proto3" json:"Challenge,omitempty"`
+	ProofData            [][]byte `protobuf:"bytes,3,rep,name=ProofData,proto3" json:"ProofData,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *CredUpdateRequest) Reset()                    { *m = CredUpdateRequest{} }
-func (m *CredUpdateRequest) String() string            { return proto.CompactTextString(m) }
-func (*CredUpdateRequest) ProtoMessage()               {}
-func (*CredUpdateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (m *FiatShamir) Reset()         { *m = FiatShamir{} }
+func (m *FiatShamir) String() string { return proto.CompactTextString(m) }
+func (*FiatShamir) ProtoMessage()    {}
+func (*FiatShamir) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{33}
+}
 
-func (m *CredUpdateRequest) GetNym() []byte {
+func (m *FiatShamir) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FiatShamir.Unmarshal(m, b)
+}
+func (m *FiatShamir) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FiatShamir.Marshal(b, m, deterministic)
+}
+func (m *FiatShamir) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FiatShamir.Merge(m, src)
+}
+func (m *FiatShamir) XXX_Size() int {
+	return xxx_messageInfo_FiatShamir.Size(m)
+}
+func (m *FiatShamir) XXX_DiscardUnknown() {
+	xxx_messageInfo_FiatShamir.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FiatShamir proto.InternalMessageInfo
+
+func (m *FiatShamir) GetProofRandomData() []byte {
 	if m != nil {
-		return m.Nym
+		return m.ProofRandomData
 	}
 	return nil
 }
 
-func (m *CredUpdateRequest) GetNonce() []byte {
+func (m *FiatShamir) GetChallenge() []byte {
 	if m != nil {
-		return m.Nonce
+		return m.Challenge
 	}
 	return nil
 }
 
-func (m *CredUpdateRequest) GetNewKnownAttrs() [][]byte {
+func (m *FiatShamir) GetProofData() [][]byte {
 	if m != nil {
-		return m.NewKnownAttrs
+		return m.ProofData
 	}
 	return nil
 }
 
-type CredProof struct {
-	A                          []byte             `protobuf:"bytes,1,opt,name=A,proto3" json:"A,omitempty"`
-	Proof                      *FiatShamirAlsoNeg `protobuf:"bytes,2,opt,name=Proof" json:"Proof,omitempty"`
-	KnownAttrs                 [][]byte           `protobuf:"bytes,3,rep,name=KnownAttrs,proto3" json:"KnownAttrs,omitempty"`
-	CommitmentsOfAttrs         [][]byte           `protobuf:"bytes,4,rep,name=CommitmentsOfAttrs,proto3" json:"CommitmentsOfAttrs,omitempty"`
-	RevealedKnownAttrs         []int32            `protobuf:"varint,5,rep,packed,name=RevealedKnownAttrs" json:"RevealedKnownAttrs,omitempty"`
-	RevealedCommitmentsOfAttrs []int32            `protobuf:"varint,6,rep,packed,name=RevealedCommitmentsOfAttrs" json:"RevealedCommitmentsOfAttrs,omitempty"`
+type FiatShamirAlsoNeg struct {
+	ProofRandomData      []byte   `protobuf:"bytes,1,opt,name=ProofRandomData,proto3" json:"ProofRandomData,omitempty"`
+	Challenge            []byte   `protobuf:"bytes,2,opt,name=Challenge,proto3" json:"Challenge,omitempty"`
+	ProofData            []string `protobuf:"bytes,3,rep,name=ProofData,proto3" json:"ProofData,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *CredProof) Reset()                    { *m = CredProof{} }
-func (m *CredProof) String() string            { return proto.CompactTextString(m) }
-func (*CredProof) ProtoMessage()               {}
-func (*CredProof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (m *FiatShamirAlsoNeg) Reset()         { *m = FiatShamirAlsoNeg{} }
+func (m *FiatShamirAlsoNeg) String() string { return proto.CompactTextString(m) }
+func (*FiatShamirAlsoNeg) ProtoMessage()    {}
+func (*FiatShamirAlsoNeg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{34}
+}
 
-func (m *CredProof) GetA() []byte {
+func (m *FiatShamirAlsoNeg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FiatShamirAlsoNeg.Unmarshal(m, b)
+}
+func (m *FiatShamirAlsoNeg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FiatShamirAlsoNeg.Marshal(b, m, deterministic)
+}
+func (m *FiatShamirAlsoNeg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FiatShamirAlsoNeg.Merge(m, src)
+}
+func (m *FiatShamirAlsoNeg) XXX_Size() int {
+	return xxx_messageInfo_FiatShamirAlsoNeg.Size(m)
+}
+func (m *FiatShamirAlsoNeg) XXX_DiscardUnknown() {
+	xxx_messageInfo_FiatShamirAlsoNeg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FiatShamirAlsoNeg proto.InternalMessageInfo
+
+func (m *FiatShamirAlsoNeg) GetProofRandomData() []byte {
 	if m != nil {
-		return m.A
+		return m.ProofRandomData
 	}
 	return nil
 }
 
-func (m *CredProof) GetProof() *FiatShamirAlsoNeg {
+func (m *FiatShamirAlsoNeg) GetChallenge() []byte {
 	if m != nil {
-		return m.Proof
+		return m.Challenge
 	}
 	return nil
 }
 
-func (m *CredProof) GetKnownAttrs() [][]byte {
+func (m *FiatShamirAlsoNeg) GetProofData() []string {
 	if m != nil {
-		return m.KnownAttrs
+		return m.ProofData
+	}
+	return nil
+}
+
+type AcceptableCred struct {
+	OrgName              string   `protobuf:"bytes,1,opt,name=orgName,proto3" json:"orgName,omitempty"`
+	RevealedAttrs        []string `protobuf:"bytes,2,rep,name=revealedAttrs,proto3" json:"revealedAttrs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcceptableCred) Reset()         { *m = AcceptableCred{} }
+func (m *AcceptableCred) String() string { return proto.CompactTextString(m) }
+func (*AcceptableCred) ProtoMessage()    {}
+func (*AcceptableCred) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{35}
+}
+
+func (m *AcceptableCred) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcceptableCred.Unmarshal(m, b)
+}
+func (m *AcceptableCred) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcceptableCred.Marshal(b, m, deterministic)
+}
+func (m *AcceptableCred) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcceptableCred.Merge(m, src)
+}
+func (m *AcceptableCred) XXX_Size() int {
+	return xxx_messageInfo_AcceptableCred.Size(m)
+}
+func (m *AcceptableCred) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcceptableCred.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcceptableCred proto.InternalMessageInfo
+
+func (m *AcceptableCred) GetOrgName() string {
+	if m != nil {
+		return m.OrgName
+	}
+	return ""
+}
+
+func (m *AcceptableCred) GetRevealedAttrs() []string {
+	if m != nil {
+		return m.RevealedAttrs
+	}
+	return nil
+}
+
+type AcceptableCreds struct {
+	Creds                []*AcceptableCred `protobuf:"bytes,1,rep,name=creds,proto3" json:"creds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *AcceptableCreds) Reset()         { *m = AcceptableCreds{} }
+func (m *AcceptableCreds) String() string { return proto.CompactTextString(m) }
+func (*AcceptableCreds) ProtoMessage()    {}
+func (*AcceptableCreds) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{36}
+}
+
+func (m *AcceptableCreds) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcceptableCreds.Unmarshal(m, b)
+}
+func (m *AcceptableCreds) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcceptableCreds.Marshal(b, m, deterministic)
+}
+func (m *AcceptableCreds) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcceptableCreds.Merge(m, src)
+}
+func (m *AcceptableCreds) XXX_Size() int {
+	return xxx_messageInfo_AcceptableCreds.Size(m)
+}
+func (m *AcceptableCreds) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcceptableCreds.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcceptableCreds proto.InternalMessageInfo
+
+func (m *AcceptableCreds) GetCreds() []*AcceptableCred {
+	if m != nil {
+		return m.Creds
 	}
 	return nil
 }
 
-func (m *CredProof) GetCommitmentsOfAttrs() [][]byte {
+type Attribute struct {
+	Index                int32    `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Known                bool     `protobuf:"varint,4,opt,name=known,proto3" json:"known,omitempty"`
+	Hidden               bool     `protobuf:"varint,5,opt,name=hidden,proto3" json:"hidden,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Attribute) Reset()         { *m = Attribute{} }
+func (m *Attribute) String() string { return proto.CompactTextString(m) }
+func (*Attribute) ProtoMessage()    {}
+func (*Attribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{37}
+}
+
+func (m *Attribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Attribute.Unmarshal(m, b)
+}
+func (m *Attribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Attribute.Marshal(b, m, deterministic)
+}
+func (m *Attribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Attribute.Merge(m, src)
+}
+func (m *Attribute) XXX_Size() int {
+	return xxx_messageInfo_Attribute.Size(m)
+}
+func (m *Attribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_Attribute.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Attribute proto.InternalMessageInfo
+
+func (m *Attribute) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *Attribute) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Attribute) GetKnown() bool {
+	if m != nil {
+		return m.Known
+	}
+	return false
+}
+
+func (m *Attribute) GetHidden() bool {
+	if m != nil {
+		return m.Hidden
+	}
+	return false
+}
+
+type IntAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *IntAttribute) Reset()         { *m = IntAttribute{} }
+func (m *IntAttribute) String() string { return proto.CompactTextString(m) }
+func (*IntAttribute) ProtoMessage()    {}
+func (*IntAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{38}
+}
+
+func (m *IntAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IntAttribute.Unmarshal(m, b)
+}
+func (m *IntAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IntAttribute.Marshal(b, m, deterministic)
+}
+func (m *IntAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntAttribute.Merge(m, src)
+}
+func (m *IntAttribute) XXX_Size() int {
+	return xxx_messageInfo_IntAttribute.Size(m)
+}
+func (m *IntAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntAttribute.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntAttribute proto.InternalMessageInfo
+
+func (m *IntAttribute) GetAttr() *Attribute {
 	if m != nil {
-		return m.CommitmentsOfAttrs
+		return m.Attr
 	}
 	return nil
 }
 
-func (m *CredProof) GetRevealedKnownAttrs() []int32 {
-	if m != nil {
-		return m.RevealedKnownAttrs
-	}
-	return nil
+type SignedIntAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *CredProof) GetRevealedCommitmentsOfAttrs() []int32 {
-	if m != nil {
-		return m.RevealedCommitmentsOfAttrs
-	}
-	return nil
+func (m *SignedIntAttribute) Reset()         { *m = SignedIntAttribute{} }
+func (m *SignedIntAttribute) String() string { return proto.CompactTextString(m) }
+func (*SignedIntAttribute) ProtoMessage()    {}
+func (*SignedIntAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{39}
 }
 
-type FiatShamir struct {
-	// Used for example for SchnorrProof and RepresentationProof where challenge is constructed by prover
-	// using hash function.
-	// Note that here we don't have A and B as in SchnorrProofRandomData because usually when proving
-	// the knowledge of X such that A^X = B, A is known beforehand (as part of public key).
-	// So here ProofRandomData is actually only X.
-	ProofRandomData []byte   `protobuf:"bytes,1,opt,name=ProofRandomData,proto3" json:"ProofRandomData,omitempty"`
-	Challenge       []byte   `protobuf:"bytes,2,opt,name=Challenge,proto3" json:"Challenge,omitempty"`
-	ProofData       [][]byte `protobuf:"bytes,3,rep,name=ProofData,proto3" json:"ProofData,omitempty"`
+func (m *SignedIntAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignedIntAttribute.Unmarshal(m, b)
+}
+func (m *SignedIntAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignedIntAttribute.Marshal(b, m, deterministic)
+}
+func (m *SignedIntAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignedIntAttribute.Merge(m, src)
+}
+func (m *SignedIntAttribute) XXX_Size() int {
+	return xxx_messageInfo_SignedIntAttribute.Size(m)
+}
+func (m *SignedIntAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignedIntAttribute.DiscardUnknown(m)
 }
 
-func (m *FiatShamir) Reset()                    { *m = FiatShamir{} }
-func (m *FiatShamir) String() string            { return proto.CompactTextString(m) }
-func (*FiatShamir) ProtoMessage()               {}
-func (*FiatShamir) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+var xxx_messageInfo_SignedIntAttribute proto.InternalMessageInfo
 
-func (m *FiatShamir) GetProofRandomData() []byte {
+func (m *SignedIntAttribute) GetAttr() *Attribute {
 	if m != nil {
-		return m.ProofRandomData
+		return m.Attr
 	}
 	return nil
 }
 
-func (m *FiatShamir) GetChallenge() []byte {
-	if m != nil {
-		return m.Challenge
-	}
-	return nil
+type StringAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,2,opt,name=attr,proto3" json:"attr,omitempty"`
+	Hashed               bool       `protobuf:"varint,3,opt,name=hashed,proto3" json:"hashed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *FiatShamir) GetProofData() [][]byte {
-	if m != nil {
-		return m.ProofData
-	}
-	return nil
+func (m *StringAttribute) Reset()         { *m = StringAttribute{} }
+func (m *StringAttribute) String() string { return proto.CompactTextString(m) }
+func (*StringAttribute) ProtoMessage()    {}
+func (*StringAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{40}
 }
 
-type FiatShamirAlsoNeg struct {
-	// For proofs where proof data can be negative (see RepresentationProof).
-	ProofRandomData []byte   `protobuf:"bytes,1,opt,name=ProofRandomData,proto3" json:"ProofRandomData,omitempty"`
-	Challenge       []byte   `protobuf:"bytes,2,opt,name=Challenge,proto3" json:"Challenge,omitempty"`
-	ProofData       []string `protobuf:"bytes,3,rep,name=ProofData" json:"ProofData,omitempty"`
+func (m *StringAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StringAttribute.Unmarshal(m, b)
+}
+func (m *StringAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StringAttribute.Marshal(b, m, deterministic)
+}
+func (m *StringAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StringAttribute.Merge(m, src)
+}
+func (m *StringAttribute) XXX_Size() int {
+	return xxx_messageInfo_StringAttribute.Size(m)
+}
+func (m *StringAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_StringAttribute.DiscardUnknown(m)
 }
 
-func (m *FiatShamirAlsoNeg) Reset()                    { *m = FiatShamirAlsoNeg{} }
-func (m *FiatShamirAlsoNeg) String() string            { return proto.CompactTextString(m) }
-func (*FiatShamirAlsoNeg) ProtoMessage()               {}
-func (*FiatShamirAlsoNeg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+var xxx_messageInfo_StringAttribute proto.InternalMessageInfo
 
-func (m *FiatShamirAlsoNeg) GetProofRandomData() []byte {
+func (m *StringAttribute) GetAttr() *Attribute {
 	if m != nil {
-		return m.ProofRandomData
+		return m.Attr
 	}
 	return nil
 }
 
-func (m *FiatShamirAlsoNeg) GetChallenge() []byte {
+func (m *StringAttribute) GetHashed() bool {
 	if m != nil {
-		return m.Challenge
+		return m.Hashed
 	}
-	return nil
+	return false
 }
 
-func (m *FiatShamirAlsoNeg) GetProofData() []string {
-	if m != nil {
-		return m.ProofData
-	}
-	return nil
+type DateAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-type AcceptableCred struct {
-	OrgName       string   `protobuf:"bytes,1,opt,name=orgName" json:"orgName,omitempty"`
-	RevealedAttrs []string `protobuf:"bytes,2,rep,name=revealedAttrs" json:"revealedAttrs,omitempty"`
+func (m *DateAttribute) Reset()         { *m = DateAttribute{} }
+func (m *DateAttribute) String() string { return proto.CompactTextString(m) }
+func (*DateAttribute) ProtoMessage()    {}
+func (*DateAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{41}
 }
 
-func (m *AcceptableCred) Reset()                    { *m = AcceptableCred{} }
-func (m *AcceptableCred) String() string            { return proto.CompactTextString(m) }
-func (*AcceptableCred) ProtoMessage()               {}
-func (*AcceptableCred) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
-
-func (m *AcceptableCred) GetOrgName() string {
-	if m != nil {
-		return m.OrgName
-	}
-	return ""
+func (m *DateAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DateAttribute.Unmarshal(m, b)
+}
+func (m *DateAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DateAttribute.Marshal(b, m, deterministic)
+}
+func (m *DateAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DateAttribute.Merge(m, src)
+}
+func (m *DateAttribute) XXX_Size() int {
+	return xxx_messageInfo_DateAttribute.Size(m)
+}
+func (m *DateAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_DateAttribute.DiscardUnknown(m)
 }
 
-func (m *AcceptableCred) GetRevealedAttrs() []string {
+var xxx_messageInfo_DateAttribute proto.InternalMessageInfo
+
+func (m *DateAttribute) GetAttr() *Attribute {
 	if m != nil {
-		return m.RevealedAttrs
+		return m.Attr
 	}
 	return nil
 }
 
-type AcceptableCreds struct {
-	Creds []*AcceptableCred `protobuf:"bytes,1,rep,name=creds" json:"creds,omitempty"`
+type BoolAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *AcceptableCreds) Reset()                    { *m = AcceptableCreds{} }
-func (m *AcceptableCreds) String() string            { return proto.CompactTextString(m) }
-func (*AcceptableCreds) ProtoMessage()               {}
-func (*AcceptableCreds) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (m *BoolAttribute) Reset()         { *m = BoolAttribute{} }
+func (m *BoolAttribute) String() string { return proto.CompactTextString(m) }
+func (*BoolAttribute) ProtoMessage()    {}
+func (*BoolAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{42}
+}
 
-func (m *AcceptableCreds) GetCreds() []*AcceptableCred {
+func (m *BoolAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BoolAttribute.Unmarshal(m, b)
+}
+func (m *BoolAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BoolAttribute.Marshal(b, m, deterministic)
+}
+func (m *BoolAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BoolAttribute.Merge(m, src)
+}
+func (m *BoolAttribute) XXX_Size() int {
+	return xxx_messageInfo_BoolAttribute.Size(m)
+}
+func (m *BoolAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_BoolAttribute.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BoolAttribute proto.InternalMessageInfo
+
+func (m *BoolAttribute) GetAttr() *Attribute {
 	if m != nil {
-		return m.Creds
+		return m.Attr
 	}
 	return nil
 }
 
-type Attribute struct {
-	Index int32  `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
-	Name  string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
-	Known bool   `protobuf:"varint,4,opt,name=known" json:"known,omitempty"`
+type EnumAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	Values               []string   `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *Attribute) Reset()                    { *m = Attribute{} }
-func (m *Attribute) String() string            { return proto.CompactTextString(m) }
-func (*Attribute) ProtoMessage()               {}
-func (*Attribute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (m *EnumAttribute) Reset()         { *m = EnumAttribute{} }
+func (m *EnumAttribute) String() string { return proto.CompactTextString(m) }
+func (*EnumAttribute) ProtoMessage()    {}
+func (*EnumAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{43}
+}
 
-func (m *Attribute) GetIndex() int32 {
-	if m != nil {
-		return m.Index
-	}
-	return 0
+func (m *EnumAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EnumAttribute.Unmarshal(m, b)
+}
+func (m *EnumAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EnumAttribute.Marshal(b, m, deterministic)
+}
+func (m *EnumAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EnumAttribute.Merge(m, src)
+}
+func (m *EnumAttribute) XXX_Size() int {
+	return xxx_messageInfo_EnumAttribute.Size(m)
+}
+func (m *EnumAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_EnumAttribute.DiscardUnknown(m)
 }
 
-func (m *Attribute) GetName() string {
+var xxx_messageInfo_EnumAttribute proto.InternalMessageInfo
+
+func (m *EnumAttribute) GetAttr() *Attribute {
 	if m != nil {
-		return m.Name
+		return m.Attr
 	}
-	return ""
+	return nil
 }
 
-func (m *Attribute) GetKnown() bool {
+func (m *EnumAttribute) GetValues() []string {
 	if m != nil {
-		return m.Known
+		return m.Values
 	}
-	return false
+	return nil
 }
 
-type IntAttribute struct {
-	Attr *Attribute `protobuf:"bytes,1,opt,name=attr" json:"attr,omitempty"`
+type BytesAttribute struct {
+	Attr                 *Attribute `protobuf:"bytes,1,opt,name=attr,proto3" json:"attr,omitempty"`
+	Hashed               bool       `protobuf:"varint,2,opt,name=hashed,proto3" json:"hashed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *IntAttribute) Reset()                    { *m = IntAttribute{} }
-func (m *IntAttribute) String() string            { return proto.CompactTextString(m) }
-func (*IntAttribute) ProtoMessage()               {}
-func (*IntAttribute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
-
-func (m *IntAttribute) GetAttr() *Attribute {
-	if m != nil {
-		return m.Attr
-	}
-	return nil
+func (m *BytesAttribute) Reset()         { *m = BytesAttribute{} }
+func (m *BytesAttribute) String() string { return proto.CompactTextString(m) }
+func (*BytesAttribute) ProtoMessage()    {}
+func (*BytesAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{44}
 }
 
-type StringAttribute struct {
-	Attr *Attribute `protobuf:"bytes,2,opt,name=attr" json:"attr,omitempty"`
+func (m *BytesAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BytesAttribute.Unmarshal(m, b)
+}
+func (m *BytesAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BytesAttribute.Marshal(b, m, deterministic)
+}
+func (m *BytesAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BytesAttribute.Merge(m, src)
+}
+func (m *BytesAttribute) XXX_Size() int {
+	return xxx_messageInfo_BytesAttribute.Size(m)
+}
+func (m *BytesAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_BytesAttribute.DiscardUnknown(m)
 }
 
-func (m *StringAttribute) Reset()                    { *m = StringAttribute{} }
-func (m *StringAttribute) String() string            { return proto.CompactTextString(m) }
-func (*StringAttribute) ProtoMessage()               {}
-func (*StringAttribute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+var xxx_messageInfo_BytesAttribute proto.InternalMessageInfo
 
-func (m *StringAttribute) GetAttr() *Attribute {
+func (m *BytesAttribute) GetAttr() *Attribute {
 	if m != nil {
 		return m.Attr
 	}
 	return nil
 }
 
+func (m *BytesAttribute) GetHashed() bool {
+	if m != nil {
+		return m.Hashed
+	}
+	return false
+}
+
 type CredAttribute struct {
 	// Types that are valid to be assigned to Type:
 	//	*CredAttribute_StringAttr
 	//	*CredAttribute_IntAttr
-	Type isCredAttribute_Type `protobuf_oneof:"type"`
+	//	*CredAttribute_DateAttr
+	//	*CredAttribute_SignedIntAttr
+	//	*CredAttribute_BoolAttr
+	//	*CredAttribute_EnumAttr
+	//	*CredAttribute_BytesAttr
+	Type                 isCredAttribute_Type `protobuf_oneof:"type"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *CredAttribute) Reset()         { *m = CredAttribute{} }
+func (m *CredAttribute) String() string { return proto.CompactTextString(m) }
+func (*CredAttribute) ProtoMessage()    {}
+func (*CredAttribute) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{45}
+}
+
+func (m *CredAttribute) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CredAttribute.Unmarshal(m, b)
+}
+func (m *CredAttribute) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CredAttribute.Marshal(b, m, deterministic)
+}
+func (m *CredAttribute) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CredAttribute.Merge(m, src)
+}
+func (m *CredAttribute) XXX_Size() int {
+	return xxx_messageInfo_CredAttribute.Size(m)
+}
+func (m *CredAttribute) XXX_DiscardUnknown() {
+	xxx_messageInfo_CredAttribute.DiscardUnknown(m)
 }
 
-func (m *CredAttribute) Reset()                    { *m = CredAttribute{} }
-func (m *CredAttribute) String() string            { return proto.CompactTextString(m) }
-func (*CredAttribute) ProtoMessage()               {}
-func (*CredAttribute) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+var xxx_messageInfo_CredAttribute proto.InternalMessageInfo
 
 type isCredAttribute_Type interface {
 	isCredAttribute_Type()
 }
 
 type CredAttribute_StringAttr struct {
-	StringAttr *StringAttribute `protobuf:"bytes,1,opt,name=stringAttr,oneof"`
+	StringAttr *StringAttribute `protobuf:"bytes,1,opt,name=stringAttr,proto3,oneof"`
 }
+
 type CredAttribute_IntAttr struct {
-	IntAttr *IntAttribute `protobuf:"bytes,2,opt,name=intAttr,oneof"`
+	IntAttr *IntAttribute `protobuf:"bytes,2,opt,name=intAttr,proto3,oneof"`
+}
+
+type CredAttribute_DateAttr struct {
+	DateAttr *DateAttribute `protobuf:"bytes,3,opt,name=dateAttr,proto3,oneof"`
+}
+
+type CredAttribute_SignedIntAttr struct {
+	SignedIntAttr *SignedIntAttribute `protobuf:"bytes,4,opt,name=signedIntAttr,proto3,oneof"`
+}
+
+type CredAttribute_BoolAttr struct {
+	BoolAttr *BoolAttribute `protobuf:"bytes,5,opt,name=boolAttr,proto3,oneof"`
+}
+
+type CredAttribute_EnumAttr struct {
+	EnumAttr *EnumAttribute `protobuf:"bytes,6,opt,name=enumAttr,proto3,oneof"`
+}
+
+type CredAttribute_BytesAttr struct {
+	BytesAttr *BytesAttribute `protobuf:"bytes,7,opt,name=bytesAttr,proto3,oneof"`
 }
 
 func (*CredAttribute_StringAttr) isCredAttribute_Type() {}
-func (*CredAttribute_IntAttr) isCredAttribute_Type()    {}
+
+func (*CredAttribute_IntAttr) isCredAttribute_Type() {}
+
+func (*CredAttribute_DateAttr) isCredAttribute_Type() {}
+
+func (*CredAttribute_SignedIntAttr) isCredAttribute_Type() {}
+
+func (*CredAttribute_BoolAttr) isCredAttribute_Type() {}
+
+func (*CredAttribute_EnumAttr) isCredAttribute_Type() {}
+
+func (*CredAttribute_BytesAttr) isCredAttribute_Type() {}
 
 func (m *CredAttribute) GetType() isCredAttribute_Type {
 	if m != nil {
@@ -1090,91 +3087,90 @@ func (m *CredAttribute) GetIntAttr() *IntAttribute {
 	return nil
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*CredAttribute) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _CredAttribute_OneofMarshaler, _CredAttribute_OneofUnmarshaler, _CredAttribute_OneofSizer, []interface{}{
+func (m *CredAttribute) GetDateAttr() *DateAttribute {
+	if x, ok := m.GetType().(*CredAttribute_DateAttr); ok {
+		return x.DateAttr
+	}
+	return nil
+}
+
+func (m *CredAttribute) GetSignedIntAttr() *SignedIntAttribute {
+	if x, ok := m.GetType().(*CredAttribute_SignedIntAttr); ok {
+		return x.SignedIntAttr
+	}
+	return nil
+}
+
+func (m *CredAttribute) GetBoolAttr() *BoolAttribute {
+	if x, ok := m.GetType().(*CredAttribute_BoolAttr); ok {
+		return x.BoolAttr
+	}
+	return nil
+}
+
+func (m *CredAttribute) GetEnumAttr() *EnumAttribute {
+	if x, ok := m.GetType().(*CredAttribute_EnumAttr); ok {
+		return x.EnumAttr
+	}
+	return nil
+}
+
+func (m *CredAttribute) GetBytesAttr() *BytesAttribute {
+	if x, ok := m.GetType().(*CredAttribute_BytesAttr); ok {
+		return x.BytesAttr
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*CredAttribute) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
 		(*CredAttribute_StringAttr)(nil),
 		(*CredAttribute_IntAttr)(nil),
+		(*CredAttribute_DateAttr)(nil),
+		(*CredAttribute_SignedIntAttr)(nil),
+		(*CredAttribute_BoolAttr)(nil),
+		(*CredAttribute_EnumAttr)(nil),
+		(*CredAttribute_BytesAttr)(nil),
 	}
 }
 
-func _CredAttribute_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*CredAttribute)
-	// type
-	switch x := m.Type.(type) {
-	case *CredAttribute_StringAttr:
-		b.EncodeVarint(1<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.StringAttr); err != nil {
-			return err
-		}
-	case *CredAttribute_IntAttr:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.IntAttr); err != nil {
-			return err
-		}
-	case nil:
-	default:
-		return fmt.Errorf("CredAttribute.Type has unexpected type %T", x)
-	}
-	return nil
-}
-
-func _CredAttribute_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*CredAttribute)
-	switch tag {
-	case 1: // type.stringAttr
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(StringAttribute)
-		err := b.DecodeMessage(msg)
-		m.Type = &CredAttribute_StringAttr{msg}
-		return true, err
-	case 2: // type.intAttr
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(IntAttribute)
-		err := b.DecodeMessage(msg)
-		m.Type = &CredAttribute_IntAttr{msg}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _CredAttribute_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*CredAttribute)
-	// type
-	switch x := m.Type.(type) {
-	case *CredAttribute_StringAttr:
-		s := proto.Size(x.StringAttr)
-		n += proto.SizeVarint(1<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *CredAttribute_IntAttr:
-		s := proto.Size(x.IntAttr)
-		n += proto.SizeVarint(2<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
+type CredStructure struct {
+	NKnown               int32            `protobuf:"varint,1,opt,name=nKnown,proto3" json:"nKnown,omitempty"`
+	NCommitted           int32            `protobuf:"varint,2,opt,name=nCommitted,proto3" json:"nCommitted,omitempty"`
+	NHidden              int32            `protobuf:"varint,3,opt,name=nHidden,proto3" json:"nHidden,omitempty"`
+	Attributes           []*CredAttribute `protobuf:"bytes,4,rep,name=attributes,proto3" json:"attributes,omitempty"`
+	Name                 string           `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Version              int32            `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
 }
 
-type CredStructure struct {
-	NKnown     int32            `protobuf:"varint,1,opt,name=nKnown" json:"nKnown,omitempty"`
-	NCommitted int32            `protobuf:"varint,2,opt,name=nCommitted" json:"nCommitted,omitempty"`
-	NHidden    int32            `protobuf:"varint,3,opt,name=nHidden" json:"nHidden,omitempty"`
-	Attributes []*CredAttribute `protobuf:"bytes,4,rep,name=attributes" json:"attributes,omitempty"`
+func (m *CredStructure) Reset()         { *m = CredStructure{} }
+func (m *CredStructure) String() string { return proto.CompactTextString(m) }
+func (*CredStructure) ProtoMessage()    {}
+func (*CredStructure) Descriptor() ([]byte, []int) {
+	return fileDescriptor_3dd99ad1325b3d7a, []int{46}
+}
+
+func (m *CredStructure) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CredStructure.Unmarshal(m, b)
+}
+func (m *CredStructure) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CredStructure.Marshal(b, m, deterministic)
+}
+func (m *CredStructure) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CredStructure.Merge(m, src)
+}
+func (m *CredStructure) XXX_Size() int {
+	return xxx_messageInfo_CredStructure.Size(m)
+}
+func (m *CredStructure) XXX_DiscardUnknown() {
+	xxx_messageInfo_CredStructure.DiscardUnknown(m)
 }
 
-func (m *CredStructure) Reset()                    { *m = CredStructure{} }
-func (m *CredStructure) String() string            { return proto.CompactTextString(m) }
-func (*CredStructure) ProtoMessage()               {}
-func (*CredStructure) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+var xxx_messageInfo_CredStructure proto.InternalMessageInfo
 
 func (m *CredStructure) GetNKnown() int32 {
 	if m != nil {
@@ -1204,31 +3200,233 @@ func (m *CredStructure) GetAttributes() []*CredAttribute {
 	return nil
 }
 
+func (m *CredStructure) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *CredStructure) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Request)(nil), "clpb.Request")
+	proto.RegisterType((*KeyIds)(nil), "clpb.KeyIds")
 	proto.RegisterType((*Response)(nil), "clpb.Response")
+	proto.RegisterType((*ProofParams)(nil), "clpb.ProofParams")
+	proto.RegisterType((*ProofRequest)(nil), "clpb.ProofRequest")
+	proto.RegisterType((*AcceptedIssuer)(nil), "clpb.AcceptedIssuer")
+	proto.RegisterType((*CredProofParams)(nil), "clpb.CredProofParams")
+	proto.RegisterType((*Predicate)(nil), "clpb.Predicate")
 	proto.RegisterType((*Empty)(nil), "clpb.Empty")
 	proto.RegisterType((*SchnorrGroup)(nil), "clpb.SchnorrGroup")
 	proto.RegisterType((*PedersenParams)(nil), "clpb.PedersenParams")
 	proto.RegisterType((*PubKey)(nil), "clpb.PubKey")
+	proto.RegisterType((*KeyProof)(nil), "clpb.KeyProof")
 	proto.RegisterType((*Params)(nil), "clpb.Params")
 	proto.RegisterType((*PublicParams)(nil), "clpb.PublicParams")
+	proto.RegisterType((*TrustedIssuer)(nil), "clpb.TrustedIssuer")
+	proto.RegisterType((*RetiredPubKey)(nil), "clpb.RetiredPubKey")
 	proto.RegisterType((*CredIssueRequest)(nil), "clpb.CredIssueRequest")
 	proto.RegisterType((*Cred)(nil), "clpb.Cred")
 	proto.RegisterType((*IssuedCred)(nil), "clpb.IssuedCred")
+	proto.RegisterType((*Witness)(nil), "clpb.Witness")
+	proto.RegisterType((*Accumulator)(nil), "clpb.Accumulator")
+	proto.RegisterType((*AccumulatorUpdatesRequest)(nil), "clpb.AccumulatorUpdatesRequest")
+	proto.RegisterType((*AccumulatorUpdates)(nil), "clpb.AccumulatorUpdates")
+	proto.RegisterType((*AccumulatorUpdate)(nil), "clpb.AccumulatorUpdate")
 	proto.RegisterType((*CredUpdateRequest)(nil), "clpb.CredUpdateRequest")
 	proto.RegisterType((*CredProof)(nil), "clpb.CredProof")
+	proto.RegisterType((*MultiCredProof)(nil), "clpb.MultiCredProof")
+	proto.RegisterType((*PredicateProof)(nil), "clpb.PredicateProof")
+	proto.RegisterType((*RangeProof)(nil), "clpb.RangeProof")
+	proto.RegisterType((*NonRevocationProof)(nil), "clpb.NonRevocationProof")
+	proto.RegisterType((*ScopePseudonymProof)(nil), "clpb.ScopePseudonymProof")
+	proto.RegisterType((*SetMembershipProof)(nil), "clpb.SetMembershipProof")
 	proto.RegisterType((*FiatShamir)(nil), "clpb.FiatShamir")
 	proto.RegisterType((*FiatShamirAlsoNeg)(nil), "clpb.FiatShamirAlsoNeg")
 	proto.RegisterType((*AcceptableCred)(nil), "clpb.AcceptableCred")
 	proto.RegisterType((*AcceptableCreds)(nil), "clpb.AcceptableCreds")
 	proto.RegisterType((*Attribute)(nil), "clpb.Attribute")
 	proto.RegisterType((*IntAttribute)(nil), "clpb.IntAttribute")
+	proto.RegisterType((*SignedIntAttribute)(nil), "clpb.SignedIntAttribute")
 	proto.RegisterType((*StringAttribute)(nil), "clpb.StringAttribute")
+	proto.RegisterType((*DateAttribute)(nil), "clpb.DateAttribute")
+	proto.RegisterType((*BoolAttribute)(nil), "clpb.BoolAttribute")
+	proto.RegisterType((*EnumAttribute)(nil), "clpb.EnumAttribute")
+	proto.RegisterType((*BytesAttribute)(nil), "clpb.BytesAttribute")
 	proto.RegisterType((*CredAttribute)(nil), "clpb.CredAttribute")
 	proto.RegisterType((*CredStructure)(nil), "clpb.CredStructure")
 }
 
+func init() { proto.RegisterFile("anauth/cl/clpb/cl.proto", fileDescriptor_3dd99ad1325b3d7a) }
+
+var fileDescriptor_3dd99ad1325b3d7a = []byte{
+	// 2503 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x19, 0x4d, 0x73, 0x1b, 0x49,
+	0xd5, 0x33, 0xfa, 0xb2, 0x9e, 0x25, 0x39, 0xe9, 0x4d, 0xb2, 0xb3, 0xae, 0xad, 0xe0, 0x9a, 0x0d,
+	0xc1, 0x05, 0x59, 0x7b, 0xfd, 0xc1, 0x2e, 0x59, 0xd8, 0x14, 0x8e, 0x62, 0x22, 0xe3, 0xc4, 0x11,
+	0x6d, 0x3b, 0x54, 0xc1, 0x69, 0x3c, 0xea, 0x58, 0x53, 0x91, 0x66, 0x94, 0x99, 0x91, 0xb3, 0xca,
+	0x81, 0x13, 0xc5, 0x85, 0x2a, 0xaa, 0xa8, 0xa2, 0x28, 0x4e, 0xdc, 0xe1, 0xca, 0x81, 0x3f, 0xc0,
+	0xdf, 0x80, 0x23, 0x47, 0x4e, 0x70, 0xe3, 0x40, 0xbd, 0x7e, 0xdd, 0x33, 0x3d, 0x92, 0x6c, 0x6c,
+	0xaa, 0xd8, 0x93, 0xe6, 0x7d, 0x75, 0xbf, 0xef, 0xee, 0x7e, 0x82, 0xf7, 0xbd, 0xd0, 0x1b, 0xa7,
+	0xfd, 0x0d, 0x7f, 0xb0, 0xe1, 0x0f, 0x46, 0xa7, 0x1b, 0xfe, 0x60, 0x7d, 0x14, 0x47, 0x69, 0xc4,
+	0xca, 0x08, 0xba, 0xff, 0xb4, 0xa1, 0xc6, 0xc5, 0x9b, 0xb1, 0x48, 0x52, 0xf6, 0x11, 0x54, 0xc4,
+	0x70, 0x94, 0x4e, 0x1c, 0x6b, 0xd5, 0x5a, 0x5b, 0xda, 0x5a, 0x5a, 0x47, 0x8e, 0xf5, 0x3d, 0x44,
+	0x75, 0x16, 0x38, 0xd1, 0x98, 0x03, 0xd5, 0x58, 0x9c, 0x1d, 0x88, 0x89, 0x63, 0xaf, 0x5a, 0x6b,
+	0xf5, 0xce, 0x02, 0x57, 0x30, 0xfb, 0x14, 0xea, 0x7e, 0x2c, 0x7a, 0xfb, 0x49, 0x32, 0x16, 0x4e,
+	0x49, 0x2e, 0x71, 0x87, 0x96, 0x68, 0x6b, 0xb4, 0xda, 0xa9, 0xb3, 0xc0, 0x73, 0x56, 0xb6, 0x41,
+	0x72, 0xdd, 0x38, 0x3a, 0x17, 0x4e, 0x59, 0xca, 0x2d, 0xe7, 0x72, 0xdd, 0x38, 0x8a, 0x5e, 0x69,
+	0x01, 0xc9, 0xc3, 0x1e, 0x02, 0x20, 0x70, 0x32, 0xea, 0x79, 0xa9, 0x70, 0x2a, 0x52, 0xe2, 0xfd,
+	0x5c, 0x82, 0xf0, 0xf9, 0x56, 0x06, 0x33, 0xbb, 0x03, 0x95, 0xd7, 0x62, 0xb2, 0xdf, 0x73, 0xaa,
+	0x4a, 0x79, 0x02, 0xd9, 0x7d, 0xa8, 0xca, 0x8f, 0xc4, 0xa9, 0xc9, 0xe5, 0x1a, 0xb4, 0xdc, 0x81,
+	0xc4, 0xa1, 0x8d, 0x44, 0x65, 0x8f, 0xa0, 0x35, 0x1c, 0x0f, 0xd2, 0xa0, 0x9d, 0x29, 0xbc, 0x28,
+	0xf9, 0x6f, 0x11, 0xff, 0x73, 0x83, 0x26, 0xb5, 0x9e, 0xe2, 0x7e, 0x5c, 0x85, 0x72, 0x3a, 0x19,
+	0x09, 0x77, 0x05, 0xaa, 0xb4, 0x36, 0xbb, 0x01, 0xa5, 0xa0, 0x97, 0x38, 0xd6, 0x6a, 0x69, 0xad,
+	0xce, 0xf1, 0xd3, 0xfd, 0xb3, 0x05, 0x8b, 0x5c, 0x24, 0xa3, 0x28, 0x4c, 0xa4, 0xc2, 0x61, 0x14,
+	0xfa, 0x42, 0xc6, 0xa4, 0x81, 0x0a, 0x4b, 0x90, 0x6d, 0x01, 0x04, 0xe8, 0xbd, 0x1e, 0xae, 0x2d,
+	0x43, 0xb1, 0xb4, 0x75, 0x83, 0x94, 0xd8, 0xcf, 0xf0, 0x68, 0x7c, 0xce, 0xc5, 0x56, 0x01, 0x12,
+	0x91, 0x24, 0x41, 0x14, 0x62, 0xf8, 0x4a, 0xca, 0x03, 0x06, 0x8e, 0x7d, 0x1b, 0x96, 0x46, 0xa8,
+	0x79, 0xd7, 0x8b, 0xbd, 0x61, 0xa2, 0x82, 0x71, 0x93, 0x96, 0xed, 0xe6, 0x84, 0xce, 0x02, 0x37,
+	0xf9, 0x32, 0xab, 0x7e, 0x6b, 0xc3, 0x92, 0xc1, 0xc6, 0x6e, 0x15, 0x94, 0xd7, 0xaa, 0x6f, 0x00,
+	0x8c, 0x62, 0xd1, 0x0b, 0x7c, 0x2f, 0x15, 0x89, 0x63, 0xaf, 0x96, 0xf2, 0x80, 0x77, 0x35, 0x9e,
+	0x1b, 0x2c, 0x6c, 0x1b, 0x96, 0x3c, 0xdf, 0x1f, 0x0f, 0xc7, 0x03, 0x2f, 0x8d, 0x62, 0xa7, 0x64,
+	0x6a, 0xb5, 0x9b, 0x13, 0xb8, 0xc9, 0x85, 0x7b, 0x27, 0x7e, 0x34, 0xa2, 0x8c, 0xaa, 0x73, 0x02,
+	0xd8, 0x0a, 0x2c, 0x9e, 0x8b, 0x38, 0x78, 0x15, 0x88, 0x58, 0x26, 0x4e, 0x9d, 0x67, 0x30, 0xfb,
+	0x16, 0x54, 0x30, 0x53, 0x12, 0xa7, 0x2a, 0x55, 0xba, 0x3d, 0x95, 0x83, 0x64, 0x13, 0x27, 0x1e,
+	0xf6, 0x00, 0x6a, 0x31, 0x65, 0x98, 0xca, 0x18, 0x66, 0x78, 0x49, 0xe5, 0x1e, 0xd7, 0x2c, 0xee,
+	0xaf, 0x2c, 0x68, 0x98, 0x14, 0x76, 0x0f, 0x9a, 0xb1, 0x38, 0x17, 0xde, 0x40, 0xf4, 0x76, 0xd3,
+	0x34, 0xd6, 0xf1, 0x2f, 0x22, 0xd9, 0x23, 0x58, 0xf6, 0x7c, 0x5f, 0x8c, 0x52, 0x55, 0x2a, 0xb1,
+	0x76, 0xd7, 0xad, 0xcc, 0x78, 0x83, 0xc8, 0xa7, 0x99, 0x99, 0x03, 0xb5, 0xd1, 0x38, 0x1e, 0x45,
+	0x09, 0xd5, 0x63, 0x9d, 0x6b, 0xd0, 0xfd, 0x1c, 0x5a, 0x45, 0x61, 0xc6, 0xa0, 0x1c, 0x7a, 0x43,
+	0x0a, 0x55, 0x9d, 0xcb, 0x6f, 0xf4, 0x21, 0x55, 0x8b, 0x4d, 0x3e, 0x94, 0x80, 0xfb, 0x27, 0x0b,
+	0x96, 0xa7, 0xbc, 0x92, 0x73, 0x5a, 0x06, 0xe7, 0x57, 0x14, 0xe9, 0x19, 0x5f, 0x96, 0xe7, 0xf8,
+	0xd2, 0xfd, 0x29, 0xd4, 0xb3, 0x3d, 0xd1, 0x58, 0x2f, 0x4d, 0x63, 0x6d, 0x2c, 0x7e, 0x23, 0xce,
+	0x8f, 0x42, 0x6d, 0xab, 0xfc, 0x46, 0xb3, 0xce, 0xbd, 0x81, 0x6a, 0x67, 0x25, 0x4e, 0x00, 0x96,
+	0x6c, 0x22, 0x52, 0xb5, 0x0d, 0x7e, 0xba, 0x35, 0xa8, 0xc8, 0x36, 0xe9, 0x7e, 0x07, 0x1a, 0x47,
+	0x7e, 0x3f, 0x8c, 0xe2, 0xf8, 0x69, 0x1c, 0x8d, 0x47, 0xac, 0x01, 0xd6, 0x48, 0xae, 0xd8, 0xe0,
+	0x96, 0x84, 0xce, 0xe4, 0x52, 0x0d, 0x6e, 0x9d, 0x21, 0xf4, 0x46, 0x66, 0x67, 0x83, 0x5b, 0x6f,
+	0xdc, 0x97, 0xd0, 0xea, 0x8a, 0x9e, 0x88, 0x13, 0x11, 0x2a, 0x9f, 0x7e, 0x0a, 0x8d, 0xc4, 0x58,
+	0xcb, 0xb1, 0xcc, 0x3c, 0x33, 0x77, 0xe1, 0x05, 0x3e, 0x5c, 0xb7, 0xaf, 0xf7, 0xec, 0xbb, 0xff,
+	0xb6, 0xa1, 0xda, 0x1d, 0x9f, 0x62, 0x75, 0x37, 0xc0, 0x0a, 0x55, 0x29, 0x5a, 0x21, 0x42, 0x89,
+	0x66, 0x4b, 0x10, 0x7a, 0xa7, 0x55, 0x7b, 0x87, 0x89, 0x13, 0x27, 0x07, 0x61, 0xf4, 0x36, 0x94,
+	0x56, 0x36, 0xb8, 0x06, 0xd9, 0x2a, 0x2c, 0xc5, 0x49, 0x3b, 0x1a, 0x0e, 0x83, 0x34, 0x15, 0x3d,
+	0xa7, 0x22, 0xa9, 0x26, 0x0a, 0x4b, 0x2c, 0x4e, 0x3a, 0x41, 0xaf, 0x27, 0x42, 0x59, 0x49, 0x0d,
+	0x9e, 0xc1, 0xec, 0x7b, 0xd0, 0x1a, 0x15, 0x8c, 0x54, 0xc5, 0xa3, 0xf2, 0xb9, 0xe8, 0x00, 0x3e,
+	0xc5, 0xcb, 0x5a, 0x60, 0x87, 0x9b, 0xb2, 0xe1, 0x36, 0xb8, 0x1d, 0x6e, 0x92, 0x3b, 0xeb, 0x86,
+	0x3b, 0xfb, 0x0e, 0x28, 0xb3, 0xd1, 0x02, 0xcf, 0xf7, 0xf7, 0xc3, 0x20, 0x75, 0x96, 0x24, 0x4e,
+	0x83, 0x32, 0xf6, 0xbe, 0xff, 0xd4, 0x69, 0x48, 0xb4, 0xfc, 0x56, 0xb8, 0x8e, 0xd3, 0xcc, 0x70,
+	0x1d, 0x76, 0x0f, 0x2a, 0xb2, 0xc7, 0x39, 0x2d, 0xa9, 0x62, 0x2b, 0x3b, 0x11, 0xa8, 0x90, 0x89,
+	0xc8, 0xee, 0x02, 0x24, 0x7e, 0x5f, 0x0c, 0xbd, 0x8e, 0x97, 0xf4, 0x9d, 0x65, 0x29, 0x6f, 0x60,
+	0xdc, 0x9f, 0xc1, 0xa2, 0x16, 0x61, 0x1f, 0x42, 0xdd, 0xef, 0x7b, 0x83, 0x81, 0x08, 0xcf, 0x74,
+	0x4b, 0xcc, 0x11, 0x48, 0x8d, 0x55, 0xd7, 0xa7, 0x5a, 0x69, 0xf0, 0x1c, 0xc1, 0x3e, 0x81, 0xf7,
+	0x7c, 0xe9, 0xe2, 0xa1, 0x08, 0x53, 0x9e, 0xf1, 0x51, 0x74, 0xe6, 0x91, 0x7e, 0x58, 0x5e, 0x2c,
+	0xdd, 0x28, 0xbb, 0xbf, 0xc1, 0xf0, 0x93, 0xfb, 0x3e, 0x84, 0x3a, 0xef, 0x47, 0x8f, 0x83, 0xf4,
+	0x99, 0xa0, 0x34, 0xa8, 0xf0, 0x1c, 0x81, 0x0e, 0x3b, 0x7c, 0x26, 0xc2, 0xb3, 0x94, 0x72, 0xa7,
+	0xc2, 0x35, 0x88, 0x26, 0x62, 0x09, 0x29, 0xc1, 0xaa, 0x24, 0x1a, 0x18, 0xa4, 0xa3, 0xa9, 0x8a,
+	0x5e, 0x23, 0x7a, 0x8e, 0xc1, 0x84, 0x38, 0x12, 0xbe, 0x54, 0x42, 0x06, 0xaf, 0xc2, 0x33, 0x18,
+	0x77, 0xdd, 0x53, 0x82, 0x75, 0xda, 0x75, 0x2f, 0x97, 0xda, 0xdb, 0x54, 0x24, 0x20, 0x29, 0x0d,
+	0xa3, 0xd4, 0x4b, 0x45, 0x5a, 0x22, 0x29, 0x05, 0xb2, 0xfb, 0xd0, 0x6a, 0x6b, 0x8f, 0x1e, 0x8d,
+	0x3c, 0x5f, 0xc8, 0x30, 0x57, 0xf8, 0x14, 0xd6, 0xfd, 0x83, 0x0d, 0x8d, 0xee, 0xf8, 0x74, 0x10,
+	0xf8, 0xca, 0x39, 0xf7, 0xa0, 0x3a, 0x92, 0x55, 0xe2, 0x58, 0xe6, 0x05, 0x80, 0x2a, 0x87, 0x2b,
+	0x9a, 0xe4, 0xa2, 0xbc, 0xb5, 0x0b, 0x5c, 0x12, 0xc7, 0x15, 0x8d, 0x3d, 0x84, 0x26, 0x1e, 0x12,
+	0x47, 0x69, 0x3c, 0xf6, 0xd3, 0x71, 0xac, 0x2f, 0x43, 0xef, 0xe5, 0x07, 0x4a, 0x46, 0xe2, 0x45,
+	0x4e, 0x3c, 0x80, 0x63, 0x91, 0x06, 0xb1, 0xe8, 0x1d, 0x88, 0x09, 0x85, 0x37, 0x13, 0xe4, 0x44,
+	0x50, 0x2a, 0x99, 0x7c, 0x53, 0x59, 0x58, 0x99, 0xce, 0x42, 0xf6, 0x5d, 0x68, 0xa5, 0xf1, 0x38,
+	0x31, 0xce, 0x91, 0xaa, 0xb9, 0xf2, 0xb1, 0x49, 0xe3, 0x53, 0xac, 0xee, 0x1f, 0x2d, 0x68, 0x16,
+	0x38, 0xe6, 0x9e, 0x15, 0xb9, 0x03, 0xed, 0x2b, 0x39, 0xb0, 0x74, 0x1d, 0x07, 0x96, 0xaf, 0xea,
+	0x40, 0xf7, 0x05, 0x34, 0x0b, 0x7e, 0xba, 0x62, 0x60, 0x1d, 0xa8, 0x89, 0x2f, 0x47, 0x41, 0x2c,
+	0x28, 0xb2, 0x25, 0xae, 0x41, 0xf7, 0xaf, 0x36, 0xdc, 0x98, 0xbe, 0xbf, 0xe2, 0x09, 0x70, 0x38,
+	0x19, 0xaa, 0x1a, 0xc6, 0x4f, 0x8c, 0x80, 0x6c, 0x90, 0x74, 0x02, 0x51, 0xf9, 0x1a, 0x18, 0xb6,
+	0x0e, 0xac, 0x9d, 0x15, 0x69, 0xf2, 0xe2, 0x15, 0xf1, 0x95, 0x24, 0xdf, 0x1c, 0x0a, 0x7b, 0x00,
+	0x8b, 0x87, 0x93, 0xa1, 0xec, 0x1b, 0x4e, 0xd9, 0xbc, 0xdd, 0xfd, 0x20, 0xf0, 0xd2, 0xa3, 0xbe,
+	0x37, 0x0c, 0x62, 0x9e, 0x71, 0x60, 0xef, 0x3b, 0x51, 0x61, 0xb7, 0x4e, 0xd8, 0x06, 0x54, 0x4f,
+	0x48, 0xb2, 0x6a, 0xde, 0x8d, 0x73, 0xc9, 0xdd, 0x41, 0x12, 0x1d, 0x8a, 0x33, 0xae, 0xd8, 0xd8,
+	0x33, 0x70, 0x66, 0x55, 0x90, 0x24, 0x6c, 0xd0, 0xa5, 0xb9, 0x9b, 0x5f, 0x28, 0x81, 0x87, 0xe6,
+	0xa1, 0xbc, 0xf5, 0x51, 0xa7, 0x26, 0x80, 0xdd, 0x81, 0x2a, 0xa7, 0x77, 0x43, 0x5d, 0x66, 0x8d,
+	0x82, 0xdc, 0x1d, 0x28, 0xcb, 0xcb, 0x69, 0x03, 0xac, 0x5d, 0x7d, 0x38, 0xed, 0x22, 0xb4, 0xa7,
+	0x0f, 0xa7, 0x3d, 0x74, 0xf7, 0xcb, 0xcd, 0x4d, 0x75, 0x3c, 0xe1, 0xa7, 0xfb, 0x0b, 0x0b, 0x20,
+	0xbf, 0xe7, 0xb2, 0xbb, 0x50, 0xc6, 0x34, 0x50, 0x21, 0x86, 0x3c, 0x4f, 0xb8, 0xc4, 0xa3, 0x47,
+	0x76, 0xc9, 0x23, 0xf6, 0x7f, 0xf1, 0x08, 0xb1, 0xb1, 0x6f, 0x40, 0xed, 0x6d, 0x90, 0x86, 0x22,
+	0xd1, 0x89, 0xda, 0x24, 0x89, 0x1f, 0x13, 0x92, 0x6b, 0xaa, 0xfb, 0x10, 0x6a, 0x0a, 0x87, 0x39,
+	0x74, 0x2e, 0x62, 0xbc, 0x4a, 0x4b, 0x3d, 0x4a, 0x5c, 0x83, 0xf9, 0x35, 0x82, 0x2c, 0x22, 0xc0,
+	0xfd, 0x02, 0x96, 0x8c, 0x3b, 0xcd, 0xb5, 0xc5, 0x0f, 0xe0, 0x03, 0x43, 0x9c, 0xde, 0x37, 0x89,
+	0x4e, 0xd0, 0x4b, 0x17, 0x9b, 0x73, 0xa7, 0x7b, 0x0a, 0x6c, 0x76, 0x31, 0xb6, 0x09, 0xb5, 0x31,
+	0x7d, 0xca, 0xfb, 0x69, 0xe6, 0xb7, 0x19, 0x56, 0xae, 0xf9, 0xdc, 0xd7, 0x70, 0x73, 0x86, 0x7a,
+	0x89, 0x36, 0x0d, 0xb0, 0xb4, 0x59, 0x96, 0xe4, 0x8b, 0xc5, 0x30, 0x3a, 0x17, 0x3d, 0xe9, 0xf5,
+	0x45, 0xae, 0xc1, 0xdc, 0x05, 0x65, 0xd3, 0x05, 0xbf, 0x2c, 0xc1, 0xcd, 0x99, 0x17, 0xdf, 0x9c,
+	0xe2, 0xcc, 0x32, 0xd2, 0x36, 0x33, 0xf2, 0x1e, 0x34, 0x0f, 0xc5, 0x5b, 0xa3, 0x6a, 0xa9, 0x1a,
+	0x8b, 0xc8, 0xaf, 0xb6, 0x10, 0x77, 0xe0, 0xf6, 0xa1, 0x78, 0x3b, 0xa7, 0x51, 0xd4, 0xa4, 0x6a,
+	0xf3, 0x89, 0x97, 0x96, 0xef, 0xe2, 0xb5, 0xcb, 0xf7, 0x33, 0x80, 0x6e, 0x2c, 0xce, 0x95, 0xe2,
+	0xf5, 0xcb, 0x15, 0x37, 0x58, 0xdd, 0x7f, 0x95, 0xa0, 0x9e, 0xbd, 0x0b, 0xa6, 0xea, 0xf9, 0x63,
+	0xa8, 0x5c, 0xa9, 0xfe, 0x88, 0x6b, 0xaa, 0x9b, 0x96, 0xae, 0xd8, 0x4d, 0xcb, 0x17, 0x76, 0xd3,
+	0x75, 0x60, 0x5c, 0xbd, 0x06, 0x8c, 0x75, 0xf1, 0xf2, 0x5a, 0xe1, 0x73, 0x28, 0xec, 0x11, 0xac,
+	0x68, 0xec, 0x9c, 0x7d, 0xaa, 0x52, 0xee, 0x12, 0x0e, 0x7c, 0xb8, 0x65, 0x8f, 0x8d, 0x42, 0x1f,
+	0xbd, 0x35, 0xf5, 0xfa, 0x91, 0x44, 0x3e, 0xcd, 0xcc, 0x3a, 0xc0, 0x0e, 0xa3, 0x90, 0x8b, 0xf3,
+	0xc8, 0xf7, 0xd2, 0x20, 0x0a, 0xc9, 0x77, 0x34, 0x6a, 0x70, 0x68, 0x89, 0x59, 0x3a, 0x9f, 0x23,
+	0xc3, 0x0e, 0xe0, 0xbd, 0x23, 0x7c, 0xf9, 0x76, 0x13, 0x31, 0xee, 0x45, 0xe1, 0x64, 0x68, 0x86,
+	0xf5, 0x03, 0xfd, 0x96, 0x98, 0x61, 0xe0, 0xf3, 0xa4, 0xb0, 0x8e, 0xe4, 0xd4, 0x42, 0x5e, 0xc8,
+	0xea, 0x9c, 0x00, 0xf7, 0xe7, 0x16, 0xb4, 0x8a, 0x83, 0x0f, 0xf6, 0x75, 0xfd, 0x94, 0xb6, 0xcc,
+	0x37, 0x5f, 0x46, 0xd7, 0x8f, 0xe8, 0x0b, 0x94, 0xb3, 0xff, 0x17, 0xe5, 0xdc, 0xdf, 0x59, 0xd0,
+	0x2a, 0xfa, 0x11, 0x6f, 0x83, 0x59, 0x50, 0xf7, 0xc3, 0x9e, 0xf8, 0x52, 0x5d, 0x7b, 0xa7, 0xb0,
+	0x6c, 0x0d, 0x2a, 0xb1, 0x87, 0x97, 0xf2, 0xc2, 0x1c, 0x85, 0x23, 0x4a, 0x0f, 0x72, 0x88, 0x81,
+	0x3d, 0xa0, 0xa7, 0x5f, 0xc9, 0x8c, 0xc4, 0x91, 0x48, 0x9f, 0x8b, 0xe1, 0xa9, 0x88, 0x93, 0x7e,
+	0x30, 0xd2, 0xfc, 0xc8, 0x96, 0xcd, 0x45, 0xfe, 0x6e, 0x01, 0xe4, 0xab, 0x61, 0x69, 0x1c, 0x4b,
+	0xcf, 0x34, 0xb8, 0x75, 0x8c, 0x07, 0xe3, 0xf1, 0x13, 0x31, 0x48, 0x3d, 0xd5, 0x9d, 0x14, 0x24,
+	0xf1, 0xc7, 0xc1, 0xa0, 0x27, 0x54, 0xfe, 0x2b, 0x08, 0x5f, 0x60, 0xc4, 0x41, 0x44, 0x6a, 0x88,
+	0x26, 0x0a, 0x25, 0x7f, 0x44, 0x44, 0xea, 0x44, 0x0a, 0xc2, 0x6b, 0xdb, 0x49, 0xc7, 0x4b, 0x65,
+	0xfe, 0xd6, 0xb9, 0xfc, 0x46, 0x1c, 0x47, 0x5c, 0x8d, 0x70, 0xf8, 0x2d, 0x1f, 0x0a, 0x72, 0x39,
+	0x24, 0x2c, 0xca, 0x50, 0xe7, 0x08, 0xbc, 0x98, 0xef, 0x0e, 0x46, 0x7d, 0x49, 0xa4, 0xa3, 0x3c,
+	0x83, 0xdd, 0x7f, 0x58, 0xf3, 0x12, 0x17, 0x1f, 0x6e, 0xed, 0x13, 0xd5, 0x0c, 0xec, 0xf6, 0x89,
+	0x84, 0xb9, 0x32, 0xd7, 0x6e, 0x73, 0xec, 0xfb, 0x6d, 0xae, 0x6d, 0x45, 0xa4, 0x06, 0x71, 0xb3,
+	0x17, 0xa1, 0x30, 0x2d, 0xcd, 0x60, 0xa9, 0x88, 0xef, 0x9b, 0x86, 0x66, 0x30, 0x66, 0x2a, 0xdf,
+	0x22, 0x5b, 0x65, 0xa6, 0x4a, 0x40, 0x62, 0xb7, 0xc9, 0x5a, 0xc2, 0x6e, 0x2b, 0x73, 0xa5, 0x71,
+	0x9b, 0x86, 0xb9, 0x19, 0x22, 0xa3, 0x6e, 0xe5, 0xf6, 0xe6, 0x08, 0xb7, 0x3d, 0x37, 0x83, 0xe7,
+	0x1c, 0x41, 0x2b, 0xf2, 0x18, 0x21, 0x65, 0xc9, 0xf0, 0x0c, 0x76, 0x7f, 0x6f, 0x01, 0x9b, 0x4d,
+	0x22, 0x4c, 0x93, 0xb6, 0xee, 0xa0, 0x6d, 0x0c, 0x6a, 0xdb, 0x14, 0x57, 0xd0, 0x85, 0x69, 0x72,
+	0x17, 0x20, 0x7b, 0xf3, 0xe8, 0xd6, 0x68, 0x60, 0xb2, 0xc0, 0xd3, 0x14, 0x4c, 0x7e, 0xe3, 0x5a,
+	0xbc, 0x1f, 0xe5, 0x29, 0xa2, 0x20, 0x37, 0x06, 0xc8, 0x5b, 0x35, 0x5b, 0x83, 0x65, 0x2a, 0x43,
+	0x2f, 0xec, 0x45, 0xc3, 0x27, 0x5e, 0xea, 0x29, 0x2d, 0xa7, 0xd1, 0xe8, 0xbb, 0x6c, 0x47, 0xa5,
+	0x76, 0x8e, 0x40, 0xaa, 0x14, 0x90, 0x2b, 0x90, 0xf2, 0x39, 0xc2, 0x9d, 0xc0, 0xcd, 0x99, 0xe3,
+	0xe1, 0xff, 0xb7, 0x75, 0xdd, 0xdc, 0xba, 0xab, 0x87, 0x63, 0xde, 0xe9, 0x40, 0xc8, 0xfb, 0xa5,
+	0x03, 0xb5, 0x28, 0x3e, 0x3b, 0xcc, 0xdf, 0x3c, 0x1a, 0x9c, 0x1d, 0x3e, 0xd9, 0xf3, 0x86, 0x4f,
+	0x5f, 0xc0, 0x72, 0x71, 0xc5, 0x84, 0x7d, 0xb3, 0xd8, 0x22, 0x0b, 0x13, 0x3d, 0xcd, 0xa5, 0xfa,
+	0xa4, 0xeb, 0x43, 0x1d, 0xd7, 0x09, 0x4e, 0xc7, 0xa9, 0x4c, 0xed, 0xc0, 0xe8, 0x65, 0x04, 0x64,
+	0x4f, 0x32, 0x7b, 0x6a, 0x7c, 0xa7, 0x66, 0x38, 0x78, 0x99, 0x22, 0x00, 0x83, 0xdc, 0xa7, 0xe9,
+	0x4c, 0x45, 0xa2, 0x15, 0xe4, 0x6e, 0x43, 0x63, 0x3f, 0x4c, 0xf3, 0x7d, 0x3e, 0x32, 0x66, 0x64,
+	0x59, 0x0b, 0xcf, 0xc8, 0x34, 0x34, 0x73, 0x1f, 0x02, 0x3b, 0x0a, 0xce, 0x42, 0xd1, 0xbb, 0xbe,
+	0xe8, 0x21, 0x2c, 0x1f, 0xa5, 0x71, 0x10, 0x9e, 0xcd, 0xca, 0xd9, 0x97, 0xc8, 0x49, 0xfd, 0xbd,
+	0xa4, 0x9f, 0xdd, 0x11, 0x15, 0xe4, 0xee, 0x40, 0xf3, 0x89, 0x97, 0x8a, 0x6b, 0x6a, 0xb1, 0x03,
+	0xcd, 0xc7, 0x51, 0x34, 0xb8, 0xa6, 0xd4, 0x33, 0x68, 0xee, 0x85, 0xe3, 0xe1, 0xf5, 0xa4, 0x50,
+	0x73, 0x79, 0x6f, 0xd5, 0x49, 0xa2, 0x20, 0xf7, 0x39, 0xb4, 0x1e, 0x4f, 0x52, 0x91, 0x5c, 0x7f,
+	0x39, 0xe5, 0x08, 0xbb, 0xe0, 0x88, 0x5f, 0x97, 0xa0, 0x89, 0xd9, 0x93, 0x2f, 0xf7, 0x19, 0x40,
+	0x92, 0xb9, 0x5a, 0x2d, 0xaa, 0xc6, 0xdb, 0x53, 0x21, 0x90, 0xff, 0x07, 0x64, 0x28, 0xb6, 0x0e,
+	0xb5, 0x80, 0x02, 0xeb, 0xd8, 0xe6, 0xf4, 0xd1, 0x8c, 0x76, 0x67, 0x81, 0x6b, 0x26, 0xb6, 0x09,
+	0x8b, 0x3d, 0x15, 0x83, 0xe2, 0xd0, 0xa3, 0x10, 0x99, 0xce, 0x02, 0xcf, 0xd8, 0xd8, 0xf7, 0xa1,
+	0x99, 0x98, 0x19, 0xe4, 0x94, 0x0b, 0x67, 0xeb, 0x4c, 0x72, 0x75, 0x16, 0x78, 0x51, 0x00, 0x37,
+	0x3d, 0x55, 0x21, 0x74, 0x2a, 0xe6, 0xa6, 0x85, 0xc0, 0xe2, 0xa6, 0x9a, 0x0d, 0x45, 0x84, 0x8a,
+	0x9f, 0x53, 0x35, 0x45, 0x0a, 0x51, 0x45, 0x11, 0xcd, 0xc6, 0x76, 0xa0, 0x7e, 0xaa, 0x83, 0x54,
+	0x9c, 0x5a, 0x16, 0x63, 0x87, 0x7f, 0x55, 0x65, 0x8c, 0xd9, 0x0d, 0xe0, 0x2f, 0x16, 0xc5, 0x24,
+	0x9f, 0xf4, 0xdc, 0x81, 0x6a, 0x48, 0x13, 0x56, 0xaa, 0x63, 0x05, 0x61, 0xdf, 0x0e, 0xf3, 0xf9,
+	0x2a, 0x8d, 0xe2, 0x0c, 0x0c, 0xb6, 0xa2, 0x50, 0x4d, 0x57, 0x4b, 0x92, 0xa8, 0x41, 0xb6, 0x0d,
+	0xe0, 0x69, 0x2d, 0xa6, 0x46, 0x47, 0x85, 0x74, 0xe0, 0x06, 0x5b, 0xd6, 0x37, 0x2a, 0x46, 0xdf,
+	0x30, 0x9e, 0x6b, 0x34, 0xed, 0xd3, 0xe0, 0xd6, 0xdf, 0x6c, 0xa8, 0xef, 0x86, 0x51, 0x48, 0x2d,
+	0x6c, 0x07, 0x96, 0x9f, 0x8a, 0xb4, 0x30, 0x46, 0x33, 0xff, 0x33, 0x5c, 0x61, 0xd9, 0xa8, 0x25,
+	0x63, 0x70, 0x17, 0xd8, 0xe7, 0xc0, 0x9e, 0x8a, 0x74, 0xba, 0x1d, 0x16, 0x04, 0x6f, 0xcf, 0x6b,
+	0x86, 0x28, 0xfb, 0x00, 0x2a, 0xf4, 0x9f, 0x61, 0x53, 0x8f, 0xc4, 0xe4, 0x93, 0x6f, 0xa5, 0xa5,
+	0x41, 0x1a, 0x7e, 0xba, 0x0b, 0x6b, 0xd6, 0x27, 0x16, 0xfb, 0x18, 0xaa, 0xea, 0x01, 0x7a, 0x25,
+	0xf6, 0x07, 0xf2, 0x8d, 0x72, 0x7e, 0x45, 0xee, 0x63, 0xb8, 0x4d, 0x66, 0x4c, 0x3f, 0x9a, 0xbf,
+	0x76, 0xc1, 0x1b, 0x59, 0xbf, 0xcd, 0x57, 0x9c, 0x8b, 0x18, 0xdc, 0x85, 0xc7, 0x6b, 0x3f, 0xb9,
+	0x7f, 0x16, 0xa4, 0xfd, 0xf1, 0xe9, 0xba, 0x1f, 0x0d, 0x37, 0xc4, 0x70, 0x38, 0x79, 0xf7, 0x7a,
+	0x24, 0x7f, 0x37, 0x8a, 0xff, 0xe3, 0x9e, 0x56, 0xe5, 0xbf, 0xb8, 0xdb, 0xff, 0x19, 0x00, 0x6c,
+	0xa4, 0x22, 0xfc, 0xe0, 0x1d, 0x00, 0x00,
+}
+
 // Reference imports to suppress errors if they are not otherwise used.
 var _ context.Context
 var _ grpc.ClientConn
@@ -1237,15 +3435,16 @@ var _ grpc.ClientConn
 // is compatible with the grpc package it is being compiled against.
 const _ = grpc.SupportPackageIsVersion4
 
-// Client API for AnonCreds service
-
+// AnonCredsClient is the client API for AnonCreds service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type AnonCredsClient interface {
 	GetPublicParams(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PublicParams, error)
-	// TODO merge with GetPublicParams
 	GetAcceptableCreds(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AcceptableCreds, error)
 	Issue(ctx context.Context, opts ...grpc.CallOption) (AnonCreds_IssueClient, error)
-	Update(ctx context.Context, in *CredUpdateRequest, opts ...grpc.CallOption) (*IssuedCred, error)
+	Update(ctx context.Context, opts ...grpc.CallOption) (AnonCreds_UpdateClient, error)
 	Prove(ctx context.Context, opts ...grpc.CallOption) (AnonCreds_ProveClient, error)
+	GetAccumulatorUpdates(ctx context.Context, in *AccumulatorUpdatesRequest, opts ...grpc.CallOption) (*AccumulatorUpdates, error)
 }
 
 type anonCredsClient struct {
@@ -1258,7 +3457,7 @@ func NewAnonCredsClient(cc *grpc.ClientConn) AnonCredsClient {
 
 func (c *anonCredsClient) GetPublicParams(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PublicParams, error) {
 	out := new(PublicParams)
-	err := grpc.Invoke(ctx, "/clpb.AnonCreds/GetPublicParams", in, out, c.cc, opts...)
+	err := c.cc.Invoke(ctx, "/clpb.AnonCreds/GetPublicParams", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1267,7 +3466,7 @@ func (c *anonCredsClient) GetPublicParams(ctx context.Context, in *Empty, opts .
 
 func (c *anonCredsClient) GetAcceptableCreds(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*AcceptableCreds, error) {
 	out := new(AcceptableCreds)
-	err := grpc.Invoke(ctx, "/clpb.AnonCreds/GetAcceptableCreds", in, out, c.cc, opts...)
+	err := c.cc.Invoke(ctx, "/clpb.AnonCreds/GetAcceptableCreds", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1275,7 +3474,7 @@ func (c *anonCredsClient) GetAcceptableCreds(ctx context.Context, in *Empty, opt
 }
 
 func (c *anonCredsClient) Issue(ctx context.Context, opts ...grpc.CallOption) (AnonCreds_IssueClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_AnonCreds_serviceDesc.Streams[0], c.cc, "/clpb.AnonCreds/Issue", opts...)
+	stream, err := c.cc.NewStream(ctx, &_AnonCreds_serviceDesc.Streams[0], "/clpb.AnonCreds/Issue", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1305,17 +3504,39 @@ func (x *anonCredsIssueClient) Recv() (*Response, error) {
 	return m, nil
 }
 
-func (c *anonCredsClient) Update(ctx context.Context, in *CredUpdateRequest, opts ...grpc.CallOption) (*IssuedCred, error) {
-	out := new(IssuedCred)
-	err := grpc.Invoke(ctx, "/clpb.AnonCreds/Update", in, out, c.cc, opts...)
+func (c *anonCredsClient) Update(ctx context.Context, opts ...grpc.CallOption) (AnonCreds_UpdateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AnonCreds_serviceDesc.Streams[1], "/clpb.AnonCreds/Update", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	x := &anonCredsUpdateClient{stream}
+	return x, nil
+}
+
+type AnonCreds_UpdateClient interface {
+	Send(*Request) error
+	Recv() (*Response, error)
+	grpc.ClientStream
+}
+
+type anonCredsUpdateClient struct {
+	grpc.ClientStream
+}
+
+func (x *anonCredsUpdateClient) Send(m *Request) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *anonCredsUpdateClient) Recv() (*Response, error) {
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func (c *anonCredsClient) Prove(ctx context.Context, opts ...grpc.CallOption) (AnonCreds_ProveClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_AnonCreds_serviceDesc.Streams[1], c.cc, "/clpb.AnonCreds/Prove", opts...)
+	stream, err := c.cc.NewStream(ctx, &_AnonCreds_serviceDesc.Streams[2], "/clpb.AnonCreds/Prove", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1345,15 +3566,23 @@ func (x *anonCredsProveClient) Recv() (*Response, error) {
 	return m, nil
 }
 
-// Server API for AnonCreds service
+func (c *anonCredsClient) GetAccumulatorUpdates(ctx context.Context, in *AccumulatorUpdatesRequest, opts ...grpc.CallOption) (*AccumulatorUpdates, error) {
+	out := new(AccumulatorUpdates)
+	err := c.cc.Invoke(ctx, "/clpb.AnonCreds/GetAccumulatorUpdates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
+// AnonCredsServer is the server API for AnonCreds service.
 type AnonCredsServer interface {
 	GetPublicParams(context.Context, *Empty) (*PublicParams, error)
-	// TODO merge with GetPublicParams
 	GetAcceptableCreds(context.Context, *Empty) (*AcceptableCreds, error)
 	Issue(AnonCreds_IssueServer) error
-	Update(context.Context, *CredUpdateRequest) (*IssuedCred, error)
+	Update(AnonCreds_UpdateServer) error
 	Prove(AnonCreds_ProveServer) error
+	GetAccumulatorUpdates(context.Context, *AccumulatorUpdatesRequest) (*AccumulatorUpdates, error)
 }
 
 func RegisterAnonCredsServer(s *grpc.Server, srv AnonCredsServer) {
@@ -1422,22 +3651,30 @@ func (x *anonCredsIssueServer) Recv() (*Request, error) {
 	return m, nil
 }
 
-func _AnonCreds_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CredUpdateRequest)
-	if err := dec(in); err != nil {
+func _AnonCreds_Update_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AnonCredsServer).Update(&anonCredsUpdateServer{stream})
+}
+
+type AnonCreds_UpdateServer interface {
+	Send(*Response) error
+	Recv() (*Request, error)
+	grpc.ServerStream
+}
+
+type anonCredsUpdateServer struct {
+	grpc.ServerStream
+}
+
+func (x *anonCredsUpdateServer) Send(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *anonCredsUpdateServer) Recv() (*Request, error) {
+	m := new(Request)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(AnonCredsServer).Update(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/clpb.AnonCreds/Update",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AnonCredsServer).Update(ctx, req.(*CredUpdateRequest))
-	}
-	return interceptor(ctx, in, info, handler)
+	return m, nil
 }
 
 func _AnonCreds_Prove_Handler(srv interface{}, stream grpc.ServerStream) error {
@@ -1466,6 +3703,24 @@ func (x *anonCredsProveServer) Recv() (*Request, error) {
 	return m, nil
 }
 
+func _AnonCreds_GetAccumulatorUpdates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccumulatorUpdatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnonCredsServer).GetAccumulatorUpdates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clpb.AnonCreds/GetAccumulatorUpdates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnonCredsServer).GetAccumulatorUpdates(ctx, req.(*AccumulatorUpdatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _AnonCreds_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "clpb.AnonCreds",
 	HandlerType: (*AnonCredsServer)(nil),
@@ -1479,8 +3734,8 @@ var _AnonCreds_serviceDesc = grpc.ServiceDesc{
 			Handler:    _AnonCreds_GetAcceptableCreds_Handler,
 		},
 		{
-			MethodName: "Update",
-			Handler:    _AnonCreds_Update_Handler,
+			MethodName: "GetAccumulatorUpdates",
+			Handler:    _AnonCreds_GetAccumulatorUpdates_Handler,
 		},
 	},
 	Streams: []grpc.StreamDesc{
@@ -1490,6 +3745,12 @@ var _AnonCreds_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Update",
+			Handler:       _AnonCreds_Update_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "Prove",
 			Handler:       _AnonCreds_Prove_Handler,
@@ -1499,89 +3760,3 @@ var _AnonCreds_serviceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "anauth/cl/clpb/cl.proto",
 }
-
-func init() { proto.RegisterFile("anauth/cl/clpb/cl.proto", fileDescriptor0) }
-
-var fileDescriptor0 = []byte{
-	// 1267 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb4, 0x57, 0x5f, 0x4f, 0x1b, 0x47,
-	0x10, 0xe7, 0x6c, 0x9f, 0x8d, 0x07, 0x63, 0xc8, 0x36, 0x09, 0x16, 0xaa, 0xaa, 0xe8, 0x12, 0x45,
-	0xa8, 0x4a, 0xa1, 0x40, 0x9a, 0xfe, 0x51, 0x5b, 0xc9, 0x50, 0x37, 0x20, 0x22, 0x17, 0x2d, 0x82,
-	0x87, 0x4a, 0x7d, 0x38, 0xdb, 0x1b, 0xfb, 0x14, 0xdf, 0x1f, 0xee, 0xd6, 0x49, 0xcd, 0x53, 0x1f,
-	0xfb, 0xd8, 0x87, 0xb6, 0xdf, 0xa5, 0x1f, 0xa6, 0xdf, 0xa2, 0x1f, 0xa0, 0x3b, 0xb3, 0x7b, 0xff,
-	0x0c, 0xa1, 0xbc, 0x54, 0x8a, 0xc2, 0xcd, 0xcc, 0x6f, 0xfe, 0xee, 0xec, 0xec, 0x18, 0x36, 0xdc,
-	0xc0, 0x9d, 0xc9, 0xc9, 0xce, 0x70, 0xaa, 0xfe, 0x45, 0x03, 0xf5, 0xdf, 0x76, 0x14, 0x87, 0x32,
-	0x64, 0x35, 0x24, 0x9d, 0xbf, 0x2c, 0x68, 0x70, 0x71, 0x39, 0x13, 0x89, 0x64, 0x8f, 0xc1, 0x16,
-	0x7e, 0x24, 0xe7, 0x1d, 0xeb, 0x91, 0xb5, 0xb5, 0xb2, 0xb7, 0xb2, 0x8d, 0x88, 0xed, 0x1e, 0xb2,
-	0x8e, 0x96, 0xb8, 0x96, 0xb1, 0x0e, 0xd4, 0x63, 0x31, 0x3e, 0x11, 0xf3, 0x4e, 0x45, 0xa1, 0x9a,
-	0x4a, 0x60, 0x68, 0xf6, 0x02, 0x9a, 0xc3, 0x58, 0x8c, 0x8e, 0x93, 0x64, 0x26, 0x3a, 0x55, 0x32,
-	0xf1, 0x50, 0x9b, 0x38, 0x4c, 0xd9, 0xc6, 0x93, 0x52, 0xca, 0xa1, 0x6c, 0x47, 0xeb, 0x9d, 0xc6,
-	0xe1, 0x5b, 0xd1, 0xa9, 0x91, 0xde, 0x5a, 0xae, 0xa7, 0xd8, 0xe1, 0xeb, 0x54, 0x81, 0x30, 0x07,
-	0x75, 0xa8, 0xc9, 0x79, 0x24, 0x9c, 0x5f, 0x2c, 0x58, 0xe6, 0x22, 0x89, 0xc2, 0x20, 0x11, 0xec,
-	0x21, 0xd8, 0x41, 0x18, 0x0c, 0x05, 0x05, 0xdf, 0xc2, 0x78, 0x89, 0x64, 0x7b, 0x00, 0x1e, 0xba,
-	0x19, 0xa1, 0x31, 0x8a, 0x79, 0x65, 0x6f, 0x5d, 0x9b, 0x3f, 0xce, 0xf8, 0x0a, 0x5e, 0x40, 0xb1,
-	0x47, 0x00, 0x89, 0x48, 0x12, 0x2f, 0x0c, 0x30, 0xcf, 0xaa, 0xc9, 0xb3, 0xc0, 0xcb, 0x42, 0x68,
-	0x80, 0x4d, 0xf5, 0x71, 0xbe, 0x80, 0xd6, 0xd9, 0x70, 0x12, 0x84, 0x71, 0xfc, 0x32, 0x0e, 0x67,
-	0x11, 0x6b, 0x81, 0x15, 0x91, 0xb7, 0x16, 0xb7, 0x88, 0x1a, 0x93, 0x1d, 0x45, 0x8d, 0x91, 0xba,
-	0xa4, 0x44, 0x15, 0x75, 0xe9, 0x5c, 0x40, 0xfb, 0x54, 0x8c, 0x44, 0x9c, 0x88, 0xe0, 0xd4, 0x8d,
-	0x5d, 0x3f, 0x51, 0x85, 0x6c, 0x25, 0x05, 0x5b, 0xe6, 0x38, 0x98, 0x0e, 0xba, 0xe8, 0x85, 0x97,
-	0x70, 0x68, 0x77, 0x92, 0xfa, 0x9c, 0x38, 0xff, 0x58, 0x50, 0x3f, 0x9d, 0x0d, 0xf0, 0x64, 0x94,
-	0x20, 0xd0, 0x75, 0xe1, 0x56, 0x80, 0x54, 0x92, 0xc2, 0x12, 0xa4, 0xae, 0xd2, 0xd0, 0xae, 0xd4,
-	0xe9, 0x36, 0xe2, 0xe4, 0x24, 0x08, 0xdf, 0x05, 0x2a, 0xc0, 0xaa, 0xe2, 0xa5, 0xa4, 0xaa, 0xc9,
-	0x4a, 0x9c, 0x1c, 0x86, 0xbe, 0xef, 0x49, 0xa9, 0x0a, 0x69, 0x93, 0xb4, 0xc8, 0x62, 0x9b, 0xb0,
-	0x1c, 0x27, 0x47, 0xde, 0x68, 0x24, 0x82, 0x4e, 0x9d, 0xc4, 0x19, 0xcd, 0xbe, 0x86, 0x76, 0x54,
-	0x4a, 0xb2, 0xd3, 0xa0, 0xa4, 0xee, 0xeb, 0xa4, 0xca, 0x05, 0xe0, 0x0b, 0x58, 0xd6, 0x86, 0x4a,
-	0xb0, 0xdb, 0x59, 0xa6, 0x20, 0xd5, 0x97, 0x2e, 0x67, 0xb3, 0x50, 0xce, 0x49, 0x07, 0xd2, 0xb4,
-	0x7f, 0xaf, 0xa8, 0xb4, 0xb5, 0xda, 0x87, 0xd0, 0xe4, 0x93, 0xf0, 0xc0, 0x93, 0xaf, 0x84, 0x4e,
-	0xdf, 0xe6, 0x39, 0x03, 0x53, 0xed, 0xab, 0xbf, 0x63, 0xa9, 0x6b, 0x66, 0xf3, 0x94, 0x64, 0x1f,
-	0x01, 0x74, 0xa5, 0x8c, 0x8d, 0x62, 0x9d, 0x84, 0x05, 0x0e, 0xca, 0x8f, 0xdc, 0x64, 0x62, 0xe4,
-	0x0d, 0x2d, 0xcf, 0x39, 0x58, 0x88, 0x33, 0x31, 0xa4, 0x20, 0x28, 0x68, 0x9b, 0x67, 0x34, 0x7a,
-	0xed, 0x19, 0xc5, 0xa6, 0xf6, 0xda, 0xcb, 0xb5, 0x7a, 0xbb, 0x46, 0x04, 0x5a, 0x2b, 0xa5, 0x51,
-	0xeb, 0xc2, 0x88, 0x56, 0xb4, 0x96, 0x21, 0xd9, 0x53, 0x68, 0x1f, 0x4e, 0xdc, 0xe9, 0x54, 0x45,
-	0x2e, 0xce, 0x22, 0x57, 0xf5, 0x7f, 0x8b, 0x00, 0x0b, 0x5c, 0xe7, 0x0f, 0x0b, 0x5a, 0xaa, 0x1b,
-	0xa6, 0xde, 0xd0, 0x14, 0xe7, 0x09, 0xd4, 0x23, 0xea, 0x0e, 0xd3, 0x5e, 0x2d, 0x73, 0x12, 0xc4,
-	0xe3, 0x46, 0x46, 0x28, 0x7d, 0x5e, 0x95, 0x12, 0x4a, 0x9f, 0x93, 0x91, 0xb1, 0x2f, 0x61, 0x15,
-	0x6f, 0xe7, 0x99, 0x8c, 0x67, 0x43, 0x39, 0x8b, 0xd3, 0xdb, 0xff, 0x41, 0x7e, 0x8b, 0x33, 0x11,
-	0x2f, 0x23, 0x9d, 0xbf, 0x2b, 0xb0, 0xbe, 0x38, 0x1e, 0xd8, 0x3a, 0x54, 0xfb, 0x73, 0xdf, 0x74,
-	0x2c, 0x7e, 0x62, 0xc9, 0xa9, 0x0d, 0xf1, 0x14, 0x30, 0x16, 0xec, 0xae, 0x02, 0x87, 0x6d, 0x03,
-	0xd3, 0x8d, 0xe8, 0x8b, 0x40, 0x26, 0x3f, 0xbc, 0xd6, 0xb8, 0x2a, 0xe1, 0x6e, 0x90, 0xb0, 0x67,
-	0xb0, 0xac, 0xcc, 0xd2, 0x6c, 0x31, 0x23, 0xc7, 0xcc, 0x84, 0xef, 0x3d, 0x57, 0x9e, 0x4d, 0x5c,
-	0xdf, 0x8b, 0x79, 0x86, 0xc0, 0x0e, 0x3b, 0x57, 0x1d, 0x4f, 0x1d, 0x76, 0xae, 0xe6, 0x55, 0xfd,
-	0x5c, 0x6b, 0xd6, 0x49, 0x73, 0x63, 0x51, 0xb3, 0x3b, 0x4d, 0xc2, 0xbe, 0x18, 0x73, 0x03, 0x63,
-	0xaf, 0xa0, 0x73, 0x3d, 0x04, 0x12, 0xe1, 0x35, 0xa8, 0xde, 0xe8, 0xfc, 0xbd, 0x1a, 0xec, 0x3e,
-	0xd8, 0x7d, 0x1a, 0x74, 0xfa, 0x3e, 0x68, 0x42, 0x8d, 0xbf, 0x3a, 0xd7, 0x63, 0x19, 0xdb, 0xaa,
-	0xc9, 0x0d, 0xe5, 0x3c, 0x87, 0x1a, 0x8d, 0x34, 0x95, 0x42, 0x37, 0x1d, 0x01, 0x5d, 0xa4, 0x7a,
-	0xe9, 0x08, 0xe8, 0x61, 0xb9, 0x2f, 0x76, 0x77, 0xcd, 0x10, 0xc0, 0x4f, 0xe7, 0x27, 0x80, 0x7c,
-	0x38, 0xaa, 0xe2, 0xd7, 0xf0, 0xd0, 0x4c, 0xa3, 0x40, 0x7e, 0xaa, 0x9c, 0xf8, 0x58, 0x90, 0xae,
-	0x2e, 0x48, 0xe5, 0x3f, 0x0a, 0xa2, 0x61, 0x8e, 0x0b, 0xf7, 0x50, 0xfd, 0x3c, 0x1a, 0xb9, 0xf2,
-	0x96, 0x43, 0xcf, 0x32, 0xad, 0x14, 0x33, 0x7d, 0x02, 0xab, 0x7d, 0xf1, 0xae, 0xd0, 0x0d, 0xfa,
-	0x94, 0xcb, 0x4c, 0xe7, 0xd7, 0x0a, 0x34, 0xb3, 0xe7, 0x63, 0x21, 0xfb, 0x4f, 0xc0, 0xbe, 0x53,
-	0xb8, 0x1a, 0xb5, 0xd0, 0x7b, 0xd5, 0x3b, 0xf6, 0x5e, 0xed, 0xbd, 0xbd, 0xa7, 0xf0, 0x5c, 0xbc,
-	0x15, 0xee, 0x54, 0x8c, 0x0a, 0x76, 0x71, 0xa0, 0xda, 0xfc, 0x06, 0x09, 0xfb, 0x16, 0x36, 0x53,
-	0xee, 0x0d, 0x7e, 0xea, 0xa4, 0x77, 0x0b, 0xc2, 0x89, 0x01, 0xf2, 0xdc, 0xd8, 0x16, 0xac, 0x51,
-	0x5a, 0xdc, 0x0d, 0x46, 0xa1, 0xff, 0x9d, 0x2b, 0x5d, 0x53, 0x98, 0x45, 0x36, 0x8e, 0xcf, 0x6c,
-	0x88, 0x98, 0x23, 0xc8, 0x19, 0x28, 0x25, 0x05, 0xb2, 0xa0, 0x8b, 0x92, 0x33, 0x9c, 0x39, 0xdc,
-	0xbb, 0x56, 0xcf, 0xff, 0xcf, 0x75, 0xb3, 0xe8, 0xfa, 0x14, 0xda, 0xdd, 0xe1, 0x50, 0x44, 0xd2,
-	0x1d, 0x4c, 0x05, 0xf5, 0xaf, 0x9a, 0x9e, 0x61, 0x3c, 0xee, 0xbb, 0xbe, 0x5e, 0x0e, 0x9a, 0x3c,
-	0x25, 0xb1, 0x97, 0x62, 0x53, 0xb8, 0x7c, 0xb2, 0x34, 0x79, 0x99, 0xe9, 0x7c, 0x03, 0x6b, 0x65,
-	0x8b, 0x09, 0xfb, 0x18, 0x6c, 0x6c, 0xfd, 0x44, 0x19, 0xac, 0xe6, 0xcf, 0x58, 0x19, 0xc5, 0x35,
-	0xc4, 0x39, 0x81, 0x26, 0xda, 0xf1, 0x06, 0x33, 0x29, 0xb0, 0xa7, 0xbd, 0x60, 0x24, 0x7e, 0x36,
-	0xef, 0x91, 0x26, 0x18, 0x83, 0x5a, 0x80, 0xe1, 0xd1, 0x4a, 0xc5, 0xe9, 0x1b, 0x91, 0x6f, 0xcc,
-	0x43, 0x6c, 0x6d, 0x2d, 0x73, 0x4d, 0x38, 0xfb, 0xd0, 0x3a, 0x0e, 0x64, 0x6e, 0xef, 0x31, 0xd4,
-	0x5c, 0x45, 0x98, 0xbb, 0x69, 0xf6, 0xa6, 0x4c, 0xcc, 0x49, 0xe8, 0xbc, 0x80, 0x35, 0x35, 0x71,
-	0xbd, 0x60, 0x7c, 0x5d, 0xaf, 0x72, 0x9b, 0x9e, 0x5a, 0xb0, 0x56, 0x31, 0x93, 0x5c, 0xed, 0x73,
-	0xb5, 0x19, 0x65, 0x96, 0x8c, 0xd3, 0x07, 0x66, 0x31, 0x29, 0x7b, 0xa0, 0x85, 0x29, 0x63, 0xa9,
-	0xa6, 0x6f, 0x78, 0x3a, 0x6e, 0xe3, 0xd2, 0xac, 0x33, 0xc5, 0x64, 0x94, 0x4a, 0x0a, 0xca, 0x16,
-	0xac, 0x3f, 0x4d, 0x08, 0xd9, 0x8b, 0x81, 0x93, 0x2e, 0xd0, 0x1b, 0x8a, 0x2e, 0xa1, 0xa1, 0xf0,
-	0x9a, 0x06, 0xf9, 0x7e, 0xa2, 0x9f, 0xf4, 0x02, 0x07, 0xbb, 0x20, 0x30, 0xdb, 0x49, 0x55, 0xbf,
-	0xa1, 0x86, 0x64, 0xfb, 0x00, 0x6e, 0x1a, 0x83, 0xbe, 0xb8, 0xa5, 0xb7, 0x2b, 0xaf, 0x4a, 0x01,
-	0xb6, 0xf7, 0x9b, 0x1a, 0x30, 0x5d, 0xb5, 0x62, 0xea, 0x7e, 0x78, 0x0e, 0x6b, 0x2f, 0x85, 0x2c,
-	0x3d, 0xb0, 0xc5, 0xf5, 0x79, 0x93, 0x65, 0xaf, 0x6b, 0x06, 0x70, 0x96, 0xd8, 0x57, 0xc0, 0x94,
-	0xd6, 0x62, 0x6f, 0x95, 0x14, 0x1f, 0xdc, 0xd4, 0x59, 0xa8, 0xfb, 0x0c, 0x6c, 0xbd, 0x3e, 0xaf,
-	0x6a, 0x84, 0x19, 0xa3, 0x9b, 0xed, 0x94, 0xd4, 0x7b, 0xb1, 0xb3, 0xb4, 0x65, 0x7d, 0x6a, 0xb1,
-	0xcf, 0xd4, 0x9b, 0x45, 0xd3, 0x96, 0x6d, 0xe4, 0x89, 0x95, 0xe6, 0xef, 0xe6, 0xb5, 0xa5, 0x58,
-	0x3b, 0xa1, 0x95, 0xfb, 0x4e, 0x4e, 0x0e, 0xb6, 0x7e, 0x7c, 0x3a, 0xf6, 0xe4, 0x64, 0x36, 0xd8,
-	0x1e, 0x86, 0xfe, 0x8e, 0xf0, 0xfd, 0xf9, 0xd5, 0x9b, 0x88, 0xfe, 0xee, 0x94, 0x7f, 0x84, 0x0c,
-	0xea, 0xf4, 0x13, 0x64, 0xff, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x74, 0xcd, 0x93, 0x42, 0x9d,
-	0x0c, 0x00, 0x00,
-}
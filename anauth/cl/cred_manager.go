@@ -45,6 +45,7 @@ type CredManager struct {
 	RawCred            *RawCred
 	nymCommitter       *pedersen.Committer // nym is actually a commitment to masterSecret
 	Nym                *big.Int
+	nymRandomness      *big.Int // needed to prove the knowledge of nym opening
 	masterSecret       *big.Int
 	Attrs              *Attrs
 	CommitmentsOfAttrs []*big.Int // commitments of committedAttrs
@@ -54,6 +55,9 @@ type CredManager struct {
 	attrsCommitters           []*df.Committer     // committers for committedAttrs
 	commitmentsOfAttrsProvers []*df.OpeningProver // for proving that you know how to open CommitmentsOfAttrs
 	CredReqNonce              *big.Int
+	// commitmentsUpdated is set when Committed attributes change in
+	// Update, and the new commitments have to be sent to the organization
+	commitmentsUpdated bool
 }
 
 type CredRequest struct {
@@ -91,21 +95,56 @@ func NewCredManager(params *clpb.Params, pubKey *PubKey,
 
 	known := rawCred.GetKnownVals()
 	committed := rawCred.GetCommittedVals()
-	hidden := []*big.Int{} // currently not used
+	hidden := rawCred.GetHiddenVals()
+	if len(hidden) != len(pubKey.RsHidden) {
+		return nil, fmt.Errorf("expected %d hidden attributes, got %d",
+			len(pubKey.RsHidden), len(hidden))
+	}
 
-	attrs := NewAttrs(known, committed, hidden)
-	if !checkBitLen(attrs.join(), int(params.AttrBitLen)) {
-		return nil, fmt.Errorf("attributes length not ok")
+	if err := checkAttrValsBitLen(rawCred, params.AttrBitLen); err != nil {
+		return nil, err
 	}
 
-	attrsCommitters := make([]*df.Committer, len(attrs.Committed))
-	commitmentsOfAttrs := make([]*big.Int, len(attrs.Committed))
-	for i, attr := range attrs.Committed {
-		committer := df.NewCommitter(pubKey.N1, pubKey.G, pubKey.H,
-			pubKey.N1, int(params.SecParam))
+	credManager := CredManager{
+		Params:       params,
+		PubKey:       pubKey,
+		RawCred:      rawCred,
+		Attrs:        NewAttrs(known, committed, hidden),
+		masterSecret: masterSecret,
+	}
+	if err := credManager.commitAttrs(); err != nil {
+		return nil, err
+	}
+	credManager.generateNym()
+
+	return &credManager, nil
+}
+
+// checkAttrValsBitLen checks that the values of all attributes of
+// rawCred fit into bitLen bits.
+func checkAttrValsBitLen(rawCred *RawCred, bitLen int32) error {
+	for _, a := range rawCred.GetAttrs() {
+		if a.internalValue().BitLen() > int(bitLen) {
+			return fmt.Errorf("value of attribute %s does not fit"+
+				" into %d bits, long values need hash encoding", a.Name(),
+				bitLen)
+		}
+	}
+
+	return nil
+}
+
+// commitAttrs creates new commitments of the Committed attributes,
+// together with the provers of the knowledge of their openings.
+func (m *CredManager) commitAttrs() error {
+	attrsCommitters := make([]*df.Committer, len(m.Attrs.Committed))
+	commitmentsOfAttrs := make([]*big.Int, len(m.Attrs.Committed))
+	for i, attr := range m.Attrs.Committed {
+		committer := df.NewCommitter(m.PubKey.N1, m.PubKey.G, m.PubKey.H,
+			m.PubKey.N1, int(m.Params.SecParam))
 		com, err := committer.GetCommitMsg(attr)
 		if err != nil {
-			return nil, fmt.Errorf("error when creating Pedersen commitment: %s", err)
+			return fmt.Errorf("error when creating Pedersen commitment: %s", err)
 		}
 		commitmentsOfAttrs[i] = com
 		attrsCommitters[i] = committer
@@ -113,27 +152,20 @@ func NewCredManager(params *clpb.Params, pubKey *PubKey,
 	commitmentsOfAttrsProvers := make([]*df.OpeningProver, len(commitmentsOfAttrs))
 	for i, _ := range commitmentsOfAttrs {
 		prover := df.NewOpeningProver(attrsCommitters[i],
-			int(params.ChallengeSpace))
+			int(m.Params.ChallengeSpace))
 		commitmentsOfAttrsProvers[i] = prover
 	}
 
-	credManager := CredManager{
-		Params:                    params,
-		PubKey:                    pubKey,
-		RawCred:                   rawCred,
-		Attrs:                     attrs,
-		CommitmentsOfAttrs:        commitmentsOfAttrs,
-		attrsCommitters:           attrsCommitters,
-		commitmentsOfAttrsProvers: commitmentsOfAttrsProvers,
-		masterSecret:              masterSecret,
-	}
-	credManager.generateNym()
+	m.CommitmentsOfAttrs = commitmentsOfAttrs
+	m.attrsCommitters = attrsCommitters
+	m.commitmentsOfAttrsProvers = commitmentsOfAttrsProvers
 
-	return &credManager, nil
+	return nil
 }
 
 type CredManagerCtx struct {
 	Nym                *big.Int
+	NymRandomness      *big.Int
 	V1                 *big.Int
 	CredReqNonce       *big.Int
 	PubKey             *PubKey
@@ -143,11 +175,17 @@ type CredManagerCtx struct {
 
 func RestoreCredManager(ctx *CredManagerCtx, secret *big.Int,
 	rc *RawCred) (*CredManager, error) {
-	// nymCommitter is needed only for IssueCred (when proving that nym can be opened), so we do not need it here
+	// nymCommitter is not needed here, since the knowledge of nym opening is proved with nymRandomness
 	// the same for attrsCommitters
 	known := rc.GetKnownVals()
 	committed := rc.GetCommittedVals()
-	hidden := []*big.Int{} // currently not used
+	// hidden attributes have to be the same as when the credential
+	// was issued, since the issuer only ever saw them inside U
+	hidden := rc.GetHiddenVals()
+	if len(hidden) != len(ctx.PubKey.RsHidden) {
+		return nil, fmt.Errorf("expected %d hidden attributes, got %d",
+			len(ctx.PubKey.RsHidden), len(hidden))
+	}
 	attrs := NewAttrs(known, committed, hidden)
 
 	return &CredManager{
@@ -155,6 +193,7 @@ func RestoreCredManager(ctx *CredManagerCtx, secret *big.Int,
 		PubKey:             ctx.PubKey,
 		CommitmentsOfAttrs: ctx.CommitmentsOfAttrs,
 		Nym:                ctx.Nym,
+		nymRandomness:      ctx.NymRandomness,
 		V1:                 ctx.V1,
 		CredReqNonce:       ctx.CredReqNonce,
 		Attrs:              attrs,
@@ -166,6 +205,7 @@ func RestoreCredManager(ctx *CredManagerCtx, secret *big.Int,
 func (m *CredManager) GetContext() *CredManagerCtx {
 	return &CredManagerCtx{
 		Nym:           m.Nym,
+		NymRandomness: m.nymRandomness,
 		V1: m.V1,
 		CredReqNonce: m.CredReqNonce,
 		PubKey: m.PubKey,
@@ -184,6 +224,7 @@ func (m *CredManager) generateNym() error {
 	}
 	m.Nym = nym
 	m.nymCommitter = committer
+	_, m.nymRandomness = committer.GetDecommitMsg()
 
 	return nil
 }
@@ -201,20 +242,29 @@ func (m *CredManager) GetCredRequest(nonceOrg *big.Int) (*CredRequest, error) {
 		return nil, err
 	}
 
-	challenge := m.getCredReqChallenge(U, m.Nym, nonceOrg)
-	commitmentsOfAttrsProofs := m.getCommitmentsOfAttrsProof(challenge)
-
 	b := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(m.Params.SecParam)), nil)
 	nonce := common.GetRandomInt(b)
 	m.CredReqNonce = nonce
 
+	nymProofRandomData := nymProver.GetProofRandomData()
 	uProofRandomData, err := m.getUProofRandomData(uProver)
 	if err != nil {
 		return nil, err
 	}
+	commitmentsProofRandomData := make([]*big.Int,
+		len(m.commitmentsOfAttrsProvers))
+	for i, prover := range m.commitmentsOfAttrsProvers {
+		commitmentsProofRandomData[i] = prover.GetProofRandomData()
+	}
+
+	challenge := credRequestChallenge(m.PubKey, m.Nym, nymProofRandomData, U,
+		uProofRandomData, m.Attrs.Known, m.CommitmentsOfAttrs,
+		commitmentsProofRandomData, nonceOrg, nonce)
+	commitmentsOfAttrsProofs := m.getCommitmentsOfAttrsProof(
+		commitmentsProofRandomData, challenge)
 
 	return NewCredRequest(m.Nym, m.Attrs.Known, m.CommitmentsOfAttrs,
-		schnorr.NewProof(nymProver.GetProofRandomData(), challenge,
+		schnorr.NewProof(nymProofRandomData, challenge,
 			nymProver.GetProofData(challenge)), U,
 		qr.NewRepresentationProof(uProofRandomData, challenge,
 			uProver.GetProofData(challenge)),
@@ -268,8 +318,8 @@ func (m *CredManager) Verify(cred *Cred, AProof *qr.RepresentationProof) (bool,
 	ver := qr.NewRepresentationVerifier(group, int(m.Params.SecParam))
 	ver.SetProofRandomData(AProof.ProofRandomData, []*big.Int{Q}, cred.A)
 	// check challenge
-	context := m.PubKey.GetContext()
-	c := common.Hash(context, Q, cred.A, AProof.ProofRandomData, m.CredReqNonce)
+	c := issuedCredChallenge(m.PubKey, Q, cred.A, AProof.ProofRandomData,
+		m.CredReqNonce)
 	if AProof.Challenge.Cmp(c) != 0 {
 		return false, fmt.Errorf("challenge is not correct")
 	}
@@ -279,10 +329,174 @@ func (m *CredManager) Verify(cred *Cred, AProof *qr.RepresentationProof) (bool,
 	return ver.Verify(AProof.ProofData), nil
 }
 
-// Update updates credential.
-func (m *CredManager) Update(c *RawCred) {
+// Update updates credential. When values of Committed attributes
+// change, they are committed to anew, and the new commitments are
+// sent with the next update request (see GetCredUpdateRequest).
+func (m *CredManager) Update(c *RawCred) error {
+	if err := checkAttrValsBitLen(c, m.Params.AttrBitLen); err != nil {
+		return err
+	}
+	committed := c.GetCommittedVals()
+	if len(committed) != len(m.Attrs.Committed) {
+		return fmt.Errorf("expected %d committed attributes, got %d",
+			len(m.Attrs.Committed), len(committed))
+	}
+
+	changed := false
+	for i, a := range committed {
+		if a.Cmp(m.Attrs.Committed[i]) != 0 {
+			changed = true
+		}
+	}
+
 	m.RawCred = c
-	m.Attrs.Known = m.RawCred.GetKnownVals()
+	m.Attrs = NewAttrs(c.GetKnownVals(), committed, m.Attrs.Hidden)
+	if !changed {
+		return nil
+	}
+	if err := m.commitAttrs(); err != nil {
+		return err
+	}
+	m.commitmentsUpdated = true
+
+	return nil
+}
+
+// GetCredUpdateRequest returns a request for updating the credential
+// with the current Known attributes of the credential manager (see
+// Update), and with new commitments of Committed attributes if they
+// changed. The request proves the knowledge of nym opening and of the
+// openings of the new commitments, and is bound to nonceOrg. The nonce of the request is stored in CredReqNonce,
+// as the updated credential is bound to it.
+func (m *CredManager) GetCredUpdateRequest(nonceOrg *big.Int) (*CredUpdateRequest,
+	error) {
+	nymProver, err := m.getNymProver()
+	if err != nil {
+		return nil, err
+	}
+
+	b := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(m.Params.SecParam)), nil)
+	nonce := common.GetRandomInt(b)
+
+	proofRandomData := nymProver.GetProofRandomData()
+	commitments, commitmentsProofRandomData := m.getUpdatedCommitments()
+	challenge := credUpdateChallenge(m.PubKey, m.Nym, proofRandomData,
+		nonceOrg, nonce, m.Attrs.Known, commitments,
+		commitmentsProofRandomData)
+	nymProof := schnorr.NewProof(proofRandomData, challenge,
+		nymProver.GetProofData(challenge))
+	m.CredReqNonce = nonce
+
+	ur := NewCredUpdateRequest(m.Nym, m.Attrs.Known, nymProof, nonce)
+	m.setUpdatedCommitments(ur, commitments, commitmentsProofRandomData,
+		challenge)
+
+	return ur, nil
+}
+
+// getUpdatedCommitments returns the commitments of Committed
+// attributes that changed in Update, together with the random data of
+// the proofs of their openings. It returns nil slices when the
+// Committed attributes did not change.
+func (m *CredManager) getUpdatedCommitments() ([]*big.Int, []*big.Int) {
+	if !m.commitmentsUpdated {
+		return nil, nil
+	}
+	proofRandomData := make([]*big.Int, len(m.commitmentsOfAttrsProvers))
+	for i, prover := range m.commitmentsOfAttrsProvers {
+		proofRandomData[i] = prover.GetProofRandomData()
+	}
+
+	return m.CommitmentsOfAttrs, proofRandomData
+}
+
+// setUpdatedCommitments sets the new commitments of attributes and
+// the proofs of their openings in the update request ur.
+func (m *CredManager) setUpdatedCommitments(ur *CredUpdateRequest,
+	commitments, proofRandomData []*big.Int, challenge *big.Int) {
+	if commitments == nil {
+		return
+	}
+	ur.NewCommitmentsOfAttrs = commitments
+	ur.CommitmentsOfAttrsProofs = m.getCommitmentsOfAttrsProof(
+		proofRandomData, challenge)
+	m.commitmentsUpdated = false
+}
+
+// GetCredReissueRequest returns a request for re-issuing the
+// credential with public key pk, which replaced the key that the
+// credential was issued with (see Org.ReissueCred). U is computed
+// anew with pk, and the credential manager switches to pk, so the
+// re-issued credential can be verified with Verify. Just like with
+// GetCredUpdateRequest, the request holds the current Known attributes
+// and the new commitments of Committed attributes, if they changed.
+//
+// The request also proves the representation of the U that the
+// credential was issued for with the previous key, with the same
+// responses for Hidden attributes as the proof of the new U, so that
+// Hidden attributes cannot change in the re-issued credential.
+func (m *CredManager) GetCredReissueRequest(nonceOrg *big.Int,
+	pk *PubKey) (*CredUpdateRequest, error) {
+	if len(pk.RsHidden) != len(m.Attrs.Hidden) {
+		return nil, fmt.Errorf("expected %d hidden attributes, got %d",
+			len(pk.RsHidden), len(m.Attrs.Hidden))
+	}
+	if m.V1 == nil {
+		return nil, fmt.Errorf("v1 is not set (generated in GetCredRequest)")
+	}
+	prevPubKey, prevV1 := m.PubKey, m.V1
+	prevU := computeUWithKey(prevPubKey, m.Attrs.Hidden, prevV1)
+
+	m.PubKey = pk
+	if m.commitmentsUpdated {
+		// commitments are made with the parameters of pk
+		if err := m.commitAttrs(); err != nil {
+			return nil, err
+		}
+	}
+	U, v1 := m.computeU()
+	m.V1 = v1
+	nymProver, err := m.getNymProver()
+	if err != nil {
+		return nil, err
+	}
+
+	b := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(m.Params.SecParam)), nil)
+	nonce := common.GetRandomInt(b)
+	m.CredReqNonce = nonce
+
+	// random values of Hidden attributes are shared by both proofs
+	hiddenTilde := make([]*big.Int, len(m.Attrs.Hidden))
+	for i := range hiddenTilde {
+		hiddenTilde[i] = getRandomBoundedInt(int(m.Params.AttrBitLen +
+			m.Params.SecParam + m.Params.HashBitLen + 1))
+	}
+	b_v1 := int(m.Params.NLength + 2*m.Params.SecParam + m.Params.HashBitLen)
+	v1Tilde := getRandomBoundedInt(b_v1)
+	prevV1Tilde := getRandomBoundedInt(b_v1)
+
+	nymProofRandomData := nymProver.GetProofRandomData()
+	uProofRandomData := computeUWithKey(pk, hiddenTilde, v1Tilde)
+	prevUProofRandomData := computeUWithKey(prevPubKey, hiddenTilde,
+		prevV1Tilde)
+	commitments, commitmentsProofRandomData := m.getUpdatedCommitments()
+	challenge := credReissueChallenge(pk, m.Nym, nymProofRandomData, U,
+		uProofRandomData, prevU, prevUProofRandomData, nonceOrg, nonce,
+		m.Attrs.Known, commitments, commitmentsProofRandomData)
+
+	ur := NewCredUpdateRequest(m.Nym, m.Attrs.Known,
+		schnorr.NewProof(nymProofRandomData, challenge,
+			nymProver.GetProofData(challenge)), nonce)
+	ur.U = U
+	ur.UProof = qr.NewRepresentationProof(uProofRandomData, challenge,
+		uProofData(m.Attrs.Hidden, v1, hiddenTilde, v1Tilde, challenge))
+	ur.PrevUProof = qr.NewRepresentationProof(prevUProofRandomData,
+		challenge, uProofData(m.Attrs.Hidden, prevV1, hiddenTilde,
+			prevV1Tilde, challenge))
+	m.setUpdatedCommitments(ur, commitments, commitmentsProofRandomData,
+		challenge)
+
+	return ur, nil
 }
 
 // FilterAttributes returns only attributes to be revealed to the verifier.
@@ -320,21 +534,77 @@ func (m *CredManager) randomize(cred *Cred) *Cred {
 	return NewCred(A, cred.E, v11)
 }
 
-func (m *CredManager) GetProofChallenge(credProofRandomData, nonceOrg *big.Int) *big.Int {
-	context := m.PubKey.GetContext()
-	l := []*big.Int{context, credProofRandomData, nonceOrg}
-	//l = append(l, ...) // TODO: add other values
-
-	return common.Hash(l...)
+// GetProofChallenge computes the challenge of the proof of possession
+// of a credential with transcript t.
+func (m *CredManager) GetProofChallenge(t *CredProofTranscript) *big.Int {
+	return t.Challenge(m.PubKey)
 }
 
 // BuildProof builds a proof of knowledge for the given credential.
+// For each of the predicates, which have to be over Known attributes
+// that are not revealed, a PredicateProof is built as well.
+// If acc is not nil, a NonRevocationProof for the accumulator acc is
+// built with the witness of the credential, which has to be updated
+// to the version of acc.
+// If scope is not empty, a ScopePseudonymProof for the scope pseudonym
+// of the holder is built as well.
+//
+// The proof is bound to verifier, which identifies the verifier
+// that the proof is made for, and to nonceOrg. The indices of revealed
+// attributes have to be sorted.
 func (m *CredManager) BuildProof(cred *Cred, revealedKnownAttrsIndices,
-	revealedCommitmentsOfAttrsIndices []int, nonceOrg *big.Int) (*Cred,
-	*qr.RepresentationProof, error) {
+	revealedCommitmentsOfAttrsIndices []int, predicates []*Predicate,
+	acc *Accumulator, scope, verifier string, nonceOrg *big.Int) (*Cred,
+	*qr.RepresentationProof, []*PredicateProof, *NonRevocationProof,
+	*ScopePseudonymProof, error) {
+	p, err := m.newCredProver(cred, revealedKnownAttrsIndices,
+		revealedCommitmentsOfAttrsIndices, predicates, acc, scope, nil)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	p.transcript.Verifier = verifier
+	p.transcript.Nonce = nonceOrg
+	proof := p.respond(m.GetProofChallenge(p.transcript))
+
+	return p.rCred, proof, p.transcript.PredicateProofs,
+		p.transcript.NonRevocationProof, p.transcript.ScopePseudonymProof, nil
+}
+
+// credProver holds the state of a proof of possession of a credential
+// between the choice of random values and the responses to the
+// challenge, so that the challenge can be shared with proofs of other
+// credentials (see BuildMultiCredProof).
+type credProver struct {
+	rCred            *Cred
+	secrets          []*big.Int
+	randomVals       []*big.Int
+	predicateProvers []predicateProver
+	nonRevProver     *nonRevocationProver
+	// transcript of the proof, without the identity of the verifier
+	// and the nonce
+	transcript *CredProofTranscript
+}
+
+// newCredProver chooses the random values of a proof of possession of
+// cred (see BuildProof). If masterSecretTilde is not nil, it is used as
+// the random value of the first Hidden attribute, so that proofs of
+// credentials with the same first Hidden attribute have the same
+// response for it.
+func (m *CredManager) newCredProver(cred *Cred, revealedKnownAttrsIndices,
+	revealedCommitmentsOfAttrsIndices []int, predicates []*Predicate,
+	acc *Accumulator, scope string, masterSecretTilde *big.Int) (*credProver,
+	error) {
 	if m.V1 == nil {
-		return nil, nil, fmt.Errorf("v1 is not set (generated in GetCredRequest)")
+		return nil, fmt.Errorf("v1 is not set (generated in GetCredRequest)")
+	}
+	if scope != "" && len(m.Attrs.Hidden) == 0 {
+		return nil, fmt.Errorf("scope pseudonyms require a hidden attribute")
 	}
+	if masterSecretTilde != nil && len(m.Attrs.Hidden) == 0 {
+		return nil, fmt.Errorf("proofs of several credentials require" +
+			" a hidden attribute")
+	}
+
 	rCred := m.randomize(cred)
 	// Z = cred.A^cred.e * S^cred.v11 * R_1^m_1 * ... * R_l^m_l
 	// Z = rCred.A^rCred.e * S^rCred.v11 * R_1^m_1 * ... * R_l^m_l
@@ -343,8 +613,11 @@ func (m *CredManager) BuildProof(cred *Cred, revealedKnownAttrsIndices,
 	bases := []*big.Int{}
 	unrevealedKnownAttrs := []*big.Int{}
 	unrevealedCommitmentsOfAttrs := []*big.Int{}
+	// position of an unrevealed Known attribute among secrets
+	secretIndices := make(map[int]int)
 	for i := 0; i < len(m.Attrs.Known); i++ {
 		if !common.Contains(revealedKnownAttrsIndices, i) {
+			secretIndices[i] = len(bases)
 			bases = append(bases, m.PubKey.RsKnown[i])
 			unrevealedKnownAttrs = append(unrevealedKnownAttrs, m.Attrs.Known[i])
 		}
@@ -356,6 +629,8 @@ func (m *CredManager) BuildProof(cred *Cred, revealedKnownAttrsIndices,
 		}
 	}
 
+	// position of the first Hidden attribute among secrets
+	hiddenInd := len(bases)
 	bases = append(bases, m.PubKey.RsHidden...)
 	bases = append(bases, rCred.A)
 	bases = append(bases, m.PubKey.S)
@@ -366,26 +641,6 @@ func (m *CredManager) BuildProof(cred *Cred, revealedKnownAttrsIndices,
 	v := new(big.Int).Add(rCred.V11, m.V1)
 	secrets = append(secrets, v)
 
-	denom := big.NewInt(1)
-	for i := 0; i < len(m.Attrs.Known); i++ {
-		if common.Contains(revealedKnownAttrsIndices, i) {
-			t1 := group.Exp(m.PubKey.RsKnown[i], m.Attrs.Known[i])
-			denom = group.Mul(denom, t1)
-		}
-	}
-
-	for i := 0; i < len(m.Attrs.Committed); i++ {
-		if common.Contains(revealedCommitmentsOfAttrsIndices, i) {
-			t1 := group.Exp(m.PubKey.RsCommitted[i], m.CommitmentsOfAttrs[i])
-			denom = group.Mul(denom, t1)
-		}
-	}
-	denomInv := group.Inv(denom)
-	y := group.Mul(m.PubKey.Z, denomInv)
-
-	prover := qr.NewRepresentationProver(group, int(m.Params.SecParam),
-		secrets, bases, y)
-
 	// boundary for m_tilde
 	b_m := int(m.Params.AttrBitLen + m.Params.SecParam + m.Params.HashBitLen)
 	// boundary for e
@@ -406,15 +661,103 @@ func (m *CredManager) BuildProof(cred *Cred, revealedKnownAttrsIndices,
 	boundaries = append(boundaries, b_e)
 	boundaries = append(boundaries, b_v1)
 
-	proofRandomData, err := prover.GetProofRandomDataGivenBoundaries(boundaries, true)
-	if err != nil {
-		return nil, nil, fmt.Errorf("error when generating representation proof random data: %s", err)
+	// random values are chosen here and not by a qr.RepresentationProver,
+	// since those for the attributes are shared with predicate proofs
+	randomVals := make([]*big.Int, len(bases))
+	proofRandomData := big.NewInt(1)
+	for i := range bases {
+		randomVals[i] = getRandomBoundedInt(boundaries[i])
+		if i == hiddenInd && masterSecretTilde != nil {
+			randomVals[i] = masterSecretTilde
+		}
+		proofRandomData = group.Mul(proofRandomData,
+			group.Exp(bases[i], randomVals[i]))
 	}
 
-	challenge := m.GetProofChallenge(proofRandomData, nonceOrg)
-	proofData := prover.GetProofData(challenge)
+	predicateProvers := make([]predicateProver, len(predicates))
+	predicateProofs := make([]*PredicateProof, len(predicates))
+	for i, pred := range predicates {
+		ind, err := m.RawCred.knownIndex(pred.Attr)
+		if err != nil {
+			return nil, err
+		}
+		secretInd, ok := secretIndices[ind]
+		if !ok {
+			return nil, fmt.Errorf("predicate for attribute %s"+
+				" cannot be proved, the attribute is revealed", pred.Attr)
+		}
+		p, err := newPredicateProver(group, m.PubKey, m.Params, pred,
+			m.Attrs.Known[ind])
+		if err != nil {
+			return nil, err
+		}
+		predicateProvers[i] = p
+		predicateProofs[i] = p.getProofRandomData(m.Params,
+			randomVals[secretInd])
+		predicateProofs[i].KnownAttrIndex = ind
+	}
 
-	return rCred, qr.NewRepresentationProof(proofRandomData, challenge, proofData), nil
+	var nonRevProver *nonRevocationProver
+	var nonRevProof *NonRevocationProof
+	if acc != nil {
+		p, err := newNonRevocationProver(group, m.PubKey, m.Params, acc,
+			cred)
+		if err != nil {
+			return nil, err
+		}
+		nonRevProver = p
+		// random value for e is shared with the proof of possession
+		nonRevProof = p.getProofRandomData(m.Params,
+			randomVals[len(bases)-2])
+	}
+
+	var scopeProof *ScopePseudonymProof
+	if scope != "" {
+		// random value for the master secret is shared with the proof
+		// of possession
+		scopeProof = newScopePseudonymProof(m.PubKey, scope,
+			m.Attrs.Hidden[0], randomVals[hiddenInd])
+	}
+
+	revealedKnownAttrs, revealedCommitmentsOfAttrs := m.FilterAttributes(
+		revealedKnownAttrsIndices, revealedCommitmentsOfAttrsIndices)
+
+	return &credProver{
+		rCred:            rCred,
+		secrets:          secrets,
+		randomVals:       randomVals,
+		predicateProvers: predicateProvers,
+		nonRevProver:     nonRevProver,
+		transcript: &CredProofTranscript{
+			A:                                 rCred.A,
+			RevealedKnownAttrsIndices:         revealedKnownAttrsIndices,
+			RevealedKnownAttrs:                revealedKnownAttrs,
+			RevealedCommitmentsOfAttrsIndices: revealedCommitmentsOfAttrsIndices,
+			RevealedCommitmentsOfAttrs:        revealedCommitmentsOfAttrs,
+			ProofRandomData:                   proofRandomData,
+			PredicateProofs:                   predicateProofs,
+			NonRevocationProof:                nonRevProof,
+			ScopePseudonymProof:               scopeProof,
+			Scope:                             scope,
+		},
+	}, nil
+}
+
+// respond computes the responses of the proof to challenge.
+func (p *credProver) respond(challenge *big.Int) *qr.RepresentationProof {
+	proofData := make([]*big.Int, len(p.randomVals))
+	for i := range p.randomVals {
+		proofData[i] = response(p.randomVals[i], challenge, p.secrets[i])
+	}
+	for i, pp := range p.predicateProvers {
+		pp.setProofData(p.transcript.PredicateProofs[i], challenge)
+	}
+	if p.nonRevProver != nil {
+		p.nonRevProver.setProofData(p.transcript.NonRevocationProof, challenge)
+	}
+
+	return qr.NewRepresentationProof(p.transcript.ProofRandomData, challenge,
+		proofData)
 }
 
 // computeU computes U = S^v1 * R_1^m_1 * ... * R_NumAttrs^m_NumAttrs (mod n) where only hiddenAttrs are used and
@@ -424,15 +767,37 @@ func (m *CredManager) computeU() (*big.Int, *big.Int) {
 	b := new(big.Int).Exp(big.NewInt(2), exp, nil)
 	v1 := common.GetRandomIntAlsoNeg(b)
 
-	group := qr.NewRSApecialPublic(m.PubKey.N)
-	U := group.Exp(m.PubKey.S, v1)
+	return computeUWithKey(m.PubKey, m.Attrs.Hidden, v1), v1
+}
+
+// computeUWithKey computes U = S^v1 * R_1^m_1 * ... * R_L^m_L with
+// the bases of public key pk, where m_i are hidden. Given the random
+// values of m_i and v1 instead, it computes the random data of a proof
+// of the representation of U.
+func computeUWithKey(pk *PubKey, hidden []*big.Int, v1 *big.Int) *big.Int {
+	group := qr.NewRSApecialPublic(pk.N)
+	U := group.Exp(pk.S, v1)
 
-	for i, attr := range m.Attrs.Hidden {
-		t := group.Exp(m.PubKey.RsHidden[i], attr) // R_i^m_i
+	for i, attr := range hidden {
+		t := group.Exp(pk.RsHidden[i], attr) // R_i^m_i
 		U = group.Mul(U, t)
 	}
 
-	return U, v1
+	return U
+}
+
+// uProofData returns the responses of a proof of the representation of
+// U computed from hidden and v1, for random values hiddenTilde and
+// v1Tilde, ordered as the bases [R_1, ..., R_L, S].
+func uProofData(hidden []*big.Int, v1 *big.Int, hiddenTilde []*big.Int,
+	v1Tilde, challenge *big.Int) []*big.Int {
+	proofData := make([]*big.Int, len(hidden)+1)
+	for i := range hidden {
+		proofData[i] = response(hiddenTilde[i], challenge, hidden[i])
+	}
+	proofData[len(hidden)] = response(v1Tilde, challenge, v1)
+
+	return proofData
 }
 
 func (m *CredManager) getNymProver() (*schnorr.Prover, error) {
@@ -441,12 +806,14 @@ func (m *CredManager) getNymProver() (*schnorr.Prover, error) {
 		m.PubKey.PedersenParams.Group.G,
 		m.PubKey.PedersenParams.H,
 	}
-	committer := m.nymCommitter
-	val, r := committer.GetDecommitMsg() // Val is actually master key
-	secrets := []*big.Int{val, r}
+	if m.nymRandomness == nil {
+		return nil, fmt.Errorf("opening of nym is not available")
+	}
+	// nym = G^masterSecret * H^nymRandomness
+	secrets := []*big.Int{m.masterSecret, m.nymRandomness}
 
 	prover, err := schnorr.NewProver(m.PubKey.PedersenParams.Group, secrets[:], bases[:],
-		committer.Commitment)
+		m.Nym)
 	if err != nil {
 		return nil, fmt.Errorf("error when creating Schnorr prover: %s", err)
 	}
@@ -486,15 +853,6 @@ func (m *CredManager) getUProofRandomData(prover *qr.RepresentationProver) (*big
 	return UTilde, nil
 }
 
-// Fiat-Shamir is used to generate a challenge, instead of asking verifier to generate it.
-func (m *CredManager) getCredReqChallenge(U, nym, nonceOrg *big.Int) *big.Int {
-	context := m.PubKey.GetContext()
-	l := []*big.Int{context, U, nym, nonceOrg}
-	l = append(l, m.CommitmentsOfAttrs...) // TODO: add other values
-
-	return common.Hash(l...)
-}
-
 func (m *CredManager) getCredReqProvers(U *big.Int) (*schnorr.Prover,
 	*qr.RepresentationProver, error) {
 	nymProver, err := m.getNymProver()
@@ -506,12 +864,15 @@ func (m *CredManager) getCredReqProvers(U *big.Int) (*schnorr.Prover,
 	return nymProver, uProver, nil
 }
 
-func (m *CredManager) getCommitmentsOfAttrsProof(challenge *big.Int) []*df.OpeningProof {
+// getCommitmentsOfAttrsProof returns proofs of openings of commitments
+// of attributes, the random data of which were already obtained from
+// the provers.
+func (m *CredManager) getCommitmentsOfAttrsProof(proofRandomData []*big.Int,
+	challenge *big.Int) []*df.OpeningProof {
 	commitmentsOfAttrsProofs := make([]*df.OpeningProof, len(m.commitmentsOfAttrsProvers))
 	for i, prover := range m.commitmentsOfAttrsProvers {
-		proofRandomData := prover.GetProofRandomData()
 		proofData1, proofData2 := prover.GetProofData(challenge)
-		commitmentsOfAttrsProofs[i] = df.NewOpeningProof(proofRandomData, challenge,
+		commitmentsOfAttrsProofs[i] = df.NewOpeningProof(proofRandomData[i], challenge,
 			proofData1, proofData2)
 	}
 
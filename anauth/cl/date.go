@@ -0,0 +1,255 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of dates given as strings, for example
+// 2018-11-20.
+const DateLayout = "2006-01-02"
+
+// dateEpoch is the unix time of 1 January of year 1, the day that
+// dates are counted from.
+const dateEpoch = -62135596800
+
+const secondsPerDay = 24 * 60 * 60
+
+// DateAttr is an attribute holding a date, such as a date of birth or
+// a date of expiry. Its internal value is the number of days since
+// 1 January of year 1, encoded like values of int64 attributes, so
+// dates can be compared with conditions lt, lte, gt and gte without
+// being revealed.
+//
+// Reference values of date attributes are either dates or dates
+// relative to the time of verification (see ResolveDate).
+type DateAttr struct {
+	Val time.Time
+	*Attr
+}
+
+func NewEmptyDateAttr(name string, known bool) *DateAttr {
+	return &DateAttr{
+		Attr: newAttr(name, known),
+	}
+}
+
+func NewDateAttr(name string, val time.Time, known bool) (*DateAttr,
+	error) {
+	a := &DateAttr{
+		Val:  val,
+		Attr: newAttr(name, known),
+	}
+	if err := a.setInternalValue(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *DateAttr) setInternalValue() error {
+	a.Attr.Val = encodeInt64(daysOf(a.Val))
+	a.ValSet = true
+	return nil
+}
+
+func (a *DateAttr) updateInternalValue(val *big.Int) error {
+	days, err := decodeInt64(val)
+	if err != nil {
+		return fmt.Errorf("invalid value of date attribute %s", a.Name())
+	}
+	a.Val = dateOf(days)
+	return nil
+}
+
+func (a *DateAttr) getValue() interface{} {
+	return a.Val
+}
+
+// UpdateValue sets the date to d, which is either a time.Time or
+// a string in the form of DateLayout.
+func (a *DateAttr) UpdateValue(d interface{}) error {
+	t, err := parseDate(d)
+	if err != nil {
+		return fmt.Errorf("value of attribute %s: %s", a.Name(), err)
+	}
+	a.Val = t
+	return a.setInternalValue()
+}
+
+// ValidateAgainst checks the attribute against the date reference v,
+// resolved at the current time (see ResolveDate).
+func (a *DateAttr) ValidateAgainst(v interface{}) (bool, error) {
+	ref, err := ResolveDate(v, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("value provided for '%s': %s", a.Name(),
+			err)
+	}
+	actual, val := daysOf(ref), daysOf(a.Val)
+
+	switch a.cond {
+	case greaterThan:
+		return actual > val, nil
+	case greaterThanOrEqual:
+		return actual >= val, nil
+	case lessThan:
+		return actual < val, nil
+	case lessThanOrEqual:
+		return actual <= val, nil
+	case equal:
+		return actual == val, nil
+	}
+
+	return false, fmt.Errorf("invalid condition")
+}
+
+// clone returns a copy of the attribute that can be modified
+// independently of the original.
+func (a *DateAttr) clone() CredAttr {
+	attr := *a.Attr
+	return &DateAttr{
+		Val:  a.Val,
+		Attr: &attr,
+	}
+}
+
+func (a *DateAttr) String() string {
+	return fmt.Sprintf("%s, type = date", a.Attr.String())
+}
+
+// daysOf returns the number of days from 1 January of year 1 to the
+// date of t, in the location of t.
+func daysOf(t time.Time) int64 {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return (midnight.Unix() - dateEpoch) / secondsPerDay
+}
+
+// dateOf returns the date (in UTC) that is days days from 1 January of
+// year 1.
+func dateOf(days int64) time.Time {
+	return time.Unix(dateEpoch+days*secondsPerDay, 0).UTC()
+}
+
+// parseDate returns the date from d, which is either a time.Time or
+// a string in the form of DateLayout.
+func parseDate(d interface{}) (time.Time, error) {
+	switch t := d.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return time.Parse(DateLayout, strings.TrimSpace(t))
+	}
+
+	return time.Time{}, fmt.Errorf("%v is not a date", d)
+}
+
+// relativeDateTerm matches a single term of a relative date, such as
+// "- 18 years".
+var relativeDateTerm = regexp.MustCompile(`^([+-])\s*(\d+)\s*([a-z]+)\s*`)
+
+// ResolveDate returns the date that date reference ref stands for at
+// time now. Besides dates (see parseDate), references can be dates
+// relative to now, in the form of "today" followed by any number of
+// terms that add or subtract days, weeks, months or years, for example
+// "today - 18 years" or "today+1y-6m". Units can also be abbreviated
+// to d, w, m and y.
+//
+// Adding months or years to the last days of months follows
+// time.AddDate, for example 29 February minus a year is 1 March.
+func ResolveDate(ref interface{}, now time.Time) (time.Time, error) {
+	s, ok := ref.(string)
+	if !ok {
+		return parseDate(ref)
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "today") {
+		return parseDate(s)
+	}
+
+	y, m, d := now.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	rest := strings.TrimSpace(strings.TrimPrefix(s, "today"))
+	for rest != "" {
+		term := relativeDateTerm.FindStringSubmatch(rest)
+		if term == nil {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", s)
+		}
+		rest = rest[len(term[0]):]
+
+		n, err := strconv.Atoi(term[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", s)
+		}
+		if term[1] == "-" {
+			n = -n
+		}
+
+		switch term[3] {
+		case "d", "day", "days":
+			date = date.AddDate(0, 0, n)
+		case "w", "week", "weeks":
+			date = date.AddDate(0, 0, 7*n)
+		case "m", "month", "months":
+			date = date.AddDate(0, n, 0)
+		case "y", "year", "years":
+			date = date.AddDate(n, 0, 0)
+		default:
+			return time.Time{}, fmt.Errorf("invalid unit %q in relative"+
+				" date %q", term[3], s)
+		}
+	}
+
+	return date, nil
+}
+
+// resolveDateRefs returns a copy of reference values actual, with
+// the references of date attributes from attrs resolved at time now
+// (see ResolveDate). References are resolved once per proof, so that
+// all the checks of a proof use the same dates.
+func resolveDateRefs(attrs []CredAttr, actual map[string]interface{},
+	now time.Time) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(actual))
+	for name, v := range actual {
+		resolved[name] = v
+	}
+
+	for _, a := range attrs {
+		if _, ok := a.(*DateAttr); !ok {
+			continue
+		}
+		ref, ok := actual[a.Name()]
+		if !ok {
+			continue
+		}
+		date, err := ResolveDate(ref, now)
+		if err != nil {
+			return nil, fmt.Errorf("value provided for '%s': %s",
+				a.Name(), err)
+		}
+		resolved[a.Name()] = date
+	}
+
+	return resolved, nil
+}
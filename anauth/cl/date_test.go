@@ -0,0 +1,177 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateAttr(t *testing.T) {
+	a, err := NewDateAttr("birth", date(t, "1990-05-17"), true)
+	require.NoError(t, err)
+	assert.True(t, a.internalValue().Sign() > 0)
+
+	// the internal value maps back to the same date
+	b := NewEmptyDateAttr("birth", true)
+	require.NoError(t, b.updateInternalValue(a.internalValue()))
+	assert.True(t, date(t, "1990-05-17").Equal(b.Val))
+
+	// dates given as strings are encoded the same as time.Time
+	require.NoError(t, b.UpdateValue("1990-05-17"))
+	assert.Equal(t, 0, a.internalValue().Cmp(b.internalValue()))
+	v, err := encodeAttrValue(b, "1990-05-17")
+	require.NoError(t, err)
+	assert.Equal(t, 0, a.internalValue().Cmp(v))
+
+	assert.Error(t, b.UpdateValue("17.5.1990"))
+	assert.Error(t, b.UpdateValue(int64(100)))
+}
+
+func TestDateAttr_Days(t *testing.T) {
+	assert.Equal(t, int64(0), daysOf(time.Time{}))
+	assert.Equal(t, int64(1), daysOf(date(t, "0001-01-02")))
+	assert.Equal(t, int64(719162), daysOf(time.Unix(0, 0).UTC()))
+
+	// the time of day and location do not change the date
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	assert.Equal(t, daysOf(date(t, "2018-11-20")),
+		daysOf(time.Date(2018, 11, 20, 23, 59, 0, 0, loc)))
+	assert.Equal(t, int64(737018), daysOf(dateOf(737018)))
+}
+
+func TestResolveDate(t *testing.T) {
+	now := time.Date(2018, 11, 20, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		ref  interface{}
+		want string
+	}{
+		{"2000-01-01", "2000-01-01"},
+		{date(t, "2000-01-01"), "2000-01-01"},
+		{"today", "2018-11-20"},
+		{"today-18y", "2000-11-20"},
+		{"today - 18 years", "2000-11-20"},
+		{"today +1 month", "2018-12-20"},
+		{"today+2w-1d", "2018-12-03"},
+		{" today - 1 year + 6 months ", "2018-05-20"},
+	}
+
+	for _, test := range tests {
+		d, err := ResolveDate(test.ref, now)
+		require.NoError(t, err, "%v", test.ref)
+		assert.Equal(t, test.want, d.Format(DateLayout), "%v", test.ref)
+	}
+
+	for _, ref := range []interface{}{"tomorrow", "today - 18",
+		"today - 18 decades", "today 18y", "today-y", "20.11.2018",
+		int64(18)} {
+		_, err := ResolveDate(ref, now)
+		assert.Error(t, err, "%v", ref)
+	}
+}
+
+func TestDateAttr_ValidateAgainst(t *testing.T) {
+	birth := time.Now().AddDate(-20, 0, 0)
+	a, err := NewDateAttr("birth", birth, true)
+	require.NoError(t, err)
+
+	a.cond = greaterThanOrEqual
+	ok, err := a.ValidateAgainst("today-18y")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = a.ValidateAgainst("today - 21 years")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	a.cond = equal
+	ok, err = a.ValidateAgainst(birth.Format(DateLayout))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = a.ValidateAgainst(int64(18))
+	assert.Error(t, err)
+}
+
+func TestDateAttr_Predicate(t *testing.T) {
+	a := NewEmptyDateAttr("birth", true)
+	a.cond = greaterThanOrEqual
+	assert.True(t, provable(a))
+
+	ref := "today-18y"
+	pred, err := newAttrPredicate(a, map[string]interface{}{"birth": ref})
+	require.NoError(t, err)
+	adult, err := ResolveDate(ref, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, daysOf(adult), pred.Value)
+
+	a.cond = equal
+	assert.False(t, provable(a))
+}
+
+func TestResolveDateRefs(t *testing.T) {
+	attrs := []CredAttr{
+		NewEmptyDateAttr("birth", true),
+		NewEmptyInt64Attr("age", true),
+	}
+	actual := map[string]interface{}{
+		"birth": "today-18y",
+		"age":   int64(18),
+	}
+	now := time.Date(2018, 11, 20, 0, 0, 0, 0, time.UTC)
+
+	resolved, err := resolveDateRefs(attrs, actual, now)
+	require.NoError(t, err)
+	assert.Equal(t, date(t, "2000-11-20"), resolved["birth"])
+	assert.Equal(t, int64(18), resolved["age"])
+	assert.Equal(t, "today-18y", actual["birth"])
+
+	actual["birth"] = "yesterday"
+	_, err = resolveDateRefs(attrs, actual, now)
+	assert.Error(t, err)
+}
+
+func TestParseAttrs_Date(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"birth": map[string]interface{}{
+			"index": 0,
+			"type":  "date",
+			"cond":  "gte",
+		},
+	})
+
+	attrs, _, err := parseAttrs(v)
+	require.NoError(t, err)
+	require.IsType(t, &DateAttr{}, attrs[0])
+	assert.Equal(t, greaterThanOrEqual, attrs[0].getCond())
+
+	v.Set("attributes.birth.cond", "in")
+	_, _, err = parseAttrs(v)
+	assert.Error(t, err)
+}
+
+func date(t *testing.T, s string) time.Time {
+	d, err := time.Parse(DateLayout, s)
+	require.NoError(t, err)
+	return d
+}
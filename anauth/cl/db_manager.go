@@ -18,13 +18,24 @@
 package cl
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
 
 	"fmt"
 
 	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
 )
 
+// ErrRecordChanged is returned when a receiver record that is being
+// replaced no longer describes the credential it was loaded with, for
+// example because the credential was revoked meanwhile.
+var ErrRecordChanged = errors.New("receiver record was changed concurrently")
+
 // ReceiverRecordManager manages receiver records
 // tied to particular nyms.
 type ReceiverRecordManager interface {
@@ -37,6 +48,10 @@ type ReceiverRecordManager interface {
 	// in case of error in the interaction with the
 	// storage backend.
 	Load(*big.Int) (*ReceiverRecord, error)
+
+	// Delete deletes the ReceiverRecord associated with the given
+	// nym. Deleting a record that does not exist is not an error.
+	Delete(*big.Int) error
 }
 
 // RedisClient wraps a redis client in order to interact with the
@@ -68,11 +83,16 @@ func (m *RedisClient) Load(nym *big.Int) (*ReceiverRecord, error) {
 	return &rec, nil
 }
 
+func (m *RedisClient) Delete(nym *big.Int) error {
+	return m.Del(nym.String()).Err()
+}
+
 // MockRecordManager is a mock implementation of the ReceiverRecordManager
 // interface. It stores key-value pairs of nyms and corresponding
-// receiver records in a map.
+// receiver records in a map. It is safe for concurrent use.
 type MockRecordManager struct {
 	data map[string]ReceiverRecord
+	mu   sync.RWMutex
 }
 
 // NewMockRecordManager initializes the map that will hold the data.
@@ -83,6 +103,9 @@ func NewMockRecordManager() *MockRecordManager {
 }
 
 func (rm *MockRecordManager) Load(nym *big.Int) (*ReceiverRecord, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
 	r, present := rm.data[nym.String()]
 	if !present {
 		return nil, fmt.Errorf("record does not exist")
@@ -92,6 +115,363 @@ func (rm *MockRecordManager) Load(nym *big.Int) (*ReceiverRecord, error) {
 }
 
 func (rm *MockRecordManager) Store(nym *big.Int, r *ReceiverRecord) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
 	rm.data[nym.String()] = *r
 	return nil
 }
+
+func (rm *MockRecordManager) Delete(nym *big.Int) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	delete(rm.data, nym.String())
+	return nil
+}
+
+// AccumulatorStore stores the log of updates of the revocation
+// accumulator (see Revoker).
+type AccumulatorStore interface {
+	// Append appends the update to the log. It returns an error if
+	// the version of the update does not follow the version of the
+	// last update in the log, which happens when the accumulator was
+	// concurrently updated by someone else.
+	Append(*AccumulatorUpdate) error
+
+	// Updates returns the updates with versions greater than the
+	// given version, ordered by version.
+	Updates(version int64) ([]*AccumulatorUpdate, error)
+
+	// Last returns the last update, or nil if the log is empty.
+	Last() (*AccumulatorUpdate, error)
+
+	// AppendRecord appends the update to the log like Append, and in
+	// the same transaction replaces the receiver record of nym kept by
+	// recMgr with rec. It returns ErrRecordChanged, and appends nothing,
+	// if the stored record does not describe the same credential as
+	// prev (see ReceiverRecord.sameCred).
+	AppendRecord(u *AccumulatorUpdate, recMgr ReceiverRecordManager,
+		nym *big.Int, prev, rec *ReceiverRecord) error
+}
+
+// RedisAccumulatorStore stores the log of accumulator updates in
+// a redis list. Update with version v is at index v-1 in the list.
+type RedisAccumulatorStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisAccumulatorStore accepts an instance of redis.Client and
+// returns an instance of RedisAccumulatorStore that keeps the log
+// under the given key.
+func NewRedisAccumulatorStore(c *redis.Client,
+	key string) *RedisAccumulatorStore {
+	return &RedisAccumulatorStore{
+		client: c,
+		key:    key,
+	}
+}
+
+func (s *RedisAccumulatorStore) Append(u *AccumulatorUpdate) error {
+	// the length of the list is checked and the update appended
+	// in a transaction that fails if the list is modified meanwhile
+	err := s.client.Watch(func(tx *redis.Tx) error {
+		n, err := tx.LLen(s.key).Result()
+		if err != nil {
+			return err
+		}
+		if n != u.Version-1 {
+			return fmt.Errorf("accumulator update %d does not follow"+
+				" version %d", u.Version, n)
+		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			return pipe.RPush(s.key, u).Err()
+		})
+		return err
+	}, s.key)
+	if err == redis.TxFailedErr {
+		return fmt.Errorf("accumulator was updated concurrently")
+	}
+
+	return err
+}
+
+// AppendRecord requires receiver records to be kept by a RedisClient
+// in the same database as the log.
+func (s *RedisAccumulatorStore) AppendRecord(u *AccumulatorUpdate,
+	recMgr ReceiverRecordManager, nym *big.Int,
+	prev, rec *ReceiverRecord) error {
+	if _, ok := recMgr.(*RedisClient); !ok {
+		return fmt.Errorf("receiver records have to be kept in redis")
+	}
+
+	// both the log and the record are watched, so that neither can
+	// change between the checks and the writes
+	recKey := nym.String()
+	err := s.client.Watch(func(tx *redis.Tx) error {
+		n, err := tx.LLen(s.key).Result()
+		if err != nil {
+			return err
+		}
+		if n != u.Version-1 {
+			return fmt.Errorf("accumulator update %d does not follow"+
+				" version %d", u.Version, n)
+		}
+
+		data, err := tx.Get(recKey).Bytes()
+		if err == redis.Nil {
+			return ErrRecordChanged
+		}
+		if err != nil {
+			return err
+		}
+		var stored ReceiverRecord
+		if err := stored.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		if !stored.sameCred(prev) {
+			return ErrRecordChanged
+		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.RPush(s.key, u)
+			pipe.Set(recKey, rec, 0)
+			return nil
+		})
+		return err
+	}, s.key, recKey)
+	if err == redis.TxFailedErr {
+		return fmt.Errorf("accumulator or receiver record was updated" +
+			" concurrently")
+	}
+
+	return err
+}
+
+func (s *RedisAccumulatorStore) Updates(version int64) ([]*AccumulatorUpdate,
+	error) {
+	if version < 0 {
+		version = 0
+	}
+	res, err := s.client.LRange(s.key, version, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]*AccumulatorUpdate, len(res))
+	for i, r := range res {
+		var u AccumulatorUpdate
+		if err := u.UnmarshalBinary([]byte(r)); err != nil {
+			return nil, err
+		}
+		updates[i] = &u
+	}
+
+	return updates, nil
+}
+
+func (s *RedisAccumulatorStore) Last() (*AccumulatorUpdate, error) {
+	r, err := s.client.LIndex(s.key, -1).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var u AccumulatorUpdate
+	if err := u.UnmarshalBinary([]byte(r)); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// MockAccumulatorStore is a mock implementation of the AccumulatorStore
+// interface. It keeps the updates in memory. It is safe for concurrent
+// use.
+type MockAccumulatorStore struct {
+	updates []*AccumulatorUpdate
+	mu      sync.RWMutex
+}
+
+// mockRecordsMu serializes replacements of receiver records by all the
+// MockAccumulatorStores, as records of credentials issued with different
+// keys are kept together.
+var mockRecordsMu sync.Mutex
+
+func NewMockAccumulatorStore() *MockAccumulatorStore {
+	return &MockAccumulatorStore{
+		updates: make([]*AccumulatorUpdate, 0),
+	}
+}
+
+func (s *MockAccumulatorStore) Append(u *AccumulatorUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if int64(len(s.updates)) != u.Version-1 {
+		return fmt.Errorf("accumulator update %d does not follow"+
+			" version %d", u.Version, len(s.updates))
+	}
+	s.updates = append(s.updates, u)
+	return nil
+}
+
+func (s *MockAccumulatorStore) AppendRecord(u *AccumulatorUpdate,
+	recMgr ReceiverRecordManager, nym *big.Int,
+	prev, rec *ReceiverRecord) error {
+	mockRecordsMu.Lock()
+	defer mockRecordsMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if int64(len(s.updates)) != u.Version-1 {
+		return fmt.Errorf("accumulator update %d does not follow"+
+			" version %d", u.Version, len(s.updates))
+	}
+	stored, err := recMgr.Load(nym)
+	if err != nil {
+		return ErrRecordChanged
+	}
+	if !stored.sameCred(prev) {
+		return ErrRecordChanged
+	}
+
+	if err := recMgr.Store(nym, rec); err != nil {
+		return err
+	}
+	s.updates = append(s.updates, u)
+	return nil
+}
+
+func (s *MockAccumulatorStore) Updates(version int64) ([]*AccumulatorUpdate,
+	error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if version < 0 || version >= int64(len(s.updates)) {
+		return []*AccumulatorUpdate{}, nil
+	}
+
+	updates := make([]*AccumulatorUpdate, int64(len(s.updates))-version)
+	copy(updates, s.updates[version:])
+	return updates, nil
+}
+
+func (s *MockAccumulatorStore) Last() (*AccumulatorUpdate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.updates) == 0 {
+		return nil, nil
+	}
+	return s.updates[len(s.updates)-1], nil
+}
+
+// FileAccumulatorStore keeps the log of accumulator updates in a JSON
+// file, as an array of updates ordered by version. It mirrors the
+// updates published by an issuer (see Keyring), so it does not keep
+// receiver records. The file is read on every access, so that it can
+// be replaced by newer updates while in use.
+type FileAccumulatorStore struct {
+	path string
+	mu   sync.Mutex // serializes appends
+}
+
+func NewFileAccumulatorStore(path string) *FileAccumulatorStore {
+	return &FileAccumulatorStore{
+		path: path,
+	}
+}
+
+func (s *FileAccumulatorStore) read() ([]*AccumulatorUpdate, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var updates []*AccumulatorUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse accumulator updates"+
+			" in %s", s.path)
+	}
+	for i, u := range updates {
+		if u.Version != int64(i+1) || u.E == nil || u.Value == nil {
+			return nil, fmt.Errorf("invalid accumulator update %d in %s",
+				i+1, s.path)
+		}
+	}
+
+	return updates, nil
+}
+
+// Append appends the update to the file, which is created if it does
+// not exist.
+func (s *FileAccumulatorStore) Append(u *AccumulatorUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updates, err := s.read()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if int64(len(updates)) != u.Version-1 {
+		return fmt.Errorf("accumulator update %d does not follow"+
+			" version %d", u.Version, len(updates))
+	}
+	data, err := json.Marshal(append(updates, u))
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced, so that readers never see a partial write
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path),
+		filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileAccumulatorStore) AppendRecord(u *AccumulatorUpdate,
+	recMgr ReceiverRecordManager, nym *big.Int,
+	prev, rec *ReceiverRecord) error {
+	return fmt.Errorf("receiver records are not kept with accumulator" +
+		" updates in files")
+}
+
+func (s *FileAccumulatorStore) Updates(version int64) ([]*AccumulatorUpdate,
+	error) {
+	updates, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if version < 0 || version >= int64(len(updates)) {
+		return []*AccumulatorUpdate{}, nil
+	}
+
+	return updates[version:], nil
+}
+
+func (s *FileAccumulatorStore) Last() (*AccumulatorUpdate, error) {
+	updates, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	return updates[len(updates)-1], nil
+}
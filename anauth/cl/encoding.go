@@ -0,0 +1,68 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// AttrEncoding determines how values of string and bytes attributes
+// are mapped to internal values.
+type AttrEncoding int
+
+const (
+	// RawEncoding uses values themselves as internal values, so that
+	// revealed values are known to verifiers. Values have to fit into
+	// AttrBitLen bits.
+	RawEncoding AttrEncoding = iota
+	// HashEncoding uses SHA-256 hashes of values as internal values,
+	// and is meant for long values such as documents, photos or
+	// addresses. Holders of credentials keep the values, verifiers only
+	// learn hashes of revealed values, which they compare with hashes of
+	// reference values. Attributes need at least 256 bits.
+	HashEncoding
+)
+
+var attrEncodingStr = []string{"raw", "hash"}
+
+func (e AttrEncoding) String() string {
+	return attrEncodingStr[e]
+}
+
+func parseAttrEncoding(enc string) (AttrEncoding, error) {
+	for i, e := range attrEncodingStr {
+		if enc == e {
+			return AttrEncoding(i), nil
+		}
+	}
+
+	return -1, fmt.Errorf("invalid encoding '%s'", enc)
+}
+
+// attrHashBitLen is the bit length of internal values of attributes
+// with HashEncoding.
+const attrHashBitLen = sha256.Size * 8
+
+// hashValue returns the internal value of value v of an attribute with
+// HashEncoding.
+func hashValue(v []byte) *big.Int {
+	h := sha256.Sum256(v)
+	return new(big.Int).SetBytes(h[:])
+}
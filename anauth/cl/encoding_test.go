@@ -0,0 +1,199 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrAttr_HashEncoding(t *testing.T) {
+	address := strings.Repeat("Jamova cesta 39, 1000 Ljubljana. ", 20)
+	a, err := NewStrAttr("address", address, true)
+	require.NoError(t, err)
+	a.Encoding = HashEncoding
+	require.NoError(t, a.UpdateValue(address))
+	assert.True(t, a.internalValue().BitLen() <= attrHashBitLen)
+	assert.Equal(t, address, a.Val)
+
+	// verifiers only learn the hash of the value
+	b := NewEmptyStrAttr("address", true)
+	b.Encoding = HashEncoding
+	b.cond = equal
+	require.NoError(t, b.updateInternalValue(a.internalValue()))
+	assert.Equal(t, "", b.Val)
+
+	ok, err := b.ValidateAgainst(address)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = b.ValidateAgainst(address + ".")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	v, err := encodeAttrValue(b, address)
+	require.NoError(t, err)
+	assert.Equal(t, 0, v.Cmp(a.internalValue()))
+}
+
+func TestBytesAttr(t *testing.T) {
+	photo := []byte{0, 0, 1, 2, 3}
+	a, err := NewBytesAttr("photo", photo, RawEncoding, true)
+	require.NoError(t, err)
+
+	// leading zero bytes are kept
+	b := NewEmptyBytesAttr("photo", RawEncoding, true)
+	require.NoError(t, b.updateInternalValue(a.internalValue()))
+	assert.Equal(t, photo, b.Val)
+	assert.Error(t, b.updateInternalValue(big.NewInt(0)))
+
+	// values can be given as base64 strings
+	b.cond = equal
+	ok, err := b.ValidateAgainst(base64.StdEncoding.EncodeToString(photo))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = b.ValidateAgainst(photo[1:])
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = b.ValidateAgainst("not base64!")
+	assert.Error(t, err)
+
+	h, err := NewBytesAttr("photo", photo, HashEncoding, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, hashValue(photo).Cmp(h.internalValue()))
+	v, err := encodeAttrValue(h, base64.StdEncoding.EncodeToString(photo))
+	require.NoError(t, err)
+	assert.Equal(t, 0, v.Cmp(h.internalValue()))
+
+	c := h.clone().(*BytesAttr)
+	require.NoError(t, c.updateInternalValue(big.NewInt(5)))
+	assert.Equal(t, photo, c.Val)
+	assert.Equal(t, 0, hashValue(photo).Cmp(h.internalValue()))
+}
+
+func TestParseAttrs_Encoding(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"address": map[string]interface{}{
+			"index":    0,
+			"type":     "string",
+			"encoding": "hash",
+			"cond":     "equal",
+		},
+		"photo": map[string]interface{}{
+			"index":    1,
+			"type":     "bytes",
+			"encoding": "hash",
+		},
+		"id": map[string]interface{}{
+			"index": 2,
+			"type":  "bytes",
+		},
+	})
+
+	attrs, _, err := parseAttrs(v)
+	require.NoError(t, err)
+	assert.Equal(t, HashEncoding, attrs[0].(*StrAttr).Encoding)
+	assert.Equal(t, HashEncoding, attrs[1].(*BytesAttr).Encoding)
+	assert.Equal(t, RawEncoding, attrs[2].(*BytesAttr).Encoding)
+	assert.NoError(t, validateAttrBitLen(attrs, 256))
+	assert.Error(t, validateAttrBitLen(attrs, 255))
+
+	tests := []struct {
+		key string
+		val interface{}
+	}{
+		{"attributes.address.cond", "in"},
+		{"attributes.address.encoding", "base64"},
+		{"attributes.photo.cond", "gte"},
+	}
+	for _, tt := range tests {
+		v := viper.New()
+		v.Set("attributes", map[string]interface{}{
+			"address": map[string]interface{}{
+				"index":    0,
+				"type":     "string",
+				"encoding": "hash",
+			},
+			"photo": map[string]interface{}{
+				"index": 1,
+				"type":  "bytes",
+			},
+		})
+		v.Set(tt.key, tt.val)
+		_, _, err := parseAttrs(v)
+		assert.Error(t, err, tt.key)
+	}
+
+	v = viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"age": map[string]interface{}{
+			"index":    0,
+			"type":     "int64",
+			"encoding": "hash",
+		},
+	})
+	_, _, err = parseAttrs(v)
+	assert.Error(t, err)
+}
+
+func TestCredVerifier_HashedAttr(t *testing.T) {
+	o := newTestOrg(t)
+	address := strings.Repeat("Jamova cesta 39, 1000 Ljubljana. ", 20)
+
+	// raw values have to fit into AttrBitLen bits
+	rc := NewRawCred(NewAttrCount(1, 0, 0))
+	require.NoError(t, rc.addEmptyStrAttr("address", 0, true, RawEncoding))
+	require.NoError(t, rc.UpdateAttr("address", address))
+	_, err := NewCredManager(o.Params, o.Keys.Pub,
+		o.Keys.Pub.GenerateUserMasterSecret(), rc)
+	assert.Error(t, err)
+
+	rc = NewRawCred(NewAttrCount(1, 0, 0))
+	require.NoError(t, rc.addEmptyStrAttr("address", 0, true, HashEncoding))
+	require.NoError(t, rc.UpdateAttr("address", address))
+	cm, err := NewCredManager(o.Params, o.Keys.Pub,
+		o.Keys.Pub.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+	cred := issueTestCred(t, o, cm)
+
+	attr := NewEmptyStrAttr("address", true)
+	attr.Encoding = HashEncoding
+	attr.cond = equal
+	prove := func(ref string) (bool, error) {
+		v := o.NewCredVerifier()
+		rCred, proof, _, _, _, err := cm.BuildProof(cred, []int{0}, []int{},
+			nil, nil, "", "", v.GetNonce())
+		require.NoError(t, err)
+		return v.ProveCred(rCred.A, proof, []int{0}, []int{},
+			cm.RawCred.GetKnownVals(), []*big.Int{}, []CredAttr{attr},
+			map[string]interface{}{"address": ref}, nil, nil, nil)
+	}
+
+	ok, err := prove(address)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = prove("Jamova cesta 39")
+	assert.False(t, ok && err == nil)
+}
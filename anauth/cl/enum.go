@@ -0,0 +1,176 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// EnumAttr is an attribute whose value is one of the allowed values
+// declared for the attribute, such as a role that is either "admin" or
+// "user". Values are encoded like values of string attributes, so
+// conditions equal and in are supported in the same way.
+type EnumAttr struct {
+	Val    string
+	Values []string
+	*Attr
+}
+
+// NewEmptyEnumAttr returns an enumerated attribute with allowed values
+// values, which have to be distinct.
+func NewEmptyEnumAttr(name string, values []string,
+	known bool) (*EnumAttr, error) {
+	if err := checkEnumValues(values); err != nil {
+		return nil, fmt.Errorf("values of enum attribute %s: %s", name, err)
+	}
+
+	return &EnumAttr{
+		Values: values,
+		Attr:   newAttr(name, known),
+	}, nil
+}
+
+func NewEnumAttr(name, val string, values []string,
+	known bool) (*EnumAttr, error) {
+	a, err := NewEmptyEnumAttr(name, values, known)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.UpdateValue(val); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *EnumAttr) setInternalValue() error {
+	if !a.allows(a.Val) {
+		return fmt.Errorf("'%s' is not an allowed value of attribute %s"+
+			" (%s)", a.Val, a.Name(), strings.Join(a.Values, ", "))
+	}
+	a.Attr.Val = encodeStr(a.Val)
+	a.ValSet = true
+	return nil
+}
+
+func (a *EnumAttr) updateInternalValue(val *big.Int) error {
+	v := string(val.Bytes())
+	if !a.allows(v) {
+		return fmt.Errorf("invalid value of enum attribute %s", a.Name())
+	}
+	a.Val = v
+	return nil
+}
+
+func (a *EnumAttr) getValue() interface{} {
+	return a.Val
+}
+
+// UpdateValue sets the attribute to s, which has to be one of the
+// allowed values of the attribute.
+func (a *EnumAttr) UpdateValue(s interface{}) error {
+	v, ok := s.(string)
+	if !ok {
+		return fmt.Errorf("value of attribute %s is not string", a.Name())
+	}
+	prev := a.Val
+	a.Val = v
+	if err := a.setInternalValue(); err != nil {
+		a.Val = prev
+		return err
+	}
+	return nil
+}
+
+// ValidateAgainst checks the attribute against v. For condition equal,
+// v has to be a string, for condition in, v has to be a list of strings
+// (see parseStrSet).
+func (a *EnumAttr) ValidateAgainst(v interface{}) (bool, error) {
+	switch a.cond {
+	case equal:
+		actual, ok := v.(string)
+		if !ok {
+			return false, fmt.Errorf("value provided for '%s' is not string",
+				a.Name())
+		}
+		return actual == a.Val, nil
+	case in:
+		set, err := parseStrSet(v)
+		if err != nil {
+			return false, fmt.Errorf("value provided for '%s': %s", a.Name(),
+				err)
+		}
+		for _, s := range set {
+			if s == a.Val {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, fmt.Errorf("invalid condition")
+}
+
+// allows reports whether v is one of the allowed values of the
+// attribute.
+func (a *EnumAttr) allows(v string) bool {
+	for _, allowed := range a.Values {
+		if v == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the attribute that can be modified
+// independently of the original.
+func (a *EnumAttr) clone() CredAttr {
+	attr := *a.Attr
+	return &EnumAttr{
+		Val:    a.Val,
+		Values: a.Values,
+		Attr:   &attr,
+	}
+}
+
+func (a *EnumAttr) String() string {
+	return fmt.Sprintf("%s, type = enum (%s)", a.Attr.String(),
+		strings.Join(a.Values, ", "))
+}
+
+// checkEnumValues checks that values can be allowed values of an
+// enumerated attribute.
+func checkEnumValues(values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values")
+	}
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("empty value")
+		}
+		if seen[v] {
+			return fmt.Errorf("duplicate value '%s'", v)
+		}
+		seen[v] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumAttr(t *testing.T) {
+	roles := []string{"admin", "user"}
+	a, err := NewEnumAttr("role", "admin", roles, true)
+	require.NoError(t, err)
+
+	b, err := NewEmptyEnumAttr("role", roles, true)
+	require.NoError(t, err)
+	require.NoError(t, b.updateInternalValue(a.internalValue()))
+	assert.Equal(t, "admin", b.Val)
+
+	// values that are not allowed are rejected, and do not change the
+	// attribute
+	assert.Error(t, b.UpdateValue("root"))
+	assert.Equal(t, "admin", b.Val)
+	assert.Error(t, b.updateInternalValue(encodeStr("root")))
+	_, err = encodeAttrValue(b, "root")
+	assert.Error(t, err)
+	_, err = NewEnumAttr("role", "root", roles, true)
+	assert.Error(t, err)
+
+	v, err := encodeAttrValue(b, "user")
+	require.NoError(t, err)
+	require.NoError(t, b.UpdateValue("user"))
+	assert.Equal(t, 0, v.Cmp(b.internalValue()))
+}
+
+func TestNewEmptyEnumAttr_InvalidValues(t *testing.T) {
+	for _, values := range [][]string{
+		nil,
+		{"admin", "admin"},
+		{"admin", ""},
+	} {
+		_, err := NewEmptyEnumAttr("role", values, true)
+		assert.Error(t, err, "%v", values)
+	}
+}
+
+func TestEnumAttr_ValidateAgainst(t *testing.T) {
+	a, err := NewEnumAttr("role", "user", []string{"admin", "user",
+		"guest"}, true)
+	require.NoError(t, err)
+
+	a.cond = equal
+	ok, err := a.ValidateAgainst("user")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, provable(a))
+
+	a.cond = in
+	ok, err = a.ValidateAgainst([]interface{}{"admin", "guest"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, provable(a))
+}
+
+func TestParseAttrs_Enum(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"role": map[string]interface{}{
+			"index":  0,
+			"type":   "enum",
+			"cond":   "in",
+			"values": []interface{}{"admin", "user"},
+		},
+	})
+
+	attrs, _, err := parseAttrs(v)
+	require.NoError(t, err)
+	require.IsType(t, &EnumAttr{}, attrs[0])
+	assert.Equal(t, []string{"admin", "user"}, attrs[0].(*EnumAttr).Values)
+
+	v.Set("attributes.role.cond", "lt")
+	_, _, err = parseAttrs(v)
+	assert.Error(t, err)
+
+	v.Set("attributes.role.cond", "equal")
+	v.Set("attributes.role.values", []interface{}{})
+	_, _, err = parseAttrs(v)
+	assert.Error(t, err)
+}
+
+func TestValidateAttrBitLen(t *testing.T) {
+	role, err := NewEmptyEnumAttr("role", []string{"admin", "user"}, true)
+	require.NoError(t, err)
+	attrs := []CredAttr{
+		NewEmptyInt64Attr("age", true),
+		role,
+	}
+
+	assert.NoError(t, validateAttrBitLen(attrs, 64))
+	assert.Error(t, validateAttrBitLen(attrs, 63))
+	assert.Error(t, validateAttrBitLen(attrs[1:], 38))
+}
@@ -0,0 +1,270 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+)
+
+const keyProofLabel = "emmy/cl/key-proof"
+
+// minKeyProofRounds is the number of rounds of a KeyProof that
+// verifiers require at least, a cheating issuer passes the proof with
+// probability 2^-minKeyProofRounds.
+const minKeyProofRounds = 80
+
+// KeyProof proves that a public key was generated correctly, so that
+// the issuer cannot use the key to link the credentials of a user.
+// It is a non-interactive proof of knowledge of the discrete logarithms
+// of Z, of all R_i and of the bases of the revocation accumulator to
+// base S, and of the discrete logarithm of G to base H in the group of
+// the commitments of (Committed) attributes.
+//
+// The proof is repeated in rounds with binary challenges, as the
+// proofs of Identity Mixer. A single challenge from a large interval
+// would not be sound in RSA groups of unknown order: an issuer could
+// multiply Z by an element of small order, such as -1, and repeat the
+// proof until the challenge is a multiple of that order.
+type KeyProof struct {
+	// Challenge is the hash that the challenges of the rounds are
+	// taken from, the challenge of round j is its j-th bit
+	Challenge *big.Int
+	// Responses hold the proof data for the powers of S, in the order
+	// of keyProofBases, for one round after another
+	Responses []*big.Int
+	// CommitmentResponses hold the proof data for G, one for each
+	// round
+	CommitmentResponses []*big.Int
+}
+
+// keyTrapdoor holds the discrete logarithms that the proof of
+// correctness of a public key is built from.
+type keyTrapdoor struct {
+	// exps are the logarithms of keyProofBases to base S
+	exps []*big.Int
+	// alpha is the logarithm of G to base H
+	alpha *big.Int
+}
+
+// keyProofBases returns the values of the key that need to be powers
+// of S: Z, RsKnown, RsCommitted, RsHidden and, if the key supports
+// revocation, AccInit, AccG and AccH.
+func keyProofBases(k *PubKey) []*big.Int {
+	bases := []*big.Int{k.Z}
+	bases = append(bases, k.RsKnown...)
+	bases = append(bases, k.RsCommitted...)
+	bases = append(bases, k.RsHidden...)
+	if k.SupportsRevocation() {
+		bases = append(bases, k.AccInit, k.AccG, k.AccH)
+	}
+
+	return bases
+}
+
+// keyProofChallenge computes the challenge of the proof of correctness
+// of key k with the given number of rounds and random data tildes
+// (powers of S) and tildesG (powers of H) of all rounds.
+func keyProofChallenge(k *PubKey, rounds int, tildes,
+	tildesG []*big.Int) *big.Int {
+	t := newTranscript(keyProofLabel, k)
+	t.appendList(keyProofBases(k))
+	group := k.PedersenParams.Group
+	t.append(group.P, group.G, group.Q, k.PedersenParams.H)
+	t.append(k.N1, k.G, k.H)
+	t.appendUint(uint64(rounds))
+	t.appendList(tildes)
+	t.appendList(tildesG)
+
+	return t.challenge()
+}
+
+// newKeyProof proves that key k was generated correctly, knowing the
+// discrete logarithms td. The proof has secParam rounds, but at least
+// minKeyProofRounds. Random values are chosen from
+// {0,1}^(|N| + secParam), so that the responses, which are computed
+// in Z, do not reveal the logarithms.
+func newKeyProof(k *PubKey, td *keyTrapdoor, secParam int) *KeyProof {
+	rounds := secParam
+	if rounds < minKeyProofRounds {
+		rounds = minKeyProofRounds
+	}
+
+	randoms := make([]*big.Int, rounds*len(td.exps))
+	tildes := make([]*big.Int, len(randoms))
+	for i := range randoms {
+		randoms[i] = getRandomKeyProofInt(k.N, secParam)
+		tildes[i] = new(big.Int).Exp(k.S, randoms[i], k.N)
+	}
+	randomsG := make([]*big.Int, rounds)
+	tildesG := make([]*big.Int, rounds)
+	for j := range randomsG {
+		randomsG[j] = getRandomKeyProofInt(k.N1, secParam)
+		tildesG[j] = new(big.Int).Exp(k.H, randomsG[j], k.N1)
+	}
+
+	c := keyProofChallenge(k, rounds, tildes, tildesG)
+
+	responses := make([]*big.Int, len(randoms))
+	responsesG := make([]*big.Int, rounds)
+	for j := 0; j < rounds; j++ {
+		cj := big.NewInt(int64(c.Bit(j)))
+		for i, x := range td.exps {
+			ind := j*len(td.exps) + i
+			responses[ind] = response(randoms[ind], cj, x)
+		}
+		responsesG[j] = response(randomsG[j], cj, td.alpha)
+	}
+
+	return &KeyProof{
+		Challenge:           c,
+		Responses:           responses,
+		CommitmentResponses: responsesG,
+	}
+}
+
+func getRandomKeyProofInt(n *big.Int, secParam int) *big.Int {
+	b := new(big.Int).Lsh(big.NewInt(1), uint(n.BitLen()+secParam))
+	return common.GetRandomInt(b)
+}
+
+// Verify checks that k is well-formed and that its Proof proves that
+// Z, all R_i and the bases of the revocation accumulator lie in the
+// subgroup generated by S, and that G lies in the subgroup generated
+// by H. Clients should verify public keys of issuers before they
+// use them.
+func (k *PubKey) Verify() error {
+	if err := k.checkValues(); err != nil {
+		return err
+	}
+	if err := checkPedersenParams(k); err != nil {
+		return err
+	}
+	if k.Proof == nil {
+		return fmt.Errorf("public key has no proof of correctness")
+	}
+
+	p := k.Proof
+	bases := keyProofBases(k)
+	rounds := len(p.CommitmentResponses)
+	if p.Challenge == nil || rounds < minKeyProofRounds ||
+		rounds > hashBitLen || len(p.Responses) != rounds*len(bases) {
+		return fmt.Errorf("proof of correctness of public key is malformed")
+	}
+
+	tildes := make([]*big.Int, len(p.Responses))
+	tildesG := make([]*big.Int, rounds)
+	for j := 0; j < rounds; j++ {
+		cj := big.NewInt(int64(p.Challenge.Bit(j)))
+		for i, b := range bases {
+			ind := j*len(bases) + i
+			t, err := keyProofTilde(k.N, k.S, b, p.Responses[ind], cj)
+			if err != nil {
+				return err
+			}
+			tildes[ind] = t
+		}
+		t, err := keyProofTilde(k.N1, k.H, k.G, p.CommitmentResponses[j],
+			cj)
+		if err != nil {
+			return err
+		}
+		tildesG[j] = t
+	}
+
+	if keyProofChallenge(k, rounds, tildes, tildesG).Cmp(p.Challenge) != 0 {
+		return fmt.Errorf("proof of correctness of public key is invalid")
+	}
+
+	return nil
+}
+
+// keyProofTilde computes base^resp * x^(-c) mod n, which equals the
+// random data of the proof of the discrete logarithm of x to base
+// base, when the proof is valid.
+func keyProofTilde(n, base, x, resp, c *big.Int) (*big.Int, error) {
+	if resp == nil || resp.Sign() < 0 {
+		return nil, fmt.Errorf("proof of correctness of public key is" +
+			" malformed")
+	}
+	xInv := new(big.Int).ModInverse(x, n)
+	if xInv == nil {
+		return nil, fmt.Errorf("public key value is not invertible")
+	}
+	t := new(big.Int).Exp(base, resp, n)
+	t.Mul(t, new(big.Int).Exp(xInv, c, n))
+
+	return t.Mod(t, n), nil
+}
+
+// checkValues checks that the values of k are elements of the groups
+// they belong to.
+func (k *PubKey) checkValues() error {
+	for _, n := range []*big.Int{k.N, k.N1} {
+		if n == nil || n.Sign() <= 0 || n.Bit(0) == 0 {
+			return fmt.Errorf("public key has an invalid modulus")
+		}
+	}
+
+	one := big.NewInt(1)
+	check := func(n *big.Int, xs ...*big.Int) error {
+		for _, x := range xs {
+			if x == nil || x.Cmp(one) <= 0 || x.Cmp(n) >= 0 ||
+				new(big.Int).GCD(nil, nil, x, n).Cmp(one) != 0 {
+				return fmt.Errorf("public key holds an invalid group element")
+			}
+		}
+		return nil
+	}
+	err := check(k.N, append([]*big.Int{k.S}, keyProofBases(k)...)...)
+	if err != nil {
+		return err
+	}
+
+	return check(k.N1, k.G, k.H)
+}
+
+// checkPedersenParams checks that the Pedersen parameters of k define
+// a subgroup of prime order Q of Z_P^*, and that both of its
+// generators lie in it.
+func checkPedersenParams(k *PubKey) error {
+	if k.PedersenParams == nil || k.PedersenParams.Group == nil {
+		return fmt.Errorf("public key has no Pedersen parameters")
+	}
+	group := k.PedersenParams.Group
+	P, Q := group.P, group.Q
+	if P == nil || Q == nil || !P.ProbablyPrime(20) || !Q.ProbablyPrime(20) {
+		return fmt.Errorf("Pedersen group modulus and order need to be prime")
+	}
+
+	one := big.NewInt(1)
+	pMinusOne := new(big.Int).Sub(P, one)
+	if new(big.Int).Mod(pMinusOne, Q).Sign() != 0 {
+		return fmt.Errorf("Pedersen group order does not divide P-1")
+	}
+	for _, g := range []*big.Int{group.G, k.PedersenParams.H} {
+		if g == nil || g.Cmp(one) <= 0 || g.Cmp(pMinusOne) >= 0 ||
+			new(big.Int).Exp(g, Q, P).Cmp(one) != 0 {
+			return fmt.Errorf("Pedersen generators are not of order Q")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyProof(t *testing.T) {
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), NewAttrCount(2, 1, 1))
+	require.NoError(t, err)
+	require.NoError(t, keys.Pub.Verify())
+
+	// a value outside of the subgroup generated by S, that the issuer
+	// could use to tell users apart
+	group, err := qr.NewRSASpecialFromParams(keys.Sec.RsaPrimes)
+	require.NoError(t, err)
+	outside := new(big.Int).Sub(keys.Pub.N, big.NewInt(1))
+	random := func() *big.Int {
+		return group.Exp(keys.Pub.S, common.GetRandomInt(group.Order))
+	}
+
+	tests := []struct {
+		desc   string
+		tamper func(k *PubKey)
+	}{
+		{"NoProof", func(k *PubKey) { k.Proof = nil }},
+		{"Z", func(k *PubKey) { k.Z = random() }},
+		{"ZOutside", func(k *PubKey) { k.Z = outside }},
+		{"RKnown", func(k *PubKey) { k.RsKnown[1] = random() }},
+		{"RCommitted", func(k *PubKey) { k.RsCommitted[0] = outside }},
+		{"RHidden", func(k *PubKey) { k.RsHidden[0] = random() }},
+		{"MissingR", func(k *PubKey) { k.RsHidden = nil }},
+		{"AccG", func(k *PubKey) { k.AccG = random() }},
+		{"G", func(k *PubKey) { k.G = new(big.Int).Sub(k.N1, big.NewInt(1)) }},
+		{"H", func(k *PubKey) { k.H = new(big.Int).Mul(k.H, k.H) }},
+		{"NotInvertible", func(k *PubKey) { k.Z = keys.Sec.RsaPrimes.P }},
+		{"Response", func(k *PubKey) {
+			k.Proof.Responses[0] = new(big.Int).Add(k.Proof.Responses[0],
+				big.NewInt(1))
+		}},
+		{"Rounds", func(k *PubKey) {
+			n := len(keyProofBases(k))
+			k.Proof.Responses = k.Proof.Responses[:n]
+			k.Proof.CommitmentResponses = k.Proof.CommitmentResponses[:1]
+		}},
+		{"PedersenH", func(k *PubKey) {
+			k.PedersenParams.H = new(big.Int).Sub(k.PedersenParams.Group.P,
+				big.NewInt(1))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			k := copyPubKey(keys.Pub)
+			tt.tamper(k)
+			assert.Error(t, k.Verify())
+		})
+	}
+}
+
+func TestKeyProof_SmallOrder(t *testing.T) {
+	params := GetDefaultParamSizes()
+	keys, err := GenerateKeyPair(params, NewAttrCount(1, 1, 1))
+	require.NoError(t, err)
+	k := copyPubKey(keys.Pub)
+	td := newTestKeyTrapdoor(t, k, keys.Sec)
+	k.Proof = newKeyProof(k, td, int(params.SecParam))
+	require.NoError(t, k.Verify())
+
+	// -Z is Z times -1, an element of order 2 outside of the subgroup
+	// generated by S. A proof built with the logarithm of Z would pass
+	// a single challenge whenever it is even, so the issuer would
+	// repeat the proof until it is.
+	k.Z = new(big.Int).Sub(k.N, k.Z)
+	for i := 0; i < 5; i++ {
+		k.Proof = newKeyProof(k, td, int(params.SecParam))
+		for k.Proof.Challenge.Bit(0) != 0 {
+			k.Proof = newKeyProof(k, td, int(params.SecParam))
+		}
+		assert.Error(t, k.Verify())
+	}
+}
+
+// newTestKeyTrapdoor replaces the values of k that KeyProof is about
+// with random powers of S and H, and returns their logarithms, so that
+// tests can build proofs for modified keys.
+func newTestKeyTrapdoor(t *testing.T, k *PubKey, sec *SecKey) *keyTrapdoor {
+	group, err := qr.NewRSASpecialFromParams(sec.RsaPrimes)
+	require.NoError(t, err)
+
+	exps := make([]*big.Int, len(keyProofBases(k)))
+	vals := make([]*big.Int, len(exps))
+	for i := range exps {
+		exps[i] = common.GetRandomInt(group.Order)
+		vals[i] = group.Exp(k.S, exps[i])
+	}
+	k.Z, vals = vals[0], vals[1:]
+	for _, rs := range [][]*big.Int{k.RsKnown, k.RsCommitted, k.RsHidden} {
+		copy(rs, vals)
+		vals = vals[len(rs):]
+	}
+	if k.SupportsRevocation() {
+		k.AccInit, k.AccG, k.AccH = vals[0], vals[1], vals[2]
+	}
+
+	alpha := common.GetRandomInt(k.N1)
+	k.G = new(big.Int).Exp(k.H, alpha, k.N1)
+
+	return &keyTrapdoor{exps: exps, alpha: alpha}
+}
+
+// copyPubKey returns a copy of k that can be modified without
+// affecting k.
+func TestKeyProof_Schema(t *testing.T) {
+	schema, err := DecodeSchema([]byte(testIssuerSchema))
+	require.NoError(t, err)
+	cs := schema.CredStructure()
+	keys, err := GenerateKeyPairForSchema(GetDefaultParamSizes(), cs)
+	require.NoError(t, err)
+	require.NoError(t, keys.Pub.Verify())
+	assert.Equal(t, cs, keys.Schema)
+	assert.NoError(t, keys.Pub.CheckSchema(cs))
+
+	// schema that is presented to clients instead of the one of the key
+	tampered := schema.CredStructure()
+	tampered.Attributes[1].GetSignedIntAttr().Attr.Name = "age"
+	assert.Error(t, keys.Pub.CheckSchema(tampered))
+	assert.Error(t, keys.Pub.CheckSchema(nil))
+
+	// the hash cannot be replaced or removed, as the proof of the key
+	// commits to it
+	h, err := SchemaHash(tampered)
+	require.NoError(t, err)
+	for _, schemaHash := range [][]byte{h, nil} {
+		k := copyPubKey(keys.Pub)
+		k.SchemaHash = schemaHash
+		assert.Error(t, k.Verify())
+		assert.NotEqual(t, keys.Pub.ID(), k.ID())
+		assert.NotEqual(t, keys.Pub.GetContext(), k.GetContext())
+	}
+
+	// next keys are bound to the same schema
+	next, err := GenerateNextKeyPair(keys.Params, schema.Count, keys.Pub)
+	require.NoError(t, err)
+	require.NoError(t, next.Pub.Verify())
+	assert.NoError(t, next.Pub.CheckSchema(cs))
+
+	// any schema matches keys that are not bound to a schema
+	legacy, err := GenerateKeyPair(GetDefaultParamSizes(), schema.Count)
+	require.NoError(t, err)
+	assert.NoError(t, legacy.Pub.CheckSchema(tampered))
+}
+
+func copyPubKey(k *PubKey) *PubKey {
+	c := *k
+	c.RsKnown = append([]*big.Int{}, k.RsKnown...)
+	c.RsCommitted = append([]*big.Int{}, k.RsCommitted...)
+	c.RsHidden = append([]*big.Int{}, k.RsHidden...)
+	pp := *k.PedersenParams
+	c.PedersenParams = &pp
+	proof := *k.Proof
+	proof.Responses = append([]*big.Int{}, k.Proof.Responses...)
+	proof.CommitmentResponses = append([]*big.Int{},
+		k.Proof.CommitmentResponses...)
+	c.Proof = &proof
+
+	return &c
+}
@@ -0,0 +1,393 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/pedersen"
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/emmyzkp/crypto/schnorr"
+	"github.com/emmyzkp/emmy/anauth"
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// keyFileScheme identifies CL keys in key files.
+const keyFileScheme = "cl"
+
+// ID returns the key ID of the key pair with public key k, computed
+// from all the values of k (see anauth.KeyID).
+func (k *PubKey) ID() string {
+	values := []*big.Int{k.N, k.S, k.Z}
+	for _, rs := range [][]*big.Int{k.RsKnown, k.RsCommitted, k.RsHidden} {
+		values = append(values, big.NewInt(int64(len(rs))))
+		values = append(values, rs...)
+	}
+	group := k.PedersenParams.Group
+	values = append(values, group.P, group.G, group.Q, k.PedersenParams.H,
+		k.N1, k.G, k.H, k.AccInit, k.AccG, k.AccH)
+	// IDs of keys that are not bound to a schema are kept
+	if k.SchemaHash != nil {
+		values = append(values, new(big.Int).SetBytes(k.SchemaHash))
+	}
+
+	return anauth.KeyID(keyFileScheme, values...)
+}
+
+// ParseSchema reads the schema file cl_schema or parses the attributes
+// specification from configuration v, and returns the schema of
+// credentials, which can be stored with the keys (see KeyPair).
+func ParseSchema(v *viper.Viper) (*pb.CredStructure, *AttrCount, error) {
+	schema, err := schemaFromConfig(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schema.CredStructure(), schema.Count, nil
+}
+
+// pubKeyJSON is the encoding of PubKey in key files.
+type pubKeyJSON struct {
+	N           anauth.Int   `json:"n"`
+	S           anauth.Int   `json:"s"`
+	Z           anauth.Int   `json:"z"`
+	RsKnown     []anauth.Int `json:"rs_known"`
+	RsCommitted []anauth.Int `json:"rs_committed"`
+	RsHidden    []anauth.Int `json:"rs_hidden"`
+	Pedersen    struct {
+		P anauth.Int `json:"p"`
+		G anauth.Int `json:"g"`
+		Q anauth.Int `json:"q"`
+		H anauth.Int `json:"h"`
+	} `json:"pedersen"`
+	N1         anauth.Int    `json:"n1"`
+	G          anauth.Int    `json:"g"`
+	H          anauth.Int    `json:"h"`
+	AccInit    anauth.Int    `json:"acc_init"`
+	AccG       anauth.Int    `json:"acc_g"`
+	AccH       anauth.Int    `json:"acc_h"`
+	SchemaHash []byte        `json:"schema_hash,omitempty"`
+	Proof      *keyProofJSON `json:"proof,omitempty"`
+}
+
+// keyProofJSON is the encoding of KeyProof in key files.
+type keyProofJSON struct {
+	Challenge           anauth.Int   `json:"challenge"`
+	Responses           []anauth.Int `json:"responses"`
+	CommitmentResponses []anauth.Int `json:"commitment_responses"`
+}
+
+func newPubKeyJSON(k *PubKey) *pubKeyJSON {
+	j := &pubKeyJSON{
+		N:           anauth.NewInt(k.N),
+		S:           anauth.NewInt(k.S),
+		Z:           anauth.NewInt(k.Z),
+		RsKnown:     anauth.NewInts(k.RsKnown),
+		RsCommitted: anauth.NewInts(k.RsCommitted),
+		RsHidden:    anauth.NewInts(k.RsHidden),
+		N1:          anauth.NewInt(k.N1),
+		G:           anauth.NewInt(k.G),
+		H:           anauth.NewInt(k.H),
+		AccInit:     anauth.NewInt(k.AccInit),
+		AccG:        anauth.NewInt(k.AccG),
+		AccH:        anauth.NewInt(k.AccH),
+		SchemaHash:  k.SchemaHash,
+	}
+	group := k.PedersenParams.Group
+	j.Pedersen.P = anauth.NewInt(group.P)
+	j.Pedersen.G = anauth.NewInt(group.G)
+	j.Pedersen.Q = anauth.NewInt(group.Q)
+	j.Pedersen.H = anauth.NewInt(k.PedersenParams.H)
+	if k.Proof != nil {
+		j.Proof = &keyProofJSON{
+			Challenge:           anauth.NewInt(k.Proof.Challenge),
+			Responses:           anauth.NewInts(k.Proof.Responses),
+			CommitmentResponses: anauth.NewInts(k.Proof.CommitmentResponses),
+		}
+	}
+
+	return j
+}
+
+func (j *pubKeyJSON) pubKey() (*PubKey, error) {
+	for _, x := range []anauth.Int{j.N, j.S, j.Z, j.N1, j.G, j.H,
+		j.Pedersen.P, j.Pedersen.G, j.Pedersen.Q, j.Pedersen.H} {
+		if x.Int == nil {
+			return nil, errors.New("public key is missing values")
+		}
+	}
+
+	pk := &PubKey{
+		N:           j.N.Int,
+		S:           j.S.Int,
+		Z:           j.Z.Int,
+		RsKnown:     anauth.BigInts(j.RsKnown),
+		RsCommitted: anauth.BigInts(j.RsCommitted),
+		RsHidden:    anauth.BigInts(j.RsHidden),
+		PedersenParams: pedersen.NewParams(
+			schnorr.NewGroupFromParams(j.Pedersen.P.Int, j.Pedersen.G.Int,
+				j.Pedersen.Q.Int),
+			j.Pedersen.H.Int, nil),
+		N1:         j.N1.Int,
+		G:          j.G.Int,
+		H:          j.H.Int,
+		AccInit:    j.AccInit.Int,
+		AccG:       j.AccG.Int,
+		AccH:       j.AccH.Int,
+		SchemaHash: j.SchemaHash,
+	}
+	if j.Proof != nil {
+		pk.Proof = &KeyProof{
+			Challenge:           j.Proof.Challenge.Int,
+			Responses:           anauth.BigInts(j.Proof.Responses),
+			CommitmentResponses: anauth.BigInts(j.Proof.CommitmentResponses),
+		}
+	}
+
+	return pk, nil
+}
+
+// primesJSON is the encoding of qr.RSASpecialPrimes in key files.
+type primesJSON struct {
+	P  anauth.Int `json:"p"`
+	Q  anauth.Int `json:"q"`
+	P1 anauth.Int `json:"p1"`
+	Q1 anauth.Int `json:"q1"`
+}
+
+func newPrimesJSON(p *qr.RSASpecialPrimes) *primesJSON {
+	return &primesJSON{
+		P:  anauth.NewInt(p.P),
+		Q:  anauth.NewInt(p.Q),
+		P1: anauth.NewInt(p.P1),
+		Q1: anauth.NewInt(p.Q1),
+	}
+}
+
+func (j *primesJSON) primes() (*qr.RSASpecialPrimes, error) {
+	if j == nil || j.P.Int == nil || j.Q.Int == nil || j.P1.Int == nil ||
+		j.Q1.Int == nil {
+		return nil, errors.New("secret key is missing primes")
+	}
+
+	return qr.NewRSASpecialPrimes(j.P.Int, j.Q.Int, j.P1.Int, j.Q1.Int), nil
+}
+
+// secKeyJSON is the encoding of SecKey in key files.
+type secKeyJSON struct {
+	RsaPrimes                  *primesJSON `json:"rsa_primes"`
+	AttributesSpecialRSAPrimes *primesJSON `json:"attributes_rsa_primes"`
+}
+
+// newKeyFile returns a key file of type keyType holding key, with
+// the key ID and parameters of keys.
+func newKeyFile(keys *KeyPair, keyType string,
+	key interface{}) (*anauth.KeyFile, error) {
+	f, err := anauth.NewKeyFile(keyFileScheme, keyType, keys.Pub.ID(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	m := jsonpb.Marshaler{OrigName: true}
+	if keys.Params != nil {
+		params, err := m.MarshalToString(keys.Params)
+		if err != nil {
+			return nil, errors.Wrap(err, "error encoding parameters")
+		}
+		f.Params = json.RawMessage(params)
+	}
+	if keys.Schema != nil && keyType == anauth.PublicKeyType {
+		schema, err := m.MarshalToString(keys.Schema)
+		if err != nil {
+			return nil, errors.Wrap(err, "error encoding schema")
+		}
+		f.Schema = json.RawMessage(schema)
+	}
+	if !keys.RetiredAt.IsZero() && keyType == anauth.PublicKeyType {
+		retiredAt := keys.RetiredAt.UTC()
+		f.RetiredAt = &retiredAt
+	}
+
+	return f, nil
+}
+
+// WritePubKey writes the public key of keys to path, together with
+// the parameters, the schema and the time of retirement of keys
+// (see anauth.KeyFile).
+func WritePubKey(path string, keys *KeyPair) error {
+	f, err := newKeyFile(keys, anauth.PublicKeyType, newPubKeyJSON(keys.Pub))
+	if err != nil {
+		return err
+	}
+
+	return anauth.WriteKeyFile(path, f, 0644)
+}
+
+// WriteSecKey writes the secret key of keys to path, together with
+// the parameters of keys (see anauth.KeyFile). Unless passphrase is
+// nil, the key is encrypted with it. The file is only readable by its
+// owner.
+func WriteSecKey(path string, keys *KeyPair, passphrase []byte) error {
+	f, err := newKeyFile(keys, anauth.SecretKeyType, &secKeyJSON{
+		RsaPrimes: newPrimesJSON(keys.Sec.RsaPrimes),
+		AttributesSpecialRSAPrimes: newPrimesJSON(
+			keys.Sec.AttributesSpecialRSAPrimes),
+	})
+	if err != nil {
+		return err
+	}
+
+	return anauth.WriteSecretKeyFile(path, f, passphrase)
+}
+
+// readKeyFile reads a key file of type keyType from path, decoding
+// the key, decrypted with passphrase if needed, into key and the
+// parameters into the returned pb.Params. Files in the legacy format
+// are decoded as gob into legacy instead, in which case the returned
+// key file is nil.
+func readKeyFile(path, keyType string, key, legacy interface{},
+	passphrase anauth.PassphraseFunc) (*anauth.KeyFile, *pb.Params, error) {
+	f, data, err := anauth.ReadKeyFile(path)
+	if err == anauth.ErrLegacyKeyFile {
+		return nil, nil, errors.Wrap(anauth.DecodeLegacyKey(data, legacy), path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = f.Decode(keyFileScheme, keyType, key, nil, passphrase)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, path)
+	}
+
+	var params *pb.Params
+	if len(f.Params) > 0 {
+		params = new(pb.Params)
+		if err := jsonpb.UnmarshalString(string(f.Params), params); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid parameters in %s", path)
+		}
+	}
+
+	return f, params, nil
+}
+
+// ReadPubKey reads a public key written by WritePubKey from path,
+// or a public key stored as gob by older versions. The returned
+// KeyPair holds no secret key, and its Params and Schema are nil if
+// they are not stored with the key.
+func ReadPubKey(path string) (*KeyPair, error) {
+	j := new(pubKeyJSON)
+	legacy := new(PubKey)
+	f, params, err := readKeyFile(path, anauth.PublicKeyType, j, legacy, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return &KeyPair{Pub: legacy}, nil
+	}
+
+	pk, err := j.pubKey()
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+	if f.KeyID != pk.ID() {
+		return nil, errors.Errorf("key ID in %s does not match the key", path)
+	}
+
+	keys := &KeyPair{
+		Pub:    pk,
+		Params: params,
+	}
+	if f.RetiredAt != nil {
+		keys.RetiredAt = *f.RetiredAt
+	}
+	if len(f.Schema) > 0 {
+		keys.Schema = new(pb.CredStructure)
+		err := jsonpb.UnmarshalString(string(f.Schema), keys.Schema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schema in %s", path)
+		}
+	}
+	if err := pk.CheckSchema(keys.Schema); err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	return keys, nil
+}
+
+// ReadKeyPair reads the public key from pubKeyPath and the secret
+// key from secKeyPath (see ReadPubKey), and checks that they form
+// a key pair. An encrypted secret key is decrypted with the
+// passphrase returned by passphrase, which can be nil for keys that are
+// not encrypted.
+func ReadKeyPair(pubKeyPath, secKeyPath string,
+	passphrase anauth.PassphraseFunc) (*KeyPair, error) {
+	keys, err := ReadPubKey(pubKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	j := new(secKeyJSON)
+	keys.Sec = new(SecKey)
+	f, params, err := readKeyFile(secKeyPath, anauth.SecretKeyType, j,
+		keys.Sec, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if f != nil {
+		if f.KeyID != keys.Pub.ID() {
+			return nil, errors.Errorf("secret key %s is not the key of"+
+				" public key %s", f.KeyID, keys.Pub.ID())
+		}
+		if params != nil {
+			if keys.Params != nil && !proto.Equal(params, keys.Params) {
+				return nil, errors.New("secret and public key are stored" +
+					" with different parameters")
+			}
+			keys.Params = params
+		}
+		if keys.Sec.RsaPrimes, err = j.RsaPrimes.primes(); err != nil {
+			return nil, err
+		}
+		keys.Sec.AttributesSpecialRSAPrimes, err =
+			j.AttributesSpecialRSAPrimes.primes()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sk := keys.Sec
+	if sk.RsaPrimes == nil || sk.AttributesSpecialRSAPrimes == nil ||
+		!isModulus(sk.RsaPrimes, keys.Pub.N) ||
+		!isModulus(sk.AttributesSpecialRSAPrimes, keys.Pub.N1) {
+		return nil, errors.New("secret key does not match the public key")
+	}
+
+	return keys, nil
+}
+
+// isModulus reports whether n is the product of primes p.
+func isModulus(p *qr.RSASpecialPrimes, n *big.Int) bool {
+	return new(big.Int).Mul(p.P, p.Q).Cmp(n) == 0
+}
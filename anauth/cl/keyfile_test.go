@@ -0,0 +1,189 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/emmyzkp/emmy/anauth"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKeys(t *testing.T, dir string, keys *KeyPair) (string, string) {
+	pubPath, secPath := path.Join(dir, "cl_pubkey"), path.Join(dir, "cl_seckey")
+	require.NoError(t, WritePubKey(pubPath, keys))
+	require.NoError(t, WriteSecKey(secPath, keys, nil))
+
+	return pubPath, secPath
+}
+
+func TestKeyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), NewAttrCount(2, 1, 0))
+	require.NoError(t, err)
+	keys.Schema = newCredStructure([]CredAttr{
+		NewEmptyStrAttr("name", true),
+		NewEmptyInt64Attr("age", true),
+		NewEmptyInt64Attr("zip", false),
+	}, NewAttrCount(2, 1, 0))
+
+	pubPath, secPath := writeTestKeys(t, dir, keys)
+
+	info, err := os.Stat(secPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	read, err := ReadKeyPair(pubPath, secPath, nil)
+	require.NoError(t, err)
+	assert.Equal(t, keys.Pub.ID(), read.Pub.ID())
+	assert.Equal(t, keys.Pub.N, read.Pub.N)
+	assert.Equal(t, keys.Pub.RsCommitted, read.Pub.RsCommitted)
+	assert.Equal(t, keys.Pub.PedersenParams.Group, read.Pub.PedersenParams.Group)
+	assert.Equal(t, keys.Sec, read.Sec)
+	assert.Equal(t, keys.Params.String(), read.Params.String())
+	assert.Equal(t, keys.Schema.String(), read.Schema.String())
+	assert.Equal(t, keys.Pub.Proof, read.Pub.Proof)
+	assert.NoError(t, read.Pub.Verify())
+
+	pub, err := ReadPubKey(pubPath)
+	require.NoError(t, err)
+	assert.Nil(t, pub.Sec)
+	assert.Equal(t, keys.Pub.ID(), pub.Pub.ID())
+	assert.True(t, pub.RetiredAt.IsZero())
+
+	pub.RetiredAt = time.Date(2018, 11, 20, 12, 0, 0, 0, time.UTC)
+	retiredPath := path.Join(dir, "cl_pubkey.retired")
+	require.NoError(t, WritePubKey(retiredPath, pub))
+	retired, err := ReadPubKey(retiredPath)
+	require.NoError(t, err)
+	assert.True(t, pub.RetiredAt.Equal(retired.RetiredAt))
+	assert.Equal(t, keys.Pub.ID(), retired.Pub.ID())
+
+	_, err = ReadPubKey(secPath)
+	assert.Error(t, err, "secret key read as public key")
+
+	// secret key of another key pair
+	other, err := GenerateKeyPair(GetDefaultParamSizes(), NewAttrCount(2, 1, 0))
+	require.NoError(t, err)
+	otherDir, err := ioutil.TempDir("", "emmy-cl")
+	require.NoError(t, err)
+	defer os.RemoveAll(otherDir)
+	_, otherSecPath := writeTestKeys(t, otherDir, other)
+
+	_, err = ReadKeyPair(pubPath, otherSecPath, nil)
+	assert.Error(t, err)
+}
+
+func TestKeyFiles_Schema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schema, err := DecodeSchema([]byte(testIssuerSchema))
+	require.NoError(t, err)
+	keys, err := GenerateKeyPairForSchema(GetDefaultParamSizes(),
+		schema.CredStructure())
+	require.NoError(t, err)
+	pubPath, _ := writeTestKeys(t, dir, keys)
+
+	pub, err := ReadPubKey(pubPath)
+	require.NoError(t, err)
+	assert.Equal(t, keys.Pub.SchemaHash, pub.Pub.SchemaHash)
+	assert.Equal(t, keys.Pub.ID(), pub.Pub.ID())
+	assert.NoError(t, pub.Pub.Verify())
+
+	// key stored with a schema other than the one it is bound to
+	pub.Schema.Name = "transcript"
+	require.NoError(t, WritePubKey(pubPath, pub))
+	_, err = ReadPubKey(pubPath)
+	assert.Error(t, err)
+}
+
+func TestKeyFiles_Legacy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keys, err := generateKeyPair(GetLegacyParamSizes(), NewAttrCount(1, 0, 1),
+		nil, nil)
+	require.NoError(t, err)
+	pubPath, secPath := path.Join(dir, "cl_pubkey"), path.Join(dir, "cl_seckey")
+	require.NoError(t, WriteGob(pubPath, keys.Pub))
+	require.NoError(t, WriteGob(secPath, keys.Sec))
+
+	read, err := ReadKeyPair(pubPath, secPath, nil)
+	require.NoError(t, err)
+	assert.Equal(t, keys.Pub.ID(), read.Pub.ID())
+	assert.Equal(t, keys.Sec, read.Sec)
+	assert.Nil(t, read.Params)
+	assert.Nil(t, read.Schema)
+
+	org, err := LoadOrg(pubPath, secPath, nil)
+	require.NoError(t, err)
+	assert.Equal(t, GetLegacyParamSizes(), org.Params)
+
+	// legacy secret key of another key pair
+	other, err := GenerateKeyPair(GetDefaultParamSizes(), NewAttrCount(1, 0, 1))
+	require.NoError(t, err)
+	require.NoError(t, WriteGob(secPath, other.Sec))
+	_, err = ReadKeyPair(pubPath, secPath, nil)
+	assert.Error(t, err)
+}
+
+func TestKeyFiles_Encrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), NewAttrCount(1, 0, 0))
+	require.NoError(t, err)
+	pubPath, secPath := path.Join(dir, "cl_pubkey"), path.Join(dir, "cl_seckey")
+	require.NoError(t, WritePubKey(pubPath, keys))
+	require.NoError(t, WriteSecKey(secPath, keys, []byte("passphrase")))
+
+	data, err := ioutil.ReadFile(secPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), keys.Sec.RsaPrimes.P.Text(16))
+
+	_, err = ReadKeyPair(pubPath, secPath, nil)
+	assert.Equal(t, anauth.ErrPassphraseRequired, errors.Cause(err))
+
+	_, err = ReadKeyPair(pubPath, secPath, func() ([]byte, error) {
+		return []byte("wrong"), nil
+	})
+	assert.Equal(t, anauth.ErrWrongPassphrase, errors.Cause(err))
+
+	read, err := ReadKeyPair(pubPath, secPath, func() ([]byte, error) {
+		return []byte("passphrase"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, keys.Sec, read.Sec)
+
+	// passphrase is not needed for the public key
+	_, err = ReadPubKey(pubPath)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// Extensions of files in directories of trusted issuers (see
+// ReadKeyring).
+const (
+	trustedKeyExt    = ".pub"
+	trustedSchemaExt = ".yml"
+	trustedAccExt    = ".acc"
+)
+
+// TrustedIssuer is an issuer whose credentials a verifier accepts.
+type TrustedIssuer struct {
+	Name   string
+	Params *pb.Params
+	PubKey *PubKey
+	// Attrs are the attributes of credentials of the issuer ordered by
+	// index, with the conditions that the verifier checks
+	Attrs []CredAttr
+	// Schema describes the credentials of the issuer together with
+	// the name and version of their schema, it is nil if these are not
+	// known
+	Schema *pb.CredStructure
+	// RetiredAt is the time the issuer replaced PubKey with newer keys,
+	// it is zero for keys that are in use
+	RetiredAt time.Time
+	// Accumulators holds the updates of the revocation accumulator
+	// published by the issuer, it is nil if they are not available
+	Accumulators AccumulatorStore
+	org          *Org
+}
+
+// credStructure describes the credentials of the issuer to clients.
+func (i *TrustedIssuer) credStructure() *pb.CredStructure {
+	if i.Schema != nil {
+		return i.Schema
+	}
+	pk := i.PubKey
+	count := NewAttrCount(len(pk.RsKnown), len(pk.RsCommitted),
+		len(pk.RsHidden))
+
+	return newCredStructure(i.Attrs, count)
+}
+
+// Accumulator returns the accumulator that credentials of the issuer
+// have to be proved to be in, or nil if PubKey does not support
+// revocation. It returns an error if the key supports revocation but
+// the accumulator of the issuer is not available, as revoked
+// credentials could not be told apart otherwise.
+func (i *TrustedIssuer) Accumulator() (*Accumulator, error) {
+	if !i.PubKey.SupportsRevocation() {
+		return nil, nil
+	}
+	if i.Accumulators == nil {
+		return nil, fmt.Errorf("accumulator of issuer %s is not"+
+			" available", i.Name)
+	}
+
+	return currentAccumulator(i.Accumulators, i.PubKey)
+}
+
+// Keyring holds the public keys of trusted issuers. It is safe for
+// concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	issuers map[string]*TrustedIssuer // by key ID
+	// credentials issued with retired keys are accepted for
+	// gracePeriod after the keys were retired
+	gracePeriod time.Duration
+}
+
+// NewKeyring creates an empty keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{
+		issuers: make(map[string]*TrustedIssuer),
+	}
+}
+
+// Add adds public key pubKey of the issuer with the given name to
+// the keyring. Credentials issued with pubKey have attributes attrs,
+// ordered by index. The key is only added if its proof of correctness
+// is valid and it matches params and attrs. Keys bound to a schema
+// (see PubKey.SchemaHash) are added with ReadKeyring, which reads the
+// schema stored with them.
+func (k *Keyring) Add(name string, params *pb.Params, pubKey *PubKey,
+	attrs []CredAttr) error {
+	issuer, err := newTrustedIssuer(name, params, pubKey, attrs, nil)
+	if err != nil {
+		return err
+	}
+	k.add(issuer)
+
+	return nil
+}
+
+// newTrustedIssuer checks the keys of the issuer with the given name
+// and returns the issuer (see Keyring.Add), with schema cs unless it is
+// nil.
+func newTrustedIssuer(name string, params *pb.Params, pubKey *PubKey,
+	attrs []CredAttr, cs *pb.CredStructure) (*TrustedIssuer, error) {
+	if err := checkParams(params); err != nil {
+		return nil, err
+	}
+	if err := pubKey.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid public key of issuer %s: %s", name,
+			err)
+	}
+
+	count := NewAttrCount(0, 0, 0)
+	for i, a := range attrs {
+		if a.getIndex() != i {
+			return nil, fmt.Errorf("attributes are not ordered by index")
+		}
+		switch {
+		case a.isKnown():
+			count.Known++
+		case a.isHidden():
+			count.Hidden++
+		default:
+			count.Committed++
+		}
+	}
+	if count.Known != len(pubKey.RsKnown) ||
+		count.Committed != len(pubKey.RsCommitted) ||
+		count.Hidden != len(pubKey.RsHidden) {
+		return nil, fmt.Errorf("attributes of issuer %s do not match its"+
+			" public key", name)
+	}
+	if err := validateAttrBitLen(attrs, params.AttrBitLen); err != nil {
+		return nil, err
+	}
+
+	org, err := NewOrgFromParams(params, &KeyPair{Pub: pubKey})
+	if err != nil {
+		return nil, err
+	}
+	issuer := &TrustedIssuer{
+		Name:   name,
+		Params: params,
+		PubKey: pubKey,
+		Attrs:  attrs,
+		Schema: cs,
+		org:    org,
+	}
+	if err := pubKey.CheckSchema(issuer.credStructure()); err != nil {
+		return nil, fmt.Errorf("attributes of issuer %s do not match its"+
+			" public key: %s", name, err)
+	}
+
+	return issuer, nil
+}
+
+// add adds issuer i without checking its keys.
+func (k *Keyring) add(i *TrustedIssuer) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.issuers[i.org.KeyID()] = i
+}
+
+// SetAccumulators sets the store of accumulator updates of the issuer
+// with the public key identified by keyID (see TrustedIssuer).
+func (k *Keyring) SetAccumulators(keyID string,
+	store AccumulatorStore) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i, ok := k.issuers[keyID]
+	if !ok {
+		return fmt.Errorf("unknown key %s", keyID)
+	}
+	// issuers returned by Get are not modified
+	issuer := *i
+	issuer.Accumulators = store
+	k.issuers[keyID] = &issuer
+
+	return nil
+}
+
+// SetGracePeriod sets the period after the keys of an issuer were
+// retired during which credentials issued with them are still
+// accepted. The grace period is zero by default.
+func (k *Keyring) SetGracePeriod(d time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.gracePeriod = d
+}
+
+// Get returns the trusted issuer with the public key identified by
+// keyID, unless the grace period of the key expired.
+func (k *Keyring) Get(keyID string) (*TrustedIssuer, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	i, ok := k.issuers[keyID]
+	if !ok || k.expired(i) {
+		return nil, false
+	}
+
+	return i, true
+}
+
+// Issuers returns the trusted issuers of the keyring, ordered by name,
+// without those whose keys expired.
+func (k *Keyring) Issuers() []*TrustedIssuer {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	issuers := make([]*TrustedIssuer, 0, len(k.issuers))
+	for _, i := range k.issuers {
+		if !k.expired(i) {
+			issuers = append(issuers, i)
+		}
+	}
+	sort.Slice(issuers, func(i, j int) bool {
+		return issuers[i].Name < issuers[j].Name
+	})
+
+	return issuers
+}
+
+// expired reports whether the grace period of the retired keys of
+// issuer i passed. The caller holds k.mu.
+func (k *Keyring) expired(i *TrustedIssuer) bool {
+	return !i.RetiredAt.IsZero() &&
+		time.Now().After(i.RetiredAt.Add(k.gracePeriod))
+}
+
+// ReadKeyring reads the public keys of trusted issuers from directory
+// dir. Public key of an issuer is kept in <name>.pub, as written by
+// WritePubKey, with the parameters and the schema of its credentials.
+// Conditions that the verifier checks for attributes of the issuer are
+// read from schema file <name>.yml (see ReadSchema), whose attributes
+// have to match the schema stored with the key. Without a schema
+// file, no conditions are checked. Updates of the revocation accumulator
+// published by the issuer are read from <name>.acc (see
+// FileAccumulatorStore), credentials of issuers whose keys support
+// revocation are not accepted without it. Keys that were retired by the issuer
+// are only accepted for the grace period of the keyring (see
+// SetGracePeriod).
+func ReadKeyring(dir string) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+trustedKeyExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no keys of trusted issuers in %s", dir)
+	}
+
+	k := NewKeyring()
+	for _, p := range paths {
+		name := strings.TrimSuffix(filepath.Base(p), trustedKeyExt)
+		keys, err := ReadPubKey(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read key of issuer %s",
+				name)
+		}
+		if keys.Params == nil {
+			return nil, fmt.Errorf("key of issuer %s is stored without"+
+				" parameters", name)
+		}
+
+		schema, err := trustedIssuerSchema(keys,
+			filepath.Join(dir, name+trustedSchemaExt))
+		if err != nil {
+			return nil, errors.Wrapf(err, "issuer %s", name)
+		}
+		issuer, err := newTrustedIssuer(name, keys.Params, keys.Pub,
+			schema.Attrs, schema.CredStructure())
+		if err != nil {
+			return nil, err
+		}
+		issuer.RetiredAt = keys.RetiredAt
+		accPath := filepath.Join(dir, name+trustedAccExt)
+		if _, err := os.Stat(accPath); err == nil {
+			issuer.Accumulators = NewFileAccumulatorStore(accPath)
+		}
+		k.add(issuer)
+	}
+
+	return k, nil
+}
+
+// trustedIssuerSchema returns the schema of credentials issued with
+// keys, from the schema file at schemaPath if it exists, or else from
+// the schema stored with keys.
+func trustedIssuerSchema(keys *KeyPair, schemaPath string) (*Schema,
+	error) {
+	if _, err := os.Stat(schemaPath); err == nil {
+		schema, err := ReadSchema(schemaPath)
+		if err != nil {
+			return nil, err
+		}
+		if keys.Schema != nil &&
+			!proto.Equal(keys.Schema, schema.CredStructure()) {
+			return nil, fmt.Errorf("schema file does not match the" +
+				" schema stored with the key")
+		}
+		return schema, nil
+	}
+
+	if keys.Schema == nil {
+		return nil, fmt.Errorf("key is stored without a schema")
+	}
+	return schemaFromCredStructure(keys.Schema)
+}
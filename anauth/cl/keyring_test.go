@@ -0,0 +1,207 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIssuerSchema = `
+name: diploma
+version: 1
+attributes:
+  - name: degree
+    type: string
+    cond: equal
+  - name: year
+    type: int64
+    cond: gte
+  - name: link_secret
+    type: int64
+    disclosure: hidden
+`
+
+func TestReadKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl-trusted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = ReadKeyring(dir)
+	assert.Error(t, err)
+
+	schema, err := DecodeSchema([]byte(testIssuerSchema))
+	require.NoError(t, err)
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), schema.Count)
+	require.NoError(t, err)
+	keys.Schema = schema.CredStructure()
+
+	// keys written by the issuer with its parameters and schema
+	pubPath := path.Join(dir, "university.pub")
+	require.NoError(t, WritePubKey(pubPath, keys))
+
+	k, err := ReadKeyring(dir)
+	require.NoError(t, err)
+	issuer, ok := k.Get(keys.Pub.ID())
+	require.True(t, ok)
+	assert.Equal(t, "university", issuer.Name)
+	require.Len(t, issuer.Attrs, 3)
+	// without a schema file, no conditions are checked
+	for _, a := range issuer.Attrs {
+		assert.Equal(t, none, a.getCond())
+	}
+
+	schemaPath := path.Join(dir, "university.yml")
+	require.NoError(t, ioutil.WriteFile(schemaPath,
+		[]byte(testIssuerSchema), 0644))
+	k, err = ReadKeyring(dir)
+	require.NoError(t, err)
+	issuer, ok = k.Get(keys.Pub.ID())
+	require.True(t, ok)
+	assert.Equal(t, equal, issuer.Attrs[0].getCond())
+	assert.Equal(t, greaterThanOrEqual, issuer.Attrs[1].getCond())
+
+	// schema file that does not match the schema stored with the key
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`
+name: diploma
+version: 1
+attributes:
+  - name: degree
+    type: string
+  - name: year
+    type: string
+  - name: link_secret
+    type: int64
+    disclosure: hidden
+`), 0644))
+	_, err = ReadKeyring(dir)
+	assert.Error(t, err)
+	require.NoError(t, os.Remove(schemaPath))
+
+	// keys stored without parameters
+	legacy := &KeyPair{Pub: keys.Pub, Schema: keys.Schema}
+	require.NoError(t, WritePubKey(path.Join(dir, "legacy.pub"), legacy))
+	_, err = ReadKeyring(dir)
+	assert.Error(t, err)
+}
+
+func TestKeyring_Issuers(t *testing.T) {
+	o := newTestOrg(t)
+	attr := NewEmptyInt64Attr("a", true)
+
+	k := NewKeyring()
+	for _, name := range []string{"b", "a"} {
+		require.NoError(t, k.Add(name, o.Params, o.Keys.Pub,
+			[]CredAttr{attr}))
+	}
+	// issuers are identified by their keys
+	issuers := k.Issuers()
+	require.Len(t, issuers, 1)
+	assert.Equal(t, "a", issuers[0].Name)
+
+	// key with a tampered proof of correctness
+	pk := copyPubKey(o.Keys.Pub)
+	pk.Z = new(big.Int).Add(pk.Z, big.NewInt(1))
+	assert.Error(t, k.Add("c", o.Params, pk, []CredAttr{attr}))
+}
+
+func TestReadKeyring_Retired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl-trusted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schema, err := DecodeSchema([]byte(testIssuerSchema))
+	require.NoError(t, err)
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), schema.Count)
+	require.NoError(t, err)
+	keys.Schema = schema.CredStructure()
+	keys.RetiredAt = time.Now().Add(-time.Hour)
+	require.NoError(t, WritePubKey(path.Join(dir, "university.pub"), keys))
+
+	k, err := ReadKeyring(dir)
+	require.NoError(t, err)
+	// without a grace period, retired keys are not accepted
+	_, ok := k.Get(keys.Pub.ID())
+	assert.False(t, ok)
+	assert.Empty(t, k.Issuers())
+
+	k.SetGracePeriod(2 * time.Hour)
+	issuer, ok := k.Get(keys.Pub.ID())
+	require.True(t, ok)
+	assert.Equal(t, keys.RetiredAt.Unix(), issuer.RetiredAt.Unix())
+	assert.Len(t, k.Issuers(), 1)
+
+	k.SetGracePeriod(30 * time.Minute)
+	_, ok = k.Get(keys.Pub.ID())
+	assert.False(t, ok)
+}
+
+func TestReadKeyring_Accumulators(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl-trusted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	schema, err := DecodeSchema([]byte(testIssuerSchema))
+	require.NoError(t, err)
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), schema.Count)
+	require.NoError(t, err)
+	keys.Schema = schema.CredStructure()
+	require.NoError(t, WritePubKey(path.Join(dir, "university.pub"), keys))
+
+	// key supports revocation, but the accumulator is not available
+	k, err := ReadKeyring(dir)
+	require.NoError(t, err)
+	issuer, ok := k.Get(keys.Pub.ID())
+	require.True(t, ok)
+	_, err = issuer.Accumulator()
+	assert.Error(t, err)
+
+	accPath := path.Join(dir, "university.acc")
+	store := NewFileAccumulatorStore(accPath)
+	u := &AccumulatorUpdate{
+		Version: 1,
+		E:       big.NewInt(65537),
+		Value: new(big.Int).Exp(keys.Pub.AccInit, big.NewInt(65537),
+			keys.Pub.N),
+	}
+	require.NoError(t, store.Append(u))
+	assert.Error(t, store.Append(u))
+
+	k, err = ReadKeyring(dir)
+	require.NoError(t, err)
+	issuer, ok = k.Get(keys.Pub.ID())
+	require.True(t, ok)
+	acc, err := issuer.Accumulator()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), acc.Version)
+	assert.Equal(t, u.Value, acc.Value)
+
+	// updates are read from the file on every access
+	require.NoError(t, ioutil.WriteFile(accPath, []byte("[]"), 0644))
+	acc, err = issuer.Accumulator()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), acc.Version)
+	assert.Equal(t, keys.Pub.AccInit, acc.Value)
+}
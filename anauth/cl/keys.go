@@ -18,7 +18,10 @@
 package cl
 
 import (
+	"bytes"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/emmyzkp/crypto/common"
 	"github.com/emmyzkp/crypto/df"
@@ -32,6 +35,16 @@ import (
 type KeyPair struct {
 	Sec *SecKey
 	Pub *PubKey
+	// Params are the parameters the keys were generated with, they are
+	// nil for keys stored without parameters
+	Params *pb.Params
+	// Schema describes the attributes of credentials issued with the
+	// keys, it is nil if the attributes were not known when the keys
+	// were generated
+	Schema *pb.CredStructure
+	// RetiredAt is the time the keys were replaced by newer keys, it
+	// is zero for keys that are in use (see anauth.RetireKeyFile)
+	RetiredAt time.Time
 }
 
 // SecKey is a secret key for the CL scheme.
@@ -62,36 +75,122 @@ type PubKey struct {
 	N1 *big.Int
 	G  *big.Int
 	H  *big.Int
+	// the fields below are for the revocation accumulator (see
+	// Accumulator), they are nil in keys that do not support revocation
+	AccInit *big.Int // initial value of the accumulator
+	AccG    *big.Int
+	AccH    *big.Int
+	// SchemaHash is the hash of the schema of credentials issued with
+	// the key (see SchemaHash). It is part of the context of the key,
+	// so the proof of the key and the proofs of credentials are bound
+	// to the schema. It is nil in keys that are not bound to a schema.
+	SchemaHash []byte
+	// Proof proves that the key was generated correctly, it is nil in
+	// keys generated before the proof was introduced
+	Proof *KeyProof
 }
 
 // NewPubKey accepts group g, parameters p and commitment receiver recv,
-// and returns a public key for the CL scheme.
+// and returns a public key for the CL scheme. alpha is the discrete
+// logarithm of recv.G to base recv.H, which is needed for the proof of
+// correctness of the key (see KeyProof).
 func NewPubKey(g *qr.RSASpecial, p *pb.Params,
-	attrs *AttrCount, recv *df.Receiver) (*PubKey,
+	attrs *AttrCount, recv *df.Receiver, alpha *big.Int) (*PubKey,
 	error) {
-	S, Z, RsKnown, RsCommitted, RsHidden, err := generateQuadraticResidues(
-		g, attrs.Known, attrs.Committed, attrs.Hidden)
+	pp, err := pedersen.GenerateParams(int(p.RhoBitLen))
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating quadratic residues")
+		return nil, errors.Wrap(err, "error creating Pedersen receiver")
 	}
 
-	pp, err := pedersen.GenerateParams(int(p.RhoBitLen))
+	return newPubKey(g, p, attrs, recv, alpha, pp, nil)
+}
+
+// newPubKey is like NewPubKey, but uses the given Pedersen parameters
+// pp for nyms, and binds the key to the schema with schemaHash unless
+// it is nil.
+func newPubKey(g *qr.RSASpecial, p *pb.Params, attrs *AttrCount,
+	recv *df.Receiver, alpha *big.Int, pp *pedersen.Params,
+	schemaHash []byte) (*PubKey, error) {
+	// accumulator lives in the same group, its bases are random powers
+	// of S as well
+	n := 1 + attrs.Known + attrs.Committed + attrs.Hidden + 3
+	S, residues, exps, err := generateQuadraticResidues(g, n)
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating Pedersen receiver")
+		return nil, errors.Wrap(err, "error creating quadratic residues")
 	}
 
-	return &PubKey{
+	next := func(k int) []*big.Int {
+		rs := residues[:k]
+		residues = residues[k:]
+		return rs
+	}
+	pk := &PubKey{
 		N:              g.N,
 		S:              S,
-		Z:              Z,
-		RsKnown:        RsKnown,
-		RsCommitted:    RsCommitted,
-		RsHidden:       RsHidden,
+		Z:              next(1)[0],
+		RsKnown:        next(attrs.Known),
+		RsCommitted:    next(attrs.Committed),
+		RsHidden:       next(attrs.Hidden),
 		PedersenParams: pp,
 		N1:             recv.QRSpecialRSA.N,
 		G:              recv.G,
 		H:              recv.H,
-	}, nil
+		SchemaHash:     schemaHash,
+	}
+	accBases := next(3)
+	pk.AccInit, pk.AccG, pk.AccH = accBases[0], accBases[1], accBases[2]
+
+	pk.Proof = newKeyProof(pk, &keyTrapdoor{
+		exps:  exps,
+		alpha: alpha,
+	}, int(p.SecParam))
+
+	return pk, nil
+}
+
+// SupportsRevocation reports whether the key holds the parameters of
+// the revocation accumulator.
+func (k *PubKey) SupportsRevocation() bool {
+	return k.AccInit != nil && k.AccG != nil && k.AccH != nil
+}
+
+// CheckParams checks that the key was generated with parameters p.
+func (k *PubKey) CheckParams(p *pb.Params) error {
+	if n := k.N.BitLen(); n < int(p.NLength)-1 || n > int(p.NLength) {
+		return fmt.Errorf("RSA modulus has %d bits, expected %d", n,
+			p.NLength)
+	}
+	if n := k.N1.BitLen(); n < int(p.NLength)-1 || n > int(p.NLength) {
+		return fmt.Errorf("RSA modulus for commitments has %d bits,"+
+			" expected %d", n, p.NLength)
+	}
+	if n := k.PedersenParams.Group.Q.BitLen(); n != int(p.RhoBitLen) {
+		return fmt.Errorf("commitment group order has %d bits,"+
+			" expected %d", n, p.RhoBitLen)
+	}
+
+	return nil
+}
+
+// CheckSchema checks that cs describes the schema that the key is
+// bound to. Any schema matches keys that are not bound to a schema.
+func (k *PubKey) CheckSchema(cs *pb.CredStructure) error {
+	if k.SchemaHash == nil {
+		return nil
+	}
+	if cs == nil {
+		return fmt.Errorf("key is bound to a schema, but no schema is" +
+			" given")
+	}
+	h, err := SchemaHash(cs)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, k.SchemaHash) {
+		return fmt.Errorf("schema does not match the schema of the key")
+	}
+
+	return nil
 }
 
 // GenerateUserMasterSecret generates a secret key that needs to be encoded into every user's credential as a
@@ -106,13 +205,62 @@ func (k *PubKey) GetContext() *big.Int {
 	numbers = append(numbers, k.RsKnown...)
 	numbers = append(numbers, k.RsCommitted...)
 	numbers = append(numbers, k.RsHidden...)
+	if k.SchemaHash != nil {
+		numbers = append(numbers, new(big.Int).SetBytes(k.SchemaHash))
+	}
 	concatenated := common.ConcatenateNumbers(numbers...)
 	return new(big.Int).SetBytes(concatenated)
 }
 
 // GenerateKeyPair takes and constructs a keypair containing public and
-// secret key for the CL scheme.
+// secret key for the CL scheme. Parameters p have to be valid (see
+// ValidateParams).
 func GenerateKeyPair(p *pb.Params, attrs *AttrCount) (*KeyPair, error) {
+	if err := ValidateParams(p); err != nil {
+		return nil, errors.Wrap(err, "invalid parameters")
+	}
+	return generateKeyPair(p, attrs, nil, nil)
+}
+
+// GenerateKeyPairForSchema generates a keypair for credentials with
+// the schema described by cs. The public key is bound to the schema
+// (see PubKey.SchemaHash), which is stored with the keys.
+func GenerateKeyPairForSchema(p *pb.Params,
+	cs *pb.CredStructure) (*KeyPair, error) {
+	if err := ValidateParams(p); err != nil {
+		return nil, errors.Wrap(err, "invalid parameters")
+	}
+	h, err := SchemaHash(cs)
+	if err != nil {
+		return nil, err
+	}
+	attrs := NewAttrCount(int(cs.NKnown), int(cs.NCommitted),
+		int(cs.NHidden))
+	keys, err := generateKeyPair(p, attrs, nil, h)
+	if err != nil {
+		return nil, err
+	}
+	keys.Schema = cs
+
+	return keys, nil
+}
+
+// GenerateNextKeyPair generates a keypair that replaces keypair prev
+// of an organization (see Server.RotateKeys). The new keys use the
+// Pedersen parameters of prev, so that nyms of credentials issued
+// with prev remain valid, and are bound to the schema of prev. Keys
+// are generated with the parameters p of prev even if they predate
+// ValidateParams.
+func GenerateNextKeyPair(p *pb.Params, attrs *AttrCount,
+	prev *PubKey) (*KeyPair, error) {
+	return generateKeyPair(p, attrs, prev.PedersenParams, prev.SchemaHash)
+}
+
+// generateKeyPair generates a keypair with Pedersen parameters pp,
+// or with new Pedersen parameters if pp is nil. The public key is bound
+// to the schema with schemaHash unless it is nil.
+func generateKeyPair(p *pb.Params, attrs *AttrCount,
+	pp *pedersen.Params, schemaHash []byte) (*KeyPair, error) {
 	g, err := qr.NewRSASpecial(int(p.NLength) / 2)
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating RSASpecial group")
@@ -123,16 +271,28 @@ func GenerateKeyPair(p *pb.Params, attrs *AttrCount) (*KeyPair, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating DF commitment receiver")
 	}
+	// the receiver does not reveal the discrete logarithm of G to base
+	// H, which the proof of correctness of the key is built from, so G
+	// is chosen anew
+	alpha := common.GetRandomInt(commRecv.QRSpecialRSA.Order)
+	commRecv.G = commRecv.QRSpecialRSA.Exp(commRecv.H, alpha)
 
 	sk := NewSecKey(g, commRecv)
 
-	pk, err := NewPubKey(g, p, attrs, commRecv)
+	if pp == nil {
+		pp, err = pedersen.GenerateParams(int(p.RhoBitLen))
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating Pedersen receiver")
+		}
+	}
+	pk, err := newPubKey(g, p, attrs, commRecv, alpha, pp, schemaHash)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KeyPair{
-		Sec: sk,
-		Pub: pk,
+		Sec:    sk,
+		Pub:    pk,
+		Params: p,
 	}, nil
 }
@@ -0,0 +1,295 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+)
+
+// multiCredNonceBitLen is the bit length of nonces of proofs of
+// several credentials, which is the largest security parameter of
+// the parameter profiles.
+const multiCredNonceBitLen = 128
+
+// CredPresentation is a credential presented in a proof of possession
+// of several credentials (see BuildMultiCredProof).
+type CredPresentation struct {
+	Manager *CredManager
+	Cred    *Cred
+	// RevealedAttrs are the names of the attributes revealed to the
+	// verifier
+	RevealedAttrs []string
+	// Predicates over Known attributes that are not revealed
+	Predicates []*Predicate
+	// Accumulator that the credential is proved to be in, nil if the
+	// verifier does not check the revocation of the credential
+	Accumulator *Accumulator
+}
+
+// CredProofPart is the proof of possession of one of the credentials
+// of a MultiCredProof.
+type CredProofPart struct {
+	// KeyID identifies the public key the credential was issued with
+	KeyID string
+	// A of the randomized credential
+	A                                 *big.Int
+	Proof                             *qr.RepresentationProof
+	RevealedKnownAttrsIndices         []int
+	RevealedKnownAttrs                []*big.Int
+	RevealedCommitmentsOfAttrsIndices []int
+	RevealedCommitmentsOfAttrs        []*big.Int
+	PredicateProofs                   []*PredicateProof
+	NonRevocationProof                *NonRevocationProof
+}
+
+// MultiCredProof proves the possession of several credentials, which
+// can be issued by different issuers. The proofs of the credentials
+// share the challenge, and prove that the first Hidden attributes of
+// all the credentials, which hold the master secret of the holder, are
+// equal, so that credentials of different holders cannot be combined.
+type MultiCredProof struct {
+	Parts []*CredProofPart
+	// ScopePseudonymProof proves the scope pseudonym of the holder
+	// with the public key of the first credential
+	ScopePseudonymProof *ScopePseudonymProof
+}
+
+// BuildMultiCredProof builds a proof of possession of credentials
+// creds, which is bound to verifier and nonce. All the credentials
+// have to share the first Hidden attribute. If scope is not empty,
+// the proof includes a ScopePseudonymProof for the first credential.
+func BuildMultiCredProof(creds []*CredPresentation, scope, verifier string,
+	nonce *big.Int) (*MultiCredProof, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials to prove")
+	}
+
+	var masterSecret *big.Int
+	bitLen := 0
+	for _, c := range creds {
+		m := c.Manager
+		if len(m.Attrs.Hidden) == 0 {
+			return nil, fmt.Errorf("proofs of several credentials require" +
+				" a hidden attribute")
+		}
+		if masterSecret == nil {
+			masterSecret = m.Attrs.Hidden[0]
+		}
+		if m.Attrs.Hidden[0].Cmp(masterSecret) != 0 {
+			return nil, fmt.Errorf("credentials do not share the master" +
+				" secret")
+		}
+		// the random value of the master secret has to hide it in
+		// the proofs of all the credentials
+		b := int(m.Params.AttrBitLen + m.Params.SecParam + m.Params.HashBitLen)
+		if b > bitLen {
+			bitLen = b
+		}
+	}
+	masterSecretTilde := getRandomBoundedInt(bitLen)
+
+	provers := make([]*credProver, len(creds))
+	pubKeys := make([]*PubKey, len(creds))
+	transcripts := make([]*CredProofTranscript, len(creds))
+	for i, c := range creds {
+		known, committed, err := c.Manager.RawCred.revealedIndices(
+			c.RevealedAttrs)
+		if err != nil {
+			return nil, err
+		}
+		credScope := ""
+		if i == 0 {
+			credScope = scope
+		}
+		p, err := c.Manager.newCredProver(c.Cred, known, committed,
+			c.Predicates, c.Accumulator, credScope, masterSecretTilde)
+		if err != nil {
+			return nil, err
+		}
+		p.transcript.Verifier = verifier
+		p.transcript.Nonce = nonce
+
+		provers[i] = p
+		pubKeys[i] = c.Manager.PubKey
+		transcripts[i] = p.transcript
+	}
+
+	challenge := multiCredProofChallenge(pubKeys, transcripts)
+	proof := &MultiCredProof{
+		Parts:               make([]*CredProofPart, len(creds)),
+		ScopePseudonymProof: transcripts[0].ScopePseudonymProof,
+	}
+	for i, p := range provers {
+		t := p.transcript
+		proof.Parts[i] = &CredProofPart{
+			KeyID:                             pubKeys[i].ID(),
+			A:                                 t.A,
+			Proof:                             p.respond(challenge),
+			RevealedKnownAttrsIndices:         t.RevealedKnownAttrsIndices,
+			RevealedKnownAttrs:                t.RevealedKnownAttrs,
+			RevealedCommitmentsOfAttrsIndices: t.RevealedCommitmentsOfAttrsIndices,
+			RevealedCommitmentsOfAttrs:        t.RevealedCommitmentsOfAttrs,
+			PredicateProofs:                   t.PredicateProofs,
+			NonRevocationProof:                t.NonRevocationProof,
+		}
+	}
+
+	return proof, nil
+}
+
+// MultiCredVerifier holds the state of a single proof of possession of
+// several credentials, issued by the trusted issuers of a keyring.
+//
+// A new MultiCredVerifier must be obtained with NewMultiCredVerifier
+// for every proof, so that concurrent proofs do not interfere.
+type MultiCredVerifier struct {
+	keyring    *Keyring
+	nonce      *big.Int
+	accs       map[string]*Accumulator
+	scope      string
+	verifierID string
+	scopeNym   *big.Int
+}
+
+// NewMultiCredVerifier creates a MultiCredVerifier for a single proof
+// of possession of credentials issued by the issuers of keyring.
+// A fresh nonce is generated for the proof, and can be obtained with
+// GetNonce.
+func NewMultiCredVerifier(keyring *Keyring) *MultiCredVerifier {
+	b := new(big.Int).Exp(big.NewInt(2), big.NewInt(multiCredNonceBitLen),
+		nil)
+
+	return &MultiCredVerifier{
+		keyring: keyring,
+		nonce:   common.GetRandomInt(b),
+		accs:    make(map[string]*Accumulator),
+	}
+}
+
+// GetNonce returns the nonce that the prover has to bind the proof to.
+func (v *MultiCredVerifier) GetNonce() *big.Int {
+	return v.nonce
+}
+
+// SetAccumulator requires the prover to prove that the credential
+// issued with the public key identified by keyID is in accumulator acc.
+func (v *MultiCredVerifier) SetAccumulator(keyID string, acc *Accumulator) {
+	v.accs[keyID] = acc
+}
+
+// SetScope requires the prover to present its scope pseudonym for
+// scope, which is available with ScopePseudonym once the proof is
+// verified.
+func (v *MultiCredVerifier) SetScope(scope string) {
+	v.scope = scope
+}
+
+// SetVerifierID sets the identity of the verifier, which the prover
+// has to bind the proof to.
+func (v *MultiCredVerifier) SetVerifierID(id string) {
+	v.verifierID = id
+}
+
+// ScopePseudonym returns the scope pseudonym of the prover, or nil if
+// no scope was set or the proof was not verified.
+func (v *MultiCredVerifier) ScopePseudonym() *big.Int {
+	return v.scopeNym
+}
+
+// Verify verifies the proof of possession of several credentials.
+// Every credential has to be issued by a trusted issuer, and conditions
+// of its attributes are checked against reference values in actual,
+// as with CredVerifier.ProveCred. The credentials have to share
+// the master secret.
+func (v *MultiCredVerifier) Verify(proof *MultiCredProof,
+	actual map[string]interface{}) (bool, error) {
+	if len(proof.Parts) == 0 {
+		return false, fmt.Errorf("no credentials in the proof")
+	}
+	// the challenge shared by all the proofs is checked once all
+	// the transcripts are known
+	challenge := proof.Parts[0].Proof.Challenge
+	pubKeys := make([]*PubKey, len(proof.Parts))
+	transcripts := make([]*CredProofTranscript, len(proof.Parts))
+
+	var masterSecretHat *big.Int
+	var scopeNym *big.Int
+	for i, p := range proof.Parts {
+		issuer, ok := v.keyring.Get(p.KeyID)
+		if !ok {
+			return false, fmt.Errorf("credential issued with unknown key %s",
+				p.KeyID)
+		}
+		pk := issuer.PubKey
+		if len(pk.RsHidden) == 0 {
+			return false, fmt.Errorf("proofs of several credentials require"+
+				" a hidden attribute, issuer %s has none", issuer.Name)
+		}
+
+		cv := &CredVerifier{
+			org:        issuer.org,
+			nonce:      v.nonce,
+			acc:        v.accs[p.KeyID],
+			verifierID: v.verifierID,
+		}
+		var scopeProof *ScopePseudonymProof
+		if i == 0 {
+			cv.scope = v.scope
+			scopeProof = proof.ScopePseudonymProof
+		}
+
+		i := i
+		valid, err := cv.verifyCred(p.A, p.Proof, p.RevealedKnownAttrsIndices,
+			p.RevealedCommitmentsOfAttrsIndices, p.RevealedKnownAttrs,
+			p.RevealedCommitmentsOfAttrs, issuer.Attrs, actual,
+			p.PredicateProofs, p.NonRevocationProof, scopeProof,
+			func(t *CredProofTranscript) bool {
+				transcripts[i] = t
+				return p.Proof.Challenge.Cmp(challenge) == 0
+			})
+		if err != nil || !valid {
+			return valid, err
+		}
+		pubKeys[i] = pk
+
+		// proof data for the first Hidden attribute precedes those for
+		// the other Hidden attributes, e and v
+		hat := p.Proof.ProofData[len(p.Proof.ProofData)-len(pk.RsHidden)-2]
+		if masterSecretHat == nil {
+			masterSecretHat = hat
+		}
+		if hat.Cmp(masterSecretHat) != 0 {
+			return false, fmt.Errorf("credentials do not share the master" +
+				" secret")
+		}
+		if i == 0 {
+			scopeNym = cv.ScopePseudonym()
+		}
+	}
+
+	if challenge.Cmp(multiCredProofChallenge(pubKeys, transcripts)) != 0 {
+		return false, fmt.Errorf("challenge is not correct")
+	}
+	v.scopeNym = scopeNym
+
+	return true, nil
+}
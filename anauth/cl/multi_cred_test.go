@@ -0,0 +1,146 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHolderCred returns a credential manager and a credential
+// issued by o, with Known attribute a set to val and Hidden attribute
+// secret set to secret.
+func newTestHolderCred(t *testing.T, o *Org, val, secret int) (*CredManager,
+	*Cred) {
+	rc := NewRawCred(NewAttrCount(1, 0, 1))
+	require.NoError(t, rc.addEmptyInt64Attr("a", 0, true))
+	require.NoError(t, rc.addEmptyHiddenInt64Attr("secret", 1))
+	require.NoError(t, rc.UpdateAttr("a", val))
+	require.NoError(t, rc.UpdateAttr("secret", secret))
+
+	cm, err := NewCredManager(o.Params, o.Keys.Pub,
+		o.Keys.Pub.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+
+	return cm, issueTestCred(t, o, cm)
+}
+
+// newTestHolderAttrs returns attributes of credentials built with
+// newTestHolderCred, without conditions.
+func newTestHolderAttrs() []CredAttr {
+	a := NewEmptyInt64Attr("a", true)
+	a.cond = none
+	secret := NewEmptyInt64Attr("secret", false)
+	secret.Hidden = true
+	secret.Index = 1
+
+	return []CredAttr{a, secret}
+}
+
+func TestMultiCredProof(t *testing.T) {
+	orgs := make([]*Org, 2)
+	for i := range orgs {
+		o, err := NewOrg(GetDefaultParamSizes(), NewAttrCount(1, 0, 1))
+		require.NoError(t, err)
+		orgs[i] = o
+	}
+	cm1, cred1 := newTestHolderCred(t, orgs[0], 30, 42)
+	cm2, cred2 := newTestHolderCred(t, orgs[1], 40, 42)
+
+	keyring := NewKeyring()
+	for _, o := range orgs {
+		require.NoError(t, keyring.Add("org", o.Params, o.Keys.Pub,
+			newTestHolderAttrs()))
+		_, ok := keyring.Get(o.KeyID())
+		assert.True(t, ok)
+	}
+	// attributes do not match the public key
+	assert.Error(t, NewKeyring().Add("org", orgs[0].Params,
+		orgs[0].Keys.Pub, newTestHolderAttrs()[:1]))
+
+	present := func(cm *CredManager, cred *Cred) *CredPresentation {
+		return &CredPresentation{
+			Manager:       cm,
+			Cred:          cred,
+			RevealedAttrs: []string{"a"},
+		}
+	}
+	verify := func(proof *MultiCredProof, v *MultiCredVerifier) (bool,
+		error) {
+		return v.Verify(proof, nil)
+	}
+
+	v := NewMultiCredVerifier(keyring)
+	v.SetScope("test")
+	v.SetVerifierID("verifier")
+	proof, err := BuildMultiCredProof([]*CredPresentation{present(cm1, cred1),
+		present(cm2, cred2)}, "test", "verifier", v.GetNonce())
+	require.NoError(t, err)
+	require.Len(t, proof.Parts, 2)
+	assert.Equal(t, []*big.Int{encodeInt64(40)},
+		proof.Parts[1].RevealedKnownAttrs)
+	ok, err := verify(proof, v)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotNil(t, v.ScopePseudonym())
+
+	// proof bound to another nonce
+	ok, err = verify(proof, NewMultiCredVerifier(keyring))
+	assert.False(t, ok && err == nil)
+
+	// parts combined from different proofs
+	v = NewMultiCredVerifier(keyring)
+	other, err := BuildMultiCredProof([]*CredPresentation{present(cm1, cred1),
+		present(cm2, cred2)}, "", "", v.GetNonce())
+	require.NoError(t, err)
+	proof, err = BuildMultiCredProof([]*CredPresentation{present(cm1, cred1),
+		present(cm2, cred2)}, "", "", v.GetNonce())
+	require.NoError(t, err)
+	proof.Parts[1] = other.Parts[1]
+	ok, err = verify(proof, v)
+	assert.False(t, ok && err == nil)
+
+	// revealed attribute changed after the proof was built
+	proof, err = BuildMultiCredProof([]*CredPresentation{present(cm1, cred1),
+		present(cm2, cred2)}, "", "", v.GetNonce())
+	require.NoError(t, err)
+	proof.Parts[1].RevealedKnownAttrs = []*big.Int{encodeInt64(41)}
+	ok, err = verify(proof, v)
+	assert.False(t, ok && err == nil)
+
+	// credential of an issuer that is not trusted
+	ok, err = verify(proof, NewMultiCredVerifier(NewKeyring()))
+	assert.False(t, ok && err == nil)
+
+	// credentials of different holders
+	cm3, cred3 := newTestHolderCred(t, orgs[1], 40, 43)
+	_, err = BuildMultiCredProof([]*CredPresentation{present(cm1, cred1),
+		present(cm3, cred3)}, "", "", v.GetNonce())
+	assert.Error(t, err)
+
+	// prover pretending to share the master secret
+	cm3.Attrs.Hidden[0] = cm1.Attrs.Hidden[0]
+	proof, err = BuildMultiCredProof([]*CredPresentation{present(cm1, cred1),
+		present(cm3, cred3)}, "", "", v.GetNonce())
+	require.NoError(t, err)
+	ok, err = verify(proof, v)
+	assert.False(t, ok && err == nil)
+}
@@ -27,27 +27,18 @@ import (
 	"os"
 
 	"github.com/emmyzkp/crypto/common"
-	"github.com/emmyzkp/crypto/df"
 	"github.com/emmyzkp/crypto/pedersen"
 	"github.com/emmyzkp/crypto/qr"
-	"github.com/emmyzkp/crypto/schnorr"
+	"github.com/emmyzkp/emmy/anauth"
 	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
 )
 
 type Org struct {
-	Params             *pb.Params
-	Group              *qr.RSASpecial     // in this group attributes will be used as exponents (basis is PubKey.Rs...)
-	pedersenReceiver   *pedersen.Receiver // used for nyms (nym is Pedersen commitment)
-	nym                *big.Int
-	nymVerifier        *schnorr.Verifier
-	U                  *big.Int
-	UVerifier          *qr.RepresentationVerifier
-	Keys               *KeyPair
-	commitmentsOfAttrs []*big.Int
-	knownAttrs         []*big.Int
-	attrsVerifiers     []*df.OpeningVerifier // user proves the knowledge of commitment opening (committedAttrs)
-	credIssueNonceOrg  *big.Int
-	proveCredNonceOrg  *big.Int
+	Params           *pb.Params
+	Group            *qr.RSASpecial     // in this group attributes will be used as exponents (basis is PubKey.Rs...)
+	pedersenReceiver *pedersen.Receiver // used for nyms (nym is Pedersen commitment)
+	Keys             *KeyPair
+	keyID            string
 }
 
 func NewOrg(params *pb.Params, attrCount *AttrCount) (*Org, error) {
@@ -81,26 +72,30 @@ func NewOrgFromParams(params *pb.Params, keys *KeyPair) (*Org, error) {
 		Keys:             keys,
 		Group:            group,
 		pedersenReceiver: pedersenReceiver,
+		keyID:            keys.Pub.ID(),
 	}, nil
 }
 
-// FIXME
-func LoadOrg(pubKeyPath, secKeyPath string) (*Org, error) {
-	pubKey := new(PubKey)
-	if err := ReadGob(pubKeyPath, pubKey); err != nil {
-		return nil, err
-	}
-	secKey := new(SecKey)
-	if err := ReadGob(secKeyPath, secKey); err != nil {
+// KeyID returns the identifier of the public key of the organization.
+func (o *Org) KeyID() string {
+	return o.keyID
+}
+
+// LoadOrg creates an organization with the keys read from pubKeyPath
+// and secKeyPath (see ReadKeyPair). Keys stored without parameters are
+// used with the legacy parameters they were generated with (see
+// GetLegacyParamSizes).
+func LoadOrg(pubKeyPath, secKeyPath string,
+	passphrase anauth.PassphraseFunc) (*Org, error) {
+	keys, err := ReadKeyPair(pubKeyPath, secKeyPath, passphrase)
+	if err != nil {
 		return nil, err
 	}
 
-	keys := &KeyPair{
-		Sec: secKey,
-		Pub: pubKey,
+	params := keys.Params
+	if params == nil {
+		params = GetLegacyParamSizes()
 	}
-
-	params := GetDefaultParamSizes()
 	org, err := NewOrgFromParams(params, keys)
 	if err != nil {
 		return nil, fmt.Errorf("error when loading CL org: %v", err)
@@ -136,12 +131,12 @@ func (o *Org) genCredRandoms() (*big.Int, *big.Int) {
 	return e, v11
 }
 
-func (o *Org) genAProof(nonceUser, context, eInv, Q, A *big.Int) *qr.RepresentationProof {
+func (o *Org) genAProof(nonceUser, eInv, Q, A *big.Int) *qr.RepresentationProof {
 	prover := qr.NewRepresentationProver(o.Group, int(o.Params.SecParam),
 		[]*big.Int{eInv}, []*big.Int{Q}, A)
 	proofRandomData := prover.GetProofRandomData(true)
-	// challenge = hash(context||Q||A||AProofRandomData||nonceUser)
-	challenge := common.Hash(context, Q, A, proofRandomData, nonceUser)
+	challenge := issuedCredChallenge(o.Keys.Pub, Q, A, proofRandomData,
+		nonceUser)
 	proofData := prover.GetProofData(challenge)
 
 	return qr.NewRepresentationProof(proofRandomData, challenge, proofData)
@@ -153,76 +148,39 @@ type CredResult struct {
 	Record *ReceiverRecord
 }
 
-func (o *Org) IssueCred(cr *CredRequest) (*CredResult, error) {
-	o.nymVerifier = schnorr.NewVerifier(o.pedersenReceiver.Params.Group)
-	o.UVerifier = qr.NewRepresentationVerifier(o.Group, int(o.Params.SecParam))
-
-	o.nym = cr.Nym
-	o.knownAttrs = cr.KnownAttrs
-	err := o.setUpAttrVerifiers(cr.CommitmentsOfAttrs)
-	if err != nil {
-		return nil, err
-	}
-	o.U = cr.U
-
-	if verified := o.verifyCredRequest(cr); !verified {
-		return nil, fmt.Errorf("credential request not valid")
+// UpdateCred updates the credential with receiver record rec, setting
+// its Known attributes to newKnownAttrs. Commitments of Committed
+// attributes are replaced with newCommitmentsOfAttrs, unless it is nil,
+// in which case the commitments from rec are kept.
+func (o *Org) UpdateCred(nym *big.Int, rec *ReceiverRecord, nonceUser *big.Int,
+	newKnownAttrs, newCommitmentsOfAttrs []*big.Int) (*CredResult, error) {
+	if len(newKnownAttrs) != len(rec.KnownAttrs) {
+		return nil, fmt.Errorf("expected %d known attributes, got %d",
+			len(rec.KnownAttrs), len(newKnownAttrs))
 	}
-
-	e, v11 := o.genCredRandoms()
-
-	// denom = U * S^v11 * R_1^attr_1 * ... * R_j^attr_j where only attributes from knownAttrs and committedAttrs
-	acc := big.NewInt(1)
-	for ind := 0; ind < len(o.knownAttrs); ind++ {
-		t1 := o.Group.Exp(o.Keys.Pub.RsKnown[ind], o.knownAttrs[ind])
-		acc = o.Group.Mul(acc, t1)
+	if newCommitmentsOfAttrs == nil {
+		newCommitmentsOfAttrs = rec.CommitmentsOfAttrs
 	}
-
-	for ind := 0; ind < len(o.commitmentsOfAttrs); ind++ {
-		t1 := o.Group.Exp(o.Keys.Pub.RsCommitted[ind], o.commitmentsOfAttrs[ind])
-		acc = o.Group.Mul(acc, t1)
-	}
-
-	t := o.Group.Exp(o.Keys.Pub.S, v11) // s^v11
-	denom := o.Group.Mul(t, o.U)        // U * s^v11
-	denom = o.Group.Mul(denom, acc)     // U * s^v11 * acc
-	denomInv := o.Group.Inv(denom)
-	Q := o.Group.Mul(o.Keys.Pub.Z, denomInv)
-
-	phiN := new(big.Int).Mul(o.Group.P1, o.Group.Q1)
-	eInv := new(big.Int).ModInverse(e, phiN)
-	A := o.Group.Exp(Q, eInv)
-
-	context := o.Keys.Pub.GetContext()
-	AProof := o.genAProof(cr.Nonce, context, eInv, Q, A) // nonceUser!
-
-	res := &CredResult{
-		Cred:   NewCred(A, e, v11),
-		AProof: AProof,
-		Record: NewReceiverRecord(o.knownAttrs, o.commitmentsOfAttrs, Q, v11, context),
-	}
-
-	return res, nil
-}
-
-func (o *Org) UpdateCred(nym *big.Int, rec *ReceiverRecord, nonceUser *big.Int, newKnownAttrs []*big.Int) (*CredResult, error) {
-	if o.knownAttrs == nil { // for example when Org is instantiated and there is no call to IssueCred
-		o.knownAttrs = newKnownAttrs
-		o.setUpAttrVerifiers(rec.CommitmentsOfAttrs)
-		o.nymVerifier = schnorr.NewVerifier(o.pedersenReceiver.Params.Group) // pubKey.Params.Group
-		o.UVerifier = qr.NewRepresentationVerifier(o.Group,
-			int(o.Params.SecParam))
+	if len(newCommitmentsOfAttrs) != len(rec.CommitmentsOfAttrs) {
+		return nil, fmt.Errorf("expected %d commitments of attributes, got %d",
+			len(rec.CommitmentsOfAttrs), len(newCommitmentsOfAttrs))
 	}
 
 	e, v11 := o.genCredRandoms()
 	v11Diff := new(big.Int).Sub(v11, rec.V11)
 
 	acc := big.NewInt(1)
-	for ind := 0; ind < len(o.knownAttrs); ind++ {
+	for ind := 0; ind < len(newKnownAttrs); ind++ {
 		t1 := o.Group.Exp(o.Keys.Pub.RsKnown[ind],
 			new(big.Int).Sub(newKnownAttrs[ind], rec.KnownAttrs[ind]))
 		acc = o.Group.Mul(acc, t1)
 	}
+	for ind := 0; ind < len(newCommitmentsOfAttrs); ind++ {
+		t1 := o.Group.Exp(o.Keys.Pub.RsCommitted[ind],
+			new(big.Int).Sub(newCommitmentsOfAttrs[ind],
+				rec.CommitmentsOfAttrs[ind]))
+		acc = o.Group.Mul(acc, t1)
+	}
 	t := o.Group.Exp(o.Keys.Pub.S, v11Diff)
 	denom := o.Group.Mul(acc, t)
 	denomInv := o.Group.Inv(denom)
@@ -233,120 +191,43 @@ func (o *Org) UpdateCred(nym *big.Int, rec *ReceiverRecord, nonceUser *big.Int,
 	newA := o.Group.Exp(newQ, eInv)
 
 	context := o.Keys.Pub.GetContext()
-	AProof := o.genAProof(nonceUser, context, eInv, newQ, newA)
-	// currently commitmentsOfAttrs cannot be updated
+	AProof := o.genAProof(nonceUser, eInv, newQ, newA)
 
 	res := &CredResult{
 		Cred:   NewCred(newA, e, v11),
 		AProof: AProof,
-		Record: NewReceiverRecord(newKnownAttrs, rec.CommitmentsOfAttrs, newQ, v11, context),
+		Record: NewReceiverRecord(newKnownAttrs, newCommitmentsOfAttrs, newQ,
+			v11, context),
 	}
+	res.Record.E = e
+	res.Record.KeyID = o.KeyID()
 
 	return res, nil
 }
 
-func (o *Org) GetProveCredNonce() *big.Int {
-	nonce := o.GenNonce()
-	o.proveCredNonceOrg = nonce
-
-	return nonce
-}
-
-// ProveCred proves the possession of a valid credential and reveals only the attributes the user desires
-// to reveal. Which knownAttrs and commitmentsOfAttrs are to be revealed are given by revealedKnownAttrsIndices and
-// revealedCommitmentsOfAttrsIndices parameters. Parameters knownAttrs and commitmentsOfAttrs must contain only
-// Known attributes and commitments of attributes (of attributes for which only commitment is Known) which are
-// to be revealed to the organization.
-func (o *Org) ProveCred(A *big.Int, proof *qr.RepresentationProof,
-	revealedKnownAttrsIndices, revealedCommitmentsOfAttrsIndices []int,
-	revealedKnownAttrs, revealedCommitmentsOfAttrs []*big.Int,
-	attrs []CredAttr, actual map[string]interface{}) (bool,
-	error) {
-
-	knownAttrs := make([]CredAttr, 0)
-	revealedIndices := make([]int, 0) // indices of Known attributes with all attributes taken into account
-	count := 0
-	for _, a := range attrs { // Attrs are ordered by Index, so knownAttrs will be too
-		if a.isKnown() {
-			knownAttrs = append(knownAttrs, a)
-			revealedIndices = append(revealedIndices, count)
-		}
-		count++
-	}
-
-	count = 0
-	for _, ind := range revealedKnownAttrsIndices {
-		a := knownAttrs[ind]
-
-		err := a.updateInternalValue(revealedKnownAttrs[count])
-		if err != nil {
-			return false, err
-		}
-		count++
-
-		valToCheck, ok := actual[a.Name()]
-		if a.getCond() != none { // condition was specified
-			if !ok {
-				return false, fmt.Errorf(
-					"missing reference value for attribute '%s'", a.Name())
-			}
-
-			valid, err := a.ValidateAgainst(valToCheck)
-			if err != nil {
-				return false, err
-			}
-
-			// TODO add some logging
-			if !valid {
-				return false, fmt.Errorf("attribute value validation failed for %s\n", a.Name())
-			}
-		}
-	}
-
-	ver := qr.NewRepresentationVerifier(o.Group, int(o.Params.SecParam))
-	bases := []*big.Int{}
-	for i := 0; i < len(o.Keys.Pub.RsKnown); i++ {
-		if !common.Contains(revealedKnownAttrsIndices, i) {
-			bases = append(bases, o.Keys.Pub.RsKnown[i])
-		}
-	}
-	for i := 0; i < len(o.Keys.Pub.RsCommitted); i++ {
-		if !common.Contains(revealedCommitmentsOfAttrsIndices, i) {
-			bases = append(bases, o.Keys.Pub.RsCommitted[i])
-		}
-	}
-	bases = append(bases, o.Keys.Pub.RsHidden...)
-	bases = append(bases, A)
-	bases = append(bases, o.Keys.Pub.S)
-
-	denom := big.NewInt(1)
-	for i := 0; i < len(revealedKnownAttrs); i++ {
-		rInd := revealedKnownAttrsIndices[i]
-		t1 := o.Group.Exp(o.Keys.Pub.RsKnown[rInd], revealedKnownAttrs[i])
-		denom = o.Group.Mul(denom, t1)
-	}
-
-	for i := 0; i < len(revealedCommitmentsOfAttrs); i++ {
-		rInd := revealedCommitmentsOfAttrsIndices[i]
-		t1 := o.Group.Exp(o.Keys.Pub.RsCommitted[rInd], revealedCommitmentsOfAttrs[i])
-		denom = o.Group.Mul(denom, t1)
-	}
-	denomInv := o.Group.Inv(denom)
-	y := o.Group.Mul(o.Keys.Pub.Z, denomInv)
-	ver.SetProofRandomData(proof.ProofRandomData, bases, y)
-
-	context := o.Keys.Pub.GetContext()
-	l := []*big.Int{context, proof.ProofRandomData, o.proveCredNonceOrg}
-	//l = append(l, ...) // TODO: add other values
-
-	c := common.Hash(l...) // TODO: function for GetChallenge
-	if proof.Challenge.Cmp(c) != 0 {
-		return false, fmt.Errorf("challenge is not correct")
-	}
-
-	ver.SetChallenge(proof.Challenge)
-
-	return ver.Verify(proof.ProofData), nil
+// ReissueCred issues a new credential with the keys of the organization
+// to the receiver with record rec of a credential issued with other
+// (for example retired) keys. The receiver computed U from its Hidden
+// attributes with the keys of the organization, while Known attributes
+// are set to newKnownAttrs. Committed attributes are set to
+// newCommitmentsOfAttrs, or taken from rec if it is nil.
+func (o *Org) ReissueCred(rec *ReceiverRecord, U, nonceUser *big.Int,
+	newKnownAttrs, newCommitmentsOfAttrs []*big.Int) (*CredResult, error) {
+	pk := o.Keys.Pub
+	if len(newKnownAttrs) != len(pk.RsKnown) {
+		return nil, fmt.Errorf("expected %d known attributes, got %d",
+			len(pk.RsKnown), len(newKnownAttrs))
+	}
+	if newCommitmentsOfAttrs == nil {
+		newCommitmentsOfAttrs = rec.CommitmentsOfAttrs
+	}
+	if len(newCommitmentsOfAttrs) != len(pk.RsCommitted) {
+		return nil, fmt.Errorf("expected %d commitments of attributes, got %d",
+			len(pk.RsCommitted), len(newCommitmentsOfAttrs))
+	}
+
+	return o.issueCred(U, newKnownAttrs, newCommitmentsOfAttrs,
+		nonceUser), nil
 }
 
 // Cred represents anonymous credentials.
@@ -354,6 +235,9 @@ type Cred struct {
 	A   *big.Int
 	E   *big.Int
 	V11 *big.Int
+	// Witness proves that the credential was not revoked, it is nil
+	// when the issuer does not support revocation
+	Witness *Witness
 }
 
 func NewCred(A, e, v11 *big.Int) *Cred {
@@ -365,135 +249,23 @@ func NewCred(A, e, v11 *big.Int) *Cred {
 	}
 }
 
-func generateQuadraticResidues(group *qr.RSASpecial, knownAttrsNum, committedAttrsNum,
-	hiddenAttrsNum int) (*big.Int, *big.Int, []*big.Int,
+// generateQuadraticResidues returns a random generator S of group and
+// n random powers of S, together with their exponents.
+func generateQuadraticResidues(group *qr.RSASpecial, n int) (*big.Int,
 	[]*big.Int, []*big.Int, error) {
 	S, err := group.GetRandomGenerator()
 	if err != nil {
-		return nil, nil, nil, nil, nil, fmt.Errorf("error when searching for RSASpecial generator: %s", err)
-	}
-	Z := group.Exp(S, common.GetRandomInt(group.Order))
-
-	RsKnown := make([]*big.Int, knownAttrsNum)
-	for i, _ := range RsKnown {
-		RsKnown[i] = group.Exp(S, common.GetRandomInt(group.Order))
-	}
-
-	RsCommitted := make([]*big.Int, committedAttrsNum)
-	for i, _ := range RsCommitted {
-		RsCommitted[i] = group.Exp(S, common.GetRandomInt(group.Order))
+		return nil, nil, nil, fmt.Errorf("error when searching for RSASpecial generator: %s", err)
 	}
 
-	RsHidden := make([]*big.Int, hiddenAttrsNum)
-	for i, _ := range RsHidden {
-		RsHidden[i] = group.Exp(S, common.GetRandomInt(group.Order))
-	}
-
-	return S, Z, RsKnown, RsCommitted, RsHidden, nil
-}
-
-func (o *Org) GetCredIssueNonce() *big.Int {
-	nonce := o.GenNonce()
-	o.credIssueNonceOrg = nonce
-
-	return nonce
-}
-
-func (o *Org) verifyCredRequest(cr *CredRequest) bool {
-	return o.verifyNym(cr.NymProof) &&
-		o.verifyU(cr.UProof) &&
-		o.verifyCommitmentsOfAttrs(cr.CommitmentsOfAttrs, cr.CommitmentsOfAttrsProofs) &&
-		o.verifyChallenge(cr.UProof.Challenge) &&
-		o.verifyUProofDataLengths(cr.UProof.ProofData)
-}
-
-func (o *Org) verifyNym(proof *schnorr.Proof) bool {
-	bases := []*big.Int{
-		o.pedersenReceiver.Params.Group.G,
-		o.pedersenReceiver.Params.H,
+	residues := make([]*big.Int, n)
+	exps := make([]*big.Int, n)
+	for i := range residues {
+		exps[i] = common.GetRandomInt(group.Order)
+		residues[i] = group.Exp(S, exps[i])
 	}
-	o.nymVerifier.SetProofRandomData(proof.ProofRandomData, bases, o.nym)
-	o.nymVerifier.SetChallenge(proof.Challenge)
 
-	return o.nymVerifier.Verify(proof.ProofData)
-}
-
-func (o *Org) verifyU(UProof *qr.RepresentationProof) bool {
-	// bases are [R_1, ..., R_L, S]
-	bases := append(o.Keys.Pub.RsHidden, o.Keys.Pub.S)
-	o.UVerifier.SetProofRandomData(UProof.ProofRandomData, bases, o.U)
-	o.UVerifier.SetChallenge(UProof.Challenge)
-
-	return o.UVerifier.Verify(UProof.ProofData)
-}
-
-func (o *Org) setUpAttrVerifiers(commitmentsOfAttrs []*big.Int) error {
-	attrsVerifiers := make([]*df.OpeningVerifier, len(commitmentsOfAttrs))
-	for i, attr := range commitmentsOfAttrs {
-		receiver, err := df.NewReceiverFromParams(
-			o.Keys.Sec.AttributesSpecialRSAPrimes, o.Keys.Pub.G, o.Keys.Pub.H,
-			int(o.Params.SecParam))
-		if err != nil {
-			return err
-		}
-		receiver.SetCommitment(attr)
-
-		verifier := df.NewOpeningVerifier(receiver,
-			int(o.Params.ChallengeSpace))
-		attrsVerifiers[i] = verifier
-	}
-
-	o.attrsVerifiers = attrsVerifiers
-	o.commitmentsOfAttrs = commitmentsOfAttrs
-
-	return nil
-}
-
-// commitments ... commitmentsOfAttrs
-// proofs ... commitmentsOfAttrsProofs
-func (o *Org) verifyCommitmentsOfAttrs(commitmentsOfAttrs []*big.Int, proofs []*df.OpeningProof) bool {
-	//o.setUpAttrVerifiers(commitmentsOfAttrs)
-	for i, v := range o.attrsVerifiers {
-		v.SetProofRandomData(proofs[i].ProofRandomData)
-		v.SetChallenge(proofs[i].Challenge)
-		if !v.Verify(proofs[i].ProofData1, proofs[i].ProofData2) {
-			return false
-		}
-	}
-
-	return true
-}
-
-func (o *Org) verifyChallenge(challenge *big.Int) bool {
-	context := o.Keys.Pub.GetContext()
-	l := []*big.Int{context, o.U, o.nym, o.credIssueNonceOrg}
-	l = append(l, o.commitmentsOfAttrs...)
-	c := common.Hash(l...)
-	return c.Cmp(challenge) == 0
-}
-
-func (o *Org) verifyUProofDataLengths(UProofData []*big.Int) bool {
-	// boundary for m_tilde
-	b_m := o.Params.AttrBitLen + o.Params.SecParam + o.Params.HashBitLen + 2
-	// boundary for v1_tilde
-	b_v1 := o.Params.NLength + 2*o.Params.SecParam + o.Params.HashBitLen + 1
-
-	exp := big.NewInt(int64(b_m))
-	b1 := new(big.Int).Exp(big.NewInt(2), exp, nil)
-
-	exp = big.NewInt(int64(b_v1))
-	b2 := new(big.Int).Exp(big.NewInt(2), exp, nil)
-
-	for ind := 0; ind < len(o.Keys.Pub.RsHidden); ind++ {
-		if UProofData[ind].Cmp(b1) > 0 {
-			return false
-		}
-	}
-	if UProofData[len(o.Keys.Pub.RsHidden)].Cmp(b2) > 0 {
-		return false
-	}
-
-	return true
+	return S, residues, exps, nil
 }
 
 type ReceiverRecord struct {
@@ -502,8 +274,34 @@ type ReceiverRecord struct {
 	Q                  *big.Int
 	V11                *big.Int
 	Context            *big.Int
+	// E is the prime of the credential, it is needed to revoke
+	// the credential
+	E       *big.Int
+	Revoked bool
+	// KeyID identifies the keys the credential was issued with, it is
+	// empty for records stored before keys could be rotated
+	KeyID string `json:",omitempty"`
+	// Encoding is the version of the encoding of KnownAttrs, it is
+	// LegacyRecordEncoding for records stored before int64 values were
+	// shifted (see encodeInt64)
+	Encoding int `json:",omitempty"`
 }
 
+// Versions of the encoding of attribute values in receiver records,
+// see ReceiverRecord.Encoding.
+const (
+	// LegacyRecordEncoding uses values of int64 and date attributes as
+	// their internal values directly.
+	LegacyRecordEncoding = iota
+	// OffsetRecordEncoding shifts values of int64 and date attributes by
+	// 2^63, see encodeInt64.
+	OffsetRecordEncoding
+)
+
+// RecordEncoding is the encoding of attribute values of new receiver
+// records.
+const RecordEncoding = OffsetRecordEncoding
+
 // Returns ReceiverRecord which contains user data needed when updating the credential for this user.
 func NewReceiverRecord(knownAttrs, commitmentsOfAttrs []*big.Int, Q, v11, context *big.Int) *ReceiverRecord {
 	return &ReceiverRecord{
@@ -512,7 +310,67 @@ func NewReceiverRecord(knownAttrs, commitmentsOfAttrs []*big.Int, Q, v11, contex
 		Q:                  Q,
 		V11:                v11,
 		Context:            context,
+		Encoding:           RecordEncoding,
+	}
+}
+
+// EncodedKnownAttrs returns Known attributes of the record in the
+// current encoding (see RecordEncoding), given the attributes of the
+// credential. Values of int64 and date attributes of records with
+// LegacyRecordEncoding are encoded anew, while KnownAttrs, which Q of
+// the record was computed with, are left as they are.
+func (r *ReceiverRecord) EncodedKnownAttrs(attrs []CredAttr) ([]*big.Int,
+	error) {
+	switch r.Encoding {
+	case RecordEncoding:
+		return r.KnownAttrs, nil
+	case LegacyRecordEncoding:
+	default:
+		return nil, fmt.Errorf("unknown encoding %d of receiver record",
+			r.Encoding)
+	}
+
+	known := make([]*big.Int, 0, len(r.KnownAttrs))
+	for _, a := range attrs {
+		if !a.isKnown() {
+			continue
+		}
+		if len(known) == len(r.KnownAttrs) {
+			return nil, fmt.Errorf("receiver record does not match" +
+				" the attributes")
+		}
+		v := r.KnownAttrs[len(known)]
+		switch a.(type) {
+		case *Int64Attr, *DateAttr:
+			if !v.IsInt64() {
+				return nil, fmt.Errorf("value of attribute %s is not"+
+					" an int64 value", a.Name())
+			}
+			v = encodeInt64(v.Int64())
+		}
+		known = append(known, v)
+	}
+	if len(known) != len(r.KnownAttrs) {
+		return nil, fmt.Errorf("receiver record does not match" +
+			" the attributes")
+	}
+
+	return known, nil
+}
+
+// sameCred reports whether records r and o describe the same
+// credential, in the same state of revocation.
+func (r *ReceiverRecord) sameCred(o *ReceiverRecord) bool {
+	return r.Revoked == o.Revoked && r.KeyID == o.KeyID &&
+		equalOptionalInts(r.E, o.E) && equalOptionalInts(r.V11, o.V11)
+}
+
+// equalOptionalInts reports whether a and b are equal, or both nil.
+func equalOptionalInts(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
 	}
+	return a.Cmp(b) == 0
 }
 
 func (r *ReceiverRecord) MarshalBinary() ([]byte, error) {
@@ -527,16 +385,21 @@ func (r *ReceiverRecord) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-// TODO: where should we put WriteGob and ReadGob?
+// WriteGob writes object to filePath, encoded as gob.
+//
+// Keys should rather be written with WritePubKey and WriteSecKey.
 func WriteGob(filePath string, object interface{}) error {
 	file, err := os.Create(filePath)
-	if err == nil {
-		encoder := gob.NewEncoder(file)
-		encoder.Encode(object)
+	if err != nil {
+		return err
 	}
-	file.Close()
 
-	return err
+	if err := gob.NewEncoder(file).Encode(object); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
 }
 
 func ReadGob(filePath string, object interface{}) error {
@@ -0,0 +1,288 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/df"
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/emmyzkp/crypto/schnorr"
+)
+
+// CredIssuer holds the state of a single credential issuance.
+//
+// Org can serve many receivers at the same time, which is why a new
+// CredIssuer must be obtained with Org.NewCredIssuer for every issuance.
+// A CredIssuer must not be shared between issuances.
+type CredIssuer struct {
+	org *Org
+
+	nonce              *big.Int
+	nym                *big.Int
+	nymVerifier        *schnorr.Verifier
+	U                  *big.Int
+	commitmentsOfAttrs []*big.Int
+	knownAttrs         []*big.Int
+	attrsVerifiers     []*df.OpeningVerifier // user proves the knowledge of commitment opening (committedAttrs)
+}
+
+// NewCredIssuer creates a CredIssuer for a single issuance of a
+// credential by organization o. A fresh nonce is generated for the
+// issuance, and can be obtained with GetNonce.
+func (o *Org) NewCredIssuer() *CredIssuer {
+	return &CredIssuer{
+		org:         o,
+		nonce:       o.GenNonce(),
+		nymVerifier: schnorr.NewVerifier(o.pedersenReceiver.Params.Group),
+	}
+}
+
+// GetNonce returns the nonce that the receiver has to bind
+// the credential request to.
+func (i *CredIssuer) GetNonce() *big.Int {
+	return i.nonce
+}
+
+// IssueCred verifies the credential request cr, and issues a
+// new credential in case the request is valid.
+func (i *CredIssuer) IssueCred(cr *CredRequest) (*CredResult, error) {
+	o := i.org
+
+	i.nym = cr.Nym
+	i.knownAttrs = cr.KnownAttrs
+	err := i.setUpAttrVerifiers(cr.CommitmentsOfAttrs)
+	if err != nil {
+		return nil, err
+	}
+	i.U = cr.U
+
+	if verified := i.verifyCredRequest(cr); !verified {
+		return nil, fmt.Errorf("credential request not valid")
+	}
+
+	return o.issueCred(i.U, i.knownAttrs, i.commitmentsOfAttrs, cr.Nonce), nil
+}
+
+// issueCred issues a credential with Known attributes knownAttrs and
+// commitments of Committed attributes commitmentsOfAttrs to the
+// receiver that computed U from the Hidden attributes. The proof of
+// correctness of the credential is bound to nonceUser.
+func (o *Org) issueCred(U *big.Int, knownAttrs, commitmentsOfAttrs []*big.Int,
+	nonceUser *big.Int) *CredResult {
+	e, v11 := o.genCredRandoms()
+
+	// denom = U * S^v11 * R_1^attr_1 * ... * R_j^attr_j where only attributes from knownAttrs and committedAttrs
+	acc := big.NewInt(1)
+	for ind := 0; ind < len(knownAttrs); ind++ {
+		t1 := o.Group.Exp(o.Keys.Pub.RsKnown[ind], knownAttrs[ind])
+		acc = o.Group.Mul(acc, t1)
+	}
+
+	for ind := 0; ind < len(commitmentsOfAttrs); ind++ {
+		t1 := o.Group.Exp(o.Keys.Pub.RsCommitted[ind], commitmentsOfAttrs[ind])
+		acc = o.Group.Mul(acc, t1)
+	}
+
+	t := o.Group.Exp(o.Keys.Pub.S, v11) // s^v11
+	denom := o.Group.Mul(t, U)          // U * s^v11
+	denom = o.Group.Mul(denom, acc)     // U * s^v11 * acc
+	denomInv := o.Group.Inv(denom)
+	Q := o.Group.Mul(o.Keys.Pub.Z, denomInv)
+
+	phiN := new(big.Int).Mul(o.Group.P1, o.Group.Q1)
+	eInv := new(big.Int).ModInverse(e, phiN)
+	A := o.Group.Exp(Q, eInv)
+
+	context := o.Keys.Pub.GetContext()
+	AProof := o.genAProof(nonceUser, eInv, Q, A)
+
+	res := &CredResult{
+		Cred:   NewCred(A, e, v11),
+		AProof: AProof,
+		Record: NewReceiverRecord(knownAttrs, commitmentsOfAttrs, Q, v11, context),
+	}
+	res.Record.E = e
+	res.Record.KeyID = o.KeyID()
+
+	return res
+}
+
+func (i *CredIssuer) verifyCredRequest(cr *CredRequest) bool {
+	return i.verifyChallenge(cr) &&
+		i.verifyNym(cr.NymProof) &&
+		i.verifyU(cr.UProof) &&
+		i.verifyCommitmentsOfAttrs(cr.CommitmentsOfAttrs, cr.CommitmentsOfAttrsProofs)
+}
+
+func (i *CredIssuer) verifyNym(proof *schnorr.Proof) bool {
+	bases := []*big.Int{
+		i.org.pedersenReceiver.Params.Group.G,
+		i.org.pedersenReceiver.Params.H,
+	}
+	i.nymVerifier.SetProofRandomData(proof.ProofRandomData, bases, i.nym)
+	i.nymVerifier.SetChallenge(proof.Challenge)
+
+	return i.nymVerifier.Verify(proof.ProofData)
+}
+
+func (i *CredIssuer) verifyU(UProof *qr.RepresentationProof) bool {
+	return i.org.verifyU(i.U, UProof)
+}
+
+// verifyU checks the proof that U = S^v1 * R_1^m_1 * ... * R_L^m_L,
+// where R_i are the bases of the Hidden attributes.
+func (o *Org) verifyU(U *big.Int, UProof *qr.RepresentationProof) bool {
+	return o.verifyUWithKey(o.Keys.Pub, U, UProof)
+}
+
+// verifyUWithKey checks the proof of U like verifyU, with the bases of
+// public key pk, which shares the parameters of o.
+func (o *Org) verifyUWithKey(pk *PubKey, U *big.Int,
+	UProof *qr.RepresentationProof) bool {
+	// bases are [R_1, ..., R_L, S]
+	bases := make([]*big.Int, 0, len(pk.RsHidden)+1)
+	bases = append(bases, pk.RsHidden...)
+	bases = append(bases, pk.S)
+	if len(UProof.ProofData) != len(bases) {
+		return false
+	}
+	v := qr.NewRepresentationVerifier(qr.NewRSApecialPublic(pk.N),
+		int(o.Params.SecParam))
+	v.SetProofRandomData(UProof.ProofRandomData, bases, U)
+	v.SetChallenge(UProof.Challenge)
+
+	return v.Verify(UProof.ProofData) &&
+		o.verifyUProofDataLengths(UProof.ProofData)
+}
+
+func (i *CredIssuer) setUpAttrVerifiers(commitmentsOfAttrs []*big.Int) error {
+	attrsVerifiers, err := i.org.newAttrVerifiers(commitmentsOfAttrs)
+	if err != nil {
+		return err
+	}
+
+	i.attrsVerifiers = attrsVerifiers
+	i.commitmentsOfAttrs = commitmentsOfAttrs
+
+	return nil
+}
+
+// newAttrVerifiers returns verifiers of the proofs of openings of
+// commitments of attributes commitmentsOfAttrs.
+func (o *Org) newAttrVerifiers(commitmentsOfAttrs []*big.Int) (
+	[]*df.OpeningVerifier, error) {
+	attrsVerifiers := make([]*df.OpeningVerifier, len(commitmentsOfAttrs))
+	for j, attr := range commitmentsOfAttrs {
+		receiver, err := df.NewReceiverFromParams(
+			o.Keys.Sec.AttributesSpecialRSAPrimes, o.Keys.Pub.G, o.Keys.Pub.H,
+			int(o.Params.SecParam))
+		if err != nil {
+			return nil, err
+		}
+		receiver.SetCommitment(attr)
+
+		verifier := df.NewOpeningVerifier(receiver,
+			int(o.Params.ChallengeSpace))
+		attrsVerifiers[j] = verifier
+	}
+
+	return attrsVerifiers, nil
+}
+
+// commitments ... commitmentsOfAttrs
+// proofs ... commitmentsOfAttrsProofs
+func (i *CredIssuer) verifyCommitmentsOfAttrs(commitmentsOfAttrs []*big.Int, proofs []*df.OpeningProof) bool {
+	return verifyOpenings(i.attrsVerifiers, proofs)
+}
+
+// verifyOpenings checks the proofs of openings of commitments, one
+// proof for each of the verifiers.
+func verifyOpenings(verifiers []*df.OpeningVerifier,
+	proofs []*df.OpeningProof) bool {
+	if len(proofs) != len(verifiers) {
+		return false
+	}
+	for j, v := range verifiers {
+		v.SetProofRandomData(proofs[j].ProofRandomData)
+		v.SetChallenge(proofs[j].Challenge)
+		if !v.Verify(proofs[j].ProofData1, proofs[j].ProofData2) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// verifyChallenge checks that all the proofs in the credential request
+// cr share the challenge computed from the whole request.
+func (i *CredIssuer) verifyChallenge(cr *CredRequest) bool {
+	if cr.NymProof == nil || cr.UProof == nil ||
+		len(cr.CommitmentsOfAttrsProofs) != len(cr.CommitmentsOfAttrs) {
+		return false
+	}
+	commitmentsTilde := make([]*big.Int, len(cr.CommitmentsOfAttrsProofs))
+	for j, p := range cr.CommitmentsOfAttrsProofs {
+		commitmentsTilde[j] = p.ProofRandomData
+	}
+
+	c := credRequestChallenge(i.org.Keys.Pub, cr.Nym,
+		cr.NymProof.ProofRandomData, cr.U, cr.UProof.ProofRandomData,
+		cr.KnownAttrs, cr.CommitmentsOfAttrs, commitmentsTilde, i.nonce,
+		cr.Nonce)
+	if cr.NymProof.Challenge.Cmp(c) != 0 || cr.UProof.Challenge.Cmp(c) != 0 {
+		return false
+	}
+	for _, p := range cr.CommitmentsOfAttrsProofs {
+		if p.Challenge.Cmp(c) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o *Org) verifyUProofDataLengths(UProofData []*big.Int) bool {
+	p := o.Params
+	// boundary for m_tilde
+	b_m := p.AttrBitLen + p.SecParam + p.HashBitLen + 2
+	// boundary for v1_tilde
+	b_v1 := p.NLength + 2*p.SecParam + p.HashBitLen + 1
+
+	exp := big.NewInt(int64(b_m))
+	b1 := new(big.Int).Exp(big.NewInt(2), exp, nil)
+
+	exp = big.NewInt(int64(b_v1))
+	b2 := new(big.Int).Exp(big.NewInt(2), exp, nil)
+
+	nHidden := len(o.Keys.Pub.RsHidden)
+	if len(UProofData) != nHidden+1 {
+		return false
+	}
+	for ind := 0; ind < nHidden; ind++ {
+		if UProofData[ind].Cmp(b1) > 0 {
+			return false
+		}
+	}
+	if UProofData[nHidden].Cmp(b2) > 0 {
+		return false
+	}
+
+	return true
+}
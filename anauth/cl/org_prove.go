@@ -0,0 +1,460 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+)
+
+// CredVerifier holds the state of a single proof of possession of
+// a credential.
+//
+// A new CredVerifier must be obtained with Org.NewCredVerifier for
+// every proof, so that concurrent proofs do not interfere.
+type CredVerifier struct {
+	org   *Org
+	nonce *big.Int
+	acc   *Accumulator
+	scope string
+	// verifierID identifies the verifier that proofs are bound to
+	verifierID string
+	// scopeNym is the scope pseudonym of the prover, set once
+	// the proof is verified
+	scopeNym *big.Int
+}
+
+// NewCredVerifier creates a CredVerifier for a single proof of
+// possession of a credential issued by organization o. A fresh nonce
+// is generated for the proof, and can be obtained with GetNonce.
+func (o *Org) NewCredVerifier() *CredVerifier {
+	return &CredVerifier{
+		org:   o,
+		nonce: o.GenNonce(),
+	}
+}
+
+// GetNonce returns the nonce that the prover has to bind the proof to.
+func (v *CredVerifier) GetNonce() *big.Int {
+	return v.nonce
+}
+
+// SetAccumulator requires the prover to prove that the credential
+// is in the accumulator acc, that is, that it was not revoked.
+func (v *CredVerifier) SetAccumulator(acc *Accumulator) {
+	v.acc = acc
+}
+
+// SetScope requires the prover to present its scope pseudonym for
+// scope, which is available with ScopePseudonym once the proof is
+// verified.
+func (v *CredVerifier) SetScope(scope string) {
+	v.scope = scope
+}
+
+// SetVerifierID sets the identity of the verifier, which the prover
+// has to bind the proof to.
+func (v *CredVerifier) SetVerifierID(id string) {
+	v.verifierID = id
+}
+
+// ScopePseudonym returns the scope pseudonym of the prover, or nil if
+// no scope was set or the proof was not verified.
+func (v *CredVerifier) ScopePseudonym() *big.Int {
+	return v.scopeNym
+}
+
+// ProveCred proves the possession of a valid credential and reveals only the attributes the user desires
+// to reveal. Which knownAttrs and commitmentsOfAttrs are to be revealed are given by revealedKnownAttrsIndices and
+// revealedCommitmentsOfAttrsIndices parameters. Parameters knownAttrs and commitmentsOfAttrs must contain only
+// Known attributes and commitments of attributes (of attributes for which only commitment is Known) which are
+// to be revealed to the organization.
+//
+// Conditions of revealed attributes are checked against reference values
+// in actual. Conditions of Known attributes that are not revealed must
+// be proved with predicateProofs (see Predicates). If an accumulator
+// was set with SetAccumulator, nonRevProof has to prove that the
+// credential is in the accumulator. If a scope was set with SetScope,
+// scopeProof has to prove the scope pseudonym of the prover.
+//
+// The challenge of proof is computed from the whole CredProofTranscript
+// of the proof, including revealed attributes and the identity of
+// the verifier set with SetVerifierID.
+//
+// Attributes in attrs are not modified, so they can be shared among
+// several CredVerifiers.
+func (v *CredVerifier) ProveCred(A *big.Int, proof *qr.RepresentationProof,
+	revealedKnownAttrsIndices, revealedCommitmentsOfAttrsIndices []int,
+	revealedKnownAttrs, revealedCommitmentsOfAttrs []*big.Int,
+	attrs []CredAttr, actual map[string]interface{},
+	predicateProofs []*PredicateProof,
+	nonRevProof *NonRevocationProof,
+	scopeProof *ScopePseudonymProof) (bool, error) {
+	return v.verifyCred(A, proof, revealedKnownAttrsIndices,
+		revealedCommitmentsOfAttrsIndices, revealedKnownAttrs,
+		revealedCommitmentsOfAttrs, attrs, actual, predicateProofs,
+		nonRevProof, scopeProof,
+		func(t *CredProofTranscript) bool {
+			return proof.Challenge.Cmp(t.Challenge(v.org.Keys.Pub)) == 0
+		})
+}
+
+// verifyCred verifies the proof of possession of a credential (see
+// ProveCred), where checkChallenge checks that the challenge of proof
+// matches the transcript of the proof.
+func (v *CredVerifier) verifyCred(A *big.Int, proof *qr.RepresentationProof,
+	revealedKnownAttrsIndices, revealedCommitmentsOfAttrsIndices []int,
+	revealedKnownAttrs, revealedCommitmentsOfAttrs []*big.Int,
+	attrs []CredAttr, actual map[string]interface{},
+	predicateProofs []*PredicateProof,
+	nonRevProof *NonRevocationProof,
+	scopeProof *ScopePseudonymProof,
+	checkChallenge func(t *CredProofTranscript) bool) (bool, error) {
+	o := v.org
+
+	if v.acc != nil && nonRevProof == nil {
+		return false, fmt.Errorf("missing non-revocation proof")
+	}
+	if v.acc == nil && nonRevProof != nil {
+		return false, fmt.Errorf("unexpected non-revocation proof")
+	}
+	if v.scope != "" && scopeProof == nil {
+		return false, fmt.Errorf("missing scope pseudonym proof")
+	}
+	if v.scope == "" && scopeProof != nil {
+		return false, fmt.Errorf("unexpected scope pseudonym proof")
+	}
+	if scopeProof != nil && len(o.Keys.Pub.RsHidden) == 0 {
+		return false, fmt.Errorf("scope pseudonyms require a hidden attribute")
+	}
+
+	if len(revealedKnownAttrsIndices) != len(revealedKnownAttrs) ||
+		len(revealedCommitmentsOfAttrsIndices) != len(revealedCommitmentsOfAttrs) {
+		return false, fmt.Errorf("revealed attributes do not match their indices")
+	}
+	// a repeated index would let the prover split the value of an
+	// attribute among several revealed values
+	if err := checkRevealedIndices(revealedKnownAttrsIndices); err != nil {
+		return false, err
+	}
+	if err := checkRevealedIndices(revealedCommitmentsOfAttrsIndices); err != nil {
+		return false, err
+	}
+
+	knownAttrs := make([]CredAttr, 0)
+	for _, a := range attrs { // Attrs are ordered by Index, so knownAttrs will be too
+		if a.isKnown() {
+			knownAttrs = append(knownAttrs, a)
+		}
+	}
+
+	for i, ind := range revealedKnownAttrsIndices {
+		if ind < 0 || ind >= len(knownAttrs) {
+			return false, fmt.Errorf("invalid index of revealed attribute: %d", ind)
+		}
+		// work on a copy, attrs are shared with concurrent proofs
+		a := knownAttrs[ind].clone()
+
+		err := a.updateInternalValue(revealedKnownAttrs[i])
+		if err != nil {
+			return false, err
+		}
+
+		valToCheck, ok := actual[a.Name()]
+		if a.getCond() != none { // condition was specified
+			if !ok {
+				return false, fmt.Errorf(
+					"missing reference value for attribute '%s'", a.Name())
+			}
+
+			valid, err := a.ValidateAgainst(valToCheck)
+			if err != nil {
+				return false, err
+			}
+
+			// TODO add some logging
+			if !valid {
+				return false, fmt.Errorf("attribute value validation failed for %s", a.Name())
+			}
+		}
+	}
+
+	for _, ind := range revealedCommitmentsOfAttrsIndices {
+		if ind < 0 || ind >= len(o.Keys.Pub.RsCommitted) {
+			return false, fmt.Errorf("invalid index of revealed commitment: %d", ind)
+		}
+	}
+
+	ver := qr.NewRepresentationVerifier(o.Group, int(o.Params.SecParam))
+	bases := []*big.Int{}
+	for i := 0; i < len(o.Keys.Pub.RsKnown); i++ {
+		if !common.Contains(revealedKnownAttrsIndices, i) {
+			bases = append(bases, o.Keys.Pub.RsKnown[i])
+		}
+	}
+	for i := 0; i < len(o.Keys.Pub.RsCommitted); i++ {
+		if !common.Contains(revealedCommitmentsOfAttrsIndices, i) {
+			bases = append(bases, o.Keys.Pub.RsCommitted[i])
+		}
+	}
+	// position of proof data for the first Hidden attribute
+	hiddenInd := len(bases)
+	bases = append(bases, o.Keys.Pub.RsHidden...)
+	bases = append(bases, A)
+	bases = append(bases, o.Keys.Pub.S)
+
+	if len(proof.ProofData) != len(bases) {
+		return false, fmt.Errorf("expected %d proof data elements, got %d",
+			len(bases), len(proof.ProofData))
+	}
+
+	denom := big.NewInt(1)
+	for i := 0; i < len(revealedKnownAttrs); i++ {
+		rInd := revealedKnownAttrsIndices[i]
+		t1 := o.Group.Exp(o.Keys.Pub.RsKnown[rInd], revealedKnownAttrs[i])
+		denom = o.Group.Mul(denom, t1)
+	}
+
+	for i := 0; i < len(revealedCommitmentsOfAttrs); i++ {
+		rInd := revealedCommitmentsOfAttrsIndices[i]
+		t1 := o.Group.Exp(o.Keys.Pub.RsCommitted[rInd], revealedCommitmentsOfAttrs[i])
+		denom = o.Group.Mul(denom, t1)
+	}
+	denomInv := o.Group.Inv(denom)
+	y := o.Group.Mul(o.Keys.Pub.Z, denomInv)
+	ver.SetProofRandomData(proof.ProofRandomData, bases, y)
+
+	t := &CredProofTranscript{
+		A:                                 A,
+		RevealedKnownAttrsIndices:         revealedKnownAttrsIndices,
+		RevealedKnownAttrs:                revealedKnownAttrs,
+		RevealedCommitmentsOfAttrsIndices: revealedCommitmentsOfAttrsIndices,
+		RevealedCommitmentsOfAttrs:        revealedCommitmentsOfAttrs,
+		ProofRandomData:                   proof.ProofRandomData,
+		PredicateProofs:                   predicateProofs,
+		NonRevocationProof:                nonRevProof,
+		ScopePseudonymProof:               scopeProof,
+		Scope:                             v.scope,
+		Verifier:                          v.verifierID,
+		Nonce:                             v.nonce,
+	}
+	if !checkChallenge(t) {
+		return false, fmt.Errorf("challenge is not correct")
+	}
+
+	ver.SetChallenge(proof.Challenge)
+
+	if !ver.Verify(proof.ProofData) {
+		return false, nil
+	}
+
+	if v.acc != nil {
+		// proof data for e precedes the one for v
+		eHat := proof.ProofData[len(proof.ProofData)-2]
+		valid, err := verifyNonRevocationProof(o.Group, o.Keys.Pub, v.acc,
+			nonRevProof, eHat, proof.Challenge)
+		if err != nil {
+			return false, err
+		}
+		if !valid {
+			return false, nil
+		}
+	}
+
+	if scopeProof != nil {
+		valid, err := verifyScopePseudonymProof(o.Keys.Pub, v.scope,
+			scopeProof, proof.ProofData[hiddenInd], proof.Challenge)
+		if err != nil {
+			return false, err
+		}
+		if !valid {
+			return false, nil
+		}
+	}
+
+	valid, err := v.verifyPredicates(knownAttrs, revealedKnownAttrsIndices,
+		actual, proof, predicateProofs)
+	if err != nil || !valid {
+		return valid, err
+	}
+
+	if scopeProof != nil {
+		v.scopeNym = scopeProof.Nym
+	}
+
+	return true, nil
+}
+
+// verifyPredicates checks that conditions of all the Known attributes
+// that were not revealed are proved with predicateProofs.
+func (v *CredVerifier) verifyPredicates(knownAttrs []CredAttr,
+	revealedKnownAttrsIndices []int, actual map[string]interface{},
+	proof *qr.RepresentationProof, predicateProofs []*PredicateProof) (bool,
+	error) {
+	proofs := make(map[int]*PredicateProof, len(predicateProofs))
+	for _, p := range predicateProofs {
+		if _, ok := proofs[p.KnownAttrIndex]; ok {
+			return false, fmt.Errorf("duplicate predicate proof for"+
+				" attribute %d", p.KnownAttrIndex)
+		}
+		proofs[p.KnownAttrIndex] = p
+	}
+
+	// proof data for unrevealed Known attributes comes first
+	secretInd := 0
+	for ind, a := range knownAttrs {
+		if common.Contains(revealedKnownAttrsIndices, ind) {
+			if _, ok := proofs[ind]; ok {
+				return false, fmt.Errorf("unexpected predicate proof"+
+					" for revealed attribute %s", a.Name())
+			}
+			continue
+		}
+		mHat := proof.ProofData[secretInd]
+		secretInd++
+
+		p, ok := proofs[ind]
+		delete(proofs, ind)
+		if a.getCond() == none {
+			if ok {
+				return false, fmt.Errorf("unexpected predicate proof"+
+					" for attribute %s", a.Name())
+			}
+			continue
+		}
+
+		pred, err := newAttrPredicate(a, actual)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, fmt.Errorf("missing predicate proof for"+
+				" attribute %s", a.Name())
+		}
+
+		valid, err := verifyPredicateProof(v.org.Group, v.org.Keys.Pub,
+			pred, p, mHat, proof.Challenge)
+		if err != nil {
+			return false, err
+		}
+		if !valid {
+			return false, nil
+		}
+	}
+
+	if len(proofs) > 0 {
+		return false, fmt.Errorf("predicate proof for unknown attribute")
+	}
+
+	return true, nil
+}
+
+// Predicates returns predicates over Known attributes from attrs that
+// can be proved without revealing the attributes, with reference values
+// taken from actual. Provers have to prove the predicates for the
+// attributes they do not reveal.
+func Predicates(attrs []CredAttr,
+	actual map[string]interface{}) ([]*Predicate, error) {
+	preds := make([]*Predicate, 0)
+	for _, a := range attrs {
+		if !a.isKnown() || !provable(a) {
+			continue
+		}
+		pred, err := newAttrPredicate(a, actual)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+
+	return preds, nil
+}
+
+// provable reports whether the condition of attribute a can be proved
+// without revealing the attribute.
+func provable(a CredAttr) bool {
+	switch a.(type) {
+	case *Int64Attr, *DateAttr:
+		switch a.getCond() {
+		case lessThan, lessThanOrEqual, greaterThan, greaterThanOrEqual:
+			return true
+		}
+	case *StrAttr, *EnumAttr:
+		return a.getCond() == in
+	}
+
+	return false
+}
+
+// newAttrPredicate returns the predicate for the condition of attribute
+// a, with the reference value from actual.
+func newAttrPredicate(a CredAttr,
+	actual map[string]interface{}) (*Predicate, error) {
+	if !provable(a) {
+		return nil, fmt.Errorf("attribute %s has to be revealed", a.Name())
+	}
+
+	val, ok := actual[a.Name()]
+	if !ok {
+		return nil, fmt.Errorf(
+			"missing reference value for attribute '%s'", a.Name())
+	}
+
+	if a.getCond() == in {
+		set, err := parseStrSet(val)
+		if err != nil {
+			return nil, fmt.Errorf("value provided for '%s': %s", a.Name(),
+				err)
+		}
+		return NewSetPredicate(a.Name(), set), nil
+	}
+
+	if _, ok := a.(*DateAttr); ok {
+		date, err := ResolveDate(val, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("value provided for '%s': %s", a.Name(),
+				err)
+		}
+		return NewPredicate(a.Name(), a.getCond(), daysOf(date)), nil
+	}
+
+	ref, ok := val.(int64)
+	if !ok {
+		return nil, fmt.Errorf("value provided for '%s' is not int64",
+			a.Name())
+	}
+
+	return NewPredicate(a.Name(), a.getCond(), ref), nil
+}
+
+// checkRevealedIndices checks that the indices of revealed attributes are
+// strictly increasing, as produced by the prover. Among others this rejects
+// an attribute revealed more than once.
+func checkRevealedIndices(indices []int) error {
+	for i := 1; i < len(indices); i++ {
+		if indices[i] <= indices[i-1] {
+			return fmt.Errorf("indices of revealed attributes are not"+
+				" strictly increasing: %v", indices)
+		}
+	}
+	return nil
+}
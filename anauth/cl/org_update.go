@@ -0,0 +1,245 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/df"
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/emmyzkp/crypto/schnorr"
+)
+
+// CredUpdateRequest asks the organization to update the Known
+// attributes of the credential issued to Nym. NymProof proves the
+// knowledge of the opening of Nym, that is, that the request was made
+// by the holder of the credential.
+//
+// NewCommitmentsOfAttrs are set when the Committed attributes of the
+// credential change, and replace the commitments the credential was
+// issued with. CommitmentsOfAttrsProofs prove the knowledge of their
+// openings and share the challenge with NymProof.
+//
+// U and UProof are set when the credential was issued with keys other
+// than the current keys of the organization (see Org.ReissueCred).
+// U is computed with the current keys, and UProof shares its challenge
+// with NymProof. PrevUProof proves the representation of the U that the
+// credential was issued for with the other keys, and shares the
+// responses for Hidden attributes with UProof, so that the re-issued
+// credential holds the same Hidden attributes.
+type CredUpdateRequest struct {
+	Nym                      *big.Int
+	NewKnownAttrs            []*big.Int
+	NewCommitmentsOfAttrs    []*big.Int
+	NymProof                 *schnorr.Proof
+	CommitmentsOfAttrsProofs []*df.OpeningProof
+	Nonce                    *big.Int
+	U                        *big.Int
+	UProof                   *qr.RepresentationProof
+	PrevUProof               *qr.RepresentationProof
+}
+
+func NewCredUpdateRequest(nym *big.Int, newKnownAttrs []*big.Int,
+	nymProof *schnorr.Proof, nonce *big.Int) *CredUpdateRequest {
+	return &CredUpdateRequest{
+		Nym:           nym,
+		NewKnownAttrs: newKnownAttrs,
+		NymProof:      nymProof,
+		Nonce:         nonce,
+	}
+}
+
+// CredUpdater holds the state of a single update of a credential.
+//
+// A new CredUpdater must be obtained with Org.NewCredUpdater for every
+// update, so that concurrent updates do not interfere.
+type CredUpdater struct {
+	org   *Org
+	nonce *big.Int
+}
+
+// NewCredUpdater creates a CredUpdater for a single update of
+// a credential issued by organization o. A fresh nonce is generated for
+// the update, and can be obtained with GetNonce.
+func (o *Org) NewCredUpdater() *CredUpdater {
+	return &CredUpdater{
+		org:   o,
+		nonce: o.GenNonce(),
+	}
+}
+
+// GetNonce returns the nonce that the receiver has to bind the update
+// request to.
+func (u *CredUpdater) GetNonce() *big.Int {
+	return u.nonce
+}
+
+// VerifyRequest checks that the update request ur was made by the
+// holder of the nym, and that it is bound to the nonce of u. If U is
+// set in ur, it also checks the proof of the representation of U, and
+// that U holds the same Hidden attributes as the credential with
+// receiver record rec. If NewCommitmentsOfAttrs are set, it checks
+// the proofs of their openings.
+// Attributes in ur have to match recKey, the public key that the
+// credential was issued with, which is a retired key when the
+// credential is re-issued (see Org.ReissueCred).
+// It does not check whether the requested attribute changes are
+// allowed.
+func (u *CredUpdater) VerifyRequest(ur *CredUpdateRequest,
+	rec *ReceiverRecord, recKey *PubKey) error {
+	o := u.org
+	proof := ur.NymProof
+	if ur.Nym == nil || ur.Nonce == nil || proof == nil ||
+		len(proof.ProofData) != 2 {
+		return fmt.Errorf("malformed update request")
+	}
+	if len(ur.NewKnownAttrs) != len(recKey.RsKnown) {
+		return fmt.Errorf("expected %d known attributes, got %d",
+			len(recKey.RsKnown), len(ur.NewKnownAttrs))
+	}
+	if !checkBitLen(ur.NewKnownAttrs, int(o.Params.AttrBitLen)) {
+		return fmt.Errorf("attributes length not ok")
+	}
+	if len(ur.CommitmentsOfAttrsProofs) != len(ur.NewCommitmentsOfAttrs) {
+		return fmt.Errorf("malformed update request")
+	}
+	if len(ur.NewCommitmentsOfAttrs) != 0 &&
+		len(ur.NewCommitmentsOfAttrs) != len(recKey.RsCommitted) {
+		return fmt.Errorf("expected %d commitments of attributes, got %d",
+			len(recKey.RsCommitted), len(ur.NewCommitmentsOfAttrs))
+	}
+	commitmentsTilde := make([]*big.Int, len(ur.CommitmentsOfAttrsProofs))
+	for j, p := range ur.CommitmentsOfAttrsProofs {
+		commitmentsTilde[j] = p.ProofRandomData
+	}
+
+	var c *big.Int
+	if ur.U != nil {
+		if ur.UProof == nil || ur.PrevUProof == nil {
+			return fmt.Errorf("malformed update request")
+		}
+		prevU, err := issuedU(recKey, rec)
+		if err != nil {
+			return err
+		}
+		c = credReissueChallenge(o.Keys.Pub, ur.Nym, proof.ProofRandomData,
+			ur.U, ur.UProof.ProofRandomData, prevU,
+			ur.PrevUProof.ProofRandomData, u.nonce, ur.Nonce,
+			ur.NewKnownAttrs, ur.NewCommitmentsOfAttrs, commitmentsTilde)
+		if ur.UProof.Challenge.Cmp(c) != 0 || !o.verifyU(ur.U, ur.UProof) {
+			return fmt.Errorf("proof of U is not valid")
+		}
+		if ur.PrevUProof.Challenge.Cmp(c) != 0 ||
+			!o.verifyUWithKey(recKey, prevU, ur.PrevUProof) {
+			return fmt.Errorf("proof of the previous U is not valid")
+		}
+		// equal responses with equal random data and challenge prove
+		// equal Hidden attributes
+		for i := range recKey.RsHidden {
+			if ur.UProof.ProofData[i].Cmp(ur.PrevUProof.ProofData[i]) != 0 {
+				return fmt.Errorf("hidden attributes do not match" +
+					" the credential")
+			}
+		}
+	} else {
+		c = credUpdateChallenge(o.Keys.Pub, ur.Nym, proof.ProofRandomData,
+			u.nonce, ur.Nonce, ur.NewKnownAttrs, ur.NewCommitmentsOfAttrs,
+			commitmentsTilde)
+	}
+	if proof.Challenge.Cmp(c) != 0 {
+		return fmt.Errorf("challenge is not correct")
+	}
+	for _, p := range ur.CommitmentsOfAttrsProofs {
+		if p.Challenge.Cmp(c) != 0 {
+			return fmt.Errorf("challenge is not correct")
+		}
+	}
+
+	group := o.pedersenReceiver.Params.Group
+	ver := schnorr.NewVerifier(group)
+	bases := []*big.Int{group.G, o.pedersenReceiver.Params.H}
+	ver.SetProofRandomData(proof.ProofRandomData, bases, ur.Nym)
+	ver.SetChallenge(proof.Challenge)
+	if !ver.Verify(proof.ProofData) {
+		return fmt.Errorf("proof of nym opening is not valid")
+	}
+
+	verifiers, err := o.newAttrVerifiers(ur.NewCommitmentsOfAttrs)
+	if err != nil {
+		return err
+	}
+	if !verifyOpenings(verifiers, ur.CommitmentsOfAttrsProofs) {
+		return fmt.Errorf("proof of commitment opening is not valid")
+	}
+
+	return nil
+}
+
+// issuedU returns U that the credential with receiver record rec was
+// issued for with public key pk, which is Z / (Q * S^v11 * R_1^a_1 *
+// ... * R_k^a_k) for the Known and Committed attributes a_i of rec.
+func issuedU(pk *PubKey, rec *ReceiverRecord) (*big.Int, error) {
+	if rec.Q == nil || rec.V11 == nil ||
+		len(rec.KnownAttrs) != len(pk.RsKnown) ||
+		len(rec.CommitmentsOfAttrs) != len(pk.RsCommitted) {
+		return nil, fmt.Errorf("receiver record does not match the key")
+	}
+
+	group := qr.NewRSApecialPublic(pk.N)
+	denom := group.Mul(rec.Q, group.Exp(pk.S, rec.V11))
+	for i, a := range rec.KnownAttrs {
+		denom = group.Mul(denom, group.Exp(pk.RsKnown[i], a))
+	}
+	for i, a := range rec.CommitmentsOfAttrs {
+		denom = group.Mul(denom, group.Exp(pk.RsCommitted[i], a))
+	}
+	denomInv := new(big.Int).ModInverse(denom, pk.N)
+	if denomInv == nil {
+		return nil, fmt.Errorf("receiver record does not match the key")
+	}
+
+	return group.Mul(pk.Z, denomInv), nil
+}
+
+// AttrChange is a change of the value of an attribute requested in
+// a credential update. For Committed attributes, Old and New are the
+// commitments of the values, since the values are not revealed to the
+// organization.
+type AttrChange struct {
+	Name      string
+	Old, New  *big.Int
+	Committed bool
+}
+
+// UpdateAuthorizer approves changes of attributes in credential
+// updates. AuthorizeUpdate is called only after the holder of nym has
+// been authenticated, and returns an error if the update is not
+// allowed.
+type UpdateAuthorizer interface {
+	AuthorizeUpdate(nym *big.Int, changes []*AttrChange) error
+}
+
+// UpdateAuthorizerFunc is an adapter that allows the use of ordinary
+// functions as UpdateAuthorizers.
+type UpdateAuthorizerFunc func(nym *big.Int, changes []*AttrChange) error
+
+func (f UpdateAuthorizerFunc) AuthorizeUpdate(nym *big.Int,
+	changes []*AttrChange) error {
+	return f(nym, changes)
+}
@@ -0,0 +1,282 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/emmyzkp/crypto/df"
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/emmyzkp/crypto/schnorr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCredManager returns a credential manager for the credential
+// of a test organization with Known attribute a set to val.
+func newTestCredManager(t *testing.T, o *Org, val int) *CredManager {
+	rc := NewRawCred(NewAttrCount(1, 0, 0))
+	require.NoError(t, rc.addEmptyInt64Attr("a", 0, true))
+	require.NoError(t, rc.UpdateAttr("a", val))
+
+	cm, err := NewCredManager(o.Params, o.Keys.Pub,
+		o.Keys.Pub.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+
+	return cm
+}
+
+func TestCredUpdater_VerifyRequest(t *testing.T) {
+	o := newTestOrg(t)
+	cm := newTestCredManager(t, o, 30)
+
+	// manager restored from the context can still prove the
+	// knowledge of nym opening
+	cm, err := RestoreCredManager(cm.GetContext(), cm.masterSecret,
+		cm.RawCred)
+	require.NoError(t, err)
+
+	u := o.NewCredUpdater()
+	ur, err := cm.GetCredUpdateRequest(u.GetNonce())
+	require.NoError(t, err)
+	assert.NoError(t, u.VerifyRequest(ur, nil, o.Keys.Pub))
+
+	// request bound to the nonce of another update
+	assert.Error(t, o.NewCredUpdater().VerifyRequest(ur, nil, o.Keys.Pub))
+
+	// attributes other than the ones the request was made for
+	tampered := *ur
+	tampered.NewKnownAttrs = []*big.Int{big.NewInt(31)}
+	assert.Error(t, u.VerifyRequest(&tampered, nil, o.Keys.Pub))
+
+	// request made by someone who does not know the opening of nym
+	other := newTestCredManager(t, o, 30)
+	other.nymRandomness = cm.nymRandomness
+	other.Nym = cm.Nym
+	ur, err = other.GetCredUpdateRequest(u.GetNonce())
+	require.NoError(t, err)
+	assert.Error(t, u.VerifyRequest(ur, nil, o.Keys.Pub))
+
+	// malformed proof
+	ur, err = cm.GetCredUpdateRequest(u.GetNonce())
+	require.NoError(t, err)
+	ur.NymProof.ProofData = ur.NymProof.ProofData[:1]
+	assert.Error(t, u.VerifyRequest(ur, nil, o.Keys.Pub))
+}
+
+func TestOrg_ReissueCred(t *testing.T) {
+	o := newTestOrg(t)
+	cm := newTestCredManager(t, o, 30)
+
+	issuer := o.NewCredIssuer()
+	cr, err := cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+	res, err := issuer.IssueCred(cr)
+	require.NoError(t, err)
+	assert.Equal(t, o.KeyID(), res.Record.KeyID)
+
+	keys, err := GenerateNextKeyPair(o.Params, NewAttrCount(1, 0, 0),
+		o.Keys.Pub)
+	require.NoError(t, err)
+	assert.True(t, equalPedersenParams(o.Keys.Pub, keys.Pub))
+	next, err := NewOrgFromParams(o.Params, keys)
+	require.NoError(t, err)
+	require.NotEqual(t, o.KeyID(), next.KeyID())
+
+	u := next.NewCredUpdater()
+	ur, err := cm.GetCredReissueRequest(u.GetNonce(), next.Keys.Pub)
+	require.NoError(t, err)
+	require.NoError(t, u.VerifyRequest(ur, res.Record, o.Keys.Pub))
+
+	// attributes are checked against the key of the credential
+	retired := copyPubKey(o.Keys.Pub)
+	retired.RsKnown = append(retired.RsKnown, retired.RsKnown[0])
+	assert.Error(t, u.VerifyRequest(ur, res.Record, retired))
+
+	// U is computed with the new keys
+	assert.Error(t, o.NewCredUpdater().VerifyRequest(ur, res.Record, o.Keys.Pub))
+	tampered := *ur
+	tampered.U = new(big.Int).Add(ur.U, big.NewInt(1))
+	assert.Error(t, u.VerifyRequest(&tampered, res.Record, o.Keys.Pub))
+
+	res, err = next.ReissueCred(res.Record, ur.U, ur.Nonce, ur.NewKnownAttrs,
+		nil)
+	require.NoError(t, err)
+	assert.Equal(t, next.KeyID(), res.Record.KeyID)
+
+	ok, err := cm.Verify(res.Cred, res.AProof)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestOrg_ReissueCred_Hidden checks that Hidden attributes of
+// a credential cannot change when it is re-issued.
+func TestOrg_ReissueCred_Hidden(t *testing.T) {
+	o, err := NewOrg(GetDefaultParamSizes(), NewAttrCount(1, 0, 1))
+	require.NoError(t, err)
+	keys, err := GenerateNextKeyPair(o.Params, NewAttrCount(1, 0, 1),
+		o.Keys.Pub)
+	require.NoError(t, err)
+	next, err := NewOrgFromParams(o.Params, keys)
+	require.NoError(t, err)
+
+	cm, _ := newTestHolderCred(t, o, 30, 42)
+	issuer := o.NewCredIssuer()
+	cr, err := cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+	res, err := issuer.IssueCred(cr)
+	require.NoError(t, err)
+	ctx := cm.GetContext()
+
+	u := next.NewCredUpdater()
+	ur, err := cm.GetCredReissueRequest(u.GetNonce(), next.Keys.Pub)
+	require.NoError(t, err)
+	require.NoError(t, u.VerifyRequest(ur, res.Record, o.Keys.Pub))
+
+	// the proof of U the credential was issued for is required
+	tampered := *ur
+	tampered.PrevUProof = nil
+	assert.Error(t, u.VerifyRequest(&tampered, res.Record, o.Keys.Pub))
+
+	// the holder claims another Hidden attribute for the credential
+	swapped, err := RestoreCredManager(ctx, cm.masterSecret, cm.RawCred)
+	require.NoError(t, err)
+	swapped.Attrs.Hidden = []*big.Int{encodeInt64(43)}
+	ur, err = swapped.GetCredReissueRequest(u.GetNonce(), next.Keys.Pub)
+	require.NoError(t, err)
+	assert.Error(t, u.VerifyRequest(ur, res.Record, o.Keys.Pub))
+
+	// U of the re-issued credential holds another Hidden attribute
+	// than the U the credential was issued for
+	forger, err := RestoreCredManager(ctx, cm.masterSecret, cm.RawCred)
+	require.NoError(t, err)
+	ur = forgeReissueRequest(t, forger, u.GetNonce(), next.Keys.Pub,
+		encodeInt64(43))
+	err = u.VerifyRequest(ur, res.Record, o.Keys.Pub)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hidden attributes")
+}
+
+// forgeReissueRequest returns a request for re-issuing the credential
+// of cm with public key pk, which proves the U of the credential with
+// its Hidden attribute, and the new U with Hidden attribute hidden.
+func forgeReissueRequest(t *testing.T, cm *CredManager, nonceOrg *big.Int,
+	pk *PubKey, hidden *big.Int) *CredUpdateRequest {
+	params := cm.Params
+	prevKey, prevHidden, prevV1 := cm.PubKey, cm.Attrs.Hidden, cm.V1
+	prevU := computeUWithKey(prevKey, prevHidden, prevV1)
+	cm.PubKey = pk
+	newHidden := []*big.Int{hidden}
+	U, v1 := computeUWithKey(pk, newHidden, prevV1), prevV1
+
+	nymProver, err := cm.getNymProver()
+	require.NoError(t, err)
+	bm := int(params.AttrBitLen + params.SecParam + params.HashBitLen)
+	bv := int(params.NLength + 2*params.SecParam + params.HashBitLen)
+	prevTilde := []*big.Int{getRandomBoundedInt(bm)}
+	tilde := []*big.Int{getRandomBoundedInt(bm)}
+	prevV1Tilde, v1Tilde := getRandomBoundedInt(bv), getRandomBoundedInt(bv)
+
+	nymTilde := nymProver.GetProofRandomData()
+	UTilde := computeUWithKey(pk, tilde, v1Tilde)
+	prevUTilde := computeUWithKey(prevKey, prevTilde, prevV1Tilde)
+	nonce := big.NewInt(1)
+	c := credReissueChallenge(pk, cm.Nym, nymTilde, U, UTilde, prevU,
+		prevUTilde, nonceOrg, nonce, cm.Attrs.Known, nil, nil)
+
+	ur := NewCredUpdateRequest(cm.Nym, cm.Attrs.Known,
+		schnorr.NewProof(nymTilde, c, nymProver.GetProofData(c)), nonce)
+	ur.U = U
+	ur.UProof = qr.NewRepresentationProof(UTilde, c,
+		uProofData(newHidden, v1, tilde, v1Tilde, c))
+	ur.PrevUProof = qr.NewRepresentationProof(prevUTilde, c,
+		uProofData(prevHidden, prevV1, prevTilde, prevV1Tilde, c))
+
+	return ur
+}
+
+func TestOrg_UpdateCred_Committed(t *testing.T) {
+	o, err := NewOrg(GetDefaultParamSizes(), NewAttrCount(1, 1, 0))
+	require.NoError(t, err)
+
+	rc := NewRawCred(NewAttrCount(1, 1, 0))
+	require.NoError(t, rc.addEmptyInt64Attr("a", 0, true))
+	require.NoError(t, rc.addEmptyInt64Attr("b", 1, false))
+	require.NoError(t, rc.UpdateAttr("a", 30))
+	require.NoError(t, rc.UpdateAttr("b", 40))
+	cm, err := NewCredManager(o.Params, o.Keys.Pub,
+		o.Keys.Pub.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+
+	issuer := o.NewCredIssuer()
+	cr, err := cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+	res, err := issuer.IssueCred(cr)
+	require.NoError(t, err)
+
+	// unchanged Committed attributes are not committed to anew
+	require.NoError(t, cm.Update(rc))
+	ur, err := cm.GetCredUpdateRequest(o.NewCredUpdater().GetNonce())
+	require.NoError(t, err)
+	assert.Nil(t, ur.NewCommitmentsOfAttrs)
+
+	require.NoError(t, rc.UpdateAttr("b", 41))
+	require.NoError(t, cm.Update(rc))
+	u := o.NewCredUpdater()
+	ur, err = cm.GetCredUpdateRequest(u.GetNonce())
+	require.NoError(t, err)
+	require.Len(t, ur.NewCommitmentsOfAttrs, 1)
+	require.Len(t, ur.CommitmentsOfAttrsProofs, 1)
+	assert.NotEqual(t, res.Record.CommitmentsOfAttrs[0],
+		ur.NewCommitmentsOfAttrs[0])
+	require.NoError(t, u.VerifyRequest(ur, nil, o.Keys.Pub))
+
+	// commitments other than the ones the request was made for
+	tampered := *ur
+	tampered.NewCommitmentsOfAttrs = res.Record.CommitmentsOfAttrs
+	assert.Error(t, u.VerifyRequest(&tampered, nil, o.Keys.Pub))
+
+	// invalid proof of commitment opening
+	p := ur.CommitmentsOfAttrsProofs[0]
+	tampered = *ur
+	tampered.CommitmentsOfAttrsProofs = []*df.OpeningProof{
+		df.NewOpeningProof(p.ProofRandomData, p.Challenge,
+			new(big.Int).Add(p.ProofData1, big.NewInt(1)), p.ProofData2),
+	}
+	assert.Error(t, u.VerifyRequest(&tampered, nil, o.Keys.Pub))
+
+	// missing proof of commitment opening
+	tampered = *ur
+	tampered.CommitmentsOfAttrsProofs = nil
+	assert.Error(t, u.VerifyRequest(&tampered, nil, o.Keys.Pub))
+
+	res, err = o.UpdateCred(ur.Nym, res.Record, ur.Nonce, ur.NewKnownAttrs,
+		ur.NewCommitmentsOfAttrs)
+	require.NoError(t, err)
+	assert.Equal(t, ur.NewCommitmentsOfAttrs, res.Record.CommitmentsOfAttrs)
+
+	ok, err := cm.Verify(res.Cred, res.AProof)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the commitments are sent only once
+	ur, err = cm.GetCredUpdateRequest(o.NewCredUpdater().GetNonce())
+	require.NoError(t, err)
+	assert.Nil(t, ur.NewCommitmentsOfAttrs)
+}
@@ -17,13 +17,159 @@
 
 package cl
 
-import pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+import (
+	"crypto/sha512"
+	"fmt"
+	"sort"
+	"time"
 
-// TODO: add method to load params from file or blockchain or wherever they will be stored.
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+)
+
+// Names of parameter profiles, see GetParamsProfile.
+const (
+	// TestProfile is meant for testing only, its RSA modulus is far too
+	// short to be secure.
+	TestProfile = "test"
+	Profile2048 = "2048"
+	Profile3072 = "3072"
+)
+
+// paramsProfile holds the lengths that the rest of the parameters are
+// derived from.
+type paramsProfile struct {
+	nLength        int32
+	rhoBitLen      int32
+	attrBitLen     int32
+	secParam       int32
+	challengeSpace int32
+}
+
+var paramsProfiles = map[string]paramsProfile{
+	TestProfile: {
+		nLength:        256,
+		rhoBitLen:      256,
+		attrBitLen:     256,
+		secParam:       80,
+		challengeSpace: 80,
+	},
+	Profile2048: {
+		nLength:        2048,
+		rhoBitLen:      256,
+		attrBitLen:     256,
+		secParam:       80,
+		challengeSpace: 80,
+	},
+	Profile3072: {
+		nLength:        3072,
+		rhoBitLen:      256,
+		attrBitLen:     256,
+		secParam:       128,
+		challengeSpace: 128,
+	},
+}
+
+// hashBitLen is the bit length of the output of the hash function used
+// for Fiat-Shamir challenges (see transcript).
+const hashBitLen = sha512.Size * 8
+
+// e1BitLen is the bit length of the interval that e values of
+// credentials are taken from.
+const e1BitLen = 120
+
+// params derives the parameters of the profile. Lengths of e and v
+// values are the smallest that satisfy the constraints of the CL
+// scheme (see ValidateParams).
+func (p paramsProfile) params() *pb.Params {
+	params := &pb.Params{
+		RhoBitLen:      p.rhoBitLen,
+		NLength:        p.nLength,
+		AttrBitLen:     p.attrBitLen,
+		HashBitLen:     hashBitLen,
+		SecParam:       p.secParam,
+		E1BitLen:       e1BitLen,
+		ChallengeSpace: p.challengeSpace,
+	}
+	params.EBitLen = minEBitLen(params) + 1
+	params.VBitLen = minVBitLen(params) + 1
+
+	return params
+}
+
+// minEBitLen returns the bound that EBitLen has to exceed, the
+// constraint on l_e of the Identity Mixer specification (Specification
+// of the Identity Mixer Cryptographic Library, v2.3.0), with
+// l_phi = SecParam:
+//
+//	l_e > l_phi + l_H + max(l_m + 4, l_e' + 2)
+func minEBitLen(p *pb.Params) int32 {
+	return p.SecParam + p.HashBitLen + max32(p.AttrBitLen+4, p.E1BitLen+2)
+}
+
+// minVBitLen returns the bound that VBitLen has to exceed. Besides the
+// constraint on l_v of the Identity Mixer specification (see
+// minEBitLen), with l_r = SecParam:
+//
+//	l_v > l_n + l_phi + l_H + max(l_m + l_r + 3, l_phi + 2)
+//
+// v has to exceed e*r by l_phi bits, where r of l_n + l_phi bits randomizes
+// the credential in a proof of possession (see CredManager.randomize):
+//
+//	l_v > l_n + l_e + 2*l_phi
+//
+// Otherwise the randomized v is not hidden by the bound of its random
+// value in the proof, which assumes v of at most l_v bits.
+func minVBitLen(p *pb.Params) int32 {
+	return max32(
+		p.NLength+p.SecParam+p.HashBitLen+
+			max32(p.AttrBitLen+p.SecParam+3, p.SecParam+2),
+		p.NLength+p.EBitLen+2*p.SecParam)
+}
+
+// ParamsProfiles returns the names of available parameter profiles.
+func ParamsProfiles() []string {
+	names := make([]string, 0, len(paramsProfiles))
+	for name := range paramsProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// GetParamsProfile returns parameters of the profile with the given
+// name. If attrBitLen is not 0, it replaces the bit length of
+// attributes of the profile, and lengths that depend on it are derived
+// accordingly.
+func GetParamsProfile(name string, attrBitLen int32) (*pb.Params, error) {
+	p, ok := paramsProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameters profile '%s', expected"+
+			" one of %v", name, ParamsProfiles())
+	}
+	if attrBitLen != 0 {
+		p.attrBitLen = attrBitLen
+	}
+
+	return p.params(), nil
+}
+
+// GetDefaultParamSizes returns parameters of the TestProfile, which
+// must not be used outside of tests.
 func GetDefaultParamSizes() *pb.Params {
+	p, _ := GetParamsProfile(TestProfile, 0)
+	return p
+}
+
+// GetLegacyParamSizes returns the parameters that keys were generated
+// with before parameter profiles were introduced. Keys stored without
+// parameters were generated with these, so they are used with them.
+// Their EBitLen and VBitLen are shorter than ValidateParams requires,
+// and new keys must not be generated with them.
+func GetLegacyParamSizes() *pb.Params {
 	return &pb.Params{
 		RhoBitLen:      256,
-		NLength:        256, // should be at least 2048 when not testing
+		NLength:        256,
 		AttrBitLen:     256,
 		HashBitLen:     512,
 		SecParam:       80,
@@ -34,10 +180,111 @@ func GetDefaultParamSizes() *pb.Params {
 	}
 }
 
+// ValidateParams checks that parameters p are consistent with each
+// other, as required by the CL scheme:
+//
+//	EBitLen > SecParam + HashBitLen + max(AttrBitLen + 4, E1BitLen + 2)
+//	VBitLen > NLength + SecParam + HashBitLen +
+//		max(AttrBitLen + SecParam + 3, SecParam + 2)
+//	VBitLen > NLength + EBitLen + 2*SecParam
+//
+// (see minEBitLen and minVBitLen), along with the checks of
+// checkParams. New keys are only generated with valid parameters,
+// while existing keys are used with the parameters they were
+// generated with (see GetLegacyParamSizes).
+func ValidateParams(p *pb.Params) error {
+	if err := checkParams(p); err != nil {
+		return err
+	}
+
+	minE := minEBitLen(p)
+	if p.EBitLen <= minE {
+		return fmt.Errorf("EBitLen must be greater than %d, got %d", minE,
+			p.EBitLen)
+	}
+	minV := minVBitLen(p)
+	if p.VBitLen <= minV {
+		return fmt.Errorf("VBitLen must be greater than %d, got %d", minV,
+			p.VBitLen)
+	}
+
+	return nil
+}
+
+// checkParams checks that parameters p can be used at all: lengths
+// have to be positive, E1BitLen smaller than EBitLen, and HashBitLen
+// has to match the hash function used for challenges.
+func checkParams(p *pb.Params) error {
+	if p == nil {
+		return fmt.Errorf("missing parameters")
+	}
+	if p.NLength <= 0 || p.RhoBitLen <= 0 || p.AttrBitLen <= 0 ||
+		p.SecParam <= 0 || p.E1BitLen <= 0 || p.ChallengeSpace <= 0 ||
+		p.EBitLen <= 0 || p.VBitLen <= 0 {
+		return fmt.Errorf("parameters must be positive")
+	}
+	if p.HashBitLen != hashBitLen {
+		return fmt.Errorf("HashBitLen is %d, the hash function outputs %d"+
+			" bits", p.HashBitLen, hashBitLen)
+	}
+	if p.E1BitLen >= p.EBitLen {
+		return fmt.Errorf("E1BitLen must be smaller than EBitLen")
+	}
+
+	return nil
+}
+
+// CheckParamsSecurity returns an error if parameters p are too weak to
+// be used outside of tests.
+func CheckParamsSecurity(p *pb.Params) error {
+	if p.NLength < 2048 {
+		return fmt.Errorf("RSA modulus of %d bits is insecure, at least"+
+			" 2048 bits are required", p.NLength)
+	}
+	if p.RhoBitLen < 256 {
+		return fmt.Errorf("commitment group order of %d bits is insecure,"+
+			" at least 256 bits are required", p.RhoBitLen)
+	}
+	if p.SecParam < 80 || p.ChallengeSpace < 80 {
+		return fmt.Errorf("security parameter and challenge space must" +
+			" be at least 80 bits")
+	}
+
+	return nil
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // PubParams keeps all the public parameters for the scheme.
 // These can be propagated from the server to the client.
 type PubParams struct {
 	PubKey  *PubKey
 	RawCred *RawCred // contains credential structure
 	Config  *pb.Params
+	// RetiredKeys are keys replaced by PubKey, which credentials
+	// issued with are still accepted
+	RetiredKeys []*RetiredKey
+	// SchemaName and SchemaVersion identify the schema of credentials,
+	// if the issuer has one, and SchemaHash binds the parameters to
+	// the credential structure (see SchemaHash)
+	SchemaName    string
+	SchemaVersion int
+	SchemaHash    []byte
+	// TrustedIssuers are the issuers whose credentials the server
+	// accepts besides its own. Servers that only verify credentials
+	// have no keys of their own, and their PubParams hold only
+	// TrustedIssuers.
+	TrustedIssuers []*TrustedIssuer
+}
+
+// RetiredKey is a public key replaced by the active key of
+// the issuer. Credentials issued with it are accepted until Expires.
+type RetiredKey struct {
+	PubKey  *PubKey
+	Expires time.Time
 }
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"testing"
+
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetParamsProfile(t *testing.T) {
+	for _, name := range ParamsProfiles() {
+		t.Run(name, func(t *testing.T) {
+			p, err := GetParamsProfile(name, 0)
+			require.NoError(t, err)
+			assert.NoError(t, ValidateParams(p))
+			if name == TestProfile {
+				assert.Error(t, CheckParamsSecurity(p))
+			} else {
+				assert.NoError(t, CheckParamsSecurity(p))
+			}
+
+			// lengths depending on the length of attributes follow it
+			custom, err := GetParamsProfile(name, 1024)
+			require.NoError(t, err)
+			assert.NoError(t, ValidateParams(custom))
+			assert.True(t, custom.EBitLen > p.EBitLen)
+			assert.True(t, custom.VBitLen > p.VBitLen)
+		})
+	}
+
+	_, err := GetParamsProfile("1024", 0)
+	assert.Error(t, err)
+}
+
+func TestValidateParams(t *testing.T) {
+	tests := []struct {
+		desc   string
+		modify func(p *pb.Params)
+	}{
+		{"EBitLen", func(p *pb.Params) { p.EBitLen = 597 }},
+		{"VBitLen", func(p *pb.Params) { p.VBitLen = p.NLength }},
+		// satisfies the constraint of Identity Mixer, but e*r of a
+		// randomized credential is not covered
+		{"VBitLenRandomized", func(p *pb.Params) {
+			p.VBitLen = p.NLength + p.EBitLen + 2*p.SecParam
+		}},
+		{"E1BitLen", func(p *pb.Params) { p.E1BitLen = p.EBitLen }},
+		{"HashBitLen", func(p *pb.Params) { p.HashBitLen = 256 }},
+		{"Zero", func(p *pb.Params) { p.SecParam = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			p := GetDefaultParamSizes()
+			tt.modify(p)
+			assert.Error(t, ValidateParams(p))
+		})
+	}
+}
+
+func TestGenerateKeyPair_InvalidParams(t *testing.T) {
+	attrs := NewAttrCount(1, 0, 0)
+	_, err := GenerateKeyPair(GetLegacyParamSizes(), attrs)
+	assert.Error(t, err)
+	_, err = GenerateKeyPairForSchema(GetLegacyParamSizes(),
+		newCredStructure(nil, attrs))
+	assert.Error(t, err)
+
+	// keys generated with legacy parameters are still rotated with them
+	prev, err := generateKeyPair(GetLegacyParamSizes(), attrs, nil, nil)
+	require.NoError(t, err)
+	keys, err := GenerateNextKeyPair(GetLegacyParamSizes(), attrs, prev.Pub)
+	require.NoError(t, err)
+	assert.NoError(t, keys.Pub.CheckParams(GetLegacyParamSizes()))
+}
+
+func TestParams_RandomizedCredV(t *testing.T) {
+	o := newTestOrg(t)
+	cm := newTestCredManager(t, o, 30)
+	cred := issueTestCred(t, o, cm)
+
+	// the randomized v stays within VBitLen bits, which the bound of
+	// its random value in a proof of possession assumes
+	for i := 0; i < 10; i++ {
+		rCred := cm.randomize(cred)
+		assert.True(t, rCred.V11.Sign() > 0)
+		assert.True(t, rCred.V11.BitLen() <= int(o.Params.VBitLen))
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+)
+
+// Predicate is a condition that an attribute of a credential satisfies
+// with respect to a reference value provided by the verifier. Just like
+// with ValidateAgainst, the predicate holds when
+//
+//	Value <Cond> attribute value
+//
+// is true, for example Value = 2018, Cond = gte means that the
+// attribute is not greater than 2018. Value is compared with the value
+// of an int64 attribute, or with the number of days of a date attribute
+// (see DateAttr), and encoded the same way (see encodeInt64).
+//
+// For condition in, the predicate holds when attribute value is one
+// of the values in Set.
+//
+// Predicates over attributes that are not revealed are proved in zero
+// knowledge, the verifier only learns that the predicate holds.
+// Only predicates over Known attributes are supported, since for
+// Committed attributes the credential holds a commitment and not the
+// value itself.
+type Predicate struct {
+	Attr  string
+	Cond  AttrCond
+	Value int64
+	Set   []string
+}
+
+func NewPredicate(attr string, cond AttrCond, value int64) *Predicate {
+	return &Predicate{
+		Attr:  attr,
+		Cond:  cond,
+		Value: value,
+	}
+}
+
+func NewSetPredicate(attr string, set []string) *Predicate {
+	return &Predicate{
+		Attr: attr,
+		Cond: in,
+		Set:  set,
+	}
+}
+
+// delta returns s and k such that the predicate holds for attribute
+// value m exactly when delta = s*m + k >= 0.
+func (p *Predicate) delta() (int64, *big.Int, error) {
+	ref := encodeInt64(p.Value)
+	one := big.NewInt(1)
+
+	switch p.Cond {
+	case greaterThan: // ref > m, delta = ref - m - 1
+		return -1, new(big.Int).Sub(ref, one), nil
+	case greaterThanOrEqual: // ref >= m, delta = ref - m
+		return -1, ref, nil
+	case lessThan: // ref < m, delta = m - ref - 1
+		return 1, new(big.Int).Sub(new(big.Int).Neg(ref), one), nil
+	case lessThanOrEqual: // ref <= m, delta = m - ref
+		return 1, new(big.Int).Neg(ref), nil
+	}
+
+	return 0, nil, fmt.Errorf("condition %s cannot be proved without"+
+		" revealing the attribute", p.Cond)
+}
+
+// holds reports whether the predicate holds for the attribute with
+// internal value m.
+func (p *Predicate) holds(m *big.Int) (bool, error) {
+	if p.Cond == in {
+		for _, v := range encodeSet(p.Set) {
+			if v.Cmp(m) == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	s, k, err := p.delta()
+	if err != nil {
+		return false, err
+	}
+	delta := new(big.Int).Mul(big.NewInt(s), m)
+
+	return delta.Add(delta, k).Sign() >= 0, nil
+}
+
+// PredicateProof proves in zero knowledge that an unrevealed Known
+// attribute satisfies a predicate. The proof is bound to the proof of
+// possession of the credential, as it shares the challenge and the
+// proof data for the attribute with it.
+//
+// Depending on the condition of the predicate, either Range or Set
+// is set.
+type PredicateProof struct {
+	KnownAttrIndex int
+	Range          *RangeProof
+	Set            *SetMembershipProof
+}
+
+// challengeInput returns the values that are included in the challenge
+// of the proof.
+func (p *PredicateProof) challengeInput() []*big.Int {
+	if p.Range != nil {
+		return p.Range.challengeInput()
+	}
+	if p.Set != nil {
+		return p.Set.challengeInput()
+	}
+	return nil
+}
+
+// predicateProver builds a PredicateProof. Random value for the
+// attribute, mTilde, has to be the same as the one used for the
+// attribute in the proof of possession of the credential.
+type predicateProver interface {
+	// getProofRandomData returns a PredicateProof without proof data,
+	// which is set with setProofData once the challenge is known.
+	getProofRandomData(params *pb.Params, mTilde *big.Int) *PredicateProof
+	setProofData(proof *PredicateProof, challenge *big.Int)
+}
+
+func newPredicateProver(group *qr.RSASpecial, pubKey *PubKey,
+	params *pb.Params, pred *Predicate, m *big.Int) (predicateProver,
+	error) {
+	if pred.Cond == in {
+		return newSetMembershipProver(group, pubKey, params, pred, m)
+	}
+	return newRangeProver(group, pubKey, params, pred, m)
+}
+
+// verifyPredicateProof checks that proof proves predicate pred for the
+// attribute with the proof data mHat from the proof of possession of the
+// credential.
+func verifyPredicateProof(group *qr.RSASpecial, pubKey *PubKey,
+	pred *Predicate, proof *PredicateProof, mHat,
+	challenge *big.Int) (bool, error) {
+	if pred.Cond == in {
+		if proof.Set == nil {
+			return false, fmt.Errorf("expected set membership proof for %s",
+				pred.Attr)
+		}
+		return verifySetMembershipProof(group, pubKey, pred, proof.Set, mHat,
+			challenge)
+	}
+
+	if proof.Range == nil {
+		return false, fmt.Errorf("expected range proof for %s", pred.Attr)
+	}
+	return verifyRangeProof(group, pubKey, pred, proof.Range, mHat, challenge)
+}
+
+// response computes tilde + challenge * secret (in Z, not modulo).
+func response(tilde, challenge, secret *big.Int) *big.Int {
+	r := new(big.Int).Mul(challenge, secret)
+	return r.Add(r, tilde)
+}
+
+// getRandomBoundedInt returns a random integer from +-{0,1}^bitLen.
+func getRandomBoundedInt(bitLen int) *big.Int {
+	b := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(bitLen)), nil)
+	return common.GetRandomIntAlsoNeg(b)
+}
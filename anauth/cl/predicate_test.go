@@ -0,0 +1,203 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecomposeFourSquares(t *testing.T) {
+	b := new(big.Int).Exp(big.NewInt(2), big.NewInt(256), nil)
+	tests := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(7),
+		big.NewInt(1000),
+		big.NewInt(1001),
+		big.NewInt(1 << 40),
+		new(big.Int).Lsh(common.GetRandomInt(b), 2), // 0 mod 4
+		common.GetRandomInt(b),
+	}
+
+	for _, n := range tests {
+		u, err := decomposeFourSquares(n)
+		require.NoError(t, err)
+		require.Len(t, u, 4)
+
+		sum := big.NewInt(0)
+		for _, ui := range u {
+			sum.Add(sum, new(big.Int).Mul(ui, ui))
+		}
+		assert.Equal(t, 0, sum.Cmp(n), "decomposition of %s", n)
+	}
+}
+
+func TestDecomposeFourSquares_Negative(t *testing.T) {
+	_, err := decomposeFourSquares(big.NewInt(-1))
+	assert.Error(t, err)
+}
+
+func TestPredicateProof(t *testing.T) {
+	params := GetDefaultParamSizes()
+	keys, err := GenerateKeyPair(params, NewAttrCount(1, 0, 0))
+	require.NoError(t, err)
+	group := qr.NewRSApecialPublic(keys.Pub.N)
+
+	m := encodeInt64(30)
+	tests := []struct {
+		desc  string
+		pred  *Predicate
+		holds bool
+	}{
+		{"LessThan", NewPredicate("a", lessThan, 29), true},
+		{"LessThanFails", NewPredicate("a", lessThan, 30), false},
+		{"LessThanOrEqual", NewPredicate("a", lessThanOrEqual, 30), true},
+		{"LessThanOrEqualFails", NewPredicate("a", lessThanOrEqual, 31), false},
+		{"GreaterThan", NewPredicate("a", greaterThan, 31), true},
+		{"GreaterThanFails", NewPredicate("a", greaterThan, 30), false},
+		{"GreaterThanOrEqual", NewPredicate("a", greaterThanOrEqual, 30), true},
+		{"GreaterThanOrEqualFails", NewPredicate("a", greaterThanOrEqual, 29), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			p, err := newPredicateProver(group, keys.Pub, params, tt.pred, m)
+			if !tt.holds {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// random value and proof data for the attribute would
+			// come from the proof of possession of the credential
+			mTilde := getRandomBoundedInt(int(params.AttrBitLen +
+				params.SecParam + params.HashBitLen))
+			proof := p.getProofRandomData(params, mTilde)
+			challenge := common.Hash(proof.challengeInput()...)
+			p.setProofData(proof, challenge)
+			mHat := response(mTilde, challenge, m)
+
+			ok, err := verifyPredicateProof(group, keys.Pub, tt.pred, proof,
+				mHat, challenge)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			// proof data for some other value of the attribute
+			other := response(mTilde, challenge, encodeInt64(31))
+			ok, err = verifyPredicateProof(group, keys.Pub, tt.pred, proof,
+				other, challenge)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+// tests that the response to rDelta hides it statistically, even for
+// the largest deltas of int64 attributes.
+func TestRangeProof_RDeltaBound(t *testing.T) {
+	params := GetDefaultParamSizes()
+	keys, err := GenerateKeyPair(params, NewAttrCount(1, 0, 0))
+	require.NoError(t, err)
+	group := qr.NewRSApecialPublic(keys.Pub.N)
+
+	pred := NewPredicate("a", lessThan, math.MinInt64)
+	p, err := newRangeProver(group, keys.Pub, params, pred,
+		encodeInt64(math.MaxInt64))
+	require.NoError(t, err)
+
+	assert.True(t, p.rDelta.BitLen() > int(params.NLength+params.SecParam))
+	assert.True(t, p.rDelta.BitLen()+int(params.SecParam+params.HashBitLen) <=
+		int(rDeltaTildeBitLen(params)))
+}
+
+func TestPredicateProof_Set(t *testing.T) {
+	params := GetDefaultParamSizes()
+	keys, err := GenerateKeyPair(params, NewAttrCount(1, 0, 0))
+	require.NoError(t, err)
+	group := qr.NewRSApecialPublic(keys.Pub.N)
+
+	m := encodeStr("Slovenia")
+	tests := []struct {
+		desc  string
+		set   []string
+		holds bool
+	}{
+		{"Single", []string{"Slovenia"}, true},
+		{"First", []string{"Slovenia", "Croatia", "Austria"}, true},
+		{"Last", []string{"Croatia", "Austria", "Slovenia"}, true},
+		{"NotInSet", []string{"Croatia", "Austria"}, false},
+		{"Empty", []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			pred := NewSetPredicate("a", tt.set)
+			p, err := newPredicateProver(group, keys.Pub, params, pred, m)
+			if !tt.holds {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			mTilde := getRandomBoundedInt(int(params.AttrBitLen +
+				params.SecParam + params.HashBitLen))
+			proof := p.getProofRandomData(params, mTilde)
+			challenge := common.Hash(proof.challengeInput()...)
+			p.setProofData(proof, challenge)
+			mHat := response(mTilde, challenge, m)
+
+			ok, err := verifyPredicateProof(group, keys.Pub, pred, proof,
+				mHat, challenge)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			// proof data for some other value of the attribute
+			other := response(mTilde, challenge, encodeStr("Croatia"))
+			ok, err = verifyPredicateProof(group, keys.Pub, pred, proof,
+				other, challenge)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// proof for a different set
+			otherPred := NewSetPredicate("a",
+				append([]string{"Italy"}, tt.set[1:]...))
+			ok, err = verifyPredicateProof(group, keys.Pub, otherPred, proof,
+				mHat, challenge)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// range proof instead of set membership proof
+			_, err = verifyPredicateProof(group, keys.Pub,
+				NewPredicate("a", lessThan, 1), proof, mHat, challenge)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPredicate_Equal(t *testing.T) {
+	_, _, err := NewPredicate("a", equal, 30).delta()
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+
+	"github.com/emmyzkp/crypto/common"
+)
+
+// ProofRequest states what a verifier requires from a proof of
+// possession of credentials. Verifiers send it at the start of a proof,
+// so that clients can check that their credentials satisfy it, and ask
+// the holder for consent before a proof is built.
+type ProofRequest struct {
+	// Verifier is the identity that the proof is bound to
+	Verifier string
+	// Purpose tells the holder why the verifier requests the proof
+	Purpose string
+	// RevealedAttrs are the names of attributes that have to be
+	// revealed
+	RevealedAttrs []string
+	// Predicates have to hold for the attributes that are not revealed,
+	// they are proved in zero knowledge
+	Predicates []*Predicate
+	// AcceptedIssuers are the issuers whose credentials are accepted
+	AcceptedIssuers []*AcceptedIssuer
+	// Scope of the pseudonym that the holder presents, empty if none
+	Scope string
+}
+
+// AcceptedIssuer is an issuer whose credentials a verifier accepts.
+type AcceptedIssuer struct {
+	Name  string
+	KeyID string
+}
+
+// ConsentFunc is called with the proof request of a verifier before
+// a proof is built. It returns an error if the holder does not
+// consent to the proof.
+type ConsentFunc func(req *ProofRequest) error
+
+// accepts reports whether credentials issued with keys identified by
+// keyID are accepted.
+func (r *ProofRequest) accepts(keyID string) bool {
+	for _, i := range r.AcceptedIssuers {
+		if i.KeyID == keyID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckSatisfiable checks that the credential managed by cm satisfies
+// r: it is issued by one of the accepted issuers, it has all
+// the attributes to be revealed, and its unrevealed attributes satisfy
+// the predicates.
+func (r *ProofRequest) CheckSatisfiable(cm *CredManager) error {
+	if len(r.AcceptedIssuers) > 0 && !r.accepts(cm.PubKey.ID()) {
+		return fmt.Errorf("credentials issued with key %s are not accepted",
+			cm.PubKey.ID())
+	}
+	if _, _, err := cm.RawCred.revealedIndices(r.RevealedAttrs); err != nil {
+		return err
+	}
+
+	for _, p := range r.Predicates {
+		if contains(r.RevealedAttrs, p.Attr) {
+			continue
+		}
+		a, err := cm.RawCred.GetAttr(p.Attr)
+		if err != nil {
+			return err
+		}
+		if !a.isKnown() {
+			return fmt.Errorf("predicate over attribute %s that is not"+
+				" a Known attribute", p.Attr)
+		}
+		ok, err := p.holds(a.internalValue())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("attribute %s does not satisfy the predicate",
+				p.Attr)
+		}
+	}
+
+	return nil
+}
+
+// requiredRevealed returns the names of attributes from attrs that
+// have to be revealed. These are the attributes named in reveal, and
+// Known attributes whose conditions cannot be proved without revealing
+// them.
+func requiredRevealed(attrs []CredAttr, reveal []string) []string {
+	var names []string
+	for _, a := range attrs {
+		if contains(reveal, a.Name()) ||
+			(a.isKnown() && a.getCond() != none && !provable(a)) {
+			names = append(names, a.Name())
+		}
+	}
+
+	return names
+}
+
+// checkRevealed checks that the attributes from attrs with the given
+// names are among the revealed Known and Committed attributes,
+// identified by their indices among the attributes of the same kind.
+func checkRevealed(attrs []CredAttr, names []string,
+	revealedKnownAttrsIndices, revealedCommitmentsOfAttrsIndices []int) error {
+	if err := checkRevealedIndices(revealedKnownAttrsIndices); err != nil {
+		return err
+	}
+	if err := checkRevealedIndices(
+		revealedCommitmentsOfAttrsIndices); err != nil {
+		return err
+	}
+
+	known, committed := 0, 0
+	for _, a := range attrs {
+		var revealed bool
+		switch {
+		case a.isHidden():
+			continue
+		case a.isKnown():
+			revealed = common.Contains(revealedKnownAttrsIndices, known)
+			known++
+		default:
+			revealed = common.Contains(
+				revealedCommitmentsOfAttrsIndices, committed)
+			committed++
+		}
+		if contains(names, a.Name()) && !revealed {
+			return fmt.Errorf("attribute %s has to be revealed", a.Name())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicate_holds(t *testing.T) {
+	tests := []struct {
+		pred  *Predicate
+		val   int64
+		holds bool
+	}{
+		{NewPredicate("a", lessThan, 18), 30, true},
+		{NewPredicate("a", lessThan, 30), 30, false},
+		{NewPredicate("a", lessThanOrEqual, 30), 30, true},
+		{NewPredicate("a", greaterThan, 30), 18, true},
+		{NewPredicate("a", greaterThan, 30), 30, false},
+		{NewPredicate("a", greaterThanOrEqual, 30), 30, true},
+		{NewPredicate("a", greaterThanOrEqual, -5), -4, false},
+	}
+
+	for _, test := range tests {
+		holds, err := test.pred.holds(encodeInt64(test.val))
+		require.NoError(t, err)
+		assert.Equal(t, test.holds, holds, "%s %d", test.pred.Cond, test.val)
+	}
+
+	pred := NewSetPredicate("a", []string{"x", "y"})
+	holds, err := pred.holds(encodeSet([]string{"y"})[0])
+	require.NoError(t, err)
+	assert.True(t, holds)
+	holds, err = pred.holds(encodeSet([]string{"z"})[0])
+	require.NoError(t, err)
+	assert.False(t, holds)
+
+	_, err = NewPredicate("a", equal, 30).holds(encodeInt64(30))
+	assert.Error(t, err)
+}
+
+func TestProofRequest_CheckSatisfiable(t *testing.T) {
+	o, err := NewOrg(GetDefaultParamSizes(), NewAttrCount(1, 0, 1))
+	require.NoError(t, err)
+	rc := NewRawCred(NewAttrCount(1, 0, 1))
+	require.NoError(t, rc.addEmptyInt64Attr("a", 0, true))
+	require.NoError(t, rc.addEmptyHiddenInt64Attr("secret", 1))
+	require.NoError(t, rc.UpdateAttr("a", 30))
+	require.NoError(t, rc.UpdateAttr("secret", 42))
+	cm, err := NewCredManager(o.Params, o.Keys.Pub,
+		o.Keys.Pub.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+
+	accepted := []*AcceptedIssuer{{Name: "org", KeyID: o.KeyID()}}
+	tests := []struct {
+		name string
+		req  *ProofRequest
+		ok   bool
+	}{
+		{"empty", &ProofRequest{}, true},
+		{"satisfied", &ProofRequest{
+			RevealedAttrs:   []string{"a"},
+			Predicates:      []*Predicate{NewPredicate("a", lessThan, 18)},
+			AcceptedIssuers: accepted,
+		}, true},
+		{"issuer not accepted", &ProofRequest{
+			AcceptedIssuers: []*AcceptedIssuer{{Name: "org", KeyID: "x"}},
+		}, false},
+		{"missing attribute", &ProofRequest{
+			RevealedAttrs: []string{"b"},
+		}, false},
+		{"hidden attribute", &ProofRequest{
+			RevealedAttrs: []string{"secret"},
+		}, false},
+		{"predicate does not hold", &ProofRequest{
+			Predicates: []*Predicate{NewPredicate("a", lessThan, 40)},
+		}, false},
+		{"predicate over revealed attribute", &ProofRequest{
+			RevealedAttrs: []string{"a"},
+			Predicates:    []*Predicate{NewPredicate("a", lessThan, 40)},
+		}, true},
+		{"predicate over hidden attribute", &ProofRequest{
+			Predicates: []*Predicate{NewPredicate("secret", lessThan, 0)},
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.req.CheckSatisfiable(cm)
+			if test.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRequiredRevealed(t *testing.T) {
+	a := NewEmptyInt64Attr("a", true)
+	a.cond = greaterThan
+	b := NewEmptyInt64Attr("b", true)
+	b.cond = equal
+	b.Index = 1
+	c := NewEmptyInt64Attr("c", false)
+	c.cond = none
+	c.Index = 2
+	attrs := []CredAttr{a, b, c}
+
+	assert.Equal(t, []string{"b"}, requiredRevealed(attrs, nil))
+	assert.Equal(t, []string{"b", "c"},
+		requiredRevealed(attrs, []string{"c"}))
+
+	// a and b are Known attributes 0 and 1, c is Committed attribute 0
+	assert.NoError(t, checkRevealed(attrs, []string{"b", "c"},
+		[]int{1}, []int{0}))
+	assert.Error(t, checkRevealed(attrs, []string{"b", "c"},
+		[]int{0, 1}, nil))
+	assert.Error(t, checkRevealed(attrs, []string{"b"}, []int{0}, []int{0}))
+	// repeated and unsorted indices
+	assert.Error(t, checkRevealed(attrs, []string{"b"}, []int{1, 1}, nil))
+	assert.Error(t, checkRevealed(attrs, []string{"b", "c"},
+		[]int{1, 0}, []int{0}))
+}
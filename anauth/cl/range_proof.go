@@ -0,0 +1,328 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+)
+
+// RangeProof proves in zero knowledge that an unrevealed Known
+// attribute satisfies one of the conditions lt, lte, gt or gte.
+//
+// For delta = u_1^2 + u_2^2 + u_3^2 + u_4^2 >= 0 (see Predicate) the
+// prover commits to u_i as T_i = Z^u_i * S^r_i and to delta as
+// TDelta = Z^delta * S^rDelta, and proves that TDelta = T_1^u_1 * ... *
+// T_4^u_4 * S^alpha. This is the same approach as in idemix.
+type RangeProof struct {
+	T           []*big.Int
+	TDelta      *big.Int
+	TTilde      []*big.Int
+	TDeltaTilde *big.Int
+	QTilde      *big.Int
+	UHat        []*big.Int
+	RHat        []*big.Int
+	RDeltaHat   *big.Int
+	AlphaHat    *big.Int
+}
+
+// challengeInput returns the values that are included in the challenge
+// of the proof.
+func (p *RangeProof) challengeInput() []*big.Int {
+	l := append([]*big.Int{}, p.T...)
+	l = append(l, p.TDelta)
+	l = append(l, p.TTilde...)
+	l = append(l, p.TDeltaTilde, p.QTilde)
+	return l
+}
+
+// rangeProver builds a RangeProof.
+type rangeProver struct {
+	group  *qr.RSASpecial
+	pubKey *PubKey
+
+	s      int64
+	u      []*big.Int
+	r      []*big.Int
+	rDelta *big.Int
+	alpha  *big.Int
+
+	T      []*big.Int
+	TDelta *big.Int
+
+	uTilde      []*big.Int
+	rTilde      []*big.Int
+	rDeltaTilde *big.Int
+	alphaTilde  *big.Int
+}
+
+func newRangeProver(group *qr.RSASpecial, pubKey *PubKey,
+	params *pb.Params, pred *Predicate, m *big.Int) (*rangeProver, error) {
+	s, k, err := pred.delta()
+	if err != nil {
+		return nil, err
+	}
+	delta := new(big.Int).Mul(big.NewInt(s), m)
+	delta.Add(delta, k)
+	if delta.Sign() < 0 {
+		return nil, fmt.Errorf("attribute %s does not satisfy the"+
+			" predicate", pred.Attr)
+	}
+
+	u, err := decomposeFourSquares(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	b := new(big.Int).Exp(big.NewInt(2),
+		big.NewInt(int64(params.NLength+params.SecParam)), nil)
+	r := make([]*big.Int, 4)
+	T := make([]*big.Int, 4)
+	alpha := common.GetRandomInt(b)
+	rDelta := new(big.Int).Set(alpha)
+	for i := range u {
+		r[i] = common.GetRandomInt(b)
+		T[i] = group.Mul(group.Exp(pubKey.Z, u[i]), group.Exp(pubKey.S, r[i]))
+		rDelta.Add(rDelta, new(big.Int).Mul(u[i], r[i]))
+	}
+	TDelta := group.Mul(group.Exp(pubKey.Z, delta),
+		group.Exp(pubKey.S, rDelta))
+
+	return &rangeProver{
+		group:  group,
+		pubKey: pubKey,
+		s:      s,
+		u:      u,
+		r:      r,
+		rDelta: rDelta,
+		alpha:  alpha,
+		T:      T,
+		TDelta: TDelta,
+	}, nil
+}
+
+// getProofRandomData returns a PredicateProof with the commitments
+// and proof random data for the commitments to u_i, for the commitment
+// to delta and for the relation between them.
+func (p *rangeProver) getProofRandomData(params *pb.Params,
+	mTilde *big.Int) *PredicateProof {
+	// boundary for u_tilde
+	b_u := params.AttrBitLen + params.SecParam + params.HashBitLen
+	// boundary for r_tilde
+	b_r := params.NLength + 2*params.SecParam + params.HashBitLen
+	// boundary for alpha_tilde
+	b_alpha := params.NLength + 2*params.SecParam + params.AttrBitLen +
+		params.HashBitLen
+
+	p.uTilde = make([]*big.Int, 4)
+	p.rTilde = make([]*big.Int, 4)
+	TTilde := make([]*big.Int, 4)
+	p.alphaTilde = getRandomBoundedInt(int(b_alpha))
+	QTilde := p.group.Exp(p.pubKey.S, p.alphaTilde)
+	for i := range p.u {
+		p.uTilde[i] = getRandomBoundedInt(int(b_u))
+		p.rTilde[i] = getRandomBoundedInt(int(b_r))
+		TTilde[i] = p.group.Mul(p.group.Exp(p.pubKey.Z, p.uTilde[i]),
+			p.group.Exp(p.pubKey.S, p.rTilde[i]))
+		QTilde = p.group.Mul(QTilde, p.group.Exp(p.T[i], p.uTilde[i]))
+	}
+
+	p.rDeltaTilde = getRandomBoundedInt(int(rDeltaTildeBitLen(params)))
+	TDeltaTilde := p.group.Mul(
+		p.group.Exp(zToThe(p.group, p.pubKey.Z, p.s), mTilde),
+		p.group.Exp(p.pubKey.S, p.rDeltaTilde))
+
+	return &PredicateProof{
+		Range: &RangeProof{
+			T:           p.T,
+			TDelta:      p.TDelta,
+			TTilde:      TTilde,
+			TDeltaTilde: TDeltaTilde,
+			QTilde:      QTilde,
+		},
+	}
+}
+
+// rDeltaTildeBitLen returns the bound for rDelta_tilde. As
+// rDelta = alpha + sum u_i*r_i, it exceeds the bound for r_tilde by
+// the bits of u_i, at most AttrBitLen.
+func rDeltaTildeBitLen(params *pb.Params) int32 {
+	return params.NLength + 2*params.SecParam + params.HashBitLen +
+		params.AttrBitLen
+}
+
+func (p *rangeProver) setProofData(proof *PredicateProof,
+	challenge *big.Int) {
+	rp := proof.Range
+	rp.UHat = make([]*big.Int, 4)
+	rp.RHat = make([]*big.Int, 4)
+	for i := range p.u {
+		rp.UHat[i] = response(p.uTilde[i], challenge, p.u[i])
+		rp.RHat[i] = response(p.rTilde[i], challenge, p.r[i])
+	}
+	rp.RDeltaHat = response(p.rDeltaTilde, challenge, p.rDelta)
+	rp.AlphaHat = response(p.alphaTilde, challenge, p.alpha)
+}
+
+// verifyRangeProof checks that proof proves predicate pred for the
+// attribute with the proof data mHat from the proof of possession of the
+// credential.
+func verifyRangeProof(group *qr.RSASpecial, pubKey *PubKey,
+	pred *Predicate, proof *RangeProof, mHat,
+	challenge *big.Int) (bool, error) {
+	s, k, err := pred.delta()
+	if err != nil {
+		return false, err
+	}
+	if len(proof.T) != 4 || len(proof.TTilde) != 4 ||
+		len(proof.UHat) != 4 || len(proof.RHat) != 4 {
+		return false, fmt.Errorf("malformed predicate proof for %s",
+			pred.Attr)
+	}
+
+	// Z^u_hat * S^r_hat = T^c * T_tilde
+	for i := 0; i < 4; i++ {
+		left := group.Mul(group.Exp(pubKey.Z, proof.UHat[i]),
+			group.Exp(pubKey.S, proof.RHat[i]))
+		right := group.Mul(group.Exp(proof.T[i], challenge), proof.TTilde[i])
+		if left.Cmp(right) != 0 {
+			return false, nil
+		}
+	}
+
+	// (Z^s)^m_hat * S^rDelta_hat = (TDelta * Z^-k)^c * TDelta_tilde
+	left := group.Mul(group.Exp(zToThe(group, pubKey.Z, s), mHat),
+		group.Exp(pubKey.S, proof.RDeltaHat))
+	y := group.Mul(proof.TDelta, group.Exp(pubKey.Z, new(big.Int).Neg(k)))
+	right := group.Mul(group.Exp(y, challenge), proof.TDeltaTilde)
+	if left.Cmp(right) != 0 {
+		return false, nil
+	}
+
+	// T_1^u_hat_1 * ... * T_4^u_hat_4 * S^alpha_hat = TDelta^c * Q_tilde
+	left = group.Exp(pubKey.S, proof.AlphaHat)
+	for i := 0; i < 4; i++ {
+		left = group.Mul(left, group.Exp(proof.T[i], proof.UHat[i]))
+	}
+	right = group.Mul(group.Exp(proof.TDelta, challenge), proof.QTilde)
+
+	return left.Cmp(right) == 0, nil
+}
+
+// zToThe returns Z or Z^-1, depending on the sign of s.
+func zToThe(group *qr.RSASpecial, Z *big.Int, s int64) *big.Int {
+	if s < 0 {
+		return group.Inv(Z)
+	}
+	return Z
+}
+
+// decomposeFourSquares returns u_1, ..., u_4 such that
+// n = u_1^2 + u_2^2 + u_3^2 + u_4^2 (Lagrange's four-square theorem).
+// It picks random u_1, u_2 until n - u_1^2 - u_2^2 is a prime p that
+// is 1 mod 4, which can be decomposed into two squares.
+func decomposeFourSquares(n *big.Int) ([]*big.Int, error) {
+	if n.Sign() < 0 {
+		return nil, fmt.Errorf("cannot decompose a negative integer")
+	}
+
+	if n.Cmp(big.NewInt(1000)) <= 0 {
+		return decomposeFourSquaresSmall(n), nil
+	}
+
+	// for n = 0 mod 4, n - u_1^2 - u_2^2 is never 1 mod 4, so
+	// n = 4^k * m is decomposed as 2^k times the decomposition of m
+	if n.Bit(0) == 0 && n.Bit(1) == 0 {
+		k := uint(0)
+		for n.Bit(int(2*k)) == 0 && n.Bit(int(2*k+1)) == 0 {
+			k++
+		}
+		u, err := decomposeFourSquares(new(big.Int).Rsh(n, 2*k))
+		if err != nil {
+			return nil, err
+		}
+		for _, ui := range u {
+			ui.Lsh(ui, k)
+		}
+		return u, nil
+	}
+
+	zero := big.NewInt(0)
+	four := big.NewInt(4)
+	for {
+		u1 := common.GetRandomInt(new(big.Int).Add(new(big.Int).Sqrt(n),
+			big.NewInt(1)))
+		rest := new(big.Int).Sub(n, new(big.Int).Mul(u1, u1))
+		u2 := common.GetRandomInt(new(big.Int).Add(new(big.Int).Sqrt(rest),
+			big.NewInt(1)))
+		p := new(big.Int).Sub(rest, new(big.Int).Mul(u2, u2))
+
+		if p.Cmp(big.NewInt(2)) <= 0 {
+			if p.Cmp(zero) == 0 {
+				return []*big.Int{u1, u2, big.NewInt(0), big.NewInt(0)}, nil
+			}
+			return []*big.Int{u1, u2, big.NewInt(1),
+				new(big.Int).Sub(p, big.NewInt(1))}, nil
+		}
+		if new(big.Int).Mod(p, four).Cmp(big.NewInt(1)) != 0 ||
+			!p.ProbablyPrime(20) {
+			continue
+		}
+
+		u3, u4 := decomposePrime(p)
+		return []*big.Int{u1, u2, u3, u4}, nil
+	}
+}
+
+// decomposePrime returns a, b such that p = a^2 + b^2 for a prime
+// p = 1 mod 4, using the Hermite-Serret algorithm.
+func decomposePrime(p *big.Int) (*big.Int, *big.Int) {
+	// sqrt(-1) mod p
+	t := new(big.Int).ModSqrt(new(big.Int).Sub(p, big.NewInt(1)), p)
+	a, b := new(big.Int).Set(p), t
+	for new(big.Int).Mul(b, b).Cmp(p) > 0 {
+		a, b = b, new(big.Int).Mod(a, b)
+	}
+	c := new(big.Int).Sub(p, new(big.Int).Mul(b, b))
+
+	return b, c.Sqrt(c)
+}
+
+// decomposeFourSquaresSmall finds the decomposition of small n by
+// exhaustive search.
+func decomposeFourSquaresSmall(n *big.Int) []*big.Int {
+	v := n.Int64()
+	for a := int64(0); a*a <= v; a++ {
+		for b := int64(0); a*a+b*b <= v; b++ {
+			for c := int64(0); a*a+b*b+c*c <= v; c++ {
+				rest := v - a*a - b*b - c*c
+				d := new(big.Int).Sqrt(big.NewInt(rest)).Int64()
+				if d*d == rest {
+					return []*big.Int{big.NewInt(a), big.NewInt(b),
+						big.NewInt(c), big.NewInt(d)}
+				}
+			}
+		}
+	}
+
+	return nil // unreachable, every n >= 0 is a sum of four squares
+}
@@ -20,6 +20,7 @@ package cl
 import (
 	"fmt"
 	"math/big"
+	"sort"
 )
 
 // RawCred represents a credential to be used by application that
@@ -48,7 +49,7 @@ func (c *RawCred) missingAttrs() error {
 	for _, a := range c.Attrs {
 		if !a.hasVal() {
 			fmt.Println(a.Name(), " missing")
-			return fmt.Errorf(a.Name())
+			return fmt.Errorf("%s", a.Name())
 		}
 	}
 	return nil
@@ -62,6 +63,62 @@ func (c *RawCred) GetAttr(name string) (CredAttr, error) {
 	return c.Attrs[i], nil
 }
 
+// knownIndex returns the index of a Known attribute among the Known
+// attributes of the credential.
+func (c *RawCred) knownIndex(name string) (int, error) {
+	attr, err := c.GetAttr(name)
+	if err != nil {
+		return -1, err
+	}
+	if !attr.isKnown() {
+		return -1, fmt.Errorf("attribute %s is not a Known attribute", name)
+	}
+
+	return c.classIndex(attr), nil
+}
+
+// classIndex returns the index of attribute a among the attributes
+// of the same kind (Known, Committed or Hidden).
+func (c *RawCred) classIndex(a CredAttr) int {
+	ind := 0
+	for i := 0; i < a.getIndex(); i++ {
+		b := c.Attrs[i]
+		if b.isKnown() == a.isKnown() && b.isHidden() == a.isHidden() {
+			ind++
+		}
+	}
+
+	return ind
+}
+
+// revealedIndices returns the indices of the Known and the Committed
+// attributes with the given names among the attributes of the same kind,
+// in ascending order. Hidden attributes cannot be revealed.
+func (c *RawCred) revealedIndices(names []string) ([]int, []int, error) {
+	var known, committed []int
+	for _, name := range names {
+		attr, err := c.GetAttr(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unexpected attribute: %s", name)
+		}
+		if attr.isHidden() {
+			return nil, nil, fmt.Errorf("hidden attribute cannot be"+
+				" revealed: %s", name)
+		}
+
+		if attr.isKnown() {
+			known = append(known, c.classIndex(attr))
+		} else {
+			committed = append(committed, c.classIndex(attr))
+		}
+	}
+	// revealed values are ordered by index (see FilterAttributes)
+	sort.Ints(known)
+	sort.Ints(committed)
+
+	return known, committed, nil
+}
+
 func (c *RawCred) UpdateAttr(name string, val interface{}) error {
 	attr, err := c.GetAttr(name)
 	if err != nil {
@@ -77,12 +134,14 @@ func (c *RawCred) UpdateAttr(name string, val interface{}) error {
 	return nil
 }
 
-func (c *RawCred) addEmptyStrAttr(name string, i int, known bool) error {
-	if err := c.validateAttr(name, known); err != nil {
+func (c *RawCred) addEmptyStrAttr(name string, i int, known bool,
+	enc AttrEncoding) error {
+	if err := c.validateAttr(name, known, false); err != nil {
 		return err
 	}
 	//i := len(c.Attrs)
 	empty := NewEmptyStrAttr(name, known)
+	empty.Encoding = enc
 	empty.Index = i
 	c.insertAttr(i, empty)
 
@@ -90,7 +149,7 @@ func (c *RawCred) addEmptyStrAttr(name string, i int, known bool) error {
 }
 
 func (c *RawCred) addEmptyInt64Attr(name string, i int, known bool) error {
-	if err := c.validateAttr(name, known); err != nil {
+	if err := c.validateAttr(name, known, false); err != nil {
 		return err
 	}
 	//i := len(c.Attrs)
@@ -100,6 +159,142 @@ func (c *RawCred) addEmptyInt64Attr(name string, i int, known bool) error {
 	return nil
 }
 
+func (c *RawCred) addEmptyDateAttr(name string, i int, known bool) error {
+	if err := c.validateAttr(name, known, false); err != nil {
+		return err
+	}
+	empty := NewEmptyDateAttr(name, known)
+	empty.Index = i
+	c.insertAttr(i, empty)
+	return nil
+}
+
+func (c *RawCred) addEmptyBoolAttr(name string, i int, known bool) error {
+	if err := c.validateAttr(name, known, false); err != nil {
+		return err
+	}
+	empty := NewEmptyBoolAttr(name, known)
+	empty.Index = i
+	c.insertAttr(i, empty)
+	return nil
+}
+
+func (c *RawCred) addEmptyBytesAttr(name string, i int, known bool,
+	enc AttrEncoding) error {
+	if err := c.validateAttr(name, known, false); err != nil {
+		return err
+	}
+	empty := NewEmptyBytesAttr(name, enc, known)
+	empty.Index = i
+	c.insertAttr(i, empty)
+	return nil
+}
+
+func (c *RawCred) addEmptyEnumAttr(name string, i int, values []string,
+	known bool) error {
+	if err := c.validateAttr(name, known, false); err != nil {
+		return err
+	}
+	empty, err := NewEmptyEnumAttr(name, values, known)
+	if err != nil {
+		return err
+	}
+	empty.Index = i
+	c.insertAttr(i, empty)
+	return nil
+}
+
+// addEmptyHiddenStrAttr adds a string attribute whose value is
+// known only to the credential receiver.
+func (c *RawCred) addEmptyHiddenStrAttr(name string, i int,
+	enc AttrEncoding) error {
+	if err := c.validateAttr(name, false, true); err != nil {
+		return err
+	}
+	empty := NewEmptyStrAttr(name, false)
+	empty.Encoding = enc
+	empty.Hidden = true
+	empty.Index = i
+	c.insertAttr(i, empty)
+
+	return nil
+}
+
+// addEmptyHiddenInt64Attr adds an int64 attribute whose value is
+// known only to the credential receiver.
+func (c *RawCred) addEmptyHiddenInt64Attr(name string, i int) error {
+	if err := c.validateAttr(name, false, true); err != nil {
+		return err
+	}
+	empty := NewEmptyInt64Attr(name, false)
+	empty.Hidden = true
+	empty.Index = i
+	c.insertAttr(i, empty)
+
+	return nil
+}
+
+// addEmptyHiddenDateAttr adds a date attribute whose value is
+// known only to the credential receiver.
+func (c *RawCred) addEmptyHiddenDateAttr(name string, i int) error {
+	if err := c.validateAttr(name, false, true); err != nil {
+		return err
+	}
+	empty := NewEmptyDateAttr(name, false)
+	empty.Hidden = true
+	empty.Index = i
+	c.insertAttr(i, empty)
+
+	return nil
+}
+
+// addEmptyHiddenBoolAttr adds a bool attribute whose value is
+// known only to the credential receiver.
+func (c *RawCred) addEmptyHiddenBoolAttr(name string, i int) error {
+	if err := c.validateAttr(name, false, true); err != nil {
+		return err
+	}
+	empty := NewEmptyBoolAttr(name, false)
+	empty.Hidden = true
+	empty.Index = i
+	c.insertAttr(i, empty)
+
+	return nil
+}
+
+// addEmptyHiddenEnumAttr adds an enumerated attribute whose value is
+// known only to the credential receiver.
+func (c *RawCred) addEmptyHiddenEnumAttr(name string, i int,
+	values []string) error {
+	if err := c.validateAttr(name, false, true); err != nil {
+		return err
+	}
+	empty, err := NewEmptyEnumAttr(name, values, false)
+	if err != nil {
+		return err
+	}
+	empty.Hidden = true
+	empty.Index = i
+	c.insertAttr(i, empty)
+
+	return nil
+}
+
+// addEmptyHiddenBytesAttr adds a bytes attribute whose value is
+// known only to the credential receiver.
+func (c *RawCred) addEmptyHiddenBytesAttr(name string, i int,
+	enc AttrEncoding) error {
+	if err := c.validateAttr(name, false, true); err != nil {
+		return err
+	}
+	empty := NewEmptyBytesAttr(name, enc, false)
+	empty.Hidden = true
+	empty.Index = i
+	c.insertAttr(i, empty)
+
+	return nil
+}
+
 // GetKnownVals returns *big.Int values of Known attributes.
 // The returned elements are ordered by attribute's Index.
 func (c *RawCred) GetKnownVals() []*big.Int {
@@ -121,7 +316,22 @@ func (c *RawCred) GetCommittedVals() []*big.Int {
 	for i := 0; i < len(c.Attrs); i++ { // avoid range to have attributes in
 		// proper order
 		attr := c.Attrs[i]
-		if !attr.isKnown() {
+		if !attr.isKnown() && !attr.isHidden() {
+			values = append(values, attr.internalValue())
+		}
+	}
+
+	return values
+}
+
+// GetHiddenVals returns *big.Int values of Hidden attributes.
+// The returned elements are ordered by attribute's Index.
+func (c *RawCred) GetHiddenVals() []*big.Int {
+	var values []*big.Int
+	for i := 0; i < len(c.Attrs); i++ { // avoid range to have attributes in
+		// proper order
+		attr := c.Attrs[i]
+		if attr.isHidden() {
 			values = append(values, attr.internalValue())
 		}
 	}
@@ -138,12 +348,16 @@ func (c *RawCred) insertAttr(i int, a CredAttr) {
 	c.Attrs[i] = a
 }
 
-func (c *RawCred) validateAttr(name string, known bool) error {
+func (c *RawCred) validateAttr(name string, known, hidden bool) error {
 	if known && len(c.GetKnownVals()) >= c.AttrCount.Known {
 		return fmt.Errorf("Known attributes exhausted")
 	}
 
-	if !known && len(c.GetCommittedVals()) >= c.AttrCount.Committed {
+	if hidden && len(c.GetHiddenVals()) >= c.AttrCount.Hidden {
+		return fmt.Errorf("Hidden attributes exhausted")
+	}
+
+	if !known && !hidden && len(c.GetCommittedVals()) >= c.AttrCount.Committed {
 		return fmt.Errorf("Committed attributes exhausted")
 	}
 
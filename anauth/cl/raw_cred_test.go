@@ -18,6 +18,7 @@
 package cl
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,6 +45,28 @@ func TestRawCred_ExceedCommittedAttrsCount(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRawCred_ExceedHiddenAttrsCount(t *testing.T) {
+	nAttrs := NewAttrCount(0, 1, 0)
+	rc := NewRawCred(nAttrs)
+	err := rc.addEmptyHiddenInt64Attr("a", 0)
+	assert.Error(t, err)
+}
+
+// checks that values of hidden attributes are kept apart from
+// values of committed attributes.
+func TestRawCred_HiddenVals(t *testing.T) {
+	c := NewRawCred(NewAttrCount(0, 1, 1))
+	err := c.addEmptyInt64Attr("a", 0, false)
+	assert.NoError(t, err)
+	err = c.addEmptyHiddenInt64Attr("b", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, c.UpdateAttr("a", 1))
+	assert.NoError(t, c.UpdateAttr("b", 2))
+
+	assert.Equal(t, []*big.Int{encodeInt64(1)}, c.GetCommittedVals())
+	assert.Equal(t, []*big.Int{encodeInt64(2)}, c.GetHiddenVals())
+}
+
 func TestRawCred_AddInt64Attr(t *testing.T) {
 	c := NewRawCred(NewAttrCount(1, 0, 0))
 	err := c.addEmptyInt64Attr("Age", 0, true)
@@ -70,3 +93,11 @@ func TestRawCred_GetAttributeInvalid(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, a)
 }
+
+func intsToBig(s ...int) []*big.Int {
+	bigS := make([]*big.Int, len(s))
+	for i, el := range s {
+		bigS[i] = big.NewInt(int64(el))
+	}
+	return bigS
+}
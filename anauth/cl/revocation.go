@@ -0,0 +1,324 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/pkg/errors"
+)
+
+// ErrRevoked is returned when a witness cannot be updated because the
+// credential was revoked.
+var ErrRevoked = errors.New("credential was revoked")
+
+// Accumulator is a dynamic RSA accumulator (Camenisch, Lysyanskaya:
+// Dynamic Accumulators and Application to Efficient Revocation of
+// Anonymous Credentials) of primes e of credentials that were issued
+// and not revoked. Its value is AccInit^(e_1 * ... * e_k) in the group
+// of the issuer's public key.
+//
+// Every change of the accumulator increments its version.
+type Accumulator struct {
+	Version int64
+	Value   *big.Int
+}
+
+// AccumulatorUpdate is a change of the accumulator. Updates are
+// published, so that holders of credentials can update their witnesses.
+type AccumulatorUpdate struct {
+	Version int64    // version of the accumulator after the update
+	E       *big.Int // prime that was added or removed
+	Removed bool
+	Value   *big.Int // value of the accumulator after the update
+}
+
+// Accumulator returns the accumulator after the update.
+func (u *AccumulatorUpdate) Accumulator() *Accumulator {
+	return &Accumulator{
+		Version: u.Version,
+		Value:   u.Value,
+	}
+}
+
+func (u *AccumulatorUpdate) MarshalBinary() ([]byte, error) {
+	return json.Marshal(u)
+}
+
+func (u *AccumulatorUpdate) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, u)
+}
+
+// Witness proves that prime e of a credential is in the accumulator of
+// the given version, that is Value^e = value of the accumulator.
+type Witness struct {
+	Version int64
+	Value   *big.Int
+}
+
+// Update brings witness w for prime e to the given version of the
+// accumulator. Updates have to contain all the updates following the
+// version of the witness up to the given version, later updates are
+// ignored. ErrRevoked is returned if e was removed from the
+// accumulator, in which case w is left unchanged.
+func (w *Witness) Update(pubKey *PubKey, e *big.Int,
+	updates []*AccumulatorUpdate, version int64) error {
+	group := qr.NewRSApecialPublic(pubKey.N)
+	val := w.Value
+	ver := w.Version
+
+	for _, u := range updates {
+		if u.Version <= ver {
+			continue
+		}
+		if u.Version > version {
+			break
+		}
+		if u.Version != ver+1 {
+			return fmt.Errorf("missing accumulator update %d", ver+1)
+		}
+
+		if u.Removed {
+			if u.E.Cmp(e) == 0 {
+				return ErrRevoked
+			}
+			// for a*e + b*e' = 1 it holds (w^b * acc'^a)^e = acc',
+			// since acc = acc'^e'
+			a, b := new(big.Int), new(big.Int)
+			gcd := new(big.Int).GCD(a, b, e, u.E)
+			if gcd.Cmp(big.NewInt(1)) != 0 {
+				return fmt.Errorf("invalid accumulator update %d", u.Version)
+			}
+			val = group.Mul(group.Exp(val, b), group.Exp(u.Value, a))
+		} else {
+			val = group.Exp(val, u.E)
+		}
+		ver = u.Version
+	}
+
+	if ver != version {
+		return fmt.Errorf("missing accumulator updates up to version %d",
+			version)
+	}
+
+	w.Value = val
+	w.Version = ver
+	return nil
+}
+
+// Revoker maintains the accumulator of an organization. Removing
+// primes from the accumulator requires the secret key of the
+// organization.
+//
+// Revoker is safe for concurrent use, updates of the accumulator made
+// by other processes are detected by the AccumulatorStore.
+type Revoker struct {
+	org   *Org
+	store AccumulatorStore
+	mu    sync.Mutex
+}
+
+func NewRevoker(org *Org, store AccumulatorStore) (*Revoker, error) {
+	if !org.Keys.Pub.SupportsRevocation() {
+		return nil, fmt.Errorf("public key does not support revocation")
+	}
+
+	return &Revoker{
+		org:   org,
+		store: store,
+	}, nil
+}
+
+// Accumulator returns the current accumulator.
+func (r *Revoker) Accumulator() (*Accumulator, error) {
+	return currentAccumulator(r.store, r.org.Keys.Pub)
+}
+
+// currentAccumulator returns the accumulator of public key pk after
+// the last update in store.
+func currentAccumulator(store AccumulatorStore, pk *PubKey) (*Accumulator,
+	error) {
+	u, err := store.Last()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load accumulator")
+	}
+	if u == nil {
+		return &Accumulator{
+			Version: 0,
+			Value:   pk.AccInit,
+		}, nil
+	}
+
+	return u.Accumulator(), nil
+}
+
+// Updates returns updates of the accumulator following the given
+// version.
+func (r *Revoker) Updates(version int64) ([]*AccumulatorUpdate, error) {
+	return r.store.Updates(version)
+}
+
+// Add adds prime e of a newly issued credential to the accumulator,
+// and returns the witness for e.
+func (r *Revoker) Add(e *big.Int) (*Witness, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, w, err := r.addition(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.Append(u); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// AddRecord adds prime e of an updated credential to the accumulator
+// like Add, and in the same transaction replaces the receiver record
+// prev of nym in recMgr with rec. It returns ErrRecordChanged if the
+// stored record no longer describes the credential of prev, which
+// happens when the credential was revoked meanwhile.
+func (r *Revoker) AddRecord(e *big.Int, recMgr ReceiverRecordManager,
+	nym *big.Int, prev, rec *ReceiverRecord) (*Witness, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, w, err := r.addition(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.store.AppendRecord(u, recMgr, nym, prev, rec); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// addition returns the update of the accumulator that adds prime e,
+// and the witness for e.
+func (r *Revoker) addition(e *big.Int) (*AccumulatorUpdate, *Witness,
+	error) {
+	acc, err := r.Accumulator()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := &AccumulatorUpdate{
+		Version: acc.Version + 1,
+		E:       e,
+		Value:   r.org.Group.Exp(acc.Value, e),
+	}
+
+	// the previous value of the accumulator is the witness for e
+	return u, &Witness{
+		Version: u.Version,
+		Value:   acc.Value,
+	}, nil
+}
+
+// Remove removes prime e from the accumulator. Holders of the
+// credential with prime e are not able to update their witnesses
+// afterwards.
+func (r *Revoker) Remove(e *big.Int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, err := r.removal(e)
+	if err != nil {
+		return err
+	}
+
+	return r.store.Append(u)
+}
+
+// removal returns the update of the accumulator that removes prime e.
+func (r *Revoker) removal(e *big.Int) (*AccumulatorUpdate, error) {
+	if r.org.Group.P1 == nil || r.org.Group.Q1 == nil {
+		return nil, fmt.Errorf("removing from accumulator requires" +
+			" secret key")
+	}
+
+	acc, err := r.Accumulator()
+	if err != nil {
+		return nil, err
+	}
+
+	order := new(big.Int).Mul(r.org.Group.P1, r.org.Group.Q1)
+	eInv := new(big.Int).ModInverse(e, order)
+	if eInv == nil {
+		return nil, fmt.Errorf("cannot remove %s from accumulator", e)
+	}
+
+	return &AccumulatorUpdate{
+		Version: acc.Version + 1,
+		E:       e,
+		Removed: true,
+		Value:   r.org.Group.Exp(acc.Value, eInv),
+	}, nil
+}
+
+// revokeAttempts bounds how many times RevokeNym reloads a receiver
+// record that was changed concurrently.
+const revokeAttempts = 3
+
+// RevokeNym revokes the credential issued to nym, with the receiver
+// record loaded from recMgr. The credential is removed from the
+// accumulator in the same transaction that marks the record as revoked,
+// so that a concurrent update cannot undo the revocation. If the
+// credential was updated meanwhile, the updated credential is revoked.
+func (r *Revoker) RevokeNym(recMgr ReceiverRecordManager,
+	nym *big.Int) error {
+	for i := 0; ; i++ {
+		err := r.revokeRecord(recMgr, nym)
+		if err != ErrRecordChanged || i == revokeAttempts-1 {
+			return err
+		}
+	}
+}
+
+func (r *Revoker) revokeRecord(recMgr ReceiverRecordManager,
+	nym *big.Int) error {
+	rec, err := recMgr.Load(nym)
+	if err != nil {
+		return errors.Wrap(err, "cannot load receiver record")
+	}
+	if rec.Revoked {
+		return fmt.Errorf("credential for nym %s is already revoked", nym)
+	}
+	if rec.E == nil {
+		return fmt.Errorf("credential for nym %s cannot be revoked", nym)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, err := r.removal(rec.E)
+	if err != nil {
+		return err
+	}
+	revoked := *rec
+	revoked.Revoked = true
+
+	return r.store.AppendRecord(u, recMgr, nym, rec, &revoked)
+}
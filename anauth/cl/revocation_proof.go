@@ -0,0 +1,193 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+)
+
+// NonRevocationProof proves in zero knowledge that the prime e of
+// a credential is in the accumulator, that is, that the prover knows
+// a witness w such that w^e = acc. The proof is bound to the proof of
+// possession of the credential, as it shares the challenge and the
+// proof data for e with it.
+//
+// The prover commits to the witness as CU = w * H^r2 and to r2 as
+// CR = G^r2 * H^r3 (G and H are AccG and AccH of the public key), and
+// proves that
+//
+//	acc = CU^e * H^-delta1 and 1 = CR^e * G^-delta1 * H^-delta2
+//
+// for delta1 = e*r2 and delta2 = e*r3, as in Camenisch, Lysyanskaya:
+// Dynamic Accumulators and Application to Efficient Revocation of
+// Anonymous Credentials.
+type NonRevocationProof struct {
+	CU        *big.Int
+	CR        *big.Int
+	CRTilde   *big.Int
+	OneTilde  *big.Int
+	AccTilde  *big.Int
+	R2Hat     *big.Int
+	R3Hat     *big.Int
+	Delta1Hat *big.Int
+	Delta2Hat *big.Int
+}
+
+// challengeInput returns the values that are included in the challenge
+// of the proof.
+func (p *NonRevocationProof) challengeInput() []*big.Int {
+	return []*big.Int{p.CU, p.CR, p.CRTilde, p.OneTilde, p.AccTilde}
+}
+
+// nonRevocationProver builds a NonRevocationProof. Random value for
+// e has to be the same as the one used for e in the proof of
+// possession of the credential.
+type nonRevocationProver struct {
+	group  *qr.RSASpecial
+	pubKey *PubKey
+
+	r2     *big.Int
+	r3     *big.Int
+	delta1 *big.Int
+	delta2 *big.Int
+
+	CU *big.Int
+	CR *big.Int
+
+	r2Tilde     *big.Int
+	r3Tilde     *big.Int
+	delta1Tilde *big.Int
+	delta2Tilde *big.Int
+}
+
+func newNonRevocationProver(group *qr.RSASpecial, pubKey *PubKey,
+	params *pb.Params, acc *Accumulator, cred *Cred) (*nonRevocationProver,
+	error) {
+	if !pubKey.SupportsRevocation() {
+		return nil, fmt.Errorf("public key does not support revocation")
+	}
+	w := cred.Witness
+	if w == nil {
+		return nil, fmt.Errorf("credential has no witness")
+	}
+	if w.Version != acc.Version {
+		return nil, fmt.Errorf("witness is for accumulator version %d,"+
+			" expected %d", w.Version, acc.Version)
+	}
+
+	b := new(big.Int).Exp(big.NewInt(2),
+		big.NewInt(int64(params.NLength+params.SecParam)), nil)
+	r2 := common.GetRandomInt(b)
+	r3 := common.GetRandomInt(b)
+
+	return &nonRevocationProver{
+		group:  group,
+		pubKey: pubKey,
+		r2:     r2,
+		r3:     r3,
+		delta1: new(big.Int).Mul(cred.E, r2),
+		delta2: new(big.Int).Mul(cred.E, r3),
+		CU:     group.Mul(w.Value, group.Exp(pubKey.AccH, r2)),
+		CR: group.Mul(group.Exp(pubKey.AccG, r2),
+			group.Exp(pubKey.AccH, r3)),
+	}, nil
+}
+
+// getProofRandomData returns a NonRevocationProof with the commitments
+// and proof random data. Proof data is set with setProofData once the
+// challenge is known.
+func (p *nonRevocationProver) getProofRandomData(params *pb.Params,
+	eTilde *big.Int) *NonRevocationProof {
+	// boundary for r_tilde
+	b_r := params.NLength + 2*params.SecParam + params.HashBitLen
+	// boundary for delta_tilde
+	b_delta := b_r + params.EBitLen
+
+	p.r2Tilde = getRandomBoundedInt(int(b_r))
+	p.r3Tilde = getRandomBoundedInt(int(b_r))
+	p.delta1Tilde = getRandomBoundedInt(int(b_delta))
+	p.delta2Tilde = getRandomBoundedInt(int(b_delta))
+
+	G, H := p.pubKey.AccG, p.pubKey.AccH
+	minus := func(x *big.Int) *big.Int { return new(big.Int).Neg(x) }
+
+	CRTilde := p.group.Mul(p.group.Exp(G, p.r2Tilde),
+		p.group.Exp(H, p.r3Tilde))
+	OneTilde := p.group.Mul(p.group.Exp(p.CR, eTilde),
+		p.group.Mul(p.group.Exp(G, minus(p.delta1Tilde)),
+			p.group.Exp(H, minus(p.delta2Tilde))))
+	AccTilde := p.group.Mul(p.group.Exp(p.CU, eTilde),
+		p.group.Exp(H, minus(p.delta1Tilde)))
+
+	return &NonRevocationProof{
+		CU:       p.CU,
+		CR:       p.CR,
+		CRTilde:  CRTilde,
+		OneTilde: OneTilde,
+		AccTilde: AccTilde,
+	}
+}
+
+func (p *nonRevocationProver) setProofData(proof *NonRevocationProof,
+	challenge *big.Int) {
+	proof.R2Hat = response(p.r2Tilde, challenge, p.r2)
+	proof.R3Hat = response(p.r3Tilde, challenge, p.r3)
+	proof.Delta1Hat = response(p.delta1Tilde, challenge, p.delta1)
+	proof.Delta2Hat = response(p.delta2Tilde, challenge, p.delta2)
+}
+
+// verifyNonRevocationProof checks that proof proves that e is in
+// the accumulator acc, with the proof data eHat for e from the proof of
+// possession of the credential.
+func verifyNonRevocationProof(group *qr.RSASpecial, pubKey *PubKey,
+	acc *Accumulator, proof *NonRevocationProof, eHat,
+	challenge *big.Int) (bool, error) {
+	if !pubKey.SupportsRevocation() {
+		return false, fmt.Errorf("public key does not support revocation")
+	}
+
+	G, H := pubKey.AccG, pubKey.AccH
+	minus := func(x *big.Int) *big.Int { return new(big.Int).Neg(x) }
+
+	// G^r2_hat * H^r3_hat = CR^c * CR_tilde
+	left := group.Mul(group.Exp(G, proof.R2Hat), group.Exp(H, proof.R3Hat))
+	right := group.Mul(group.Exp(proof.CR, challenge), proof.CRTilde)
+	if left.Cmp(right) != 0 {
+		return false, nil
+	}
+
+	// CR^e_hat * G^-delta1_hat * H^-delta2_hat = 1^c * One_tilde
+	left = group.Mul(group.Exp(proof.CR, eHat),
+		group.Mul(group.Exp(G, minus(proof.Delta1Hat)),
+			group.Exp(H, minus(proof.Delta2Hat))))
+	if left.Cmp(proof.OneTilde) != 0 {
+		return false, nil
+	}
+
+	// CU^e_hat * H^-delta1_hat = acc^c * Acc_tilde
+	left = group.Mul(group.Exp(proof.CU, eHat),
+		group.Exp(H, minus(proof.Delta1Hat)))
+	right = group.Mul(group.Exp(acc.Value, challenge), proof.AccTilde)
+
+	return left.Cmp(right) == 0, nil
+}
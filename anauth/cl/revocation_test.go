@@ -0,0 +1,240 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOrg is shared among the tests, since generating keys
+// takes a while
+var testOrg struct {
+	org  *Org
+	once sync.Once
+}
+
+// newTestOrg returns an organization with a single Known attribute.
+func newTestOrg(t *testing.T) *Org {
+	testOrg.once.Do(func() {
+		org, err := NewOrg(GetDefaultParamSizes(), NewAttrCount(1, 0, 0))
+		require.NoError(t, err)
+		testOrg.org = org
+	})
+	require.NotNil(t, testOrg.org)
+
+	return testOrg.org
+}
+
+// newTestRevoker returns a Revoker with an empty accumulator.
+func newTestRevoker(t *testing.T) *Revoker {
+	r, err := NewRevoker(newTestOrg(t), NewMockAccumulatorStore())
+	require.NoError(t, err)
+
+	return r
+}
+
+// isAccumulated checks that w is a witness for e in acc.
+func isAccumulated(r *Revoker, w *Witness, e *big.Int) bool {
+	acc, _ := r.Accumulator()
+	return w.Version == acc.Version &&
+		r.org.Group.Exp(w.Value, e).Cmp(acc.Value) == 0
+}
+
+func TestRevoker(t *testing.T) {
+	r := newTestRevoker(t)
+	pk := r.org.Keys.Pub
+
+	acc, err := r.Accumulator()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), acc.Version)
+	assert.Equal(t, pk.AccInit, acc.Value)
+
+	e := make([]*big.Int, 3)
+	w := make([]*Witness, 3)
+	for i := range e {
+		e[i], _ = r.org.genCredRandoms()
+		w[i], err = r.Add(e[i])
+		require.NoError(t, err)
+		assert.True(t, isAccumulated(r, w[i], e[i]))
+	}
+
+	require.NoError(t, r.Remove(e[1]))
+
+	updates, err := r.Updates(w[0].Version)
+	require.NoError(t, err)
+	require.Len(t, updates, 3)
+
+	// witness can be updated to an intermediate version
+	w0 := *w[0]
+	require.NoError(t, w0.Update(pk, e[0], updates, 2))
+	assert.Equal(t, int64(2), w0.Version)
+
+	require.NoError(t, w[0].Update(pk, e[0], updates, 4))
+	assert.True(t, isAccumulated(r, w[0], e[0]))
+
+	updates, err = r.Updates(w[2].Version)
+	require.NoError(t, err)
+	require.NoError(t, w[2].Update(pk, e[2], updates, 4))
+	assert.True(t, isAccumulated(r, w[2], e[2]))
+
+	// revoked credential
+	updates, err = r.Updates(w[1].Version)
+	require.NoError(t, err)
+	err = w[1].Update(pk, e[1], updates, 4)
+	assert.Equal(t, ErrRevoked, err)
+	assert.Equal(t, int64(2), w[1].Version)
+}
+
+func TestWitness_UpdateMissing(t *testing.T) {
+	r := newTestRevoker(t)
+
+	e, _ := r.org.genCredRandoms()
+	w, err := r.Add(e)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		other, _ := r.org.genCredRandoms()
+		_, err := r.Add(other)
+		require.NoError(t, err)
+	}
+
+	updates, err := r.Updates(w.Version)
+	require.NoError(t, err)
+	assert.Error(t, w.Update(r.org.Keys.Pub, e, updates[1:], 3))
+	assert.Error(t, w.Update(r.org.Keys.Pub, e, updates, 4))
+}
+
+// TestRevoker_RevokeNymUpdate checks that an update of a credential
+// does not undo its revocation, whichever of the two comes first.
+func TestRevoker_RevokeNymUpdate(t *testing.T) {
+	r := newTestRevoker(t)
+	recMgr := NewMockRecordManager()
+	nym := big.NewInt(42)
+
+	e, _ := r.org.genCredRandoms()
+	_, err := r.Add(e)
+	require.NoError(t, err)
+	rec := &ReceiverRecord{V11: big.NewInt(1), E: e}
+	require.NoError(t, recMgr.Store(nym, rec))
+
+	// the credential is revoked while it is being updated
+	require.NoError(t, r.RevokeNym(recMgr, nym))
+	acc, err := r.Accumulator()
+	require.NoError(t, err)
+	updated, _ := r.org.genCredRandoms()
+	_, err = r.AddRecord(updated, recMgr, nym, rec,
+		&ReceiverRecord{V11: big.NewInt(2), E: updated})
+	assert.Equal(t, ErrRecordChanged, err)
+
+	stored, err := recMgr.Load(nym)
+	require.NoError(t, err)
+	assert.True(t, stored.Revoked)
+	unchanged, err := r.Accumulator()
+	require.NoError(t, err)
+	assert.Equal(t, acc, unchanged)
+
+	// the updated credential is revoked
+	rec = &ReceiverRecord{V11: big.NewInt(3), E: e}
+	require.NoError(t, recMgr.Store(nym, rec))
+	w, err := r.AddRecord(updated, recMgr, nym, rec,
+		&ReceiverRecord{V11: big.NewInt(4), E: updated})
+	require.NoError(t, err)
+	assert.True(t, isAccumulated(r, w, updated))
+
+	require.NoError(t, r.RevokeNym(recMgr, nym))
+	stored, err = recMgr.Load(nym)
+	require.NoError(t, err)
+	assert.True(t, stored.Revoked)
+	last, err := r.store.Last()
+	require.NoError(t, err)
+	assert.True(t, last.Removed)
+	assert.Equal(t, updated, last.E)
+}
+
+func TestMockAccumulatorStore_Append(t *testing.T) {
+	s := NewMockAccumulatorStore()
+	u := &AccumulatorUpdate{
+		Version: 2,
+		E:       big.NewInt(3),
+		Value:   big.NewInt(8),
+	}
+	assert.Error(t, s.Append(u))
+
+	u.Version = 1
+	assert.NoError(t, s.Append(u))
+	assert.Error(t, s.Append(u))
+
+	last, err := s.Last()
+	require.NoError(t, err)
+	assert.Equal(t, u, last)
+}
+
+func TestNonRevocationProof(t *testing.T) {
+	r := newTestRevoker(t)
+	params := r.org.Params
+	pk := r.org.Keys.Pub
+
+	e, _ := r.org.genCredRandoms()
+	w, err := r.Add(e)
+	require.NoError(t, err)
+	acc, err := r.Accumulator()
+	require.NoError(t, err)
+	cred := &Cred{E: e, Witness: w}
+
+	p, err := newNonRevocationProver(r.org.Group, pk, params, acc, cred)
+	require.NoError(t, err)
+
+	// random value and proof data for e would come from the proof of
+	// possession of the credential
+	eTilde := getRandomBoundedInt(int(params.EBitLen + params.SecParam +
+		params.HashBitLen))
+	proof := p.getProofRandomData(params, eTilde)
+	challenge := common.Hash(proof.challengeInput()...)
+	p.setProofData(proof, challenge)
+	eHat := response(eTilde, challenge, e)
+
+	ok, err := verifyNonRevocationProof(r.org.Group, pk, acc, proof, eHat,
+		challenge)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// proof data for some other e
+	other, _ := r.org.genCredRandoms()
+	ok, err = verifyNonRevocationProof(r.org.Group, pk, acc, proof,
+		response(eTilde, challenge, other), challenge)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// e is no longer in the accumulator
+	require.NoError(t, r.Remove(e))
+	newAcc, err := r.Accumulator()
+	require.NoError(t, err)
+	ok, err = verifyNonRevocationProof(r.org.Group, pk, newAcc, proof, eHat,
+		challenge)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// witness is out of date
+	_, err = newNonRevocationProver(r.org.Group, pk, params, newAcc, cred)
+	assert.Error(t, err)
+}
@@ -0,0 +1,684 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema describes the credentials issued by an organization. It has
+// a name and a version, and lists the attributes of credentials in
+// order, the index of an attribute is its position in Attrs.
+//
+// Schemas are read from schema files (see DecodeSchema), or built from
+// the attributes specification in configuration (see parseAttrs).
+type Schema struct {
+	Name    string
+	Version int
+	Attrs   []CredAttr
+	Count   *AttrCount
+}
+
+// attrTypes are the types of attributes in schemas.
+var attrTypes = []string{"string", "int64", "date", "bool", "enum", "bytes"}
+
+// Disclosure classes of attributes in schema files.
+const (
+	knownDisclosure     = "known"
+	committedDisclosure = "committed"
+	hiddenDisclosure    = "hidden"
+)
+
+// attrSpec specifies an attribute of a schema.
+type attrSpec struct {
+	name     string
+	typ      string
+	known    bool
+	hidden   bool
+	cond     AttrCond
+	values   []string
+	encoding AttrEncoding
+}
+
+// newSchema returns a schema with attributes specified by specs, which
+// have already been checked (see checkAttrCond and checkAttrEncoding).
+func newSchema(name string, version int, specs []*attrSpec) (*Schema,
+	error) {
+	attrs := make([]CredAttr, len(specs))
+	count := NewAttrCount(0, 0, 0)
+	for i, s := range specs {
+		a, err := s.attr(i)
+		if err != nil {
+			return nil, err
+		}
+		attrs[i] = a
+
+		switch {
+		case s.known:
+			count.Known++
+		case s.hidden:
+			count.Hidden++
+		default:
+			count.Committed++
+		}
+	}
+
+	return &Schema{
+		Name:    name,
+		Version: version,
+		Attrs:   attrs,
+		Count:   count,
+	}, nil
+}
+
+// attr returns the attribute specified by s, with index i.
+func (s *attrSpec) attr(i int) (CredAttr, error) {
+	var a CredAttr
+	var base *Attr
+
+	switch s.typ {
+	case "string":
+		str := NewEmptyStrAttr(s.name, s.known)
+		str.Encoding = s.encoding
+		a, base = str, str.Attr
+	case "int64":
+		n := NewEmptyInt64Attr(s.name, s.known)
+		a, base = n, n.Attr
+	case "date":
+		d := NewEmptyDateAttr(s.name, s.known)
+		a, base = d, d.Attr
+	case "bool":
+		b := NewEmptyBoolAttr(s.name, s.known)
+		a, base = b, b.Attr
+	case "enum":
+		e, err := NewEmptyEnumAttr(s.name, s.values, s.known)
+		if err != nil {
+			return nil, err
+		}
+		a, base = e, e.Attr
+	case "bytes":
+		b := NewEmptyBytesAttr(s.name, s.encoding, s.known)
+		a, base = b, b.Attr
+	default:
+		return nil, fmt.Errorf("unsupported attribute type: %s", s.typ)
+	}
+
+	base.cond = s.cond
+	base.Hidden = s.hidden
+	base.Index = i
+
+	return a, nil
+}
+
+// schemaFromCredStructure returns the schema of credentials described
+// by cs, with no conditions on attributes, since credential structures
+// do not include them.
+func schemaFromCredStructure(cs *pb.CredStructure) (*Schema, error) {
+	specs := make([]*attrSpec, len(cs.Attributes))
+	for i, ca := range cs.Attributes {
+		var a *pb.Attribute
+		spec := &attrSpec{cond: none}
+		switch t := ca.Type.(type) {
+		case *pb.CredAttribute_StringAttr:
+			a, spec.typ = t.StringAttr.Attr, "string"
+			spec.encoding = pbAttrEncoding(t.StringAttr.Hashed)
+		case *pb.CredAttribute_SignedIntAttr:
+			a, spec.typ = t.SignedIntAttr.Attr, "int64"
+		case *pb.CredAttribute_DateAttr:
+			a, spec.typ = t.DateAttr.Attr, "date"
+		case *pb.CredAttribute_BoolAttr:
+			a, spec.typ = t.BoolAttr.Attr, "bool"
+		case *pb.CredAttribute_EnumAttr:
+			a, spec.typ = t.EnumAttr.Attr, "enum"
+			spec.values = t.EnumAttr.Values
+		case *pb.CredAttribute_BytesAttr:
+			a, spec.typ = t.BytesAttr.Attr, "bytes"
+			spec.encoding = pbAttrEncoding(t.BytesAttr.Hashed)
+		default:
+			return nil, fmt.Errorf("unsupported attribute type")
+		}
+		if a == nil || int(a.Index) != i {
+			return nil, fmt.Errorf("attributes are not ordered by index")
+		}
+		spec.name, spec.known, spec.hidden = a.Name, a.Known, a.Hidden
+		specs[i] = spec
+	}
+
+	return newSchema(cs.Name, int(cs.Version), specs)
+}
+
+// checkAttrCond checks that condition cond is supported for attributes
+// of type typ with encoding enc.
+func checkAttrCond(typ string, cond AttrCond, enc AttrEncoding) error {
+	switch cond {
+	case none, equal:
+		return nil
+	case in:
+		if typ == "string" && enc == HashEncoding {
+			return fmt.Errorf("condition in is not supported for hashed" +
+				" string attributes")
+		}
+		if typ == "string" || typ == "enum" {
+			return nil
+		}
+	default:
+		if typ == "int64" || typ == "date" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("condition %s is not supported for %s attributes",
+		cond, typ)
+}
+
+// checkAttrEncoding checks that the encoding can be set for attributes
+// of type typ.
+func checkAttrEncoding(typ string) error {
+	if typ != "string" && typ != "bytes" {
+		return fmt.Errorf("encoding is only supported for string and" +
+			" bytes attributes")
+	}
+	return nil
+}
+
+// SchemaError is an error at line Line and column Column of a schema
+// file.
+type SchemaError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+func schemaErr(n *yaml.Node, format string, args ...interface{}) error {
+	return &SchemaError{
+		Line:   n.Line,
+		Column: n.Column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// ReadSchema reads the schema from the file at path (see DecodeSchema).
+// Errors in the schema are reported as path:line:column: message.
+func ReadSchema(path string) (*Schema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := DecodeSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s", path, err)
+	}
+
+	return s, nil
+}
+
+// DecodeSchema decodes a schema in YAML or JSON, for example
+//
+//	name: student-card
+//	version: 1
+//	attributes:
+//	  - name: name
+//	    type: string
+//	  - name: birth_date
+//	    type: date
+//	    cond: gte
+//	  - name: role
+//	    type: enum
+//	    values: [student, staff]
+//	    cond: in
+//	  - name: link_secret
+//	    type: int64
+//	    disclosure: hidden
+//
+// Attributes are ordered as listed. Every attribute has a name, a type
+// (string, int64, date, bool, enum or bytes) and a disclosure class
+// (known, which is the default, committed or hidden), and can have
+// a condition (lt, lte, gt, gte, equal or in) that the verifier checks.
+// Only known attributes can have conditions, as the verifier learns
+// committed attributes only through their commitments, and predicates
+// are not proved over commitments.
+// Enumerated attributes list their allowed values, string and bytes
+// attributes can set their encoding (raw, which is the default, or
+// hash).
+//
+// Schemas are validated strictly, unknown fields are errors. Errors in
+// the schema are reported with their position as *SchemaError.
+func DecodeSchema(data []byte) (*Schema, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("empty schema")
+	}
+
+	root := doc.Content[0]
+	fields, err := mappingFields(root, "name", "version", "attributes")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := requiredScalar(root, fields, "name")
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, schemaErr(fields["name"], "name must not be empty")
+	}
+
+	if _, err := requiredScalar(root, fields, "version"); err != nil {
+		return nil, err
+	}
+	v := fields["version"]
+	version, err := strconv.Atoi(v.Value)
+	if err != nil || v.Tag != "!!int" || version <= 0 {
+		return nil, schemaErr(v, "version must be a positive integer")
+	}
+
+	attrs, ok := fields["attributes"]
+	if !ok {
+		return nil, schemaErr(root, "missing field attributes")
+	}
+	if attrs.Kind != yaml.SequenceNode || len(attrs.Content) == 0 {
+		return nil, schemaErr(attrs, "attributes must be a non-empty list")
+	}
+
+	specs := make([]*attrSpec, len(attrs.Content))
+	names := make(map[string]*yaml.Node, len(attrs.Content))
+	for i, n := range attrs.Content {
+		spec, nameNode, err := decodeAttrSpec(n)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := names[spec.name]; ok {
+			return nil, schemaErr(nameNode, "attribute %s is already"+
+				" defined at line %d", spec.name, prev.Line)
+		}
+		names[spec.name] = nameNode
+		specs[i] = spec
+	}
+
+	return newSchema(name, version, specs)
+}
+
+// decodeAttrSpec decodes the specification of an attribute from node n
+// of a schema file. It also returns the node of the attribute name.
+func decodeAttrSpec(n *yaml.Node) (*attrSpec, *yaml.Node, error) {
+	fields, err := mappingFields(n, "name", "type", "disclosure", "cond",
+		"values", "encoding")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, err := requiredScalar(n, fields, "name")
+	if err != nil {
+		return nil, nil, err
+	}
+	if name == "" {
+		return nil, nil, schemaErr(fields["name"], "name must not be empty")
+	}
+	spec := &attrSpec{
+		name:  name,
+		known: true,
+		cond:  none,
+	}
+
+	spec.typ, err = requiredScalar(n, fields, "type")
+	if err != nil {
+		return nil, nil, err
+	}
+	if !contains(attrTypes, spec.typ) {
+		return nil, nil, schemaErr(fields["type"], "unsupported attribute type"+
+			" %s, expected one of %v", spec.typ, attrTypes)
+	}
+
+	if d, ok := fields["disclosure"]; ok {
+		if d.Kind != yaml.ScalarNode {
+			return nil, nil, schemaErr(d, "disclosure must be a string")
+		}
+		switch d.Value {
+		case knownDisclosure:
+		case committedDisclosure:
+			spec.known = false
+		case hiddenDisclosure:
+			spec.known = false
+			spec.hidden = true
+		default:
+			return nil, nil, schemaErr(d, "invalid disclosure %s, expected"+
+				" known, committed or hidden", d.Value)
+		}
+	}
+
+	if e, ok := fields["encoding"]; ok {
+		if e.Kind != yaml.ScalarNode {
+			return nil, nil, schemaErr(e, "encoding must be a string")
+		}
+		if err := checkAttrEncoding(spec.typ); err != nil {
+			return nil, nil, schemaErr(e, "%s", err)
+		}
+		spec.encoding, err = parseAttrEncoding(e.Value)
+		if err != nil {
+			return nil, nil, schemaErr(e, "%s", err)
+		}
+	}
+
+	if c, ok := fields["cond"]; ok {
+		if c.Kind != yaml.ScalarNode {
+			return nil, nil, schemaErr(c, "cond must be a string")
+		}
+		spec.cond, err = parseAttrCond(c.Value)
+		if err != nil {
+			return nil, nil, schemaErr(c, "%s", err)
+		}
+		if err := checkAttrCond(spec.typ, spec.cond,
+			spec.encoding); err != nil {
+			return nil, nil, schemaErr(c, "%s", err)
+		}
+		if spec.hidden && spec.cond != none {
+			return nil, nil, schemaErr(c, "hidden attributes cannot have"+
+				" a condition")
+		}
+		if !spec.known && !spec.hidden && spec.cond != none {
+			return nil, nil, schemaErr(c, "committed attributes cannot"+
+				" have a condition")
+		}
+	}
+
+	values, ok := fields["values"]
+	if spec.typ == "enum" && !ok {
+		return nil, nil, schemaErr(n, "missing field values of enum"+
+			" attribute %s", name)
+	}
+	if ok {
+		if spec.typ != "enum" {
+			return nil, nil, schemaErr(values, "values are only supported for"+
+				" enum attributes")
+		}
+		if values.Kind != yaml.SequenceNode {
+			return nil, nil, schemaErr(values, "values must be a list")
+		}
+		for _, v := range values.Content {
+			if v.Kind != yaml.ScalarNode {
+				return nil, nil, schemaErr(v, "values must be strings")
+			}
+			spec.values = append(spec.values, v.Value)
+		}
+		if err := checkEnumValues(spec.values); err != nil {
+			return nil, nil, schemaErr(values, "%s", err)
+		}
+	}
+
+	return spec, fields["name"], nil
+}
+
+// mappingFields returns the values of mapping node n by key. Keys other
+// than allowed and duplicate keys are errors.
+func mappingFields(n *yaml.Node,
+	allowed ...string) (map[string]*yaml.Node, error) {
+	if n.Kind != yaml.MappingNode {
+		return nil, schemaErr(n, "expected a mapping with fields %v",
+			allowed)
+	}
+
+	fields := make(map[string]*yaml.Node, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if !contains(allowed, k.Value) {
+			return nil, schemaErr(k, "unknown field %s, expected one of"+
+				" %v", k.Value, allowed)
+		}
+		if _, ok := fields[k.Value]; ok {
+			return nil, schemaErr(k, "duplicate field %s", k.Value)
+		}
+		fields[k.Value] = v
+	}
+
+	return fields, nil
+}
+
+// requiredScalar returns the value of field key of mapping node n,
+// which has to be a scalar.
+func requiredScalar(n *yaml.Node, fields map[string]*yaml.Node,
+	key string) (string, error) {
+	v, ok := fields[key]
+	if !ok {
+		return "", schemaErr(n, "missing field %s", key)
+	}
+	if v.Kind != yaml.ScalarNode {
+		return "", schemaErr(v, "%s must be a scalar", key)
+	}
+
+	return v.Value, nil
+}
+
+// CredStructure describes credentials with the schema, as clients
+// receive it with the public parameters.
+func (s *Schema) CredStructure() *pb.CredStructure {
+	cs := newCredStructure(s.Attrs, s.Count)
+	cs.Name = s.Name
+	cs.Version = int32(s.Version)
+	return cs
+}
+
+// SchemaHash returns the hash of the schema described by cs, which
+// binds the public parameters of an organization to the schema of its
+// credentials.
+func SchemaHash(cs *pb.CredStructure) ([]byte, error) {
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	if err := b.Marshal(cs); err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(b.Bytes())
+
+	return h[:], nil
+}
+
+// schemaFromConfig returns the schema from the schema file cl_schema
+// from configuration v or, if no schema file is configured, from the
+// attributes specification in v (see parseAttrs).
+func schemaFromConfig(v *viper.Viper) (*Schema, error) {
+	if path := v.GetString("cl_schema"); path != "" {
+		if v.IsSet("attributes") {
+			return nil, fmt.Errorf("attributes cannot be configured" +
+				" together with a schema file")
+		}
+		return ReadSchema(path)
+	}
+
+	attrs, count, err := parseAttrs(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{
+		Attrs: attrs,
+		Count: count,
+	}, nil
+}
+
+// parseAttrs parses the attributes specification from configuration v,
+// where attributes are given by name, each with its index. Indices have
+// to be distinct and cover all attributes. Schema files (see
+// DecodeSchema) should be preferred, as they also carry a name and
+// a version.
+func parseAttrs(v *viper.Viper) ([]CredAttr, *AttrCount, error) {
+	if !v.IsSet("attributes") {
+		return nil, nil, fmt.Errorf("missing attributes declaration")
+	}
+
+	raw := v.GetStringMap("attributes")
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]*attrSpec, len(raw))
+	for _, name := range names {
+		data, ok := raw[name].(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid configuration of"+
+				" attribute %s", name)
+		}
+
+		index, ok := data["index"]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing index of attribute %s",
+				name)
+		}
+		i, ok := index.(int)
+		if !ok {
+			return nil, nil, fmt.Errorf("index of attribute %s must be"+
+				" an integer", name)
+		}
+		if i < 0 || i >= len(specs) {
+			return nil, nil, fmt.Errorf("index of attribute %s must be"+
+				" between 0 and %d", name, len(specs)-1)
+		}
+		if specs[i] != nil {
+			return nil, nil, fmt.Errorf("attributes %s and %s have the"+
+				" same index", specs[i].name, name)
+		}
+
+		spec, err := parseAttrSpec(name, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("attribute %s: %s", name, err)
+		}
+		specs[i] = spec
+	}
+
+	// indices are distinct and in range, so every attribute has one
+	s, err := newSchema("", 0, specs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.Attrs, s.Count, nil
+}
+
+// parseAttrSpec parses the specification of attribute name from its
+// configuration data.
+func parseAttrSpec(name string, data map[string]interface{}) (*attrSpec,
+	error) {
+	for k := range data {
+		switch k {
+		case "index", "type", "known", "hidden", "cond", "values",
+			"encoding":
+		default:
+			return nil, fmt.Errorf("unknown field %s", k)
+		}
+	}
+
+	t, ok := data["type"]
+	if !ok {
+		return nil, fmt.Errorf("missing type specifier")
+	}
+	typ, _ := t.(string)
+	if !contains(attrTypes, typ) {
+		return nil, fmt.Errorf("unsupported attribute type: %v", t)
+	}
+	spec := &attrSpec{
+		name:  name,
+		typ:   typ,
+		known: true,
+		cond:  none,
+	}
+
+	if k, ok := data["known"]; ok {
+		known, err := parseBool(k)
+		if err != nil {
+			return nil, fmt.Errorf("known must be true or false")
+		}
+		spec.known = known
+	}
+	if h, ok := data["hidden"]; ok {
+		hidden, err := parseBool(h)
+		if err != nil {
+			return nil, fmt.Errorf("hidden must be true or false")
+		}
+		spec.hidden = hidden
+	}
+	if spec.hidden && spec.known {
+		if _, ok := data["known"]; ok {
+			return nil, fmt.Errorf("attribute cannot be both known and" +
+				" hidden")
+		}
+		spec.known = false
+	}
+
+	if e, ok := data["encoding"]; ok {
+		if err := checkAttrEncoding(typ); err != nil {
+			return nil, err
+		}
+		enc, _ := e.(string)
+		var err error
+		if spec.encoding, err = parseAttrEncoding(enc); err != nil {
+			return nil, err
+		}
+	}
+
+	if c, ok := data["cond"]; ok {
+		cond, _ := c.(string)
+		var err error
+		if spec.cond, err = parseAttrCond(cond); err != nil {
+			return nil, err
+		}
+		if err := checkAttrCond(typ, spec.cond, spec.encoding); err != nil {
+			return nil, err
+		}
+		if spec.hidden && spec.cond != none {
+			return nil, fmt.Errorf("hidden attribute cannot have" +
+				" a condition")
+		}
+		if !spec.known && !spec.hidden && spec.cond != none {
+			return nil, fmt.Errorf("committed attribute cannot have" +
+				" a condition")
+		}
+	}
+
+	if values, ok := data["values"]; ok || typ == "enum" {
+		if typ != "enum" {
+			return nil, fmt.Errorf("values are only supported for enum" +
+				" attributes")
+		}
+		set, err := parseStrSet(values)
+		if err != nil {
+			return nil, fmt.Errorf("values: %s", err)
+		}
+		if err := checkEnumValues(set); err != nil {
+			return nil, fmt.Errorf("values: %s", err)
+		}
+		spec.values = set
+	}
+
+	return spec, nil
+}
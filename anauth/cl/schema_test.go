@@ -0,0 +1,365 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `name: student-card
+version: 2
+attributes:
+  - name: name
+    type: string
+  - name: birth_date
+    type: date
+    cond: gte
+  - name: role
+    type: enum
+    values: [student, staff]
+    cond: in
+  - name: address
+    type: string
+    disclosure: committed
+    encoding: hash
+  - name: link_secret
+    type: int64
+    disclosure: hidden
+`
+
+func TestDecodeSchema(t *testing.T) {
+	s, err := DecodeSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	assert.Equal(t, "student-card", s.Name)
+	assert.Equal(t, 2, s.Version)
+	assert.Equal(t, NewAttrCount(3, 1, 1), s.Count)
+
+	names := []string{"name", "birth_date", "role", "address",
+		"link_secret"}
+	require.Len(t, s.Attrs, len(names))
+	for i, a := range s.Attrs {
+		assert.Equal(t, names[i], a.Name())
+		assert.Equal(t, i, a.getIndex())
+	}
+	assert.IsType(t, &DateAttr{}, s.Attrs[1])
+	assert.Equal(t, greaterThanOrEqual, s.Attrs[1].getCond())
+	assert.Equal(t, []string{"student", "staff"},
+		s.Attrs[2].(*EnumAttr).Values)
+	assert.Equal(t, in, s.Attrs[2].getCond())
+	assert.Equal(t, HashEncoding, s.Attrs[3].(*StrAttr).Encoding)
+	assert.False(t, s.Attrs[3].isKnown())
+	assert.False(t, s.Attrs[3].isHidden())
+	assert.True(t, s.Attrs[4].isHidden())
+	assert.Equal(t, none, s.Attrs[4].getCond())
+}
+
+func TestDecodeSchema_JSON(t *testing.T) {
+	s, err := DecodeSchema([]byte(`{
+  "name": "membership",
+  "version": 1,
+  "attributes": [
+    {"name": "member", "type": "bool", "cond": "equal"},
+    {"name": "photo", "type": "bytes", "encoding": "hash"}
+  ]
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, "membership", s.Name)
+	assert.IsType(t, &BoolAttr{}, s.Attrs[0])
+	assert.Equal(t, HashEncoding, s.Attrs[1].(*BytesAttr).Encoding)
+
+	_, err = DecodeSchema([]byte(`{
+  "name": "membership",
+  "version": 1,
+  "attributes": [
+    {"name": "member", "type": "bool", "cond": "gte"}
+  ]
+}`))
+	assert.EqualError(t, err, "5:48: condition gte is not supported for"+
+		" bool attributes")
+}
+
+func TestDecodeSchema_Errors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		schema string
+		err    string
+	}{
+		{"UnknownField", `name: s
+version: 1
+owner: me
+attributes:
+  - name: a
+    type: string
+`, "3:1: unknown field owner"},
+		{"MissingVersion", `name: s
+attributes:
+  - name: a
+    type: string
+`, "1:1: missing field version"},
+		{"InvalidVersion", `name: s
+version: one
+attributes:
+  - name: a
+    type: string
+`, "2:10: version must be a positive integer"},
+		{"NoAttributes", `name: s
+version: 1
+attributes: []
+`, "3:13: attributes must be a non-empty list"},
+		{"UnknownAttrField", `name: s
+version: 1
+attributes:
+  - name: a
+    type: string
+    index: 0
+`, "6:5: unknown field index"},
+		{"MissingType", `name: s
+version: 1
+attributes:
+  - name: a
+`, "4:5: missing field type"},
+		{"UnsupportedType", `name: s
+version: 1
+attributes:
+  - name: a
+    type: float
+`, "5:11: unsupported attribute type float"},
+		{"DuplicateName", `name: s
+version: 1
+attributes:
+  - name: a
+    type: string
+  - name: a
+    type: int64
+`, "6:11: attribute a is already defined at line 4"},
+		{"DuplicateField", `name: s
+version: 1
+attributes:
+  - name: a
+    type: string
+    type: int64
+`, "6:5: duplicate field type"},
+		{"InvalidDisclosure", `name: s
+version: 1
+attributes:
+  - name: a
+    type: string
+    disclosure: secret
+`, "6:17: invalid disclosure secret"},
+		{"InvalidCond", `name: s
+version: 1
+attributes:
+  - name: a
+    type: int64
+    cond: in
+`, "6:11: condition in is not supported for int64 attributes"},
+		{"HiddenCond", `name: s
+version: 1
+attributes:
+  - name: a
+    type: int64
+    disclosure: hidden
+    cond: gte
+`, "7:11: hidden attributes cannot have a condition"},
+		{"CommittedCond", `name: s
+version: 1
+attributes:
+  - name: a
+    type: int64
+    disclosure: committed
+    cond: gte
+`, "7:11: committed attributes cannot have a condition"},
+		{"MissingValues", `name: s
+version: 1
+attributes:
+  - name: a
+    type: enum
+`, "4:5: missing field values"},
+		{"DuplicateValues", `name: s
+version: 1
+attributes:
+  - name: a
+    type: enum
+    values: [x, x]
+`, "6:13: duplicate value 'x'"},
+		{"ValuesNotEnum", `name: s
+version: 1
+attributes:
+  - name: a
+    type: string
+    values: [x]
+`, "6:13: values are only supported for enum attributes"},
+		{"EncodingNotSupported", `name: s
+version: 1
+attributes:
+  - name: a
+    type: int64
+    encoding: hash
+`, "6:15: encoding is only supported for string and bytes attributes"},
+		{"AttrNotMapping", `name: s
+version: 1
+attributes:
+  - a
+`, "4:5: expected a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := DecodeSchema([]byte(tt.schema))
+			require.Error(t, err)
+			assert.IsType(t, &SchemaError{}, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	_, err := DecodeSchema([]byte("name: [s\n"))
+	assert.Error(t, err)
+	_, err = DecodeSchema([]byte(""))
+	assert.Error(t, err)
+}
+
+func TestReadSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "schema.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testSchema), 0600))
+	s, err := ReadSchema(path)
+	require.NoError(t, err)
+	assert.Equal(t, "student-card", s.Name)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("name: s\n"), 0600))
+	_, err = ReadSchema(path)
+	assert.EqualError(t, err, path+":1:1: missing field version")
+}
+
+func TestSchemaHash(t *testing.T) {
+	s, err := DecodeSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	h1, err := SchemaHash(s.CredStructure())
+	require.NoError(t, err)
+	h2, err := SchemaHash(s.CredStructure())
+	require.NoError(t, err)
+	assert.Equal(t, h1, h2)
+
+	s.Version++
+	h3, err := SchemaHash(s.CredStructure())
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h3)
+}
+
+func TestSchemaFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "schema.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testSchema), 0600))
+
+	v := viper.New()
+	v.Set("cl_schema", path)
+	s, err := schemaFromConfig(v)
+	require.NoError(t, err)
+	assert.Equal(t, "student-card", s.Name)
+
+	v.Set("attributes", map[string]interface{}{
+		"a": map[string]interface{}{"index": 0, "type": "string"},
+	})
+	_, err = schemaFromConfig(v)
+	assert.Error(t, err)
+}
+
+func TestParseAttrs_Ordering(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"c": map[string]interface{}{"index": 0, "type": "string"},
+		"a": map[string]interface{}{"index": 2, "type": "int64"},
+		"b": map[string]interface{}{
+			"index": 1,
+			"type":  "string",
+			"known": false,
+		},
+	})
+
+	attrs, count, err := parseAttrs(v)
+	require.NoError(t, err)
+	assert.Equal(t, NewAttrCount(2, 1, 0), count)
+	for i, name := range []string{"c", "b", "a"} {
+		assert.Equal(t, name, attrs[i].Name())
+		assert.Equal(t, i, attrs[i].getIndex())
+	}
+	assert.False(t, attrs[1].isKnown())
+}
+
+func TestParseAttrs_Invalid(t *testing.T) {
+	tests := []struct {
+		desc  string
+		attrs map[string]interface{}
+	}{
+		{"IndexOutOfRange", map[string]interface{}{
+			"a": map[string]interface{}{"index": 1, "type": "string"},
+		}},
+		{"NegativeIndex", map[string]interface{}{
+			"a": map[string]interface{}{"index": -1, "type": "string"},
+		}},
+		{"DuplicateIndex", map[string]interface{}{
+			"a": map[string]interface{}{"index": 0, "type": "string"},
+			"b": map[string]interface{}{"index": 0, "type": "string"},
+		}},
+		{"InvalidKnown", map[string]interface{}{
+			"a": map[string]interface{}{
+				"index": 0,
+				"type":  "string",
+				"known": 5,
+			},
+		}},
+		{"UnknownField", map[string]interface{}{
+			"a": map[string]interface{}{
+				"index": 0,
+				"type":  "string",
+				"hiden": true,
+			},
+		}},
+		{"CommittedCond", map[string]interface{}{
+			"a": map[string]interface{}{
+				"index": 0,
+				"type":  "int64",
+				"known": false,
+				"cond":  "gte",
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			v := viper.New()
+			v.Set("attributes", tt.attrs)
+			_, _, err := parseAttrs(v)
+			assert.Error(t, err)
+		})
+	}
+}
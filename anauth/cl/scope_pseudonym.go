@@ -0,0 +1,129 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/schnorr"
+)
+
+// A scope pseudonym is a pseudonym of the holder of a credential that
+// is exclusive to a scope chosen by the verifier, for example the name
+// of a service. It is computed as
+//
+//	nym = base(scope)^m
+//
+// in the Schnorr group of the Pedersen parameters of the public key,
+// where m is the master secret of the credential, that is, its first
+// Hidden attribute (usually named link_secret), and base(scope) is
+// derived from scope and the Pedersen group by hashing. The holder
+// always presents the same pseudonym within a scope, so the verifier
+// can recognize returning holders, while pseudonyms from different
+// scopes cannot be linked. As the base does not depend on the rest of
+// the public key, pseudonyms remain the same when keys are rotated
+// (rotated keys keep the Pedersen parameters), and are the same for
+// credentials of issuers that share the Pedersen group.
+
+// scopeBase returns the base of scope pseudonyms for scope, which is an
+// element of the subgroup of order Q of the Pedersen group.
+func scopeBase(group *schnorr.Group, scope string) *big.Int {
+	cofactor := new(big.Int).Sub(group.P, big.NewInt(1))
+	cofactor.Div(cofactor, group.Q)
+
+	s := new(big.Int).SetBytes([]byte(scope))
+	one := big.NewInt(1)
+	for i := int64(0); ; i++ {
+		h := common.Hash(group.P, group.Q, group.G, s, big.NewInt(i))
+		base := group.Exp(h, cofactor)
+		if base.Cmp(one) != 0 {
+			return base
+		}
+	}
+}
+
+// ScopePseudonym returns the scope pseudonym of the holder of
+// the credential for scope.
+func (m *CredManager) ScopePseudonym(scope string) (*big.Int, error) {
+	if len(m.Attrs.Hidden) == 0 {
+		return nil, fmt.Errorf("scope pseudonyms require a hidden attribute")
+	}
+	group := m.PubKey.PedersenParams.Group
+
+	return group.Exp(scopeBase(group, scope), m.Attrs.Hidden[0]), nil
+}
+
+// ScopePseudonymProof proves in zero knowledge that Nym is the scope
+// pseudonym of the holder of the credential. The proof is bound to the
+// proof of possession of the credential, as it shares the challenge and
+// the proof data for the master secret with it.
+type ScopePseudonymProof struct {
+	Nym      *big.Int
+	NymTilde *big.Int
+}
+
+// challengeInput returns the values that are included in the challenge
+// of the proof.
+func (p *ScopePseudonymProof) challengeInput() []*big.Int {
+	return []*big.Int{p.Nym, p.NymTilde}
+}
+
+// newScopePseudonymProof builds the proof for the master secret m.
+// Random value for the master secret, mTilde, has to be the same as the
+// one used in the proof of possession of the credential. Proof data is
+// not needed, as it is the one from the proof of possession.
+func newScopePseudonymProof(pubKey *PubKey, scope string,
+	m, mTilde *big.Int) *ScopePseudonymProof {
+	group := pubKey.PedersenParams.Group
+	base := scopeBase(group, scope)
+
+	return &ScopePseudonymProof{
+		Nym:      group.Exp(base, m),
+		NymTilde: group.Exp(base, mTilde),
+	}
+}
+
+// verifyScopePseudonymProof checks that proof proves that proof.Nym is
+// the scope pseudonym for scope, with the proof data mHat for the master
+// secret from the proof of possession of the credential.
+func verifyScopePseudonymProof(pubKey *PubKey, scope string,
+	proof *ScopePseudonymProof, mHat, challenge *big.Int) (bool, error) {
+	group := pubKey.PedersenParams.Group
+	if !isGroupElement(group, proof.Nym) ||
+		!isGroupElement(group, proof.NymTilde) {
+		return false, fmt.Errorf("scope pseudonym is not a group element")
+	}
+
+	// base^m_hat = nym^c * nym_tilde
+	left := group.Exp(scopeBase(group, scope), mHat)
+	right := group.Mul(group.Exp(proof.Nym, challenge), proof.NymTilde)
+
+	return left.Cmp(right) == 0, nil
+}
+
+// isGroupElement checks that x is an element of the subgroup of order
+// Q of group.
+func isGroupElement(group *schnorr.Group, x *big.Int) bool {
+	if x == nil || x.Sign() <= 0 || x.Cmp(group.P) >= 0 {
+		return false
+	}
+
+	return group.IsElementInGroup(x)
+}
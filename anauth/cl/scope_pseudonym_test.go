@@ -0,0 +1,87 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopePseudonymProof(t *testing.T) {
+	o := newTestOrg(t)
+	pk := o.Keys.Pub
+	params := o.Params
+
+	m := common.GetRandomInt(new(big.Int).Exp(big.NewInt(2),
+		big.NewInt(int64(params.AttrBitLen)), nil))
+	mTilde := getRandomBoundedInt(int(params.AttrBitLen +
+		params.SecParam + params.HashBitLen))
+
+	proof := newScopePseudonymProof(pk, "service", m, mTilde)
+	challenge := common.Hash(proof.challengeInput()...)
+	mHat := response(mTilde, challenge, m)
+
+	ok, err := verifyScopePseudonymProof(pk, "service", proof, mHat,
+		challenge)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// pseudonym is the same in every proof for the scope, and
+	// different in other scopes
+	again := newScopePseudonymProof(pk, "service", m, mTilde)
+	assert.Equal(t, proof.Nym, again.Nym)
+	other := newScopePseudonymProof(pk, "other service", m, mTilde)
+	assert.NotEqual(t, proof.Nym, other.Nym)
+
+	ok, err = verifyScopePseudonymProof(pk, "other service", proof, mHat,
+		challenge)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// proof data for some other master secret
+	other = newScopePseudonymProof(pk, "service", big.NewInt(1), mTilde)
+	ok, err = verifyScopePseudonymProof(pk, "service", other, mHat,
+		challenge)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = verifyScopePseudonymProof(pk, "service",
+		&ScopePseudonymProof{Nym: big.NewInt(0), NymTilde: proof.NymTilde},
+		mHat, challenge)
+	assert.Error(t, err)
+}
+
+// tests that the scope pseudonym of a holder does not change when
+// the keys of the issuer are rotated.
+func TestScopePseudonym_KeyRotation(t *testing.T) {
+	o := newTestOrg(t)
+	next, err := GenerateNextKeyPair(o.Params, NewAttrCount(1, 0, 0),
+		o.Keys.Pub)
+	require.NoError(t, err)
+	require.NotEqual(t, o.Keys.Pub.N, next.Pub.N)
+
+	m := big.NewInt(123456789)
+	mTilde := big.NewInt(987654321)
+	nym := newScopePseudonymProof(o.Keys.Pub, "service", m, mTilde).Nym
+	assert.Equal(t, nym,
+		newScopePseudonymProof(next.Pub, "service", m, mTilde).Nym)
+}
@@ -19,10 +19,14 @@ package cl
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/proto"
 
 	"github.com/spf13/viper"
 
@@ -44,10 +48,22 @@ import (
 
 type Server struct {
 	ReceiverRecordManager
-	*Org
 
+	// schema of issued credentials, with its attributes and their
+	// counts kept in attrs and attrCount
+	schema    *Schema
 	attrs     []CredAttr
 	attrCount *AttrCount
+	// scope of pseudonyms that clients have to present in Prove,
+	// empty if scope pseudonyms are not required
+	scope string
+	// verifierID identifies the server as a verifier, proofs
+	// of clients are bound to it
+	verifierID string
+	// reveal holds the names of attributes that clients have to
+	// reveal in Prove, and purpose tells them why
+	reveal  []string
+	purpose string
 
 	config *viper.Viper
 
@@ -55,6 +71,20 @@ type Server struct {
 	SessStorer  anauth.SessStorer
 	RegMgr      anauth.RegManager
 	DataFetcher AttrDataFetcher
+	// UpdateAuthorizer approves changes of attributes in credential
+	// updates. If it is nil, updates cannot change attributes.
+	UpdateAuthorizer UpdateAuthorizer
+	// TrustedIssuers holds the keys of other issuers whose credentials
+	// the server accepts in proofs of several credentials, along with
+	// its own. If it is nil, only credentials of the server are
+	// accepted.
+	TrustedIssuers *Keyring
+
+	// generations of keys, mapped by key identifiers, among which
+	// the keys with identifier activeID are used for issuance
+	keyGens  map[string]*keyGen
+	activeID string
+	mu       sync.RWMutex
 }
 
 type AttrDataFetcher interface {
@@ -102,12 +132,9 @@ func (f *RedisDataFetcher) FetchAttrData() (map[string]interface{}, error) {
 
 func NewServer(recMgr ReceiverRecordManager, keys *KeyPair,
 	v *viper.Viper) (*Server, error) {
-	params := GetDefaultParamSizes()
-	if v.IsSet("cl_attrs_bitlen") {
-		if customAttrBitLen := v.GetInt32("cl_attrs_bitlen"); customAttrBitLen != 0 {
-			params.AttrBitLen = customAttrBitLen
-			fmt.Println("Using custom attributes bit length:", customAttrBitLen)
-		}
+	params, err := serverParams(keys, v)
+	if err != nil {
+		return nil, err
 	}
 
 	org, err := NewOrgFromParams(params, keys)
@@ -115,10 +142,15 @@ func NewServer(recMgr ReceiverRecordManager, keys *KeyPair,
 		return nil, errors.Wrap(err, "error creating orgnization")
 	}
 
-	attrs, attrCount, err := parseAttrs(v)
+	schema, err := schemaFromConfig(v)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot parse attributes specification")
 	}
+	attrs, attrCount := schema.Attrs, schema.Count
+	if schema.Name != "" {
+		fmt.Printf("credential schema %s, version %d\n", schema.Name,
+			schema.Version)
+	}
 
 	fmt.Printf("attribute counts:\n%s", attrCount)
 
@@ -126,59 +158,336 @@ func NewServer(recMgr ReceiverRecordManager, keys *KeyPair,
 		return nil, errors.Wrap(err,
 			"key does not match attribute specification")
 	}
+	if err := validateAttrBitLen(attrs, params.AttrBitLen); err != nil {
+		return nil, errors.Wrap(err, "invalid attributes specification")
+	}
+	if keys.Schema != nil &&
+		!proto.Equal(keys.Schema, schema.CredStructure()) {
+		return nil, fmt.Errorf("attributes specification does not match" +
+			" the schema stored with the keys")
+	}
+	if err := keys.Pub.CheckSchema(schema.CredStructure()); err != nil {
+		return nil, errors.Wrap(err, "invalid attributes specification")
+	}
 
 	fmt.Println("server accepts the following attributes:")
 	for _, a := range attrs {
 		fmt.Printf(" %s\n", a)
 	}
 
+	scope := v.GetString("cl_scope")
+	if scope != "" {
+		if attrCount.Hidden == 0 {
+			return nil, fmt.Errorf("scope pseudonyms require" +
+				" a hidden attribute")
+		}
+		fmt.Println("clients present pseudonyms for scope", scope)
+	}
+
+	reveal := v.GetStringSlice("cl_reveal")
+	for _, name := range reveal {
+		if !revealable(attrs, name) {
+			return nil, fmt.Errorf("cannot require attribute %s to be"+
+				" revealed, it is not a Known or Committed attribute", name)
+		}
+	}
+	if len(reveal) > 0 {
+		fmt.Println("clients reveal attributes", reveal)
+	}
+
 	return &Server{
 		ReceiverRecordManager: recMgr,
-		Org:                   org,
 		config:                v,
+		schema:                schema,
 		attrs:                 attrs,
 		attrCount:             attrCount,
+		scope:                 scope,
+		verifierID:            v.GetString("cl_verifier_id"),
+		reveal:                reveal,
+		purpose:               v.GetString("cl_purpose"),
+		keyGens: map[string]*keyGen{
+			org.KeyID(): {org: org},
+		},
+		activeID: org.KeyID(),
 	}, nil
 }
 
+// NewVerifierServer creates a server that only verifies credentials
+// issued by the trusted issuers of keyring, without keys of its own.
+// Clients can obtain public parameters and prove their credentials,
+// while issuance and updates of credentials are not available.
+func NewVerifierServer(keyring *Keyring, v *viper.Viper) (*Server,
+	error) {
+	issuers := keyring.Issuers()
+	if len(issuers) == 0 {
+		return nil, fmt.Errorf("verifier has no trusted issuers")
+	}
+
+	scope := v.GetString("cl_scope")
+	for _, i := range issuers {
+		if err := CheckParamsSecurity(i.Params); err != nil {
+			if !v.GetBool("cl_allow_insecure_params") {
+				return nil, errors.Wrapf(err, "refusing to accept insecure"+
+					" parameters of issuer %s (see cl_allow_insecure_params)",
+					i.Name)
+			}
+			fmt.Printf("WARNING: issuer %s uses insecure parameters: %s\n",
+				i.Name, err)
+		}
+		if scope != "" && len(i.PubKey.RsHidden) == 0 {
+			return nil, fmt.Errorf("scope pseudonyms require a hidden"+
+				" attribute, credentials of issuer %s have none", i.Name)
+		}
+		if i.PubKey.SupportsRevocation() && i.Accumulators == nil {
+			fmt.Printf("WARNING: accumulator of issuer %s is not available,"+
+				" its credentials are refused\n", i.Name)
+		}
+		fmt.Printf("accepting credentials of issuer %s with key %s\n",
+			i.Name, i.PubKey.ID())
+	}
+	if scope != "" {
+		fmt.Println("clients present pseudonyms for scope", scope)
+	}
+
+	reveal := v.GetStringSlice("cl_reveal")
+	for _, name := range reveal {
+		var ok bool
+		for _, i := range issuers {
+			ok = ok || revealable(i.Attrs, name)
+		}
+		if !ok {
+			return nil, fmt.Errorf("cannot require attribute %s to be"+
+				" revealed, no trusted issuer has it as a Known or"+
+				" Committed attribute", name)
+		}
+	}
+	if len(reveal) > 0 {
+		fmt.Println("clients reveal attributes", reveal)
+	}
+
+	return &Server{
+		config:         v,
+		scope:          scope,
+		verifierID:     v.GetString("cl_verifier_id"),
+		reveal:         reveal,
+		purpose:        v.GetString("cl_purpose"),
+		TrustedIssuers: keyring,
+		keyGens:        map[string]*keyGen{},
+	}, nil
+}
+
+// verifierOnly reports whether the server only verifies credentials
+// (see NewVerifierServer).
+func (s *Server) verifierOnly() bool {
+	return s.activeKeys() == nil
+}
+
+// revealable reports whether attribute name is among attrs and can
+// be revealed, that is, it is not a Hidden attribute.
+func revealable(attrs []CredAttr, name string) bool {
+	for _, a := range attrs {
+		if a.Name() == name {
+			return !a.isHidden()
+		}
+	}
+
+	return false
+}
+
+// serverParams returns the parameters that the server uses with keys.
+// These are the parameters stored with keys, or, for keys stored
+// without parameters, the ones of the profile cl_params from
+// configuration v. If cl_params is not configured, keys stored without
+// parameters are used with the legacy parameters they were generated
+// with (see GetLegacyParamSizes). If cl_params or cl_attrs_bitlen are
+// configured for keys stored with parameters, they have to match the
+// stored parameters.
+//
+// Parameters have to match the keys, but existing keys are not held to
+// the constraints of ValidateParams, which new keys are generated with.
+// Insecure parameters are only accepted if cl_allow_insecure_params is
+// set.
+func serverParams(keys *KeyPair, v *viper.Viper) (*pb.Params, error) {
+	attrBitLen := v.GetInt32("cl_attrs_bitlen")
+	if attrBitLen != 0 {
+		fmt.Println("Using custom attributes bit length:", attrBitLen)
+	}
+	var params *pb.Params
+	if profile := v.GetString("cl_params"); profile != "" {
+		p, err := GetParamsProfile(profile, attrBitLen)
+		if err != nil {
+			return nil, err
+		}
+		params = p
+	}
+
+	switch {
+	case keys.Params != nil:
+		if (params != nil || attrBitLen != 0) &&
+			!proto.Equal(params, keys.Params) {
+			return nil, fmt.Errorf("configured parameters do not match" +
+				" the parameters stored with the keys")
+		}
+		params = keys.Params
+	case params == nil:
+		params = GetLegacyParamSizes()
+		if attrBitLen != 0 {
+			params.AttrBitLen = attrBitLen
+		}
+	}
+
+	if err := checkParams(params); err != nil {
+		return nil, errors.Wrap(err, "invalid parameters")
+	}
+	if err := keys.Pub.CheckParams(params); err != nil {
+		return nil, errors.Wrap(err, "keys do not match the parameters")
+	}
+	if err := CheckParamsSecurity(params); err != nil {
+		if !v.GetBool("cl_allow_insecure_params") {
+			return nil, errors.Wrap(err, "refusing to use insecure"+
+				" parameters (see cl_allow_insecure_params)")
+		}
+		fmt.Println("WARNING: using insecure parameters:", err)
+	}
+
+	return params, nil
+}
+
+// EnableRevocation enables revocation of issued credentials, with
+// the accumulator of the active keys kept in store. Credentials are
+// added to the accumulator when issued, and clients have to prove that
+// their credentials are in the accumulator when proving possession.
+//
+// Revocation has to be enabled before retired keys are added.
+func (s *Server) EnableRevocation(store AccumulatorStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.keyGens) > 1 {
+		return fmt.Errorf("revocation has to be enabled before" +
+			" keys are rotated")
+	}
+	g := *s.keyGens[s.activeID]
+	r, err := NewRevoker(g.org, store)
+	if err != nil {
+		return err
+	}
+	g.revoker = r
+	s.keyGens[s.activeID] = &g
+
+	return nil
+}
+
+// Revoke revokes the credential issued to nym. Clients are not able
+// to prove possession of a revoked credential, nor to update it.
+func (s *Server) Revoke(nym *big.Int) error {
+	rec, err := s.Load(nym)
+	if err != nil {
+		return errors.Wrap(err, "cannot load receiver record")
+	}
+
+	g, err := s.recordKeys(rec)
+	if err != nil {
+		return err
+	}
+	if g.revoker == nil {
+		return fmt.Errorf("revocation is not enabled")
+	}
+
+	return g.revoker.RevokeNym(s.ReceiverRecordManager, nym)
+}
+
 func (s *Server) RegisterTo(grpcSrv *grpc.Server) {
 	pb.RegisterAnonCredsServer(grpcSrv, s)
 }
 
 func (s *Server) GetPublicParams(ctx context.Context,
 	msg *pb.Empty) (*pb.PublicParams, error) {
-	pk := s.Org.Keys.Pub
-	group := pk.PedersenParams.Group
+	params := &pb.PublicParams{
+		TrustedIssuers: s.trustedIssuers(),
+	}
+	// keys are described as they were when the request arrived, even
+	// if they are rotated meanwhile
+	keys, retired := s.publishedKeys()
+	if keys == nil {
+		return params, nil
+	}
 
 	credStructure, err := s.getCredStructure()
 	if err != nil {
 		return nil, status.Error(codes.Internal,
 			"server cannot provide public params")
 	}
+	schemaHash, err := SchemaHash(credStructure)
+	if err != nil {
+		return nil, status.Error(codes.Internal,
+			"server cannot provide public params")
+	}
 
-	return &pb.PublicParams{
-		PubKey: &pb.PubKey{
-			N:           pk.N.Bytes(),
-			S:           pk.S.Bytes(),
-			Z:           pk.Z.Bytes(),
-			RsKnown:     toByteSlices(pk.RsKnown),
-			RsCommitted: toByteSlices(pk.RsCommitted),
-			RsHidden:    toByteSlices(pk.RsHidden),
-			PedersenParams: &pb.PedersenParams{
-				SchnorrGroup: &pb.SchnorrGroup{
-					P: group.P.Bytes(),
-					G: group.G.Bytes(),
-					Q: group.Q.Bytes(),
-				},
-				H: pk.PedersenParams.H.Bytes(),
+	retiredKeys := make([]*pb.RetiredPubKey, len(retired))
+	for i, g := range retired {
+		retiredKeys[i] = &pb.RetiredPubKey{
+			PubKey:  toPbPubKey(g.org.Keys.Pub),
+			Expires: g.expires.Unix(),
+		}
+	}
+
+	params.PubKey = toPbPubKey(keys.org.Keys.Pub)
+	params.Params = keys.org.Params
+	params.CredStructure = credStructure
+	params.RetiredKeys = retiredKeys
+	params.SchemaHash = schemaHash
+
+	return params, nil
+}
+
+// trustedIssuers describes TrustedIssuers of the server to clients.
+func (s *Server) trustedIssuers() []*pb.TrustedIssuer {
+	if s.TrustedIssuers == nil {
+		return nil
+	}
+
+	issuers := s.TrustedIssuers.Issuers()
+	pbIssuers := make([]*pb.TrustedIssuer, len(issuers))
+	for i, issuer := range issuers {
+		pbIssuers[i] = &pb.TrustedIssuer{
+			Name:          issuer.Name,
+			PubKey:        toPbPubKey(issuer.PubKey),
+			Params:        issuer.Params,
+			CredStructure: issuer.credStructure(),
+		}
+	}
+
+	return pbIssuers
+}
+
+func toPbPubKey(pk *PubKey) *pb.PubKey {
+	group := pk.PedersenParams.Group
+
+	return &pb.PubKey{
+		N:           pk.N.Bytes(),
+		S:           pk.S.Bytes(),
+		Z:           pk.Z.Bytes(),
+		RsKnown:     toByteSlices(pk.RsKnown),
+		RsCommitted: toByteSlices(pk.RsCommitted),
+		RsHidden:    toByteSlices(pk.RsHidden),
+		PedersenParams: &pb.PedersenParams{
+			SchnorrGroup: &pb.SchnorrGroup{
+				P: group.P.Bytes(),
+				G: group.G.Bytes(),
+				Q: group.Q.Bytes(),
 			},
-			N1: pk.N1.Bytes(),
-			G:  pk.G.Bytes(),
-			H:  pk.H.Bytes(),
+			H: pk.PedersenParams.H.Bytes(),
 		},
-		Params:        s.Params,
-		CredStructure: credStructure,
-	}, nil
+		N1:         pk.N1.Bytes(),
+		G:          pk.G.Bytes(),
+		H:          pk.H.Bytes(),
+		AccInit:    toOptionalBytes(pk.AccInit),
+		AccG:       toOptionalBytes(pk.AccG),
+		AccH:       toOptionalBytes(pk.AccH),
+		Proof:      toPbKeyProof(pk.Proof),
+		SchemaHash: pk.SchemaHash,
+	}
 }
 
 func (s *Server) GetAcceptableCreds(ctx context.Context,
@@ -203,49 +512,102 @@ func (s *Server) GetAcceptableCreds(ctx context.Context,
 }
 
 func (s *Server) getCredStructure() (*pb.CredStructure, error) {
-	credAttrs := make([]*pb.CredAttribute, len(s.attrs))
+	return s.schema.CredStructure(), nil
+}
 
-	for i, a := range s.attrs {
+// newCredStructure describes credentials with attributes attrs,
+// counted by attrCount.
+func newCredStructure(attrs []CredAttr,
+	attrCount *AttrCount) *pb.CredStructure {
+	credAttrs := make([]*pb.CredAttribute, len(attrs))
+
+	for i, a := range attrs {
 		attr := &pb.Attribute{
-			Index: int32(i),
-			Name:  a.Name(),
-			Known: a.isKnown(),
+			Index:  int32(i),
+			Name:   a.Name(),
+			Known:  a.isKnown(),
+			Hidden: a.isHidden(),
 		}
-		switch a.(type) {
+		switch a := a.(type) {
 		case *StrAttr:
 			credAttrs[i] = &pb.CredAttribute{
 				Type: &pb.CredAttribute_StringAttr{
 					StringAttr: &pb.StringAttribute{
-						Attr: attr,
+						Attr:   attr,
+						Hashed: a.Encoding == HashEncoding,
 					},
 				},
 			}
 		case *Int64Attr:
 			credAttrs[i] = &pb.CredAttribute{
-				Type: &pb.CredAttribute_IntAttr{
-					IntAttr: &pb.IntAttribute{
+				Type: &pb.CredAttribute_SignedIntAttr{
+					SignedIntAttr: &pb.SignedIntAttribute{
+						Attr: attr,
+					},
+				},
+			}
+		case *DateAttr:
+			credAttrs[i] = &pb.CredAttribute{
+				Type: &pb.CredAttribute_DateAttr{
+					DateAttr: &pb.DateAttribute{
 						Attr: attr,
 					},
 				},
 			}
+		case *BoolAttr:
+			credAttrs[i] = &pb.CredAttribute{
+				Type: &pb.CredAttribute_BoolAttr{
+					BoolAttr: &pb.BoolAttribute{
+						Attr: attr,
+					},
+				},
+			}
+		case *EnumAttr:
+			credAttrs[i] = &pb.CredAttribute{
+				Type: &pb.CredAttribute_EnumAttr{
+					EnumAttr: &pb.EnumAttribute{
+						Attr:   attr,
+						Values: a.Values,
+					},
+				},
+			}
+		case *BytesAttr:
+			credAttrs[i] = &pb.CredAttribute{
+				Type: &pb.CredAttribute_BytesAttr{
+					BytesAttr: &pb.BytesAttribute{
+						Attr:   attr,
+						Hashed: a.Encoding == HashEncoding,
+					},
+				},
+			}
 		}
 	}
 
 	return &pb.CredStructure{
-		NKnown:     int32(s.attrCount.Known),
-		NCommitted: int32(s.attrCount.Committed),
-		NHidden:    int32(s.attrCount.Hidden),
+		NKnown:     int32(attrCount.Known),
+		NCommitted: int32(attrCount.Committed),
+		NHidden:    int32(attrCount.Hidden),
 		Attributes: credAttrs,
-	}, nil
+	}
 }
 
 func (s *Server) Issue(stream pb.AnonCreds_IssueServer) error {
+	// the credential is issued with the keys that are active when
+	// the issuance starts, even if they are rotated meanwhile
+	keys := s.activeKeys()
+	if keys == nil {
+		return status.Error(codes.Unimplemented,
+			"server does not issue credentials")
+	}
+
 	req, err := stream.Recv()
 	if err != nil {
 		return err
 	}
 
-	regKeyOk, err := s.RegMgr.CheckRegistrationKey(req.GetRegKey())
+	// the key is only reserved until the credential is issued, so that
+	// it can be used again if the issuance fails
+	regKey, regKeyOk, err := anauth.ReserveRegKey(s.RegMgr, req.GetRegKey())
 	fmt.Println("checking reg key", req.GetRegKey())
 	if err != nil {
 		//s.Logger.Debugf("registration key %s ok=%t, error=%v",
@@ -255,8 +617,17 @@ func (s *Server) Issue(stream pb.AnonCreds_IssueServer) error {
 	if !regKeyOk {
 		return status.Error(codes.NotFound, "registration key verification failed")
 	}
+	defer regKey.Release()
+
+	regAttrs, err := s.registeredAttrs(regKey.Key())
+	if err != nil {
+		return status.Error(codes.Internal, "something went wrong")
+	}
 
-	nonce := s.GetCredIssueNonce()
+	// issuer holds the state of this issuance only, the server may
+	// be issuing credentials to other clients at the same time
+	issuer := keys.org.NewCredIssuer()
+	nonce := issuer.GetNonce()
 	resp := &pb.Response{
 		Type: &pb.Response_Nonce{
 			Nonce: nonce.Bytes(),
@@ -290,14 +661,10 @@ func (s *Server) Issue(stream pb.AnonCreds_IssueServer) error {
 		uProofData,
 	)
 
-	commitmentsOfAttrsProofs := make([]*df.OpeningProof, len(reqIssue.CommitmentsOfAttrsProofs))
-	for i, proof := range reqIssue.CommitmentsOfAttrsProofs {
-		commitmentsOfAttrsProofs[i] = df.NewOpeningProof(
-			new(big.Int).SetBytes(proof.ProofRandomData),
-			new(big.Int).SetBytes(proof.Challenge),
-			new(big.Int).SetBytes(proof.ProofData[0]),
-			new(big.Int).SetBytes(proof.ProofData[1]),
-		)
+	commitmentsOfAttrsProofs, err := fromPbOpeningProofs(
+		reqIssue.CommitmentsOfAttrsProofs)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	cReq := NewCredRequest(
@@ -311,63 +678,367 @@ func (s *Server) Issue(stream pb.AnonCreds_IssueServer) error {
 		new(big.Int).SetBytes(reqIssue.Nonce),
 	)
 
+	if err := s.checkRegisteredAttrs(regAttrs, cReq.KnownAttrs); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
 	// Issue the credential
-	res, err := s.IssueCred(cReq)
+	res, err := issuer.IssueCred(cReq)
 	if err != nil {
 		return fmt.Errorf("error when issuing credential: %v", err)
 	}
 
+	// a record that the same nym obtained before is restored if
+	// the issuance fails
+	prev, err := s.Load(cReq.Nym)
+	if err != nil {
+		prev = nil
+	}
+
+	var witness *Witness
+	if keys.revoker != nil {
+		witness, err = keys.revoker.Add(res.Cred.E)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
 	// Store the newly obtained receiver record to the database
 	if err = s.Store(cReq.Nym, res.Record); err != nil {
-		return err
+		s.rollbackIssue(keys, cReq.Nym, prev, res.Cred.E)
+		return status.Error(codes.Internal, "cannot record the credential")
+	}
+
+	// the key is used up only once the credential is recorded, so
+	// that it can be used again if recording fails. If the reservation
+	// timed out meanwhile, the key may be used by someone else, and
+	// the credential is withdrawn.
+	if err := regKey.Commit(); err != nil {
+		s.rollbackIssue(keys, cReq.Nym, prev, res.Cred.E)
+		return status.Error(codes.DeadlineExceeded,
+			"registration key reservation timed out")
 	}
 
 	resp = &pb.Response{
 		Type: &pb.Response_IssuedCred{
-			IssuedCred: &pb.IssuedCred{
-				Cred: &pb.Cred{
-					A:   res.Cred.A.Bytes(),
-					E:   res.Cred.E.Bytes(),
-					V11: res.Cred.V11.Bytes(),
-				},
-				AProof: &pb.FiatShamirAlsoNeg{
-					ProofRandomData: res.AProof.ProofRandomData.Bytes(),
-					Challenge:       res.AProof.Challenge.Bytes(),
-					ProofData:       []string{res.AProof.ProofData[0].String()},
-				},
-			},
+			IssuedCred: toPbIssuedCred(res, witness),
 		},
 	}
 
 	return stream.Send(resp)
 }
 
-func (s *Server) Update(ctx context.Context, req *pb.CredUpdateRequest) (*pb.IssuedCred, error) {
-	nym := new(big.Int).SetBytes(req.Nym)
+// rollbackIssue withdraws the credential with prime e issued to nym
+// with keys, whose issuance failed. Its receiver record is replaced by
+// the record prev that nym had before, or deleted if prev is nil, and e
+// is removed from the accumulator if revocation is enabled. Errors are
+// only reported, as the issuance has failed already.
+func (s *Server) rollbackIssue(keys *keyGen, nym *big.Int,
+	prev *ReceiverRecord, e *big.Int) {
+	var err error
+	if prev != nil {
+		err = s.Store(nym, prev)
+	} else {
+		err = s.Delete(nym)
+	}
+	if err != nil {
+		fmt.Println("cannot withdraw receiver record:", err)
+	}
 
-	// Retrieve the receiver record from the database
-	rec, err := s.Load(nym)
+	if keys.revoker != nil {
+		if err := keys.revoker.Remove(e); err != nil {
+			fmt.Println("cannot remove withdrawn credential from"+
+				" accumulator:", err)
+		}
+	}
+}
+
+// registeredAttrs returns the values of attributes registered with
+// registration key key, if RegMgr is an anauth.AttrRegManager.
+// Otherwise, it returns nil.
+func (s *Server) registeredAttrs(key string) (map[string]interface{},
+	error) {
+	if m, ok := s.RegMgr.(anauth.AttrRegManager); ok {
+		return m.RegistrationKeyData(key)
+	}
+
+	return nil, nil
+}
+
+// checkRegisteredAttrs checks that knownAttrs requested by the receiver
+// match the attribute values regAttrs registered with the registration
+// key. When regAttrs is nil, the key was registered without attribute
+// values and any knownAttrs are accepted. Otherwise, values of all the
+// Known attributes have to be registered.
+func (s *Server) checkRegisteredAttrs(regAttrs map[string]interface{},
+	knownAttrs []*big.Int) error {
+	if regAttrs == nil {
+		return nil
+	}
+	if len(knownAttrs) != s.attrCount.Known {
+		return fmt.Errorf("expected %d known attributes, got %d",
+			s.attrCount.Known, len(knownAttrs))
+	}
+
+	ind := 0
+	for _, a := range s.attrs {
+		if !a.isKnown() {
+			if _, ok := regAttrs[a.Name()]; ok {
+				return fmt.Errorf("value of attribute %s cannot be"+
+					" registered, as it is not known", a.Name())
+			}
+			continue
+		}
+
+		v, ok := regAttrs[a.Name()]
+		if !ok {
+			return fmt.Errorf("value of attribute %s is not registered",
+				a.Name())
+		}
+		regVal, err := encodeAttrValue(a, v)
+		if err != nil {
+			return err
+		}
+		if regVal.Cmp(knownAttrs[ind]) != 0 {
+			return fmt.Errorf("value of attribute %s does not match"+
+				" the registered value", a.Name())
+		}
+		ind++
+	}
+
+	if len(regAttrs) != ind {
+		return fmt.Errorf("values of unknown attributes are registered")
+	}
+
+	return nil
+}
+
+func (s *Server) Update(stream pb.AnonCreds_UpdateServer) error {
+	// credentials are updated with the keys that are active when
+	// the update starts, even if they are rotated meanwhile
+	keys := s.activeKeys()
+	if keys == nil {
+		return status.Error(codes.Unimplemented,
+			"server does not update credentials")
+	}
+
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+
+	// updater holds the state of this update only
+	updater := keys.org.NewCredUpdater()
+	resp := &pb.Response{
+		Type: &pb.Response_Nonce{
+			Nonce: updater.GetNonce().Bytes(),
+		},
+	}
+	if err := stream.Send(resp); err != nil {
+		return err
+	}
+
+	req, err := stream.Recv()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Do credential update
-	res, err := s.UpdateCred(
-		nym,
-		rec,
-		new(big.Int).SetBytes(req.Nonce),
-		fromByteSlices(req.NewKnownAttrs),
+	reqUpdate := req.GetCredUpdate()
+	if reqUpdate == nil || reqUpdate.NymProof == nil {
+		return status.Error(codes.InvalidArgument,
+			"expected credential update request")
+	}
+	ur := NewCredUpdateRequest(
+		new(big.Int).SetBytes(reqUpdate.Nym),
+		fromByteSlices(reqUpdate.NewKnownAttrs),
+		schnorr.NewProof(
+			new(big.Int).SetBytes(reqUpdate.NymProof.ProofRandomData),
+			new(big.Int).SetBytes(reqUpdate.NymProof.Challenge),
+			fromByteSlices(reqUpdate.NymProof.ProofData),
+		),
+		new(big.Int).SetBytes(reqUpdate.Nonce),
 	)
+	if len(reqUpdate.NewCommitmentsOfAttrs) != 0 {
+		proofs, err := fromPbOpeningProofs(reqUpdate.CommitmentsOfAttrsProofs)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		ur.NewCommitmentsOfAttrs = fromByteSlices(
+			reqUpdate.NewCommitmentsOfAttrs)
+		ur.CommitmentsOfAttrsProofs = proofs
+	}
+	if reqUpdate.U != nil {
+		if reqUpdate.UProof == nil || reqUpdate.PrevUProof == nil {
+			return status.Error(codes.InvalidArgument,
+				"expected proofs of U")
+		}
+		ur.U = new(big.Int).SetBytes(reqUpdate.U)
+		if ur.UProof, err = fromPbRepresentationProof(
+			reqUpdate.UProof); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if ur.PrevUProof, err = fromPbRepresentationProof(
+			reqUpdate.PrevUProof); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	// Retrieve the receiver record from the database, the request is
+	// checked against the keys the credential was issued with
+	rec, err := s.Load(ur.Nym)
+	if err != nil {
+		return status.Error(codes.NotFound, "no credential was issued to nym")
+	}
+	recKeys, err := s.recordKeys(rec)
 	if err != nil {
-		return nil, fmt.Errorf("error when updating credential: %v", err)
+		return status.Error(codes.FailedPrecondition, err.Error())
 	}
 
-	// Store the updated receiver record to the database
-	if err = s.Store(nym, res.Record); err != nil {
-		return nil, err
+	if err := updater.VerifyRequest(ur, rec,
+		recKeys.org.Keys.Pub); err != nil {
+		return status.Error(codes.Unauthenticated,
+			"credential update request verification failed")
+	}
+	if rec.Revoked {
+		return status.Error(codes.PermissionDenied,
+			"credential was revoked")
+	}
+
+	// credentials issued with retired keys are re-issued with
+	// the active keys
+	reissue := recKeys.org.KeyID() != keys.org.KeyID()
+	if reissue && ur.U == nil {
+		return status.Error(codes.FailedPrecondition,
+			"credential was issued with retired keys, it has to be re-issued")
+	}
+	if !reissue && ur.U != nil {
+		return status.Error(codes.InvalidArgument,
+			"credential was issued with the active keys")
+	}
+	if recKeys.expired(time.Now()) {
+		return status.Error(codes.FailedPrecondition,
+			"keys of the credential expired")
+	}
+
+	if err := s.authorizeUpdate(ur.Nym, rec, ur.NewKnownAttrs,
+		ur.NewCommitmentsOfAttrs); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	// Do credential update
+	var res *CredResult
+	if reissue {
+		res, err = keys.org.ReissueCred(rec, ur.U, ur.Nonce, ur.NewKnownAttrs,
+			ur.NewCommitmentsOfAttrs)
+	} else {
+		res, err = keys.org.UpdateCred(ur.Nym, rec, ur.Nonce,
+			ur.NewKnownAttrs, ur.NewCommitmentsOfAttrs)
+	}
+	if err != nil {
+		return fmt.Errorf("error when updating credential: %v", err)
+	}
+
+	// the updated credential has a new prime, which replaces
+	// the previous one in the accumulator. The previous prime cannot be
+	// removed from the accumulator of retired keys without their
+	// secret key, but the credential expires together with the keys.
+	if recKeys.revoker != nil && rec.E != nil &&
+		recKeys.org.Keys.Sec != nil {
+		if err := recKeys.revoker.Remove(rec.E); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	// Store the updated receiver record to the database. With
+	// revocation, the record is replaced in the same transaction that
+	// adds the new prime, and only if the credential was not revoked
+	// since the record was loaded.
+	var witness *Witness
+	if keys.revoker != nil {
+		witness, err = keys.revoker.AddRecord(res.Cred.E,
+			s.ReceiverRecordManager, ur.Nym, rec, res.Record)
+		if err == ErrRecordChanged {
+			return status.Error(codes.Aborted,
+				"credential was revoked or updated concurrently")
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	} else if err = s.Store(ur.Nym, res.Record); err != nil {
+		return err
 	}
 
-	return &pb.IssuedCred{
+	return stream.Send(&pb.Response{
+		Type: &pb.Response_IssuedCred{
+			IssuedCred: toPbIssuedCred(res, witness),
+		},
+	})
+}
+
+// authorizeUpdate checks that the changes of Known attributes and of
+// commitments of Committed attributes of the credential with receiver
+// record rec were approved by UpdateAuthorizer. Commitments are not
+// changed if newCommitmentsOfAttrs is empty. Known attributes of
+// records stored with an older encoding are compared in the current
+// one (see ReceiverRecord.EncodedKnownAttrs).
+func (s *Server) authorizeUpdate(nym *big.Int, rec *ReceiverRecord,
+	newKnownAttrs, newCommitmentsOfAttrs []*big.Int) error {
+	if len(newKnownAttrs) != len(rec.KnownAttrs) {
+		return fmt.Errorf("expected %d known attributes, got %d",
+			len(rec.KnownAttrs), len(newKnownAttrs))
+	}
+	knownAttrs, err := rec.EncodedKnownAttrs(s.attrs)
+	if err != nil {
+		return err
+	}
+	if len(newCommitmentsOfAttrs) == 0 {
+		newCommitmentsOfAttrs = rec.CommitmentsOfAttrs
+	}
+	if len(newCommitmentsOfAttrs) != len(rec.CommitmentsOfAttrs) {
+		return fmt.Errorf("expected %d commitments of attributes, got %d",
+			len(rec.CommitmentsOfAttrs), len(newCommitmentsOfAttrs))
+	}
+
+	changes := make([]*AttrChange, 0)
+	knownInd, committedInd := 0, 0
+	for _, a := range s.attrs {
+		switch {
+		case a.isKnown():
+			if knownAttrs[knownInd].Cmp(newKnownAttrs[knownInd]) != 0 {
+				changes = append(changes, &AttrChange{
+					Name: a.Name(),
+					Old:  knownAttrs[knownInd],
+					New:  newKnownAttrs[knownInd],
+				})
+			}
+			knownInd++
+		case !a.isHidden():
+			old := rec.CommitmentsOfAttrs[committedInd]
+			if old.Cmp(newCommitmentsOfAttrs[committedInd]) != 0 {
+				changes = append(changes, &AttrChange{
+					Name:      a.Name(),
+					Old:       old,
+					New:       newCommitmentsOfAttrs[committedInd],
+					Committed: true,
+				})
+			}
+			committedInd++
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	if s.UpdateAuthorizer == nil {
+		return fmt.Errorf("attributes cannot be changed")
+	}
+
+	return s.UpdateAuthorizer.AuthorizeUpdate(nym, changes)
+}
+
+// toPbIssuedCred converts the issued credential and its witness,
+// which can be nil, to a protobuf message.
+func toPbIssuedCred(res *CredResult, witness *Witness) *pb.IssuedCred {
+	cred := &pb.IssuedCred{
 		Cred: &pb.Cred{
 			A:   res.Cred.A.Bytes(),
 			E:   res.Cred.E.Bytes(),
@@ -378,6 +1049,41 @@ func (s *Server) Update(ctx context.Context, req *pb.CredUpdateRequest) (*pb.Iss
 			Challenge:       res.AProof.Challenge.Bytes(),
 			ProofData:       []string{res.AProof.ProofData[0].String()},
 		},
+	}
+	if witness != nil {
+		cred.Witness = &pb.Witness{
+			Version: witness.Version,
+			Value:   witness.Value.Bytes(),
+		}
+	}
+
+	return cred
+}
+
+func (s *Server) GetAccumulatorUpdates(ctx context.Context,
+	req *pb.AccumulatorUpdatesRequest) (*pb.AccumulatorUpdates, error) {
+	store, err := s.accumulatorsFor(req.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := store.Updates(req.Version)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	pbUpdates := make([]*pb.AccumulatorUpdate, len(updates))
+	for i, u := range updates {
+		pbUpdates[i] = &pb.AccumulatorUpdate{
+			Version: u.Version,
+			E:       u.E.Bytes(),
+			Removed: u.Removed,
+			Value:   u.Value.Bytes(),
+		}
+	}
+
+	return &pb.AccumulatorUpdates{
+		Updates: pbUpdates,
 	}, nil
 }
 
@@ -387,10 +1093,58 @@ func (s *Server) Prove(stream pb.AnonCreds_ProveServer) error {
 		return err
 	}
 
-	nonce := s.GetProveCredNonce()
+	if ids := req.GetKeyIds(); ids != nil {
+		return s.proveCreds(stream, ids.Ids)
+	}
+
+	// the credential is verified with the keys it was issued with,
+	// clients that do not identify the keys use the active keys
+	issuer, acc, err := s.issuerFor(req.GetKeyId())
+	if err != nil {
+		return err
+	}
+
+	toValidate, err := s.DataFetcher.FetchAttrData()
+	if err != nil {
+		return err
+	}
+	toValidate, err = resolveDateRefs(issuer.Attrs, toValidate, time.Now())
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	preds, err := Predicates(issuer.Attrs, toValidate)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	for _, name := range s.reveal {
+		if !revealable(issuer.Attrs, name) {
+			return status.Errorf(codes.FailedPrecondition, "credentials"+
+				" of issuer %s have no attribute %s to reveal",
+				issuer.Name, name)
+		}
+	}
+	reveal := requiredRevealed(issuer.Attrs, s.reveal)
+
+	verifier := issuer.org.NewCredVerifier()
+	nonce := verifier.GetNonce()
+	proofParams := &pb.ProofParams{
+		Nonce:      nonce.Bytes(),
+		Predicates: toPbPredicates(preds),
+		Scope:      s.scope,
+		Verifier:   s.verifierID,
+		Request:    s.proofRequest(reveal),
+	}
+	verifier.SetScope(s.scope)
+	verifier.SetVerifierID(s.verifierID)
+	if acc != nil {
+		verifier.SetAccumulator(acc)
+		proofParams.Accumulator = toPbAccumulator(acc)
+	}
 	resp := &pb.Response{
-		Type: &pb.Response_Nonce{
-			Nonce: nonce.Bytes(),
+		Type: &pb.Response_ProofParams{
+			ProofParams: proofParams,
 		},
 	}
 
@@ -404,68 +1158,345 @@ func (s *Server) Prove(stream pb.AnonCreds_ProveServer) error {
 	}
 
 	pReq := req.GetCredProve()
+	proof, err := fromPbCredProof(pReq)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
 
-	knownAttrs := make([]*big.Int, len(pReq.KnownAttrs))
-	for i, a := range pReq.KnownAttrs {
-		knownAttrs[i] = new(big.Int).SetBytes(a)
+	if err := checkRevealed(issuer.Attrs, reveal,
+		proof.RevealedKnownAttrsIndices,
+		proof.RevealedCommitmentsOfAttrsIndices); err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
 	}
 
-	commitmentsOfAttrs := make([]*big.Int, len(pReq.CommitmentsOfAttrs))
-	for i, a := range pReq.CommitmentsOfAttrs {
-		commitmentsOfAttrs[i] = new(big.Int).SetBytes(a)
+	var scopeProof *ScopePseudonymProof
+	if p := pReq.ScopePseudonymProof; p != nil {
+		scopeProof = fromPbScopePseudonymProof(p)
 	}
 
-	pData, err := fromStringSlices(pReq.Proof.ProofData)
+	verified, err := verifier.ProveCred(
+		proof.A,
+		proof.Proof,
+		proof.RevealedKnownAttrsIndices,
+		proof.RevealedCommitmentsOfAttrsIndices,
+		proof.RevealedKnownAttrs,
+		proof.RevealedCommitmentsOfAttrs,
+		issuer.Attrs,
+		toValidate,
+		proof.PredicateProofs,
+		proof.NonRevocationProof,
+		scopeProof,
+	)
 	if err != nil {
 		return err
 	}
 
-	revealedKnownAttrsIndices := make([]int, len(pReq.RevealedKnownAttrs))
-	for i, a := range pReq.RevealedKnownAttrs {
-		revealedKnownAttrsIndices[i] = int(a)
+	if !verified {
+		//s.Logger.Debug("User authentication failed")
+		return status.Error(codes.Unauthenticated, "user authentication failed")
 	}
 
-	revealedCommitmentsOfAttrsIndices := make([]int, len(pReq.RevealedCommitmentsOfAttrs))
-	for i, a := range pReq.RevealedCommitmentsOfAttrs {
-		revealedCommitmentsOfAttrsIndices[i] = int(a)
+	sess := anauth.NewSession("", anauth.SchemeCL)
+	sess.KeyIDs = []string{issuer.PubKey.ID()}
+	sess.Attrs, err = revealedAttrValues(issuer.Attrs,
+		proof.RevealedKnownAttrsIndices, proof.RevealedKnownAttrs)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
 
+	return s.sendSessionKey(stream, sess, verifier.ScopePseudonym())
+}
+
+// proveCreds verifies the proof of possession of several credentials
+// issued with keys identified by keyIDs (see MultiCredProof). Each of
+// the keys has to be either a key of the server or a key of one of
+// TrustedIssuers.
+//
+// Revealed attributes of all the credentials are kept in the session
+// by their names, so an attribute can only be revealed by one of the
+// credentials. Attributes that the server requires are revealed by the
+// first credential that has them.
+func (s *Server) proveCreds(stream pb.AnonCreds_ProveServer,
+	keyIDs []string) error {
+	if len(keyIDs) == 0 {
+		return status.Error(codes.InvalidArgument, "no keys of credentials")
+	}
+
+	keyring := NewKeyring()
+	verifier := NewMultiCredVerifier(keyring)
+	issuers := make([]*TrustedIssuer, len(keyIDs))
+	var attrs []CredAttr
+	for i, id := range keyIDs {
+		if id == "" {
+			return status.Error(codes.InvalidArgument,
+				"keys of credentials have to be identified")
+		}
+		issuer, acc, err := s.issuerFor(id)
+		if err != nil {
+			return err
+		}
+		if acc != nil {
+			verifier.SetAccumulator(id, acc)
+		}
+		keyring.add(issuer)
+		issuers[i] = issuer
+		attrs = append(attrs, issuer.Attrs...)
+	}
+
+	// reference values are shared among credentials by attribute names
 	toValidate, err := s.DataFetcher.FetchAttrData()
 	if err != nil {
 		return err
 	}
+	toValidate, err = resolveDateRefs(attrs, toValidate, time.Now())
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
 
-	verified, err := s.ProveCred(
-		new(big.Int).SetBytes(pReq.A),
-		qr.NewRepresentationProof(
-			new(big.Int).SetBytes(pReq.Proof.ProofRandomData),
-			new(big.Int).SetBytes(pReq.Proof.Challenge),
-			pData),
-		revealedKnownAttrsIndices,
-		revealedCommitmentsOfAttrsIndices,
-		knownAttrs,
-		commitmentsOfAttrs,
-		s.attrs,
-		toValidate,
-	)
+	// attributes of the request are revealed from the credentials
+	// that have them
+	for _, name := range s.reveal {
+		if !revealable(attrs, name) {
+			return status.Errorf(codes.FailedPrecondition, "credentials"+
+				" have no attribute %s to reveal", name)
+		}
+	}
+	reveal := make([][]string, len(issuers))
+	var allReveal []string
+	for i, issuer := range issuers {
+		var pending []string
+		for _, name := range s.reveal {
+			if !contains(allReveal, name) {
+				pending = append(pending, name)
+			}
+		}
+		reveal[i] = requiredRevealed(issuer.Attrs, pending)
+		for _, name := range reveal[i] {
+			if contains(allReveal, name) {
+				return status.Errorf(codes.FailedPrecondition, "attribute"+
+					" %s has to be revealed by several credentials", name)
+			}
+		}
+		allReveal = append(allReveal, reveal[i]...)
+	}
+
+	proofParams := &pb.ProofParams{
+		Nonce:    verifier.GetNonce().Bytes(),
+		Scope:    s.scope,
+		Verifier: s.verifierID,
+		Creds:    make([]*pb.CredProofParams, len(keyIDs)),
+		Request:  s.proofRequest(allReveal),
+	}
+	verifier.SetScope(s.scope)
+	verifier.SetVerifierID(s.verifierID)
+	for i, issuer := range issuers {
+		preds, err := Predicates(issuer.Attrs, toValidate)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		proofParams.Creds[i] = &pb.CredProofParams{
+			KeyId:         keyIDs[i],
+			Predicates:    toPbPredicates(preds),
+			RevealedAttrs: reveal[i],
+		}
+		if acc, ok := verifier.accs[keyIDs[i]]; ok {
+			proofParams.Creds[i].Accumulator = toPbAccumulator(acc)
+		}
+	}
+
+	if err := stream.Send(&pb.Response{
+		Type: &pb.Response_ProofParams{
+			ProofParams: proofParams,
+		},
+	}); err != nil {
+		return err
+	}
+
+	req, err := stream.Recv()
 	if err != nil {
 		return err
 	}
 
+	pReq := req.GetMultiCredProve()
+	if pReq == nil || len(pReq.Creds) != len(keyIDs) {
+		return status.Error(codes.InvalidArgument,
+			"proofs do not match the keys of credentials")
+	}
+	proof := &MultiCredProof{
+		Parts: make([]*CredProofPart, len(pReq.Creds)),
+	}
+	for i, p := range pReq.Creds {
+		if proof.Parts[i], err = fromPbCredProof(p); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if proof.Parts[i].KeyID != keyIDs[i] {
+			return status.Error(codes.InvalidArgument,
+				"proofs do not match the keys of credentials")
+		}
+		if err := checkRevealed(issuers[i].Attrs, reveal[i],
+			proof.Parts[i].RevealedKnownAttrsIndices,
+			proof.Parts[i].RevealedCommitmentsOfAttrsIndices); err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+	}
+	if p := pReq.ScopePseudonymProof; p != nil {
+		proof.ScopePseudonymProof = fromPbScopePseudonymProof(p)
+	}
+
+	verified, err := verifier.Verify(proof, toValidate)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
 	if !verified {
-		//s.Logger.Debug("User authentication failed")
 		return status.Error(codes.Unauthenticated, "user authentication failed")
 	}
 
+	// revealed attributes of all the credentials are kept together,
+	// by their names
+	sess := anauth.NewSession("", anauth.SchemeCL)
+	sess.KeyIDs = keyIDs
+	for i, p := range proof.Parts {
+		vals, err := revealedAttrValues(issuers[i].Attrs,
+			p.RevealedKnownAttrsIndices, p.RevealedKnownAttrs)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		for name, v := range vals {
+			if sess.Attrs == nil {
+				sess.Attrs = map[string]interface{}{}
+			}
+			if _, ok := sess.Attrs[name]; ok {
+				return status.Errorf(codes.InvalidArgument, "attribute %s"+
+					" is revealed by several credentials", name)
+			}
+			sess.Attrs[name] = v
+		}
+	}
+
+	return s.sendSessionKey(stream, sess, verifier.ScopePseudonym())
+}
+
+// issuerFor returns the issuer of credentials issued with keys
+// identified by keyID, either the server itself or one of
+// TrustedIssuers, and the accumulator that the credentials have to be
+// proved to be in, which is nil unless the server revokes them, or the
+// keys of the trusted issuer support revocation. Empty keyID identifies
+// the active keys of the server.
+func (s *Server) issuerFor(keyID string) (*TrustedIssuer, *Accumulator,
+	error) {
+	keys, err := s.keysFor(keyID)
+	if err != nil {
+		if keyID != "" && s.TrustedIssuers != nil {
+			if issuer, ok := s.TrustedIssuers.Get(keyID); ok {
+				acc, err := issuer.Accumulator()
+				if err != nil {
+					return nil, nil, status.Error(codes.FailedPrecondition,
+						err.Error())
+				}
+				return issuer, acc, nil
+			}
+		}
+		return nil, nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	issuer := &TrustedIssuer{
+		Name:   s.verifierID,
+		Params: keys.org.Params,
+		PubKey: keys.org.Keys.Pub,
+		Attrs:  s.attrs,
+		org:    keys.org,
+	}
+	if keys.revoker == nil {
+		return issuer, nil, nil
+	}
+	acc, err := keys.revoker.Accumulator()
+	if err != nil {
+		return nil, nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return issuer, acc, nil
+}
+
+// accumulatorsFor returns the updates of the accumulator of keys
+// identified by keyID, either the keys of the server or the keys of one
+// of TrustedIssuers, whose updates the server mirrors.
+func (s *Server) accumulatorsFor(keyID string) (AccumulatorStore, error) {
+	keys, err := s.keysFor(keyID)
+	if err != nil {
+		if keyID != "" && s.TrustedIssuers != nil {
+			if issuer, ok := s.TrustedIssuers.Get(keyID); ok {
+				if issuer.Accumulators == nil {
+					return nil, status.Error(codes.Unimplemented,
+						"revocation is not supported")
+				}
+				return issuer.Accumulators, nil
+			}
+		}
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if keys.revoker == nil {
+		return nil, status.Error(codes.Unimplemented,
+			"revocation is not supported")
+	}
+
+	return keys.revoker.store, nil
+}
+
+// proofRequest returns the proof request that the server sends to
+// clients in Prove, requiring attributes reveal to be revealed.
+func (s *Server) proofRequest(reveal []string) *pb.ProofRequest {
+	return &pb.ProofRequest{
+		RevealedAttrs:   reveal,
+		AcceptedIssuers: s.acceptedIssuers(),
+		Purpose:         s.purpose,
+	}
+}
+
+// acceptedIssuers returns the issuers whose credentials the server
+// accepts, that is, the server itself with its keys that did not
+// expire, and TrustedIssuers.
+func (s *Server) acceptedIssuers() []*pb.AcceptedIssuer {
+	var accepted []*pb.AcceptedIssuer
+	if keys, retired := s.publishedKeys(); keys != nil {
+		for _, g := range append([]*keyGen{keys}, retired...) {
+			accepted = append(accepted, &pb.AcceptedIssuer{
+				Name:  s.verifierID,
+				KeyId: g.org.KeyID(),
+			})
+		}
+	}
+	if s.TrustedIssuers != nil {
+		for _, i := range s.TrustedIssuers.Issuers() {
+			accepted = append(accepted, &pb.AcceptedIssuer{
+				Name:  i.Name,
+				KeyId: i.PubKey.ID(),
+			})
+		}
+	}
+
+	return accepted
+}
+
+// sendSessionKey stores session sess of a client that proved its
+// credentials with a new session key, and sends the key to the client.
+// If scope pseudonyms are required, scopeNym is stored with the session
+// as a stable identifier of the client within the scope.
+func (s *Server) sendSessionKey(stream pb.AnonCreds_ProveServer,
+	sess *anauth.Session, scopeNym *big.Int) error {
 	sessKey, err := s.SessMgr.GenerateSessionKey()
 	if err != nil {
 		//s.Logger.Debug(err)
 		return status.Error(codes.Internal, "failed to obtain session key")
 	}
+	sess.Key = *sessKey
+	if s.scope != "" {
+		sess.Scope = s.scope
+		sess.Nym = hex.EncodeToString(scopeNym.Bytes())
+	}
 
-	// Store the session key along with Known attributes to the db
-	// For integration with application logic
-	if err = s.SessStorer.Store(*sessKey); err != nil {
+	// Store the session key along with revealed Known attributes
+	// to the db, for integration with application logic
+	if err = s.SessStorer.Store(sess); err != nil {
 		fmt.Println(err)
 		return status.Error(codes.Internal,
 			"the server could not finish the proof")
@@ -479,6 +1510,60 @@ func (s *Server) Prove(stream pb.AnonCreds_ProveServer) error {
 		})
 }
 
+// revealedAttrValues returns the values of revealed Known attributes
+// from attrs, mapped by attribute names. Attributes are identified by
+// their indices among Known attributes. Values of attributes with
+// HashEncoding cannot be recovered, their hashes are returned as hex
+// strings instead.
+func revealedAttrValues(attrs []CredAttr, indices []int,
+	vals []*big.Int) (map[string]interface{}, error) {
+	if len(indices) == 0 {
+		return nil, nil
+	}
+	// otherwise a repeated index would overwrite the verified value
+	if err := checkRevealedIndices(indices); err != nil {
+		return nil, err
+	}
+
+	var known []CredAttr
+	for _, a := range attrs {
+		if a.isKnown() {
+			known = append(known, a)
+		}
+	}
+
+	res := make(map[string]interface{}, len(indices))
+	for i, ind := range indices {
+		if ind < 0 || ind >= len(known) || i >= len(vals) {
+			return nil, fmt.Errorf("invalid index of revealed attribute: %d",
+				ind)
+		}
+		// work on a copy, attrs are shared with concurrent proofs
+		a := known[ind].clone()
+		if err := a.updateInternalValue(vals[i]); err != nil {
+			return nil, err
+		}
+		res[a.Name()] = a.getValue()
+		if hashEncoded(a) {
+			res[a.Name()] = hex.EncodeToString(vals[i].Bytes())
+		}
+	}
+
+	return res, nil
+}
+
+// hashEncoded reports whether attribute a is stored with HashEncoding.
+func hashEncoded(a CredAttr) bool {
+	switch t := a.(type) {
+	case *StrAttr:
+		return t.Encoding == HashEncoding
+	case *BytesAttr:
+		return t.Encoding == HashEncoding
+	}
+
+	return false
+}
+
 // validateConfig checks that there are no discrepancies in configuration
 // of public key pertaining the organization tied to this server, and the
 // expected counts of attributes.
@@ -499,6 +1584,242 @@ func validateConfig(n *AttrCount, pk *PubKey) error {
 	return nil
 }
 
+// validateAttrBitLen checks that all values of attributes attrs fit
+// into attributes of bitLen bits. Values of string and bytes attributes
+// with RawEncoding are not known in advance and are checked when
+// credentials are requested.
+func validateAttrBitLen(attrs []CredAttr, bitLen int32) error {
+	for _, a := range attrs {
+		n := 0
+		switch a := a.(type) {
+		case *Int64Attr, *DateAttr:
+			n = int64Offset.BitLen()
+		case *StrAttr:
+			if a.Encoding == HashEncoding {
+				n = attrHashBitLen
+			}
+		case *BytesAttr:
+			if a.Encoding == HashEncoding {
+				n = attrHashBitLen
+			}
+		case *EnumAttr:
+			for _, v := range a.Values {
+				if l := encodeStr(v).BitLen(); l > n {
+					n = l
+				}
+			}
+		}
+		if n > int(bitLen) {
+			return fmt.Errorf("values of attribute %s need %d bits,"+
+				" attributes have %d bits", a.Name(), n, bitLen)
+		}
+	}
+
+	return nil
+}
+
+// fromPbCredProof converts the proof of possession of a credential
+// from its protobuf representation.
+func fromPbCredProof(p *pb.CredProof) (*CredProofPart, error) {
+	if p == nil || p.Proof == nil {
+		return nil, fmt.Errorf("missing proof of the credential")
+	}
+	pData, err := fromStringSlices(p.Proof.ProofData)
+	if err != nil {
+		return nil, err
+	}
+
+	revealedKnownAttrsIndices := make([]int, len(p.RevealedKnownAttrs))
+	for i, a := range p.RevealedKnownAttrs {
+		revealedKnownAttrsIndices[i] = int(a)
+	}
+	revealedCommitmentsOfAttrsIndices := make([]int,
+		len(p.RevealedCommitmentsOfAttrs))
+	for i, a := range p.RevealedCommitmentsOfAttrs {
+		revealedCommitmentsOfAttrsIndices[i] = int(a)
+	}
+	if err := checkRevealedIndices(revealedKnownAttrsIndices); err != nil {
+		return nil, err
+	}
+	if err := checkRevealedIndices(revealedCommitmentsOfAttrsIndices); err != nil {
+		return nil, err
+	}
+
+	predicateProofs := make([]*PredicateProof, len(p.PredicateProofs))
+	for i, pp := range p.PredicateProofs {
+		if predicateProofs[i], err = fromPbPredicateProof(pp); err != nil {
+			return nil, err
+		}
+	}
+
+	var nonRevProof *NonRevocationProof
+	if pp := p.NonRevocationProof; pp != nil {
+		if nonRevProof, err = fromPbNonRevocationProof(pp); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CredProofPart{
+		KeyID: p.KeyId,
+		A:     new(big.Int).SetBytes(p.A),
+		Proof: qr.NewRepresentationProof(
+			new(big.Int).SetBytes(p.Proof.ProofRandomData),
+			new(big.Int).SetBytes(p.Proof.Challenge),
+			pData),
+		RevealedKnownAttrsIndices:         revealedKnownAttrsIndices,
+		RevealedKnownAttrs:                fromByteSlices(p.KnownAttrs),
+		RevealedCommitmentsOfAttrsIndices: revealedCommitmentsOfAttrsIndices,
+		RevealedCommitmentsOfAttrs:        fromByteSlices(p.CommitmentsOfAttrs),
+		PredicateProofs:                   predicateProofs,
+		NonRevocationProof:                nonRevProof,
+	}, nil
+}
+
+func toPbPredicates(preds []*Predicate) []*pb.Predicate {
+	pbPreds := make([]*pb.Predicate, len(preds))
+	for i, p := range preds {
+		pbPreds[i] = &pb.Predicate{
+			Attr:  p.Attr,
+			Cond:  p.Cond.String(),
+			Value: p.Value,
+			Set:   p.Set,
+		}
+	}
+
+	return pbPreds
+}
+
+func toPbAccumulator(acc *Accumulator) *pb.Accumulator {
+	return &pb.Accumulator{
+		Version: acc.Version,
+		Value:   acc.Value.Bytes(),
+	}
+}
+
+func fromPbPredicateProof(p *pb.PredicateProof) (*PredicateProof, error) {
+	proof := &PredicateProof{
+		KnownAttrIndex: int(p.KnownAttrIndex),
+	}
+
+	switch t := p.Type.(type) {
+	case *pb.PredicateProof_Range:
+		rp := t.Range
+		uHat, err := fromStringSlices(rp.UHat)
+		if err != nil {
+			return nil, err
+		}
+		rHat, err := fromStringSlices(rp.RHat)
+		if err != nil {
+			return nil, err
+		}
+		hats, err := fromStringSlices([]string{rp.RDeltaHat, rp.AlphaHat})
+		if err != nil {
+			return nil, err
+		}
+		proof.Range = &RangeProof{
+			T:           fromByteSlices(rp.T),
+			TDelta:      new(big.Int).SetBytes(rp.TDelta),
+			TTilde:      fromByteSlices(rp.TTilde),
+			TDeltaTilde: new(big.Int).SetBytes(rp.TDeltaTilde),
+			QTilde:      new(big.Int).SetBytes(rp.QTilde),
+			UHat:        uHat,
+			RHat:        rHat,
+			RDeltaHat:   hats[0],
+			AlphaHat:    hats[1],
+		}
+	case *pb.PredicateProof_Set:
+		sp := t.Set
+		rHat, err := fromStringSlices([]string{sp.RHat})
+		if err != nil {
+			return nil, err
+		}
+		rhoHat, err := fromStringSlices(sp.RhoHat)
+		if err != nil {
+			return nil, err
+		}
+		proof.Set = &SetMembershipProof{
+			C:          new(big.Int).SetBytes(sp.C),
+			CTilde:     new(big.Int).SetBytes(sp.CTilde),
+			TTilde:     fromByteSlices(sp.TTilde),
+			Challenges: fromByteSlices(sp.Challenges),
+			RHat:       rHat[0],
+			RhoHat:     rhoHat,
+		}
+	default:
+		return nil, fmt.Errorf("missing predicate proof")
+	}
+
+	return proof, nil
+}
+
+func fromPbNonRevocationProof(p *pb.NonRevocationProof) (*NonRevocationProof,
+	error) {
+	hats, err := fromStringSlices([]string{p.R2Hat, p.R3Hat, p.Delta1Hat,
+		p.Delta2Hat})
+	if err != nil {
+		return nil, err
+	}
+
+	return &NonRevocationProof{
+		CU:        new(big.Int).SetBytes(p.CU),
+		CR:        new(big.Int).SetBytes(p.CR),
+		CRTilde:   new(big.Int).SetBytes(p.CRTilde),
+		OneTilde:  new(big.Int).SetBytes(p.OneTilde),
+		AccTilde:  new(big.Int).SetBytes(p.AccTilde),
+		R2Hat:     hats[0],
+		R3Hat:     hats[1],
+		Delta1Hat: hats[2],
+		Delta2Hat: hats[3],
+	}, nil
+}
+
+func fromPbScopePseudonymProof(p *pb.ScopePseudonymProof) *ScopePseudonymProof {
+	return &ScopePseudonymProof{
+		Nym:      new(big.Int).SetBytes(p.Nym),
+		NymTilde: new(big.Int).SetBytes(p.NymTilde),
+	}
+}
+
+// toOptionalBytes returns nil for nil x, and bytes of x otherwise.
+func toOptionalBytes(x *big.Int) []byte {
+	if x == nil {
+		return nil
+	}
+	return x.Bytes()
+}
+
+// fromOptionalBytes returns nil for empty b, and the number encoded
+// in b otherwise.
+func fromOptionalBytes(b []byte) *big.Int {
+	if len(b) == 0 {
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// toPbKeyProof returns nil for keys without a proof of correctness.
+func toPbKeyProof(p *KeyProof) *pb.KeyProof {
+	if p == nil {
+		return nil
+	}
+	return &pb.KeyProof{
+		Challenge:           p.Challenge.Bytes(),
+		Responses:           toByteSlices(p.Responses),
+		CommitmentResponses: toByteSlices(p.CommitmentResponses),
+	}
+}
+
+func fromPbKeyProof(p *pb.KeyProof) *KeyProof {
+	if p == nil {
+		return nil
+	}
+	return &KeyProof{
+		Challenge:           new(big.Int).SetBytes(p.Challenge),
+		Responses:           fromByteSlices(p.Responses),
+		CommitmentResponses: fromByteSlices(p.CommitmentResponses),
+	}
+}
+
 func fromByteSlices(s [][]byte) []*big.Int {
 	res := make([]*big.Int, len(s))
 	for i, si := range s {
@@ -508,6 +1829,42 @@ func fromByteSlices(s [][]byte) []*big.Int {
 	return res
 }
 
+// fromPbOpeningProofs converts protobuf messages to proofs of openings
+// of commitments.
+func fromPbOpeningProofs(proofs []*pb.FiatShamir) ([]*df.OpeningProof,
+	error) {
+	res := make([]*df.OpeningProof, len(proofs))
+	for i, proof := range proofs {
+		if proof == nil || len(proof.ProofData) != 2 {
+			return nil, fmt.Errorf("malformed proof of commitment opening")
+		}
+		res[i] = df.NewOpeningProof(
+			new(big.Int).SetBytes(proof.ProofRandomData),
+			new(big.Int).SetBytes(proof.Challenge),
+			new(big.Int).SetBytes(proof.ProofData[0]),
+			new(big.Int).SetBytes(proof.ProofData[1]),
+		)
+	}
+
+	return res, nil
+}
+
+// fromPbRepresentationProof converts a protobuf message to a proof of
+// representation.
+func fromPbRepresentationProof(p *pb.FiatShamirAlsoNeg) (
+	*qr.RepresentationProof, error) {
+	proofData, err := fromStringSlices(p.ProofData)
+	if err != nil {
+		return nil, err
+	}
+
+	return qr.NewRepresentationProof(
+		new(big.Int).SetBytes(p.ProofRandomData),
+		new(big.Int).SetBytes(p.Challenge),
+		proofData,
+	), nil
+}
+
 func fromStringSlices(s []string) ([]*big.Int, error) {
 	res := make([]*big.Int, len(s))
 	for i, si := range s {
@@ -0,0 +1,278 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// keyGen is a generation of keys of the server. Credentials are
+// issued with the active keys only, while credentials issued with
+// retired keys are accepted until the keys expire.
+type keyGen struct {
+	org *Org
+	// revoker is nil unless revocation is enabled
+	revoker *Revoker
+	// expires is zero for the active keys
+	expires time.Time
+}
+
+func (g *keyGen) expired(now time.Time) bool {
+	return !g.expires.IsZero() && now.After(g.expires)
+}
+
+// activeKeys returns the generation of the active keys of the server,
+// or nil if the server only verifies credentials. Handlers use the
+// returned generation for the whole stream, RotateKeys does not modify
+// it.
+func (s *Server) activeKeys() *keyGen {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keyGens[s.activeID]
+}
+
+// keysFor returns the generation of keys with identifier id, or the
+// generation of the active keys if id is empty. Keys that expired are
+// not returned.
+func (s *Server) keysFor(id string) (*keyGen, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id == "" {
+		id = s.activeID
+	}
+	g, ok := s.keyGens[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", id)
+	}
+	if g.expired(time.Now()) {
+		return nil, fmt.Errorf("key %s expired", id)
+	}
+
+	return g, nil
+}
+
+// recordKeys returns the generation of keys that the credential with
+// receiver record rec was issued with, even if the keys expired.
+// Records stored before keys could be rotated hold no key identifier,
+// their credentials were issued with the oldest keys of the server.
+func (s *Server) recordKeys(rec *ReceiverRecord) (*keyGen, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id := rec.KeyID
+	if id == "" {
+		id = oldestKeys(s.keyGens, s.activeID)
+	}
+	g, ok := s.keyGens[id]
+	if !ok {
+		return nil, fmt.Errorf("credential was issued with unknown key %s",
+			id)
+	}
+
+	return g, nil
+}
+
+// oldestKeys returns the identifier of the retired keys that expire
+// first, or activeID if there are no retired keys.
+func oldestKeys(gens map[string]*keyGen, activeID string) string {
+	oldest := activeID
+	for id, g := range gens {
+		if id == activeID {
+			continue
+		}
+		if oldest == activeID || g.expires.Before(gens[oldest].expires) {
+			oldest = id
+		}
+	}
+
+	return oldest
+}
+
+// publishedKeys returns the generation of the active keys, which is
+// nil if the server only verifies credentials, together with the
+// generations of retired keys that did not expire yet, ordered by
+// their expiry. Both are taken at once, so that a rotation of keys
+// cannot publish the same keys as both active and retired.
+func (s *Server) publishedKeys() (*keyGen, []*keyGen) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	var retired []*keyGen
+	for id, g := range s.keyGens {
+		if id != s.activeID && !g.expired(now) {
+			retired = append(retired, g)
+		}
+	}
+	sort.Slice(retired, func(i, j int) bool {
+		return retired[i].expires.Before(retired[j].expires)
+	})
+
+	return s.keyGens[s.activeID], retired
+}
+
+// RotateKeys makes keys the active keys of the server. The keys that
+// were active until now are retired, credentials issued with them are
+// accepted for gracePeriod, and can be re-issued with the new keys
+// in an update.
+//
+// If revocation is enabled, the accumulator of the new keys is kept
+// in store, otherwise store has to be nil.
+func (s *Server) RotateKeys(keys *KeyPair, gracePeriod time.Duration,
+	store AccumulatorStore) error {
+	if keys.Sec == nil {
+		return fmt.Errorf("active keys require the secret key")
+	}
+	g, err := s.newKeyGen(keys, store)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := g.org.KeyID()
+	if _, ok := s.keyGens[id]; ok {
+		return fmt.Errorf("key %s is already used", id)
+	}
+	// generations are not modified once added, handlers may be using
+	// the retired one
+	retired := *s.keyGens[s.activeID]
+	retired.expires = time.Now().Add(gracePeriod)
+	s.keyGens[s.activeID] = &retired
+	s.keyGens[id] = g
+	s.activeID = id
+
+	return nil
+}
+
+// AddRetiredKeys adds keys that were replaced by the active keys of the
+// server, and are accepted until expires. Keys without the secret key
+// can be added, but credentials issued with them cannot be revoked.
+//
+// If revocation is enabled, the accumulator of keys is kept in store,
+// otherwise store has to be nil.
+func (s *Server) AddRetiredKeys(keys *KeyPair, expires time.Time,
+	store AccumulatorStore) error {
+	g, err := s.newKeyGen(keys, store)
+	if err != nil {
+		return err
+	}
+	g.expires = expires
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := g.org.KeyID()
+	if _, ok := s.keyGens[id]; ok {
+		return fmt.Errorf("key %s is already used", id)
+	}
+	s.keyGens[id] = g
+
+	return nil
+}
+
+// newKeyGen creates a generation of keys, which have to match the
+// parameters, attributes and Pedersen parameters of the active keys,
+// as nyms of clients have to remain valid across generations.
+func (s *Server) newKeyGen(keys *KeyPair,
+	store AccumulatorStore) (*keyGen, error) {
+	active := s.activeKeys()
+	if active == nil {
+		return nil, fmt.Errorf("server has no keys of its own")
+	}
+	params := active.org.Params
+
+	if keys.Params != nil && !proto.Equal(keys.Params, params) {
+		return nil, fmt.Errorf("parameters stored with the keys do not" +
+			" match the parameters of the server")
+	}
+	if err := keys.Pub.CheckParams(params); err != nil {
+		return nil, errors.Wrap(err, "keys do not match the parameters")
+	}
+	if err := validateConfig(s.attrCount, keys.Pub); err != nil {
+		return nil, errors.Wrap(err,
+			"key does not match attribute specification")
+	}
+	if keys.Schema != nil &&
+		!proto.Equal(keys.Schema, s.schema.CredStructure()) {
+		return nil, fmt.Errorf("attributes specification does not match" +
+			" the schema stored with the keys")
+	}
+	if err := keys.Pub.CheckSchema(s.schema.CredStructure()); err != nil {
+		return nil, errors.Wrap(err, "invalid attributes specification")
+	}
+	if !equalPedersenParams(keys.Pub, active.org.Keys.Pub) {
+		return nil, fmt.Errorf("keys have to share Pedersen parameters" +
+			" with the active keys")
+	}
+
+	org, err := NewOrgFromParams(params, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating organization")
+	}
+	g := &keyGen{
+		org: org,
+	}
+
+	if active.revoker == nil {
+		if store != nil {
+			return nil, fmt.Errorf("revocation is not enabled")
+		}
+		return g, nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("revocation is enabled, accumulator" +
+			" store is required")
+	}
+	if g.revoker, err = NewRevoker(org, store); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// equalPedersenParams checks whether public keys a and b share
+// Pedersen parameters, which nyms are computed with.
+func equalPedersenParams(a, b *PubKey) bool {
+	p, q := a.PedersenParams, b.PedersenParams
+	return equalInts(
+		[]*big.Int{p.Group.P, p.Group.G, p.Group.Q, p.H},
+		[]*big.Int{q.Group.P, q.Group.G, q.Group.Q, q.H})
+}
+
+func equalInts(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -18,11 +18,17 @@
 package cl
 
 import (
+	"context"
+	"encoding/hex"
+	"math/big"
 	"testing"
 
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+	"github.com/golang/protobuf/proto"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 )
 
 // tests that server cannot be started when attribute specification
@@ -31,6 +37,7 @@ func TestNewServer_InvalidConfig(t *testing.T) {
 	// we don't expect any attributes
 	v := viper.New()
 	v.Set("attributes", map[string]interface{}{})
+	v.Set("cl_allow_insecure_params", true)
 
 	tests := []struct {
 		desc      string
@@ -57,3 +64,336 @@ func TestNewServer_InvalidConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNewServer_Params(t *testing.T) {
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), NewAttrCount(0, 0, 0))
+	require.NoError(t, err)
+	legacyKeys := &KeyPair{Sec: keys.Sec, Pub: keys.Pub}
+	// keys generated before parameters were validated, stored with
+	// and without parameters
+	oldKeys, err := generateKeyPair(GetLegacyParamSizes(),
+		NewAttrCount(0, 0, 0), nil, nil)
+	require.NoError(t, err)
+	oldLegacyKeys := &KeyPair{Sec: oldKeys.Sec, Pub: oldKeys.Pub}
+
+	tests := []struct {
+		desc   string
+		keys   *KeyPair
+		config map[string]interface{}
+		ok     bool
+	}{
+		{"Insecure", keys, nil, false},
+		{"InsecureAllowed", keys,
+			map[string]interface{}{"cl_allow_insecure_params": true}, true},
+		{"ProfileMatches", keys, map[string]interface{}{
+			"cl_params":                TestProfile,
+			"cl_allow_insecure_params": true,
+		}, true},
+		{"ProfileMismatch", keys, map[string]interface{}{
+			"cl_params":                Profile2048,
+			"cl_allow_insecure_params": true,
+		}, false},
+		{"AttrBitLenMismatch", keys, map[string]interface{}{
+			"cl_attrs_bitlen":          128,
+			"cl_allow_insecure_params": true,
+		}, false},
+		{"LegacyKeys", legacyKeys, map[string]interface{}{
+			"cl_attrs_bitlen":          128,
+			"cl_allow_insecure_params": true,
+		}, true},
+		{"OldKeys", oldKeys,
+			map[string]interface{}{"cl_allow_insecure_params": true}, true},
+		{"OldLegacyKeys", oldLegacyKeys,
+			map[string]interface{}{"cl_allow_insecure_params": true}, true},
+		{"LegacyKeysProfileMismatch", legacyKeys,
+			map[string]interface{}{"cl_params": Profile2048}, false},
+		{"UnknownProfile", legacyKeys,
+			map[string]interface{}{"cl_params": "1024"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			v := viper.New()
+			v.Set("attributes", map[string]interface{}{})
+			for k, val := range tt.config {
+				v.Set(k, val)
+			}
+
+			_, err := NewServer(nil, tt.keys, v)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestServerParams_Legacy(t *testing.T) {
+	keys, err := generateKeyPair(GetLegacyParamSizes(),
+		NewAttrCount(0, 0, 0), nil, nil)
+	require.NoError(t, err)
+	legacyKeys := &KeyPair{Sec: keys.Sec, Pub: keys.Pub}
+
+	v := viper.New()
+	v.Set("cl_allow_insecure_params", true)
+
+	// keys stored without parameters are used with the legacy ones
+	params, err := serverParams(legacyKeys, v)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(GetLegacyParamSizes(), params))
+	assert.Equal(t, int32(597), params.EBitLen)
+	assert.Equal(t, int32(2724), params.VBitLen)
+
+	v.Set("cl_attrs_bitlen", 128)
+	params, err = serverParams(legacyKeys, v)
+	require.NoError(t, err)
+	assert.Equal(t, int32(128), params.AttrBitLen)
+	assert.Equal(t, int32(2724), params.VBitLen)
+}
+
+// tests that changes of Known attributes of a receiver record stored
+// before values of int64 and date attributes were shifted are found in
+// the current encoding.
+func TestAuthorizeUpdate_LegacyRecord(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"name": map[string]interface{}{"index": 0, "type": "string"},
+		"age":  map[string]interface{}{"index": 1, "type": "int64"},
+		"born": map[string]interface{}{"index": 2, "type": "date"},
+	})
+	v.Set("cl_allow_insecure_params", true)
+	_, attrCount, err := ParseSchema(v)
+	require.NoError(t, err)
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), attrCount)
+	require.NoError(t, err)
+	s, err := NewServer(nil, keys, v)
+	require.NoError(t, err)
+
+	// a record with the values of the attributes used directly, as
+	// stored before records had an encoding
+	name := encodeStr("Jack")
+	var rec ReceiverRecord
+	require.NoError(t, rec.UnmarshalBinary([]byte(`{"KnownAttrs":[`+
+		name.String()+`,-5,10000],"CommitmentsOfAttrs":[],"Q":1,"V11":1,`+
+		`"Context":1,"E":3}`)))
+	assert.Equal(t, LegacyRecordEncoding, rec.Encoding)
+	assert.Equal(t, []*big.Int{name, big.NewInt(-5), big.NewInt(10000)},
+		rec.KnownAttrs)
+
+	known := []*big.Int{name, encodeInt64(-5), encodeInt64(10000)}
+	encoded, err := rec.EncodedKnownAttrs(s.attrs)
+	require.NoError(t, err)
+	assert.Equal(t, known, encoded)
+
+	// unchanged values need no authorization
+	assert.NoError(t, s.authorizeUpdate(big.NewInt(1), &rec, known, nil))
+
+	var changes []*AttrChange
+	s.UpdateAuthorizer = UpdateAuthorizerFunc(
+		func(nym *big.Int, c []*AttrChange) error {
+			changes = c
+			return nil
+		})
+	updated := []*big.Int{name, encodeInt64(-4), encodeInt64(10000)}
+	require.NoError(t, s.authorizeUpdate(big.NewInt(1), &rec, updated, nil))
+	require.Len(t, changes, 1)
+	assert.Equal(t, "age", changes[0].Name)
+	assert.Equal(t, encodeInt64(-5), changes[0].Old)
+
+	// new records are stored with the current encoding
+	data, err := NewReceiverRecord(known, nil, big.NewInt(1), big.NewInt(1),
+		big.NewInt(1)).MarshalBinary()
+	require.NoError(t, err)
+	var newRec ReceiverRecord
+	require.NoError(t, newRec.UnmarshalBinary(data))
+	assert.Equal(t, RecordEncoding, newRec.Encoding)
+	encoded, err = newRec.EncodedKnownAttrs(s.attrs)
+	require.NoError(t, err)
+	assert.Equal(t, known, encoded)
+
+	newRec.Encoding = RecordEncoding + 1
+	_, err = newRec.EncodedKnownAttrs(s.attrs)
+	assert.Error(t, err)
+}
+
+// tests that server cannot be started when attribute specification
+// does not match the schema stored with the keys.
+func TestNewServer_Schema(t *testing.T) {
+	attrs := map[string]interface{}{
+		"name": map[string]interface{}{
+			"index": 0,
+			"type":  "string",
+		},
+		"age": map[string]interface{}{
+			"index": 1,
+			"type":  "int64",
+		},
+	}
+	v := viper.New()
+	v.Set("attributes", attrs)
+	v.Set("cl_allow_insecure_params", true)
+
+	schema, attrCount, err := ParseSchema(v)
+	require.NoError(t, err)
+	keys, err := GenerateKeyPair(GetDefaultParamSizes(), attrCount)
+	require.NoError(t, err)
+	keys.Schema = schema
+
+	_, err = NewServer(nil, keys, v)
+	assert.NoError(t, err)
+
+	// attributes with swapped types
+	attrs["name"].(map[string]interface{})["type"] = "int64"
+	attrs["age"].(map[string]interface{})["type"] = "string"
+	v.Set("attributes", attrs)
+	_, err = NewServer(nil, keys, v)
+	assert.Error(t, err)
+}
+
+func TestNewVerifierServer(t *testing.T) {
+	o := newTestOrg(t)
+	keyring := NewKeyring()
+	_, err := NewVerifierServer(keyring, viper.New())
+	assert.Error(t, err)
+
+	require.NoError(t, keyring.Add("org", o.Params, o.Keys.Pub,
+		[]CredAttr{NewEmptyInt64Attr("a", true)}))
+	v := viper.New()
+	_, err = NewVerifierServer(keyring, v)
+	assert.Error(t, err)
+
+	v.Set("cl_allow_insecure_params", true)
+	s, err := NewVerifierServer(keyring, v)
+	require.NoError(t, err)
+	assert.True(t, s.verifierOnly())
+	accepted := s.acceptedIssuers()
+	require.Len(t, accepted, 1)
+	assert.Equal(t, "org", accepted[0].Name)
+	assert.Equal(t, o.KeyID(), accepted[0].KeyId)
+
+	v.Set("cl_reveal", []string{"b"})
+	_, err = NewVerifierServer(keyring, v)
+	assert.Error(t, err)
+	v.Set("cl_reveal", []string{"a"})
+	s, err = NewVerifierServer(keyring, v)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, s.reveal)
+
+	// credentials of the issuer have no hidden attribute
+	v.Set("cl_scope", "test")
+	_, err = NewVerifierServer(keyring, v)
+	assert.Error(t, err)
+}
+
+func TestRevealedAttrValues(t *testing.T) {
+	a := NewEmptyInt64Attr("a", true)
+	name := NewEmptyStrAttr("name", true)
+	name.Encoding = HashEncoding
+	name.Index = 1
+	c := NewEmptyInt64Attr("c", false)
+	c.Index = 2
+	attrs := []CredAttr{a, name, c}
+
+	hash := name.encode("Jack")
+	vals, err := revealedAttrValues(attrs, []int{0, 1},
+		[]*big.Int{encodeInt64(30), hash})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a":    int64(30),
+		"name": hex.EncodeToString(hash.Bytes()),
+	}, vals)
+	// attributes are not modified
+	assert.False(t, a.hasVal())
+
+	vals, err = revealedAttrValues(attrs, nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, vals)
+
+	_, err = revealedAttrValues(attrs, []int{2}, []*big.Int{big.NewInt(1)})
+	assert.Error(t, err)
+
+	// a repeated index
+	_, err = revealedAttrValues(attrs, []int{0, 0},
+		[]*big.Int{encodeInt64(30), encodeInt64(31)})
+	assert.Error(t, err)
+}
+
+// paramsClient serves public parameters params to a Client.
+type paramsClient struct {
+	pb.AnonCredsClient
+	params *pb.PublicParams
+}
+
+func (c *paramsClient) GetPublicParams(ctx context.Context, in *pb.Empty,
+	opts ...grpc.CallOption) (*pb.PublicParams, error) {
+	return c.params, nil
+}
+
+func TestGetPublicParams_Schema(t *testing.T) {
+	v := viper.New()
+	v.Set("attributes", map[string]interface{}{
+		"name": map[string]interface{}{
+			"index": 0,
+			"type":  "string",
+		},
+		"age": map[string]interface{}{
+			"index": 1,
+			"type":  "int64",
+		},
+	})
+	v.Set("cl_allow_insecure_params", true)
+	schema, attrCount, err := ParseSchema(v)
+	require.NoError(t, err)
+
+	for _, bound := range []bool{true, false} {
+		var keys *KeyPair
+		if bound {
+			keys, err = GenerateKeyPairForSchema(GetDefaultParamSizes(),
+				schema)
+		} else {
+			keys, err = GenerateKeyPair(GetDefaultParamSizes(), attrCount)
+		}
+		require.NoError(t, err)
+		s, err := NewServer(nil, keys, v)
+		require.NoError(t, err)
+		params, err := s.GetPublicParams(context.Background(), &pb.Empty{})
+		require.NoError(t, err)
+
+		c := &Client{AnonCredsClient: &paramsClient{params: params}}
+		pubParams, err := c.GetPublicParams()
+		require.NoError(t, err)
+		assert.Equal(t, params.SchemaHash, pubParams.SchemaHash)
+
+		// credential structure with swapped attributes, presented with
+		// its hash
+		tampered := proto.Clone(params.CredStructure).(*pb.CredStructure)
+		tampered.Attributes[0], tampered.Attributes[1] =
+			tampered.Attributes[1], tampered.Attributes[0]
+		tampered.Attributes[0].GetSignedIntAttr().Attr.Index = 0
+		tampered.Attributes[1].GetStringAttr().Attr.Index = 1
+		params.CredStructure = tampered
+		params.SchemaHash, err = SchemaHash(tampered)
+		require.NoError(t, err)
+		_, err = c.GetPublicParams()
+		// only keys bound to the schema authenticate it
+		assert.Equal(t, bound, err != nil)
+	}
+
+	// bound keys do not match other attributes
+	keys, err := GenerateKeyPairForSchema(GetDefaultParamSizes(), schema)
+	require.NoError(t, err)
+	keys.Schema = nil
+	v.Set("attributes", map[string]interface{}{
+		"name": map[string]interface{}{
+			"index": 0,
+			"type":  "int64",
+		},
+		"age": map[string]interface{}{
+			"index": 1,
+			"type":  "string",
+		},
+	})
+	_, err = NewServer(nil, keys, v)
+	assert.Error(t, err)
+}
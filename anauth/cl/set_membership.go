@@ -0,0 +1,221 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/common"
+	"github.com/emmyzkp/crypto/qr"
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+)
+
+// SetMembershipProof proves in zero knowledge that an unrevealed Known
+// attribute is one of the values in a set (condition in).
+//
+// The prover commits to the attribute m as C = Z^m * S^r and proves
+// that the committed value is the attribute from the credential.
+// It then proves that C * Z^-v_i = S^r for one of the set values v_i,
+// without revealing which one. The latter is an OR composition of
+// proofs of knowledge of a discrete logarithm, where the prover
+// simulates the proofs for all the values but the right one, and the
+// challenges of the composed proofs XOR to the challenge of the proof.
+type SetMembershipProof struct {
+	C          *big.Int
+	CTilde     *big.Int
+	TTilde     []*big.Int
+	RHat       *big.Int
+	Challenges []*big.Int
+	RhoHat     []*big.Int
+}
+
+// challengeInput returns the values that are included in the challenge
+// of the proof.
+func (p *SetMembershipProof) challengeInput() []*big.Int {
+	l := []*big.Int{p.C, p.CTilde}
+	return append(l, p.TTilde...)
+}
+
+// setMembershipProver builds a SetMembershipProof.
+type setMembershipProver struct {
+	group  *qr.RSASpecial
+	pubKey *PubKey
+
+	set   []*big.Int
+	index int // index of the attribute in set
+	r     *big.Int
+	C     *big.Int
+
+	rTilde   *big.Int
+	rhoTilde *big.Int
+	// challenges and proof data of the simulated proofs
+	challenges []*big.Int
+	rhoHat     []*big.Int
+}
+
+func newSetMembershipProver(group *qr.RSASpecial, pubKey *PubKey,
+	params *pb.Params, pred *Predicate, m *big.Int) (*setMembershipProver,
+	error) {
+	set := encodeSet(pred.Set)
+	index := -1
+	for i, v := range set {
+		if v.Cmp(m) == 0 {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("attribute %s does not satisfy the"+
+			" predicate", pred.Attr)
+	}
+
+	b := new(big.Int).Exp(big.NewInt(2),
+		big.NewInt(int64(params.NLength+params.SecParam)), nil)
+	r := common.GetRandomInt(b)
+	C := group.Mul(group.Exp(pubKey.Z, m), group.Exp(pubKey.S, r))
+
+	return &setMembershipProver{
+		group:  group,
+		pubKey: pubKey,
+		set:    set,
+		index:  index,
+		r:      r,
+		C:      C,
+	}, nil
+}
+
+// getProofRandomData returns a PredicateProof with the commitment to
+// the attribute and proof random data for the commitment and for the
+// proofs for each of the set values. Proofs for the values other than
+// the attribute are simulated.
+func (p *setMembershipProver) getProofRandomData(params *pb.Params,
+	mTilde *big.Int) *PredicateProof {
+	// boundary for r_tilde and rho_tilde
+	b_r := params.NLength + 2*params.SecParam + params.HashBitLen
+
+	p.rTilde = getRandomBoundedInt(int(b_r))
+	CTilde := p.group.Mul(p.group.Exp(p.pubKey.Z, mTilde),
+		p.group.Exp(p.pubKey.S, p.rTilde))
+
+	bc := new(big.Int).Exp(big.NewInt(2),
+		big.NewInt(int64(params.HashBitLen)), nil)
+	p.challenges = make([]*big.Int, len(p.set))
+	p.rhoHat = make([]*big.Int, len(p.set))
+	TTilde := make([]*big.Int, len(p.set))
+	for i, v := range p.set {
+		if i == p.index {
+			p.rhoTilde = getRandomBoundedInt(int(b_r))
+			TTilde[i] = p.group.Exp(p.pubKey.S, p.rhoTilde)
+			continue
+		}
+		// S^rho_hat = (C * Z^-v)^c * T_tilde
+		p.challenges[i] = common.GetRandomInt(bc)
+		p.rhoHat[i] = getRandomBoundedInt(int(b_r))
+		y := commitmentToSetValue(p.group, p.pubKey, p.C, v)
+		TTilde[i] = p.group.Mul(p.group.Exp(p.pubKey.S, p.rhoHat[i]),
+			p.group.Exp(y, new(big.Int).Neg(p.challenges[i])))
+	}
+
+	return &PredicateProof{
+		Set: &SetMembershipProof{
+			C:      p.C,
+			CTilde: CTilde,
+			TTilde: TTilde,
+		},
+	}
+}
+
+func (p *setMembershipProver) setProofData(proof *PredicateProof,
+	challenge *big.Int) {
+	sp := proof.Set
+	sp.RHat = response(p.rTilde, challenge, p.r)
+
+	c := new(big.Int).Set(challenge)
+	for i, ci := range p.challenges {
+		if i != p.index {
+			c.Xor(c, ci)
+		}
+	}
+
+	sp.Challenges = make([]*big.Int, len(p.set))
+	sp.RhoHat = make([]*big.Int, len(p.set))
+	copy(sp.Challenges, p.challenges)
+	copy(sp.RhoHat, p.rhoHat)
+	sp.Challenges[p.index] = c
+	sp.RhoHat[p.index] = response(p.rhoTilde, c, p.r)
+}
+
+// verifySetMembershipProof checks that proof proves predicate pred for
+// the attribute with the proof data mHat from the proof of possession
+// of the credential.
+func verifySetMembershipProof(group *qr.RSASpecial, pubKey *PubKey,
+	pred *Predicate, proof *SetMembershipProof, mHat,
+	challenge *big.Int) (bool, error) {
+	set := encodeSet(pred.Set)
+	if len(set) == 0 {
+		return false, fmt.Errorf("empty set for %s", pred.Attr)
+	}
+	if len(proof.TTilde) != len(set) || len(proof.Challenges) != len(set) ||
+		len(proof.RhoHat) != len(set) {
+		return false, fmt.Errorf("malformed predicate proof for %s",
+			pred.Attr)
+	}
+
+	// Z^m_hat * S^r_hat = C^c * C_tilde
+	left := group.Mul(group.Exp(pubKey.Z, mHat),
+		group.Exp(pubKey.S, proof.RHat))
+	right := group.Mul(group.Exp(proof.C, challenge), proof.CTilde)
+	if left.Cmp(right) != 0 {
+		return false, nil
+	}
+
+	c := new(big.Int)
+	for i, v := range set {
+		if proof.Challenges[i].Sign() < 0 {
+			return false, nil
+		}
+		c.Xor(c, proof.Challenges[i])
+
+		// S^rho_hat = (C * Z^-v)^c * T_tilde
+		left := group.Exp(pubKey.S, proof.RhoHat[i])
+		y := commitmentToSetValue(group, pubKey, proof.C, v)
+		right := group.Mul(group.Exp(y, proof.Challenges[i]), proof.TTilde[i])
+		if left.Cmp(right) != 0 {
+			return false, nil
+		}
+	}
+
+	return c.Cmp(challenge) == 0, nil
+}
+
+// commitmentToSetValue returns C * Z^-v, which is S^r when C commits
+// to v.
+func commitmentToSetValue(group *qr.RSASpecial, pubKey *PubKey,
+	C, v *big.Int) *big.Int {
+	return group.Mul(C, group.Inv(group.Exp(pubKey.Z, v)))
+}
+
+// encodeSet returns internal values of the strings in set.
+func encodeSet(set []string) []*big.Int {
+	vals := make([]*big.Int, len(set))
+	for i, s := range set {
+		vals[i] = encodeStr(s)
+	}
+	return vals
+}
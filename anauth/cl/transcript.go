@@ -0,0 +1,249 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+)
+
+// TranscriptVersion is the version of transcripts that Fiat-Shamir
+// challenges of the protocols of this package are computed from.
+// The version is part of every transcript, so that challenges computed
+// by a prover and a verifier using different transcripts never match.
+const TranscriptVersion = 1
+
+// Labels of transcripts, which separate challenges of different
+// protocols.
+const (
+	credRequestLabel    = "emmy/cl/cred-request"
+	issuedCredLabel     = "emmy/cl/issued-cred"
+	credUpdateLabel     = "emmy/cl/cred-update"
+	credReissueLabel    = "emmy/cl/cred-reissue"
+	credProofLabel      = "emmy/cl/cred-proof"
+	multiCredProofLabel = "emmy/cl/multi-cred-proof"
+)
+
+// transcript collects the public values of a protocol that its
+// challenge is computed from. Every value is written together with its
+// length, and lists together with the number of their elements, so
+// that different transcripts never encode to the same bytes.
+type transcript struct {
+	buf bytes.Buffer
+}
+
+// newTranscript starts a transcript of the protocol with label, using
+// public key pubKey. The transcript starts with TranscriptVersion,
+// label and the context of pubKey.
+func newTranscript(label string, pubKey *PubKey) *transcript {
+	t := &transcript{}
+	t.appendUint(TranscriptVersion)
+	t.appendBytes([]byte(label))
+	t.append(pubKey.GetContext())
+
+	return t
+}
+
+func (t *transcript) appendUint(n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	t.buf.Write(b[:])
+}
+
+func (t *transcript) appendBytes(b []byte) {
+	t.appendUint(uint64(len(b)))
+	t.buf.Write(b)
+}
+
+func (t *transcript) appendString(s string) {
+	t.appendBytes([]byte(s))
+}
+
+// append writes integers xs, including their signs. A nil integer is
+// written as 0.
+func (t *transcript) append(xs ...*big.Int) {
+	for _, x := range xs {
+		if x == nil {
+			x = big.NewInt(0)
+		}
+		t.buf.WriteByte(byte(x.Sign() + 1))
+		t.appendBytes(x.Bytes())
+	}
+}
+
+// appendList writes integers xs preceded by their number.
+func (t *transcript) appendList(xs []*big.Int) {
+	t.appendUint(uint64(len(xs)))
+	t.append(xs...)
+}
+
+// appendInts writes ints preceded by their number.
+func (t *transcript) appendInts(ints []int) {
+	t.appendUint(uint64(len(ints)))
+	for _, i := range ints {
+		t.appendUint(uint64(i))
+	}
+}
+
+// challenge returns the hash of the transcript.
+func (t *transcript) challenge() *big.Int {
+	h := sha512.Sum512(t.buf.Bytes())
+	return new(big.Int).SetBytes(h[:])
+}
+
+// credRequestChallenge computes the challenge shared by the proofs in
+// a credential request, that is the proof of nym opening with random
+// data nymTilde, the proof of the representation of U with random data
+// UTilde and the proofs of openings of commitments of attributes with
+// random data commitmentsTilde.
+func credRequestChallenge(pubKey *PubKey, nym, nymTilde, U, UTilde *big.Int,
+	knownAttrs, commitmentsOfAttrs, commitmentsTilde []*big.Int,
+	nonceOrg, nonceUser *big.Int) *big.Int {
+	t := newTranscript(credRequestLabel, pubKey)
+	t.append(nym, nymTilde, U, UTilde)
+	t.appendList(knownAttrs)
+	t.appendList(commitmentsOfAttrs)
+	t.appendList(commitmentsTilde)
+	t.append(nonceOrg, nonceUser)
+
+	return t.challenge()
+}
+
+// issuedCredChallenge computes the challenge of the proof that A of
+// the issued credential is Q^(e^-1), with random data ATilde.
+func issuedCredChallenge(pubKey *PubKey, Q, A, ATilde,
+	nonceUser *big.Int) *big.Int {
+	t := newTranscript(issuedCredLabel, pubKey)
+	t.append(Q, A, ATilde, nonceUser)
+
+	return t.challenge()
+}
+
+// credUpdateChallenge computes the challenge shared by the proof of nym
+// opening with random data nymTilde and the proofs of openings of new
+// commitments of attributes with random data commitmentsTilde in an
+// update request. It binds the proofs to the nonce of the organization
+// and to the requested attributes.
+func credUpdateChallenge(pubKey *PubKey, nym, nymTilde, nonceOrg,
+	nonceUser *big.Int, newKnownAttrs, newCommitmentsOfAttrs,
+	commitmentsTilde []*big.Int) *big.Int {
+	t := newTranscript(credUpdateLabel, pubKey)
+	t.append(nym, nymTilde)
+	t.appendList(newKnownAttrs)
+	t.appendList(newCommitmentsOfAttrs)
+	t.appendList(commitmentsTilde)
+	t.append(nonceOrg, nonceUser)
+
+	return t.challenge()
+}
+
+// credReissueChallenge computes the challenge shared by the proof of
+// nym opening with random data nymTilde, the proof of the
+// representation of U with random data UTilde, the proof of the
+// representation of prevU, which the credential was issued for, with
+// random data prevUTilde, and the proofs of openings of new commitments
+// of attributes with random data commitmentsTilde in a request for
+// re-issuing a credential with new public key pubKey.
+func credReissueChallenge(pubKey *PubKey, nym, nymTilde, U, UTilde, prevU,
+	prevUTilde, nonceOrg, nonceUser *big.Int, newKnownAttrs,
+	newCommitmentsOfAttrs, commitmentsTilde []*big.Int) *big.Int {
+	t := newTranscript(credReissueLabel, pubKey)
+	t.append(nym, nymTilde, U, UTilde, prevU, prevUTilde)
+	t.appendList(newKnownAttrs)
+	t.appendList(newCommitmentsOfAttrs)
+	t.appendList(commitmentsTilde)
+	t.append(nonceOrg, nonceUser)
+
+	return t.challenge()
+}
+
+// CredProofTranscript holds the public values of a proof of possession
+// of a credential, which its challenge is computed from (see
+// CredManager.BuildProof and CredVerifier.ProveCred).
+type CredProofTranscript struct {
+	// A of the randomized credential
+	A                                 *big.Int
+	RevealedKnownAttrsIndices         []int
+	RevealedKnownAttrs                []*big.Int
+	RevealedCommitmentsOfAttrsIndices []int
+	RevealedCommitmentsOfAttrs        []*big.Int
+	ProofRandomData                   *big.Int
+	PredicateProofs                   []*PredicateProof
+	NonRevocationProof                *NonRevocationProof
+	ScopePseudonymProof               *ScopePseudonymProof
+	Scope                             string
+	// Verifier identifies the verifier that the proof is made for
+	Verifier string
+	Nonce    *big.Int
+}
+
+// Challenge computes the challenge of the proof with public key pubKey.
+func (p *CredProofTranscript) Challenge(pubKey *PubKey) *big.Int {
+	t := newTranscript(credProofLabel, pubKey)
+	p.write(t)
+
+	return t.challenge()
+}
+
+// write writes the values of the proof to transcript t.
+func (p *CredProofTranscript) write(t *transcript) {
+	t.append(p.A)
+	t.appendInts(p.RevealedKnownAttrsIndices)
+	t.appendList(p.RevealedKnownAttrs)
+	t.appendInts(p.RevealedCommitmentsOfAttrsIndices)
+	t.appendList(p.RevealedCommitmentsOfAttrs)
+	t.append(p.ProofRandomData)
+
+	t.appendUint(uint64(len(p.PredicateProofs)))
+	for _, pp := range p.PredicateProofs {
+		t.appendUint(uint64(pp.KnownAttrIndex))
+		t.appendList(pp.challengeInput())
+	}
+	if p.NonRevocationProof != nil {
+		t.appendList(p.NonRevocationProof.challengeInput())
+	} else {
+		t.appendList(nil)
+	}
+	if p.ScopePseudonymProof != nil {
+		t.appendList(p.ScopePseudonymProof.challengeInput())
+	} else {
+		t.appendList(nil)
+	}
+	t.appendString(p.Scope)
+	t.appendString(p.Verifier)
+	t.append(p.Nonce)
+}
+
+// multiCredProofChallenge computes the challenge shared by the proofs
+// of possession of several credentials with transcripts ts, where the
+// i-th credential was issued with public key pubKeys[i].
+func multiCredProofChallenge(pubKeys []*PubKey,
+	ts []*CredProofTranscript) *big.Int {
+	t := &transcript{}
+	t.appendUint(TranscriptVersion)
+	t.appendBytes([]byte(multiCredProofLabel))
+	t.appendUint(uint64(len(ts)))
+	for i, p := range ts {
+		t.append(pubKeys[i].GetContext())
+		p.write(t)
+	}
+
+	return t.challenge()
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/emmyzkp/crypto/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscript_Unambiguous(t *testing.T) {
+	pubKey := newTestOrg(t).Keys.Pub
+	challenge := func(xs ...*big.Int) *big.Int {
+		tr := newTranscript(credProofLabel, pubKey)
+		tr.append(xs...)
+		return tr.challenge()
+	}
+
+	// 0x0123 split differently
+	assert.NotEqual(t,
+		challenge(big.NewInt(0x01), big.NewInt(0x23)),
+		challenge(big.NewInt(0x0123)))
+	assert.NotEqual(t, challenge(big.NewInt(5)), challenge(big.NewInt(-5)))
+
+	// label separates protocols
+	tr := newTranscript(credUpdateLabel, pubKey)
+	tr.append(big.NewInt(5))
+	assert.NotEqual(t, challenge(big.NewInt(5)), tr.challenge())
+}
+
+// issueTestCred issues a credential for the credential manager cm.
+func issueTestCred(t *testing.T, o *Org, cm *CredManager) *Cred {
+	issuer := o.NewCredIssuer()
+	cr, err := cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+
+	res, err := issuer.IssueCred(cr)
+	require.NoError(t, err)
+	ok, err := cm.Verify(res.Cred, res.AProof)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return res.Cred
+}
+
+func TestCredIssuer_TamperedRequest(t *testing.T) {
+	o := newTestOrg(t)
+	cm := newTestCredManager(t, o, 30)
+
+	issuer := o.NewCredIssuer()
+	cr, err := cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+	cr.KnownAttrs = []*big.Int{big.NewInt(31)}
+	_, err = issuer.IssueCred(cr)
+	assert.Error(t, err)
+
+	issuer = o.NewCredIssuer()
+	cr, err = cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+	cr.Nonce = new(big.Int).Add(cr.Nonce, big.NewInt(1))
+	_, err = issuer.IssueCred(cr)
+	assert.Error(t, err)
+}
+
+func TestCredVerifier_TamperedProof(t *testing.T) {
+	o := newTestOrg(t)
+	cm := newTestCredManager(t, o, 30)
+	cred := issueTestCred(t, o, cm)
+	attrs := []CredAttr{cm.RawCred.GetAttrs()[0]}
+	revealed := []int{0}
+	// the condition of a holds for the actual and tampered values
+	actual := map[string]interface{}{"a": int64(-100)}
+
+	prove := func(v *CredVerifier, rCred *Cred, proof *qr.RepresentationProof,
+		revealedAttrs []*big.Int) (bool, error) {
+		return v.ProveCred(rCred.A, proof, revealed, []int{}, revealedAttrs,
+			[]*big.Int{}, attrs, actual, nil, nil, nil)
+	}
+	build := func(v *CredVerifier, verifierID string) (*Cred,
+		*qr.RepresentationProof) {
+		rCred, proof, _, _, _, err := cm.BuildProof(cred, revealed, []int{},
+			nil, nil, "", verifierID, v.GetNonce())
+		require.NoError(t, err)
+		return rCred, proof
+	}
+
+	v := o.NewCredVerifier()
+	v.SetVerifierID("verifier")
+	rCred, proof := build(v, "verifier")
+	ok, err := prove(v, rCred, proof, []*big.Int{encodeInt64(30)})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	tests := []struct {
+		desc  string
+		value *big.Int
+	}{
+		{"Greater", encodeInt64(31)},
+		{"Smaller", encodeInt64(29)},
+		{"Zero", big.NewInt(0)},
+		{"Negative", big.NewInt(-30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			v := o.NewCredVerifier()
+			v.SetVerifierID("verifier")
+			rCred, proof := build(v, "verifier")
+			ok, err := prove(v, rCred, proof, []*big.Int{tt.value})
+			assert.False(t, ok && err == nil)
+		})
+	}
+
+	// proof made for another verifier
+	v = o.NewCredVerifier()
+	v.SetVerifierID("verifier")
+	rCred, proof = build(v, "other-verifier")
+	ok, err = prove(v, rCred, proof, []*big.Int{encodeInt64(30)})
+	assert.False(t, ok && err == nil)
+
+	// proof with another randomization of the credential
+	v = o.NewCredVerifier()
+	v.SetVerifierID("verifier")
+	rCred, proof = build(v, "verifier")
+	other, _ := build(v, "verifier")
+	ok, err = prove(v, other, proof, []*big.Int{encodeInt64(30)})
+	assert.False(t, ok && err == nil)
+}
+
+func TestCredVerifier_RepeatedRevealedIndex(t *testing.T) {
+	o := newTestOrg(t)
+	cm := newTestCredManager(t, o, 30)
+	cred := issueTestCred(t, o, cm)
+	attrs := []CredAttr{cm.RawCred.GetAttrs()[0]}
+
+	// a is revealed twice, with values that add up to its actual value,
+	// so that the denominator R_a^m1 * R_a^m2 equals R_a^m
+	revealed := []int{0, 0}
+	m1 := encodeInt64(-100)
+	m2 := new(big.Int).Sub(encodeInt64(30), m1)
+
+	v := o.NewCredVerifier()
+	p, err := cm.newCredProver(cred, revealed, []int{}, nil, nil, "", nil)
+	require.NoError(t, err)
+	p.transcript.RevealedKnownAttrs = []*big.Int{m1, m2}
+	p.transcript.Nonce = v.GetNonce()
+	proof := p.respond(cm.GetProofChallenge(p.transcript))
+
+	ok, err := v.ProveCred(p.rCred.A, proof, revealed, []int{},
+		[]*big.Int{m1, m2}, []*big.Int{}, attrs, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	// the server rejects such a proof before verifying it
+	_, err = fromPbCredProof(toPbCredProof(&CredProofPart{
+		A:                         p.rCred.A,
+		Proof:                     proof,
+		RevealedKnownAttrsIndices: revealed,
+		RevealedKnownAttrs:        []*big.Int{m1, m2},
+	}))
+	assert.Error(t, err)
+}
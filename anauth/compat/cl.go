@@ -88,6 +88,10 @@ func NewCLRawCred(bytes []byte) (*CLRawCred, error) {
 	// register concrete types that implement cl.CredAttr interface
 	gob.Register(&cl.Int64Attr{})
 	gob.Register(&cl.StrAttr{})
+	gob.Register(&cl.DateAttr{})
+	gob.Register(&cl.BoolAttr{})
+	gob.Register(&cl.EnumAttr{})
+	gob.Register(&cl.BytesAttr{})
 
 	var cred cl.RawCred
 	if err := fromBytes(bytes, &cred); err != nil {
@@ -120,10 +124,46 @@ func (c *CLRawCred) SetLongAttribute(name string, val int64) error {
 	return nil
 }
 
+// SetDateAttribute sets the date attribute name to date val, given
+// in the form of YYYY-MM-DD.
+func (c *CLRawCred) SetDateAttribute(name string, val string) error {
+	if err := c.cred.UpdateAttr(name, val); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *CLRawCred) SetBoolAttribute(name string, val bool) error {
+	if err := c.cred.UpdateAttr(name, val); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetEnumAttribute sets the enumerated attribute name to val, which has
+// to be one of the allowed values of the attribute.
+func (c *CLRawCred) SetEnumAttribute(name string, val string) error {
+	if err := c.cred.UpdateAttr(name, val); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *CLRawCred) SetBytesAttribute(name string, val []byte) error {
+	if err := c.cred.UpdateAttr(name, val); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *CLRawCred) Bytes() ([]byte, error) {
 	// register concrete types that implement cl.CredAttr interface
 	gob.Register(&cl.Int64Attr{})
 	gob.Register(&cl.StrAttr{})
+	gob.Register(&cl.DateAttr{})
+	gob.Register(&cl.BoolAttr{})
+	gob.Register(&cl.EnumAttr{})
+	gob.Register(&cl.BytesAttr{})
 	return intoBytes(c.cred)
 }
 
@@ -190,14 +230,7 @@ func (c *CLCredManagerContext) Bytes() ([]byte, error) {
 // a CLCredManager.
 func (cm *CLCredManager) GetContext() *CLCredManagerContext {
 	return &CLCredManagerContext{
-		CredManagerCtx: &cl.CredManagerCtx{
-			Nym:                cm.Nym,
-			V1:                 cm.V1,
-			CredReqNonce:       cm.CredReqNonce,
-			PubKey:             cm.PubKey,
-			Params:             cm.Params,
-			CommitmentsOfAttrs: cm.CommitmentsOfAttrs,
-		},
+		CredManagerCtx: cm.CredManager.GetContext(),
 	}
 }
 
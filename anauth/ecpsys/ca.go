@@ -29,6 +29,9 @@ import (
 	"github.com/emmyzkp/emmy/anauth/psys"
 )
 
+// CA holds the state of a single certificate generation. It must
+// not be shared between clients, as GetChallenge stores the client's
+// master nym that Verify later certifies.
 type CA struct {
 	group      *ec.Group
 	verifier   *ecschnorr.Verifier
@@ -32,13 +32,19 @@ func (s *CAServer) RegisterTo(grpcSrv *grpc.Server) {
 	pb.RegisterCA_ECServer(grpcSrv, s)
 }
 
+// CAServer serves certificates of the CA. A new CA is
+// created for each stream, so that clients can be served concurrently.
 type CAServer struct {
-	ca *CA
+	secKey *big.Int
+	pubKey *psys.PubKey
+	curve  ec.Curve
 }
 
 func NewCAServer(secKey *big.Int, pubKey *psys.PubKey, curve ec.Curve) *CAServer {
 	return &CAServer{
-		ca: NewCA(secKey, pubKey, curve),
+		secKey: secKey,
+		pubKey: pubKey,
+		curve:  curve,
 	}
 }
 
@@ -50,7 +56,8 @@ func (s *CAServer) GenerateCertificate(stream pb.
 	}
 
 	pRandData := req.GetProofRandData()
-	ch := s.ca.GetChallenge(
+	ca := NewCA(s.secKey, s.pubKey, s.curve)
+	ch := ca.GetChallenge(
 		toECGroupElement(pRandData.A),
 		toECGroupElement(pRandData.B),
 		toECGroupElement(pRandData.X),
@@ -70,7 +77,7 @@ func (s *CAServer) GenerateCertificate(stream pb.
 	}
 
 	z := new(big.Int).SetBytes(req.GetProofData())
-	cert, err := s.ca.Verify(z)
+	cert, err := ca.Verify(z)
 
 	if err != nil {
 		//s.Logger.Debug(err)
@@ -0,0 +1,218 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ecpsys
+
+import (
+	"encoding/json"
+
+	"github.com/emmyzkp/crypto/ec"
+	"github.com/emmyzkp/emmy/anauth"
+	"github.com/emmyzkp/emmy/anauth/psys"
+	"github.com/pkg/errors"
+)
+
+// keyFileScheme identifies keys of the pseudonym system in EC
+// arithmetic in key files.
+const keyFileScheme = "ecpsys"
+
+// KeyID returns the key ID of the key pair with public key pk on
+// curve c.
+func KeyID(c ec.Curve, pk *PubKey) string {
+	return anauth.KeyID(keyFileScheme+"/"+psys.CurveName(c), pk.H1.X,
+		pk.H1.Y, pk.H2.X, pk.H2.Y)
+}
+
+type paramsJSON struct {
+	Curve string `json:"curve"`
+}
+
+type pointJSON struct {
+	X anauth.Int `json:"x"`
+	Y anauth.Int `json:"y"`
+}
+
+func newPointJSON(e *ec.GroupElement) *pointJSON {
+	return &pointJSON{X: anauth.NewInt(e.X), Y: anauth.NewInt(e.Y)}
+}
+
+// groupElement returns the point p on curve c, or nil if p is not on c.
+func (p *pointJSON) groupElement(c ec.Curve) *ec.GroupElement {
+	if p == nil || p.X.Int == nil || p.Y.Int == nil ||
+		!ec.GetCurve(c).IsOnCurve(p.X.Int, p.Y.Int) {
+		return nil
+	}
+	return ec.NewGroupElement(p.X.Int, p.Y.Int)
+}
+
+type pubKeyJSON struct {
+	H1 *pointJSON `json:"h1"`
+	H2 *pointJSON `json:"h2"`
+}
+
+type secKeyJSON struct {
+	S1 anauth.Int `json:"s1"`
+	S2 anauth.Int `json:"s2"`
+}
+
+// WriteKeys writes the public key pk and the secret key sk of an
+// organization using curve c to pubKeyPath and secKeyPath, together
+// with the name of c (see anauth.KeyFile). Unless passphrase is nil,
+// the secret key is encrypted with it. The secret key file is only
+// readable by its owner.
+func WriteKeys(pubKeyPath, secKeyPath string, c ec.Curve,
+	sk *psys.SecKey, pk *PubKey, passphrase []byte) error {
+	params := &paramsJSON{Curve: psys.CurveName(c)}
+	keyID := KeyID(c, pk)
+
+	pub, err := anauth.NewKeyFile(keyFileScheme, anauth.PublicKeyType, keyID,
+		&pubKeyJSON{H1: newPointJSON(pk.H1), H2: newPointJSON(pk.H2)})
+	if err != nil {
+		return err
+	}
+	if err := setParams(pub, params); err != nil {
+		return err
+	}
+	if err := anauth.WriteKeyFile(pubKeyPath, pub, 0644); err != nil {
+		return err
+	}
+
+	sec, err := anauth.NewKeyFile(keyFileScheme, anauth.SecretKeyType, keyID,
+		&secKeyJSON{S1: anauth.NewInt(sk.S1), S2: anauth.NewInt(sk.S2)})
+	if err != nil {
+		return err
+	}
+	if err := setParams(sec, params); err != nil {
+		return err
+	}
+
+	return anauth.WriteSecretKeyFile(secKeyPath, sec, passphrase)
+}
+
+func setParams(f *anauth.KeyFile, params *paramsJSON) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	f.Params = p
+
+	return nil
+}
+
+// readKeyFile reads a key file of type keyType from path, decoding
+// the key, decrypted with passphrase if needed, into key and returning
+// the curve stored with it. Files in the legacy format are decoded as
+// gob into legacy instead, in which case the returned key file is nil
+// and the curve is 0.
+func readKeyFile(path, keyType string, key, legacy interface{},
+	passphrase anauth.PassphraseFunc) (*anauth.KeyFile, ec.Curve, error) {
+	f, data, err := anauth.ReadKeyFile(path)
+	if err == anauth.ErrLegacyKeyFile {
+		return nil, 0, errors.Wrap(anauth.DecodeLegacyKey(data, legacy), path)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var p paramsJSON
+	err = f.Decode(keyFileScheme, keyType, key, &p, passphrase)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, path)
+	}
+	c, err := psys.CurveByName(p.Curve)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, path)
+	}
+
+	return f, c, nil
+}
+
+// ReadPubKey reads a public key written by WriteKeys from path,
+// together with its curve. It also accepts a public key stored as gob
+// by older versions, in which case the returned curve is 0.
+func ReadPubKey(path string) (ec.Curve, *PubKey, error) {
+	var j pubKeyJSON
+	legacy := new(PubKey)
+	f, c, err := readKeyFile(path, anauth.PublicKeyType, &j, legacy, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if f == nil {
+		if legacy.H1 == nil || legacy.H2 == nil {
+			return 0, nil, errors.Errorf("invalid public key in %s", path)
+		}
+		return 0, legacy, nil
+	}
+
+	h1, h2 := j.H1.groupElement(c), j.H2.groupElement(c)
+	if h1 == nil || h2 == nil {
+		return 0, nil, errors.Errorf("invalid public key in %s", path)
+	}
+	pk := NewPubKey(h1, h2)
+	if f.KeyID != KeyID(c, pk) {
+		return 0, nil, errors.Errorf("key ID in %s does not match the key",
+			path)
+	}
+
+	return c, pk, nil
+}
+
+// ReadKeys reads the public key from pubKeyPath (see ReadPubKey) and
+// the secret key from secKeyPath, and checks that they form a key pair.
+// An encrypted secret key is decrypted with the passphrase returned by
+// passphrase, which can be nil for keys that are not encrypted.
+func ReadKeys(pubKeyPath, secKeyPath string,
+	passphrase anauth.PassphraseFunc) (ec.Curve, *psys.SecKey, *PubKey,
+	error) {
+	c, pk, err := ReadPubKey(pubKeyPath)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	var j secKeyJSON
+	sk := new(psys.SecKey)
+	f, secCurve, err := readKeyFile(secKeyPath, anauth.SecretKeyType, &j, sk,
+		passphrase)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if f != nil {
+		sk = psys.NewSecKey(j.S1.Int, j.S2.Int)
+		if c == 0 {
+			c = secCurve
+		}
+		if f.KeyID != KeyID(c, pk) {
+			return 0, nil, nil, errors.New("secret key does not match" +
+				" the public key")
+		}
+	}
+
+	if sk.S1 == nil || sk.S2 == nil {
+		return 0, nil, nil, errors.Errorf("invalid secret key in %s",
+			secKeyPath)
+	}
+	if c != 0 {
+		group := ec.NewGroup(c)
+		if !group.ExpBaseG(sk.S1).Equals(pk.H1) ||
+			!group.ExpBaseG(sk.S2).Equals(pk.H2) {
+			return 0, nil, nil, errors.New("secret key does not match the" +
+				" public key")
+		}
+	}
+
+	return c, sk, pk, nil
+}
@@ -48,6 +48,8 @@ func NewCred(aToGamma, bToGamma, AToGamma, BToGamma *ec.GroupElement,
 	}
 }
 
+// CredIssuer holds the state of a single credential issuance, and must
+// not be shared between clients.
 type CredIssuer struct {
 	secKey *psys.SecKey
 
@@ -41,6 +41,8 @@ func NewNym(a, b *ec.GroupElement) *Nym {
 	}
 }
 
+// NymGenerator holds the state of a single nym generation, and must
+// not be shared between clients.
 type NymGenerator struct {
 	verifier  *ecschnorr.EqualityVerifier
 	caPubKey  *psys.PubKey
@@ -35,23 +35,28 @@ func (s *OrgServer) RegisterTo(grpcSrv *grpc.Server) {
 	pb.RegisterOrg_ECServer(grpcSrv, s)
 }
 
+// OrgServer serves the pseudonym system protocols of an organization.
+// A new NymGenerator, CredIssuer or CredVerifier is created for each
+// stream, so that clients can be served concurrently.
 type OrgServer struct {
-	pubKey *PubKey
-
-	*NymGenerator
-	*CredIssuer
-	*CredVerifier
+	curve    ec.Curve
+	secKey   *psys.SecKey
+	pubKey   *PubKey
+	caPubKey *psys.PubKey
 
 	SessMgr anauth.SessManager
 	RegMgr  anauth.RegManager
+	// SessStorer stores sessions of clients that transferred their
+	// credentials. If it is nil, sessions are not stored.
+	SessStorer anauth.SessStorer
 }
 
 func NewOrgServer(c ec.Curve, secKey *psys.SecKey, pubKey *PubKey, caPubKey *psys.PubKey) *OrgServer {
 	return &OrgServer{
-		pubKey:       pubKey,
-		NymGenerator: NewNymGenerator(caPubKey, c),
-		CredIssuer:   NewCredIssuer(secKey, c),
-		CredVerifier: NewCredVerifier(secKey, c),
+		curve:    c,
+		secKey:   secKey,
+		pubKey:   pubKey,
+		caPubKey: caPubKey,
 	}
 }
 
@@ -63,13 +68,16 @@ func (s *OrgServer) GenerateNym(stream pb.Org_EC_GenerateNymServer) error {
 
 	pRandData := req.GetProofRandData()
 
-	regKeyOk, err := s.RegMgr.CheckRegistrationKey(pRandData.RegKey)
+	regKey, regKeyOk, err := anauth.ReserveRegKey(s.RegMgr, pRandData.RegKey)
 	if !regKeyOk || err != nil {
 		//s.Logger.Debugf("Registration key %s ok=%t, error=%v", pRandData.RegKey, regKeyOk, err)
 		return status.Error(codes.NotFound, "registration key verification failed")
 
 	}
-	challenge, err := s.NymGenerator.GetChallenge(
+	defer regKey.Release()
+
+	nymGen := NewNymGenerator(s.caPubKey, s.curve)
+	challenge, err := nymGen.GetChallenge(
 		toECGroupElement(pRandData.A1), // TODO call it nym a
 		toECGroupElement(pRandData.A2), // TODO call it blinded a
 		toECGroupElement(pRandData.B1), // TODO call it nym b
@@ -102,7 +110,13 @@ func (s *OrgServer) GenerateNym(stream pb.Org_EC_GenerateNymServer) error {
 
 	// SchnorrProofData is used in DLog equality proof as well
 	z := new(big.Int).SetBytes(req.GetProofData())
-	valid := s.NymGenerator.Verify(z)
+	valid := nymGen.Verify(z)
+	if valid {
+		if err := regKey.Commit(); err != nil {
+			return status.Error(codes.DeadlineExceeded,
+				"registration key reservation timed out")
+		}
+	}
 
 	return stream.Send(
 		&psyspb.GenerateNymResponse{
@@ -119,7 +133,8 @@ func (s *OrgServer) ObtainCred(stream pb.Org_EC_ObtainCredServer) error {
 	}
 
 	pRandData := req.GetProofRandData()
-	ch := s.CredIssuer.GetChallenge(
+	issuer := NewCredIssuer(s.secKey, s.curve)
+	ch := issuer.GetChallenge(
 		toECGroupElement(pRandData.A),
 		toECGroupElement(pRandData.B),
 		toECGroupElement(pRandData.X),
@@ -140,7 +155,7 @@ func (s *OrgServer) ObtainCred(stream pb.Org_EC_ObtainCredServer) error {
 	}
 
 	z := new(big.Int).SetBytes(req.GetProofData())
-	x11, x12, x21, x22, A, B, err := s.CredIssuer.Verify(z)
+	x11, x12, x21, x22, A, B, err := issuer.Verify(z)
 
 	if err != nil {
 		//s.Logger.Debug(err)
@@ -172,7 +187,7 @@ func (s *OrgServer) ObtainCred(stream pb.Org_EC_ObtainCredServer) error {
 	ch1 := new(big.Int).SetBytes(chPair.X)
 	ch2 := new(big.Int).SetBytes(chPair.Y)
 
-	z1, z2 := s.CredIssuer.GetProofData(ch1, ch2)
+	z1, z2 := issuer.GetProofData(ch1, ch2)
 
 	return stream.Send(
 		&pb.ObtainCredResponse{
@@ -217,7 +232,8 @@ func (s *OrgServer) TransferCred(stream pb.Org_EC_TransferCredServer) error {
 		t1, t2,
 	)
 
-	ch := s.CredVerifier.GetChallenge(
+	verifier := NewCredVerifier(s.secKey, s.curve)
+	ch := verifier.GetChallenge(
 		toECGroupElement(pRandData.NymA),
 		toECGroupElement(pRandData.NymB),
 		credential.SmallAToGamma,
@@ -243,7 +259,7 @@ func (s *OrgServer) TransferCred(stream pb.Org_EC_TransferCredServer) error {
 	z := new(big.Int).SetBytes(req.GetProofData())
 
 	// TODO CredVerifier should be bound to an org with given pubkeys?
-	if verified := s.CredVerifier.Verify(z, credential, s.pubKey); !verified {
+	if verified := verifier.Verify(z, credential, s.pubKey); !verified {
 		//s.Logger.Debug("User authentication failed")
 		return status.Error(codes.Unauthenticated, "user authentication failed")
 	}
@@ -253,6 +269,13 @@ func (s *OrgServer) TransferCred(stream pb.Org_EC_TransferCredServer) error {
 		//s.Logger.Debug(err)
 		return status.Error(codes.Internal, "failed to obtain session key")
 	}
+	if s.SessStorer != nil {
+		sess := anauth.NewSession(*sessionKey, anauth.SchemeECPsys)
+		if err := s.SessStorer.Store(sess); err != nil {
+			return status.Error(codes.Internal,
+				"the server could not finish the proof")
+		}
+	}
 
 	return stream.Send(
 		&psyspb.TransferCredResponse{
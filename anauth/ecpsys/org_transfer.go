@@ -25,6 +25,8 @@ import (
 	"github.com/emmyzkp/emmy/anauth/psys"
 )
 
+// CredVerifier holds the state of a single credential transfer, and must
+// not be shared between clients.
 type CredVerifier struct {
 	secKey *psys.SecKey
 
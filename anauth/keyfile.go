@@ -0,0 +1,479 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package anauth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeyFileVersion is the version of the key file format written by
+// WriteKeyFile.
+const KeyFileVersion = 1
+
+// Types of keys stored in key files.
+const (
+	PublicKeyType = "public"
+	SecretKeyType = "secret"
+)
+
+// ScryptN is the scrypt cost parameter used when encrypting keys in
+// key files (see KeyFile.Encrypt).
+var ScryptN = 1 << 15
+
+// Parameters of the encryption of keys in key files.
+const (
+	scryptR       = 8
+	scryptP       = 1
+	scryptMaxN    = 1 << 22
+	keyEncKDF     = "scrypt"
+	keyEncCipher  = "aes-256-gcm"
+	keyEncSaltLen = 32
+)
+
+var (
+	// ErrPassphraseRequired is returned when reading an encrypted key
+	// file without a passphrase.
+	ErrPassphraseRequired = errors.New("key file is encrypted, " +
+		"a passphrase is required")
+	// ErrWrongPassphrase is returned when the key in a key file cannot
+	// be decrypted, because the passphrase is wrong or the file was
+	// modified.
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key file")
+)
+
+// PassphraseFunc returns the passphrase that keys in key files are
+// encrypted with. Readers of key files only call it for encrypted
+// files, so that the user is not asked for a passphrase needlessly.
+type PassphraseFunc func() ([]byte, error)
+
+// ErrLegacyKeyFile is returned by ReadKeyFile for files that are not in
+// the key file format, such as keys stored as gob by older versions.
+var ErrLegacyKeyFile = errors.New("not a versioned key file")
+
+// KeyFile is the format of the files that keys of anonymous
+// authentication schemes are stored in. It is a JSON object, for
+// example
+//
+//	{
+//	  "version": 1,
+//	  "scheme": "cl",
+//	  "type": "public",
+//	  "key_id": "5f0c...",
+//	  "params": {...},
+//	  "schema": {...},
+//	  "key": {...}
+//	}
+//
+// Scheme is one of "cl", "psys" and "ecpsys", and Type is either
+// PublicKeyType or SecretKeyType. KeyID identifies the key pair, it is
+// the same for the public and the secret key of a pair. Params holds
+// the parameters of the scheme the keys were generated with, and Schema
+// the attributes of the credentials issued with the keys (CL only).
+// Format of Params, Schema and Key depends on the scheme, integers in
+// them are encoded as Int.
+//
+// Key can be encrypted with a passphrase (see Encrypt), in which case
+// Encryption describes the encryption and Key holds the ciphertext as
+// a base64 string.
+//
+// RetiredAt is set in files of public keys that were replaced by newer
+// keys, verifiers of credentials decide by it which keys to accept.
+type KeyFile struct {
+	Version    int             `json:"version"`
+	Scheme     string          `json:"scheme"`
+	Type       string          `json:"type"`
+	KeyID      string          `json:"key_id"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Schema     json.RawMessage `json:"schema,omitempty"`
+	Encryption *KeyEncryption  `json:"encryption,omitempty"`
+	RetiredAt  *time.Time      `json:"retired_at,omitempty"`
+	Key        json.RawMessage `json:"key"`
+}
+
+// KeyEncryption describes the encryption of the key in a KeyFile.
+// The key is encrypted with AES-256-GCM, using a key derived from
+// a passphrase with scrypt with parameters N, R and P and Salt.
+// The rest of the key file is authenticated together with the key, so
+// that the key cannot be combined with other parameters.
+type KeyEncryption struct {
+	KDF    string `json:"kdf"`
+	Salt   []byte `json:"salt"`
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	Cipher string `json:"cipher"`
+	Nonce  []byte `json:"nonce"`
+}
+
+// NewKeyFile returns a KeyFile of the current version holding key of
+// type keyType for scheme, with id keyID. Params and Schema are left
+// empty.
+func NewKeyFile(scheme, keyType, keyID string,
+	key interface{}) (*KeyFile, error) {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding key")
+	}
+
+	return &KeyFile{
+		Version: KeyFileVersion,
+		Scheme:  scheme,
+		Type:    keyType,
+		KeyID:   keyID,
+		Key:     k,
+	}, nil
+}
+
+// Check checks that f is of a supported version and holds a key of
+// type keyType for scheme.
+func (f *KeyFile) Check(scheme, keyType string) error {
+	if f.Version < 1 || f.Version > KeyFileVersion {
+		return errors.Errorf("unsupported key file version %d", f.Version)
+	}
+	if f.Scheme != scheme {
+		return errors.Errorf("key file holds a key for scheme %s, "+
+			"expected %s", f.Scheme, scheme)
+	}
+	if f.Type != keyType {
+		return errors.Errorf("key file holds a %s key, expected %s key",
+			f.Type, keyType)
+	}
+	if len(f.Key) == 0 {
+		return errors.New("key file holds no key")
+	}
+
+	return nil
+}
+
+// Decode checks that f holds a key of type keyType for scheme (see
+// Check), decrypts the key with the passphrase returned by passphrase
+// if it is encrypted, and decodes the key into key. Unless params is
+// nil, parameters are decoded into params.
+func (f *KeyFile) Decode(scheme, keyType string, key, params interface{},
+	passphrase PassphraseFunc) error {
+	if err := f.Check(scheme, keyType); err != nil {
+		return err
+	}
+	if err := f.Decrypt(passphrase); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(f.Key, key); err != nil {
+		return errors.Wrap(err, "invalid key")
+	}
+	if params != nil {
+		if err := json.Unmarshal(f.Params, params); err != nil {
+			return errors.Wrap(err, "invalid parameters")
+		}
+	}
+
+	return nil
+}
+
+// Encrypted reports whether the key in f is encrypted.
+func (f *KeyFile) Encrypted() bool {
+	return f.Encryption != nil
+}
+
+// Encrypt encrypts the key in f with a key derived from passphrase.
+func (f *KeyFile) Encrypt(passphrase []byte) error {
+	if f.Encrypted() {
+		return errors.New("key is already encrypted")
+	}
+	if len(passphrase) == 0 {
+		return errors.New("passphrase is empty")
+	}
+
+	enc := &KeyEncryption{
+		KDF:    keyEncKDF,
+		Salt:   make([]byte, keyEncSaltLen),
+		N:      ScryptN,
+		R:      scryptR,
+		P:      scryptP,
+		Cipher: keyEncCipher,
+	}
+	if _, err := rand.Read(enc.Salt); err != nil {
+		return err
+	}
+	aead, err := enc.aead(passphrase)
+	if err != nil {
+		return err
+	}
+	enc.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return err
+	}
+
+	key := f.Key
+	f.Encryption = enc
+	ad, err := f.additionalData()
+	if err != nil {
+		f.Encryption = nil
+		return err
+	}
+	f.Key, err = json.Marshal(aead.Seal(nil, enc.Nonce, key, ad))
+
+	return err
+}
+
+// Decrypt decrypts the key in f, if it is encrypted, with a key
+// derived from the passphrase returned by passphrase.
+func (f *KeyFile) Decrypt(passphrase PassphraseFunc) error {
+	if !f.Encrypted() {
+		return nil
+	}
+	if passphrase == nil {
+		return ErrPassphraseRequired
+	}
+
+	enc := f.Encryption
+	if enc.KDF != keyEncKDF || enc.Cipher != keyEncCipher {
+		return errors.Errorf("unsupported key encryption %s with %s",
+			enc.Cipher, enc.KDF)
+	}
+	if enc.N > scryptMaxN || enc.R > 32 || enc.P > 16 {
+		return errors.New("scrypt parameters of key encryption are too large")
+	}
+
+	var ciphertext []byte
+	if err := json.Unmarshal(f.Key, &ciphertext); err != nil {
+		return errors.Wrap(err, "invalid encrypted key")
+	}
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+	aead, err := enc.aead(pass)
+	if err != nil {
+		return err
+	}
+	if len(enc.Nonce) != aead.NonceSize() {
+		return errors.New("invalid nonce of key encryption")
+	}
+	ad, err := f.additionalData()
+	if err != nil {
+		return err
+	}
+	key, err := aead.Open(nil, enc.Nonce, ciphertext, ad)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+
+	f.Key = key
+	f.Encryption = nil
+
+	return nil
+}
+
+// additionalData returns f without the key, which is authenticated
+// together with the encrypted key.
+func (f *KeyFile) additionalData() ([]byte, error) {
+	withoutKey := *f
+	withoutKey.Key = nil
+
+	return json.Marshal(&withoutKey)
+}
+
+// aead returns the cipher that keys are encrypted with, with a key
+// derived from passphrase.
+func (e *KeyEncryption) aead(passphrase []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, e.Salt, e.N, e.R, e.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// WriteKeyFile writes f to path with permissions perm. Secret keys
+// should be written with 0600. Permissions of an existing file are
+// changed to perm as well.
+func WriteKeyFile(path string, f *KeyFile, perm os.FileMode) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding key file")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// WriteSecretKeyFile writes f holding a secret key to path, so that
+// it is only readable by its owner. Unless passphrase is nil, the key
+// is encrypted with it (see Encrypt).
+func WriteSecretKeyFile(path string, f *KeyFile, passphrase []byte) error {
+	if passphrase != nil {
+		if err := f.Encrypt(passphrase); err != nil {
+			return err
+		}
+	}
+
+	return WriteKeyFile(path, f, 0600)
+}
+
+// ReadKeyFile reads a KeyFile from path. It returns ErrLegacyKeyFile
+// together with the contents of the file if it is not in the key file
+// format, so that the caller can decode the legacy format.
+func ReadKeyFile(path string) (*KeyFile, []byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !IsKeyFile(data) {
+		return nil, data, ErrLegacyKeyFile
+	}
+
+	f := new(KeyFile)
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid key file %s", path)
+	}
+
+	return f, data, nil
+}
+
+// DecodeLegacyKey decodes a key stored as gob by older versions from
+// data into key.
+func DecodeLegacyKey(data []byte, key interface{}) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(key); err != nil {
+		return errors.Wrap(err, "invalid legacy key file")
+	}
+
+	return nil
+}
+
+// IsKeyFile reports whether data is in the key file format, rather
+// than in a legacy one. Legacy key files are gob encoded, and never
+// start with '{'.
+func IsKeyFile(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+// KeyID returns the key ID of a public key of scheme, consisting of
+// values. It is a hexadecimal hash of the scheme and the values, each
+// of them written together with its length. A nil value is written as
+// an empty one.
+func KeyID(scheme string, values ...*big.Int) string {
+	h := sha256.New()
+	write := func(b []byte) {
+		var l [8]byte
+		binary.BigEndian.PutUint64(l[:], uint64(len(b)))
+		h.Write(l[:])
+		h.Write(b)
+	}
+
+	write([]byte(scheme))
+	for _, x := range values {
+		if x == nil {
+			write(nil)
+			continue
+		}
+		write(x.Bytes())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Int is an integer that is encoded in JSON as a hexadecimal string,
+// so that it can be decoded by tools without arbitrary precision
+// numbers. A nil integer is encoded as null.
+type Int struct {
+	*big.Int
+}
+
+// NewInt wraps integer x into Int.
+func NewInt(x *big.Int) Int {
+	return Int{Int: x}
+}
+
+// NewInts wraps integers xs into Ints.
+func NewInts(xs []*big.Int) []Int {
+	ints := make([]Int, len(xs))
+	for i, x := range xs {
+		ints[i] = NewInt(x)
+	}
+
+	return ints
+}
+
+// BigInts unwraps integers from ints.
+func BigInts(ints []Int) []*big.Int {
+	xs := make([]*big.Int, len(ints))
+	for i, x := range ints {
+		xs[i] = x.Int
+	}
+
+	return xs
+}
+
+func (x Int) MarshalJSON() ([]byte, error) {
+	if x.Int == nil {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(x.Text(16))
+}
+
+func (x *Int) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		x.Int = nil
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Wrap(err, "integer must be a hexadecimal string")
+	}
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return errors.Errorf("invalid hexadecimal integer %q", s)
+	}
+	x.Int = n
+
+	return nil
+}
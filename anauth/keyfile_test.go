@@ -0,0 +1,97 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package anauth
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt_JSON(t *testing.T) {
+	x, _ := new(big.Int).SetString("123456789abcdef0123456789", 16)
+	data, err := json.Marshal([]Int{NewInt(x), NewInt(nil)})
+	require.NoError(t, err)
+	assert.Equal(t, `["123456789abcdef0123456789",null]`, string(data))
+
+	var ints []Int
+	require.NoError(t, json.Unmarshal(data, &ints))
+	assert.Equal(t, []*big.Int{x, nil}, BigInts(ints))
+
+	assert.Error(t, json.Unmarshal([]byte(`["xyz"]`), &ints))
+	assert.Error(t, json.Unmarshal([]byte(`[12]`), &ints))
+}
+
+func TestKeyFile_Check(t *testing.T) {
+	f, err := NewKeyFile("cl", PublicKeyType, "id", map[string]string{})
+	require.NoError(t, err)
+	assert.NoError(t, f.Check("cl", PublicKeyType))
+	assert.Error(t, f.Check("psys", PublicKeyType))
+	assert.Error(t, f.Check("cl", SecretKeyType))
+
+	f.Version = KeyFileVersion + 1
+	assert.Error(t, f.Check("cl", PublicKeyType))
+}
+
+func TestKeyID(t *testing.T) {
+	a, b := big.NewInt(0x0102), big.NewInt(0x03)
+	assert.Equal(t, KeyID("cl", a, b), KeyID("cl", a, b))
+	// values are not simply concatenated
+	assert.NotEqual(t, KeyID("cl", a, b),
+		KeyID("cl", big.NewInt(0x01), big.NewInt(0x0203)))
+	assert.NotEqual(t, KeyID("cl", a, b), KeyID("psys", a, b))
+	assert.NotEqual(t, KeyID("cl", a, nil), KeyID("cl", a))
+}
+
+func TestKeyFile_Encrypt(t *testing.T) {
+	passphrase := func(p string) PassphraseFunc {
+		return func() ([]byte, error) {
+			return []byte(p), nil
+		}
+	}
+	newKeyFile := func() *KeyFile {
+		f, err := NewKeyFile("cl", SecretKeyType, "id",
+			map[string]string{"x": "1234"})
+		require.NoError(t, err)
+		f.Params = json.RawMessage(`{"a": 1}`)
+		return f
+	}
+
+	f := newKeyFile()
+	require.NoError(t, f.Encrypt([]byte("secret")))
+	assert.True(t, f.Encrypted())
+	assert.NotContains(t, string(f.Key), "1234")
+	assert.Error(t, f.Encrypt([]byte("secret")))
+
+	assert.Equal(t, ErrPassphraseRequired, f.Decrypt(nil))
+	assert.Equal(t, ErrWrongPassphrase, f.Decrypt(passphrase("wrong")))
+	require.NoError(t, f.Decrypt(passphrase("secret")))
+	assert.False(t, f.Encrypted())
+	assert.Equal(t, newKeyFile().Key, f.Key)
+
+	// parameters are authenticated with the key
+	f = newKeyFile()
+	require.NoError(t, f.Encrypt([]byte("secret")))
+	f.Params = json.RawMessage(`{"a": 2}`)
+	assert.Equal(t, ErrWrongPassphrase, f.Decrypt(passphrase("secret")))
+
+	assert.Error(t, newKeyFile().Encrypt(nil))
+}
@@ -28,6 +28,9 @@ import (
 	"github.com/emmyzkp/crypto/schnorr"
 )
 
+// CA holds the state of a single certificate generation. It must
+// not be shared between clients, as GetChallenge stores the client's
+// master nym that Verify later certifies.
 type CA struct {
 	verifier   *schnorr.Verifier
 	a          *big.Int
@@ -31,13 +31,19 @@ func (s *CAServer) RegisterTo(grpcSrv *grpc.Server) {
 	pb.RegisterCAServer(grpcSrv, s)
 }
 
+// CAServer serves certificates of the CA. A new CA is
+// created for each stream, so that clients can be served concurrently.
 type CAServer struct {
-	ca *CA
+	group  *schnorr.Group
+	secKey *big.Int
+	pubKey *PubKey
 }
 
 func NewCAServer(group *schnorr.Group, secKey *big.Int, pubKey *PubKey) *CAServer {
 	return &CAServer{
-		ca: NewCA(group, secKey, pubKey),
+		group:  group,
+		secKey: secKey,
+		pubKey: pubKey,
 	}
 }
 
@@ -54,7 +60,8 @@ func (s *CAServer) GenerateCertificate(stream pb.CA_GenerateCertificateServer) e
 	a := new(big.Int).SetBytes(pRandData.A)
 	b := new(big.Int).SetBytes(pRandData.B)
 
-	ch := s.ca.GetChallenge(a, b, x)
+	ca := NewCA(s.group, s.secKey, s.pubKey)
+	ch := ca.GetChallenge(a, b, x)
 	if err := stream.Send(&pb.CAResponse{
 		Type: &pb.CAResponse_Challenge{
 			Challenge: ch.Bytes(),
@@ -69,7 +76,7 @@ func (s *CAServer) GenerateCertificate(stream pb.CA_GenerateCertificateServer) e
 	}
 
 	z := new(big.Int).SetBytes(req.GetProofData())
-	cert, err := s.ca.Verify(z)
+	cert, err := ca.Verify(z)
 	if err != nil {
 		//s.Logger.Debug(err)
 		// FIXME don't report err.Error
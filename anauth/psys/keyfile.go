@@ -0,0 +1,319 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package psys
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/emmyzkp/crypto/ec"
+	"github.com/emmyzkp/crypto/schnorr"
+	"github.com/emmyzkp/emmy/anauth"
+	"github.com/pkg/errors"
+)
+
+// Schemes that identify keys of the pseudonym system in key files.
+const (
+	keyFileScheme   = "psys"
+	caKeyFileScheme = "psys-ca"
+)
+
+// curves are the curves that keys can be stored for.
+var curves = []ec.Curve{ec.P224, ec.P256, ec.P384, ec.P521}
+
+// CurveName returns the name of curve c, for example "P-256".
+func CurveName(c ec.Curve) string {
+	return ec.GetCurve(c).Params().Name
+}
+
+// CurveByName returns the curve with name.
+func CurveByName(name string) (ec.Curve, error) {
+	for _, c := range curves {
+		if CurveName(c) == name {
+			return c, nil
+		}
+	}
+
+	return 0, errors.Errorf("unsupported curve %q", name)
+}
+
+// KeyID returns the key ID of the key pair with public key pk in
+// group.
+func KeyID(group *schnorr.Group, pk *PubKey) string {
+	return anauth.KeyID(keyFileScheme, group.P, group.G, group.Q, pk.H1,
+		pk.H2)
+}
+
+// CAKeyID returns the key ID of the CA key pair with public key pk on
+// curve c.
+func CAKeyID(c ec.Curve, pk *PubKey) string {
+	return anauth.KeyID(caKeyFileScheme+"/"+CurveName(c), pk.H1, pk.H2)
+}
+
+// groupJSON is the encoding of the schnorr group of keys in key files.
+type groupJSON struct {
+	P anauth.Int `json:"p"`
+	G anauth.Int `json:"g"`
+	Q anauth.Int `json:"q"`
+}
+
+type pubKeyJSON struct {
+	H1 anauth.Int `json:"h1"`
+	H2 anauth.Int `json:"h2"`
+}
+
+type secKeyJSON struct {
+	S1 anauth.Int `json:"s1"`
+	S2 anauth.Int `json:"s2"`
+}
+
+// curveJSON is the encoding of the curve of CA keys in key files.
+type curveJSON struct {
+	Curve string `json:"curve"`
+}
+
+type caPubKeyJSON struct {
+	X anauth.Int `json:"x"`
+	Y anauth.Int `json:"y"`
+}
+
+type caSecKeyJSON struct {
+	D anauth.Int `json:"d"`
+}
+
+// writeKeyFiles writes public key pub to pubKeyPath and secret key sec
+// to secKeyPath, both with key ID keyID and params. Unless passphrase is
+// nil, the secret key is encrypted with it.
+func writeKeyFiles(pubKeyPath, secKeyPath, scheme, keyID string, params,
+	pub, sec interface{}, passphrase []byte) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	pubFile, err := anauth.NewKeyFile(scheme, anauth.PublicKeyType, keyID, pub)
+	if err != nil {
+		return err
+	}
+	pubFile.Params = p
+	if err := anauth.WriteKeyFile(pubKeyPath, pubFile, 0644); err != nil {
+		return err
+	}
+
+	secFile, err := anauth.NewKeyFile(scheme, anauth.SecretKeyType, keyID, sec)
+	if err != nil {
+		return err
+	}
+	secFile.Params = p
+
+	return anauth.WriteSecretKeyFile(secKeyPath, secFile, passphrase)
+}
+
+// readKeyFile reads a key file of type keyType for scheme from path,
+// decoding the key, decrypted with passphrase if needed, into key and
+// the parameters into params. Files in the legacy format are decoded
+// as gob into legacy instead, in which case the returned key file is
+// nil. Legacy files are not accepted if legacy is nil.
+func readKeyFile(path, scheme, keyType string, key, params,
+	legacy interface{}, passphrase anauth.PassphraseFunc) (*anauth.KeyFile,
+	error) {
+	f, data, err := anauth.ReadKeyFile(path)
+	if err == anauth.ErrLegacyKeyFile && legacy != nil {
+		return nil, errors.Wrap(anauth.DecodeLegacyKey(data, legacy), path)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	if err := f.Decode(scheme, keyType, key, params, passphrase); err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	return f, nil
+}
+
+// WriteKeys writes the public key pk and the secret key sk of an
+// organization using group to pubKeyPath and secKeyPath, together with
+// group (see anauth.KeyFile). Unless passphrase is nil, the secret key
+// is encrypted with it. The secret key file is only readable by its
+// owner.
+func WriteKeys(pubKeyPath, secKeyPath string, group *schnorr.Group,
+	sk *SecKey, pk *PubKey, passphrase []byte) error {
+	return writeKeyFiles(pubKeyPath, secKeyPath, keyFileScheme,
+		KeyID(group, pk),
+		&groupJSON{
+			P: anauth.NewInt(group.P),
+			G: anauth.NewInt(group.G),
+			Q: anauth.NewInt(group.Q),
+		},
+		&pubKeyJSON{H1: anauth.NewInt(pk.H1), H2: anauth.NewInt(pk.H2)},
+		&secKeyJSON{S1: anauth.NewInt(sk.S1), S2: anauth.NewInt(sk.S2)},
+		passphrase)
+}
+
+// ReadPubKey reads a public key written by WriteKeys from path,
+// together with its group. It also accepts a public key stored as gob
+// by older versions, in which case the returned group is nil.
+func ReadPubKey(path string) (*schnorr.Group, *PubKey, error) {
+	var j pubKeyJSON
+	var g groupJSON
+	legacy := new(PubKey)
+	f, err := readKeyFile(path, keyFileScheme, anauth.PublicKeyType, &j, &g,
+		legacy, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f == nil {
+		if legacy.H1 == nil || legacy.H2 == nil {
+			return nil, nil, errors.Errorf("invalid public key in %s", path)
+		}
+		return nil, legacy, nil
+	}
+
+	if j.H1.Int == nil || j.H2.Int == nil || g.P.Int == nil ||
+		g.G.Int == nil || g.Q.Int == nil {
+		return nil, nil, errors.Errorf("public key in %s is missing values",
+			path)
+	}
+	group := schnorr.NewGroupFromParams(g.P.Int, g.G.Int, g.Q.Int)
+	pk := NewPubKey(j.H1.Int, j.H2.Int)
+	if f.KeyID != KeyID(group, pk) {
+		return nil, nil, errors.Errorf("key ID in %s does not match the key",
+			path)
+	}
+
+	return group, pk, nil
+}
+
+// ReadKeys reads the public key from pubKeyPath (see ReadPubKey) and
+// the secret key from secKeyPath, and checks that they form a key pair.
+// An encrypted secret key is decrypted with the passphrase returned by
+// passphrase, which can be nil for keys that are not encrypted.
+func ReadKeys(pubKeyPath, secKeyPath string,
+	passphrase anauth.PassphraseFunc) (*schnorr.Group, *SecKey, *PubKey,
+	error) {
+	group, pk, err := ReadPubKey(pubKeyPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var j secKeyJSON
+	var g groupJSON
+	sk := new(SecKey)
+	f, err := readKeyFile(secKeyPath, keyFileScheme, anauth.SecretKeyType,
+		&j, &g, sk, passphrase)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if f != nil {
+		sk = NewSecKey(j.S1.Int, j.S2.Int)
+		if group == nil && g.P.Int != nil && g.G.Int != nil && g.Q.Int != nil {
+			group = schnorr.NewGroupFromParams(g.P.Int, g.G.Int, g.Q.Int)
+		}
+		if group == nil || f.KeyID != KeyID(group, pk) {
+			return nil, nil, nil, errors.New("secret key does not match" +
+				" the public key")
+		}
+	}
+
+	if sk.S1 == nil || sk.S2 == nil {
+		return nil, nil, nil, errors.Errorf("invalid secret key in %s",
+			secKeyPath)
+	}
+	if group != nil && (group.Exp(group.G, sk.S1).Cmp(pk.H1) != 0 ||
+		group.Exp(group.G, sk.S2).Cmp(pk.H2) != 0) {
+		return nil, nil, nil, errors.New("secret key does not match the" +
+			" public key")
+	}
+
+	return group, sk, pk, nil
+}
+
+// WriteCAKeys writes the public key pk and the secret key sk of a CA
+// using curve c (see GenerateCAKeyPair) to pubKeyPath and secKeyPath,
+// together with the name of c. Unless passphrase is nil, the secret
+// key is encrypted with it. The secret key file is only readable by its
+// owner.
+func WriteCAKeys(pubKeyPath, secKeyPath string, c ec.Curve, sk *big.Int,
+	pk *PubKey, passphrase []byte) error {
+	return writeKeyFiles(pubKeyPath, secKeyPath, caKeyFileScheme,
+		CAKeyID(c, pk),
+		&curveJSON{Curve: CurveName(c)},
+		&caPubKeyJSON{X: anauth.NewInt(pk.H1), Y: anauth.NewInt(pk.H2)},
+		&caSecKeyJSON{D: anauth.NewInt(sk)},
+		passphrase)
+}
+
+// ReadCAPubKey reads a public key of a CA written by WriteCAKeys from
+// path, together with its curve.
+func ReadCAPubKey(path string) (ec.Curve, *PubKey, error) {
+	var j caPubKeyJSON
+	var p curveJSON
+	f, err := readKeyFile(path, caKeyFileScheme, anauth.PublicKeyType, &j,
+		&p, nil, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	c, err := CurveByName(p.Curve)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, path)
+	}
+
+	if j.X.Int == nil || j.Y.Int == nil ||
+		!ec.GetCurve(c).IsOnCurve(j.X.Int, j.Y.Int) {
+		return 0, nil, errors.Errorf("invalid public key in %s", path)
+	}
+	pk := NewPubKey(j.X.Int, j.Y.Int)
+	if f.KeyID != CAKeyID(c, pk) {
+		return 0, nil, errors.Errorf("key ID in %s does not match the key",
+			path)
+	}
+
+	return c, pk, nil
+}
+
+// ReadCAKeys reads the public key of a CA from pubKeyPath (see
+// ReadCAPubKey) and its secret key from secKeyPath, and checks that
+// they form a key pair. An encrypted secret key is decrypted with the
+// passphrase returned by passphrase, which can be nil for keys that are
+// not encrypted.
+func ReadCAKeys(pubKeyPath, secKeyPath string,
+	passphrase anauth.PassphraseFunc) (ec.Curve, *big.Int, *PubKey, error) {
+	c, pk, err := ReadCAPubKey(pubKeyPath)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	var j caSecKeyJSON
+	f, err := readKeyFile(secKeyPath, caKeyFileScheme, anauth.SecretKeyType,
+		&j, nil, nil, passphrase)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if j.D.Int == nil {
+		return 0, nil, nil, errors.Errorf("invalid secret key in %s",
+			secKeyPath)
+	}
+	x, y := ec.GetCurve(c).ScalarBaseMult(j.D.Bytes())
+	if f.KeyID != CAKeyID(c, pk) || x.Cmp(pk.H1) != 0 || y.Cmp(pk.H2) != 0 {
+		return 0, nil, nil, errors.New("secret key does not match the" +
+			" public key")
+	}
+
+	return c, j.D.Int, pk, nil
+}
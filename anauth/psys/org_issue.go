@@ -46,6 +46,8 @@ func NewCred(aToGamma, bToGamma, AToGamma, BToGamma *big.Int,
 	}
 }
 
+// CredIssuer holds the state of a single credential issuance, and must
+// not be shared between clients.
 type CredIssuer struct {
 	group  *schnorr.Group
 	secKey *SecKey
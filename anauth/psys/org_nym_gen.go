@@ -40,6 +40,8 @@ func NewNym(a, b *big.Int) *Nym {
 	}
 }
 
+// NymGenerator holds the state of a single nym generation, and must
+// not be shared between clients.
 type NymGenerator struct {
 	verifier *schnorr.EqualityVerifier
 	caPubKey *PubKey
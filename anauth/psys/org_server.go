@@ -28,23 +28,28 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// OrgServer serves the pseudonym system protocols of an organization.
+// A new NymGenerator, CredIssuer or CredVerifier is created for each
+// stream, so that clients can be served concurrently.
 type OrgServer struct {
-	pubKey *PubKey
-
-	*NymGenerator
-	*CredIssuer
-	*CredVerifier
+	group    *schnorr.Group
+	secKey   *SecKey
+	pubKey   *PubKey
+	caPubKey *PubKey
 
 	SessMgr anauth.SessManager
 	RegMgr  anauth.RegManager
+	// SessStorer stores sessions of clients that transferred their
+	// credentials. If it is nil, sessions are not stored.
+	SessStorer anauth.SessStorer
 }
 
 func NewOrgServer(group *schnorr.Group, secKey *SecKey, pubKey, caPubKey *PubKey) *OrgServer {
 	return &OrgServer{
-		pubKey:       pubKey,
-		NymGenerator: NewNymGenerator(group, caPubKey),
-		CredIssuer:   NewCredIssuer(group, secKey),
-		CredVerifier: NewCredVerifier(group, secKey),
+		group:    group,
+		secKey:   secKey,
+		pubKey:   pubKey,
+		caPubKey: caPubKey,
 	}
 }
 
@@ -68,15 +73,17 @@ func (s *OrgServer) GenerateNym(stream pb.Org_GenerateNymServer) error {
 	signatureR := new(big.Int).SetBytes(proofRandData.R)
 	signatureS := new(big.Int).SetBytes(proofRandData.S)
 
-	regKeyOk, err := s.RegMgr.CheckRegistrationKey(proofRandData.RegKey)
+	regKey, regKeyOk, err := anauth.ReserveRegKey(s.RegMgr, proofRandData.RegKey)
 
 	if !regKeyOk || err != nil {
 		//s.Logger.Debugf("registration key %s ok=%t, error=%v",
 		//	proofRandData.RegKey, regKeyOk, err)
 		return status.Error(codes.NotFound, "registration key verification failed")
 	}
+	defer regKey.Release()
 
-	ch, err := s.NymGenerator.GetChallenge(nymA, blindedA, nymB, blindedB, x1, x2, signatureR, signatureS)
+	nymGen := NewNymGenerator(s.group, s.caPubKey)
+	ch, err := nymGen.GetChallenge(nymA, blindedA, nymB, blindedB, x1, x2, signatureR, signatureS)
 	if err != nil {
 		//s.Logger.Debug(err)
 		return status.Error(codes.Internal, err.Error())
@@ -100,7 +107,13 @@ func (s *OrgServer) GenerateNym(stream pb.Org_GenerateNymServer) error {
 
 	// SchnorrProofData is used in DLog equality proof as well
 	z := new(big.Int).SetBytes(req.GetProofData())
-	valid := s.NymGenerator.Verify(z)
+	valid := nymGen.Verify(z)
+	if valid {
+		if err := regKey.Commit(); err != nil {
+			return status.Error(codes.DeadlineExceeded,
+				"registration key reservation timed out")
+		}
+	}
 
 	return stream.Send(
 		&pb.GenerateNymResponse{
@@ -120,7 +133,8 @@ func (s *OrgServer) ObtainCred(stream pb.Org_ObtainCredServer) error {
 	x := new(big.Int).SetBytes(sProofRandData.X)
 	a := new(big.Int).SetBytes(sProofRandData.A)
 	b := new(big.Int).SetBytes(sProofRandData.B)
-	ch := s.CredIssuer.GetChallenge(a, b, x)
+	issuer := NewCredIssuer(s.group, s.secKey)
+	ch := issuer.GetChallenge(a, b, x)
 
 	if err := stream.Send(
 		&pb.ObtainCredResponse{
@@ -138,7 +152,7 @@ func (s *OrgServer) ObtainCred(stream pb.Org_ObtainCredServer) error {
 
 	z := new(big.Int).SetBytes(req.GetProofData())
 
-	x11, x12, x21, x22, A, B, err := s.CredIssuer.Verify(z)
+	x11, x12, x21, x22, A, B, err := issuer.Verify(z)
 	if err != nil {
 		//s.Logger.Debug(err)
 		return status.Error(codes.Internal, err.Error())
@@ -168,7 +182,7 @@ func (s *OrgServer) ObtainCred(stream pb.Org_ObtainCredServer) error {
 	ch1 := new(big.Int).SetBytes(chPair.X)
 	ch2 := new(big.Int).SetBytes(chPair.Y)
 
-	z1, z2 := s.CredIssuer.GetProofData(ch1, ch2)
+	z1, z2 := issuer.GetProofData(ch1, ch2)
 
 	return stream.Send(
 		&pb.ObtainCredResponse{
@@ -209,7 +223,8 @@ func (s *OrgServer) TransferCred(stream pb.Org_TransferCredServer) error {
 		t1, t2,
 	)
 
-	challenge := s.CredVerifier.GetChallenge(
+	verifier := NewCredVerifier(s.group, s.secKey)
+	challenge := verifier.GetChallenge(
 		new(big.Int).SetBytes(data.NymA),
 		new(big.Int).SetBytes(data.NymB),
 		cred.SmallAToGamma,
@@ -236,7 +251,7 @@ func (s *OrgServer) TransferCred(stream pb.Org_TransferCredServer) error {
 	// FIXME
 	z := new(big.Int).SetBytes(req.GetProofData())
 
-	if verified := s.CredVerifier.Verify(z, cred, s.pubKey); !verified {
+	if verified := verifier.Verify(z, cred, s.pubKey); !verified {
 		//s.Logger.Debug("User authentication failed")
 		return status.Error(codes.Unauthenticated, "user authentication failed")
 	}
@@ -246,6 +261,13 @@ func (s *OrgServer) TransferCred(stream pb.Org_TransferCredServer) error {
 		//s.Logger.Debug(err)
 		return status.Error(codes.Internal, "failed to obtain session key")
 	}
+	if s.SessStorer != nil {
+		sess := anauth.NewSession(*sessKey, anauth.SchemePsys)
+		if err := s.SessStorer.Store(sess); err != nil {
+			return status.Error(codes.Internal,
+				"the server could not finish the proof")
+		}
+	}
 
 	return stream.Send(
 		&pb.TransferCredResponse{
@@ -23,6 +23,8 @@ import (
 	"github.com/emmyzkp/crypto/schnorr"
 )
 
+// CredVerifier holds the state of a single credential transfer, and must
+// not be shared between clients.
 type CredVerifier struct {
 	group  *schnorr.Group
 	secKey *SecKey
@@ -18,16 +18,118 @@
 package anauth
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
 	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
 )
 
-// SessManager checks for the presence of a registration key,
-// removing it in case it exists.
-// The bolean return argument indicates success (registration key
-// present and subsequently deleted) or failure (absence of registration
-// key).
+// RegKeyReservationTimeout is the time after which a registration key
+// reserved by a registration that was neither committed nor released
+// becomes available again, for example when a client abandons
+// the registration.
+var RegKeyReservationTimeout = 2 * time.Minute
+
+// ErrReservationNotHeld is returned when committing or releasing
+// a reservation of a registration key that timed out, or was never made.
+var ErrReservationNotHeld = errors.New("registration key reservation is not held")
+
+// RegManager manages registration keys. A registration first reserves
+// the key with ReserveRegistrationKey, which returns a reservation token.
+// While the key is reserved, other registrations cannot use it.
+// Once the registration succeeds, the key is removed with
+// CommitRegistrationKey, otherwise it is made available again with
+// ReleaseRegistrationKey. Reservations that are neither committed nor
+// released time out after timeout.
+//
+// The boolean return argument of ReserveRegistrationKey indicates
+// whether the key is present and was not reserved yet.
 type RegManager interface {
-	CheckRegistrationKey(string) (bool, error)
+	ReserveRegistrationKey(key string, timeout time.Duration) (string, bool, error)
+	CommitRegistrationKey(key, token string) error
+	ReleaseRegistrationKey(key, token string) error
+}
+
+// AttrRegManager is a RegManager that also keeps the values of
+// attributes that the issuer registered together with registration
+// keys.
+//
+// RegistrationKeyData returns the attribute values registered with
+// the key, mapped by attribute names. Integer values are int64, strings
+// are string. The returned map is nil for keys registered without
+// attribute values.
+type AttrRegManager interface {
+	RegManager
+	RegistrationKeyData(string) (map[string]interface{}, error)
+}
+
+// NewReservationToken returns a random token identifying a reservation
+// of a registration key.
+func NewReservationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// RegKeyReservation is a reservation of a registration key, held by
+// a registration in progress.
+type RegKeyReservation struct {
+	mgr   RegManager
+	key   string
+	token string
+	done  bool
+}
+
+// ReserveRegKey reserves registration key key with m for
+// RegKeyReservationTimeout. The boolean return argument indicates
+// whether the key is present and was not reserved yet.
+func ReserveRegKey(m RegManager, key string) (*RegKeyReservation, bool,
+	error) {
+	token, ok, err := m.ReserveRegistrationKey(key, RegKeyReservationTimeout)
+	if !ok || err != nil {
+		return nil, false, err
+	}
+
+	return &RegKeyReservation{
+		mgr:   m,
+		key:   key,
+		token: token,
+	}, true, nil
+}
+
+// Key returns the reserved registration key.
+func (r *RegKeyReservation) Key() string {
+	return r.key
+}
+
+// Commit removes the reserved registration key once the registration
+// succeeded.
+func (r *RegKeyReservation) Commit() error {
+	if err := r.mgr.CommitRegistrationKey(r.key, r.token); err != nil {
+		return err
+	}
+	r.done = true
+
+	return nil
+}
+
+// Release makes the reserved registration key available again, unless
+// the reservation was already committed or released. It is meant to be
+// deferred right after the key is reserved.
+func (r *RegKeyReservation) Release() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+
+	return r.mgr.ReleaseRegistrationKey(r.key, r.token)
 }
 
 type RedisClient struct {
@@ -43,6 +145,9 @@ func NewRedisClient(c *redis.Client) *RedisClient {
 // CheckRegistrationKey checks whether provided key is present in registration database and deletes it,
 // preventing another registration with the same key.
 // Returns true if key was present (registration allowed), false otherwise.
+//
+// Registrations should rather use ReserveRegistrationKey, so that the
+// key is not lost when the registration fails.
 func (c *RedisClient) CheckRegistrationKey(key string) (bool, error) {
 	resp := c.Del(key)
 
@@ -54,3 +159,140 @@ func (c *RedisClient) CheckRegistrationKey(key string) (bool, error) {
 
 	return resp.Val() == 1, nil // one deleted entry indicates that the key was present in the DB
 }
+
+// reservationKey returns the redis key holding the reservation of
+// registration key key.
+func reservationKey(key string) string {
+	return "reserved:" + key
+}
+
+// ReserveRegistrationKey reserves registration key key, if it is present
+// in the registration database and not reserved already. The reservation
+// is kept in the database and expires after timeout.
+func (c *RedisClient) ReserveRegistrationKey(key string,
+	timeout time.Duration) (string, bool, error) {
+	token, err := NewReservationToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	reserved, err := c.SetNX(reservationKey(key), token, timeout).Result()
+	if err != nil || !reserved {
+		return "", false, err
+	}
+
+	n, err := c.Exists(key).Result()
+	if err != nil || n == 0 {
+		c.Del(reservationKey(key))
+		return "", false, err
+	}
+
+	return token, true, nil
+}
+
+// commitScript deletes the registration key together with its
+// reservation, if the reservation holds the token.
+var commitScript = redis.NewScript(`
+if redis.call("GET", KEYS[2]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1], KEYS[2])
+end
+return 0`)
+
+// releaseScript deletes the reservation of the registration key, if it
+// holds the token.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[2]) == ARGV[1] then
+	return redis.call("DEL", KEYS[2])
+end
+return 0`)
+
+// CommitRegistrationKey deletes registration key key reserved with
+// token, preventing another registration with the same key.
+func (c *RedisClient) CommitRegistrationKey(key, token string) error {
+	n, err := commitScript.Run(c.Client,
+		[]string{key, reservationKey(key)}, token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReservationNotHeld
+	}
+
+	return nil
+}
+
+// ReleaseRegistrationKey releases the reservation of registration key
+// key made with token, so that the key can be used again.
+func (c *RedisClient) ReleaseRegistrationKey(key, token string) error {
+	n, err := releaseScript.Run(c.Client,
+		[]string{key, reservationKey(key)}, token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReservationNotHeld
+	}
+
+	return nil
+}
+
+// RegisterKey saves registration key key to the registration database,
+// together with the values of attributes in attrs, which can be nil.
+// Values are stored as a JSON object.
+func (c *RedisClient) RegisterKey(key string, attrs map[string]interface{}) error {
+	val := []byte(key)
+	if attrs != nil {
+		var err error
+		if val, err = json.Marshal(attrs); err != nil {
+			return errors.Wrap(err, "unable to encode attribute values")
+		}
+	}
+
+	return c.Set(key, val, 0).Err()
+}
+
+// RegistrationKeyData returns the attribute values saved with
+// registration key key (see RegisterKey).
+func (c *RedisClient) RegistrationKeyData(key string) (map[string]interface{},
+	error) {
+	val, err := c.Get(key).Result()
+	if err == redis.Nil {
+		return nil, errors.Errorf("registration key %s is not present", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAttrData(val)
+}
+
+// decodeAttrData decodes attribute values encoded as a JSON object,
+// enforcing int64 for integer values. Data that is not a JSON object
+// holds no attribute values, in which case nil is returned.
+func decodeAttrData(data string) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewBufferString(data))
+	dec.UseNumber()
+
+	var attrs map[string]interface{}
+	if err := dec.Decode(&attrs); err != nil {
+		return nil, nil
+	}
+
+	for name, v := range attrs {
+		switch t := v.(type) {
+		case json.Number:
+			n, err := t.Int64()
+			if err != nil {
+				return nil, errors.Wrapf(err,
+					"value of attribute %s is not an integer", name)
+			}
+			attrs[name] = n // enforce int64
+		case string:
+		default:
+			return nil, errors.Errorf(
+				"value of attribute %s is neither an integer nor a string", name)
+		}
+	}
+
+	return attrs, nil
+}
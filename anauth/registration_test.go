@@ -0,0 +1,44 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package anauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeAttrData(t *testing.T) {
+	attrs, err := decodeAttrData(`{"name": "Jack", "age": 50}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name": "Jack",
+		"age":  int64(50),
+	}, attrs)
+
+	// keys registered without attribute values
+	attrs, err = decodeAttrData("key1")
+	require.NoError(t, err)
+	assert.Nil(t, attrs)
+
+	_, err = decodeAttrData(`{"age": 50.5}`)
+	assert.Error(t, err)
+	_, err = decodeAttrData(`{"tags": ["a", "b"]}`)
+	assert.Error(t, err)
+}
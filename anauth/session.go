@@ -20,15 +20,59 @@ package anauth
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
+
 	"github.com/go-redis/redis"
 )
 
-// SessStorer stores arbitrary data associated with the
-// authenticated session to the storage backend, returning
-// error in case the data could not be stored.
+// Anonymous authentication schemes that sessions are established with.
+const (
+	SchemeCL     = "cl"
+	SchemePsys   = "psys"
+	SchemeECPsys = "ecpsys"
+)
+
+// Session is an authenticated session, established when a client
+// proves the possession of a credential. It holds what the server
+// learned from the proof, for integration with application logic.
+type Session struct {
+	// Key is the session key that the client received
+	Key string `json:"-"`
+	// Scheme is the scheme of the proved credential
+	Scheme string `json:"scheme"`
+	// KeyIDs identify the keys of the issuers of the proved
+	// credentials, one for each credential. They are empty for
+	// schemes without key identifiers.
+	KeyIDs []string `json:"keyIds,omitempty"`
+	// Attrs holds the values of revealed attributes, mapped by
+	// attribute names
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+	// Scope is the scope of the pseudonym Nym that the client
+	// presented, a hex string which identifies the client within
+	// the scope across sessions. Both are empty if the server does not
+	// require scope pseudonyms.
+	Scope string `json:"scope,omitempty"`
+	Nym   string `json:"nym,omitempty"`
+	// Time is the time of the proof
+	Time time.Time `json:"time"`
+}
+
+// NewSession returns a session with session key key, established
+// now with a proof of a credential of the given scheme.
+func NewSession(key, scheme string) *Session {
+	return &Session{
+		Key:    key,
+		Scheme: scheme,
+		Time:   time.Now(),
+	}
+}
+
+// SessStorer stores authenticated sessions to the storage backend,
+// returning error in case the session could not be stored.
 type SessStorer interface{
-	Store(string) error
+	Store(*Session) error
 }
 
 // SessManager generates a new session key.
@@ -86,16 +130,45 @@ func (m *RandSessionKeyGen) GenerateSessionKey() (*string, error) {
 	return &sessionKey, nil
 }
 
+// RedisSessStorer stores sessions in redis, under their session keys,
+// as JSON objects. Sessions expire after TTL, or never if TTL is 0.
 type RedisSessStorer struct {
 	*redis.Client
+	TTL time.Duration
 }
 
-func NewRedisSessStorer(c *redis.Client) *RedisSessStorer {
+func NewRedisSessStorer(c *redis.Client, ttl time.Duration) *RedisSessStorer {
 	return &RedisSessStorer{
 		Client: c,
+		TTL:    ttl,
+	}
+}
+
+func (s *RedisSessStorer) Store(sess *Session) error {
+	val, err := json.Marshal(sess)
+	if err != nil {
+		return err
 	}
+
+	return s.Client.Set(sess.Key, val, s.TTL).Err()
 }
 
-func (s *RedisSessStorer) Store(key string) error {
-	return s.Client.Set(key, nil, 0).Err()
+// Load returns the session with session key key, or nil if there is
+// no such session, for example because it expired.
+func (s *RedisSessStorer) Load(key string) (*Session, error) {
+	val, err := s.Client.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := json.Unmarshal(val, &sess); err != nil {
+		return nil, fmt.Errorf("invalid session: %v", err)
+	}
+	sess.Key = key
+
+	return &sess, nil
 }
\ No newline at end of file
@@ -18,16 +18,26 @@
 package test
 
 import (
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/spf13/viper"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/emmyzkp/emmy/anauth"
 	"github.com/emmyzkp/emmy/anauth/cl"
@@ -47,54 +57,75 @@ func TestEndToEnd_CL(t *testing.T) {
 			map[string][]string{
 				"org1": {"name", "date_from", "date_to"},
 				"org2": {"gender"},
+				"org3": {"graduated"},
 			},
 			map[string]interface{}{
 				"date_from": map[string]interface{}{
 					"index": 0,
-					"type": "int64",
+					"type": "date",
 					"cond": "gte",
 				},
 				"date_to":   map[string]interface{}{
 					"index": 1,
-					"type": "int64",
+					"type": "date",
 					"cond": "lte",
 				},
 				"name":      map[string]interface{}{
 					"index": 2,
 					"type": "string",
+					"encoding": "hash",
 				},
 				"gender":    map[string]interface{}{
 					"index": 3,
-					"type": "string",
+					"type": "enum",
+					"values": []interface{}{"F", "M", "X"},
 				},
 				"graduated": map[string]interface{}{
 					"index": 4,
 					"type": "string",
+					"cond": "in",
 				},
 				"age": map[string]interface{}{
 					"index": 5,
 					"type":  "int64",
 					"known": "false",
 				},
+				"link_secret": map[string]interface{}{
+					"index":  6,
+					"type":   "int64",
+					"hidden": "true",
+				},
 			},
 			map[string]interface{}{
-				"date_from": int64(1512643000),
-				"date_to": int64(1592643000),
+				"date_from": "today",
+				"date_to": "today",
+				"graduated": []interface{}{"true", "pending"},
 			},
 		},
 	}
 
 	for _, tt := range tests {
-		keys, err := cl.GenerateKeyPair(tt.params, cl.NewAttrCount(5, 1, 0))
-		if err != nil {
-			t.Errorf("error creating keypair: %v", err)
-		}
-
 		v := viper.New()
 		v.Set("acceptable_creds", tt.acceptableCreds)
 		v.Set("attributes", tt.attributes)
+		v.Set("cl_scope", clTestScope)
+		v.Set("cl_verifier_id", clTestVerifier)
+		v.Set("cl_reveal", []string{"gender"})
+		v.Set("cl_purpose", clTestPurpose)
+		v.Set("cl_allow_insecure_params", true)
+
+		// keys are bound to the schema of the attributes
+		schema, _, err := cl.ParseSchema(v)
+		if err != nil {
+			t.Errorf("error parsing attributes: %v", err)
+		}
+		keys, err := cl.GenerateKeyPairForSchema(tt.params, schema)
+		if err != nil {
+			t.Errorf("error creating keypair: %v", err)
+		}
 
-		clSrv, err := cl.NewServer(recDB, keys, v)
+		records := &failingRecordManager{ReceiverRecordManager: recDB}
+		clSrv, err := cl.NewServer(records, keys, v)
 		if err != nil {
 			t.Errorf("error creating cl server: %v", err)
 		}
@@ -108,6 +139,36 @@ func TestEndToEnd_CL(t *testing.T) {
 		clSrv.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
 		clSrv.SessStorer = sessionKeyStore
 		clSrv.DataFetcher = dataStore
+		// only names and committed ages can be changed in
+		// credential updates
+		clSrv.UpdateAuthorizer = cl.UpdateAuthorizerFunc(
+			func(nym *big.Int, changes []*cl.AttrChange) error {
+				for _, c := range changes {
+					if c.Name != "name" &&
+						!(c.Name == "age" && c.Committed) {
+						return fmt.Errorf("%s cannot be changed", c.Name)
+					}
+				}
+				return nil
+			})
+		if err := clSrv.EnableRevocation(
+			cl.NewMockAccumulatorStore()); err != nil {
+			t.Errorf("error enabling revocation: %v", err)
+		}
+
+		// the server also accepts credentials of another issuer
+		// in proofs of several credentials
+		foreign, foreignSchema := newForeignIssuerCL(t, tt.params)
+		foreignAccs := cl.NewMockAccumulatorStore()
+		clSrv.TrustedIssuers = cl.NewKeyring()
+		if err := clSrv.TrustedIssuers.Add("university", foreign.Params,
+			foreign.Keys.Pub, foreignSchema.Attrs); err != nil {
+			t.Errorf("error adding trusted issuer: %v", err)
+		}
+		if err := clSrv.TrustedIssuers.SetAccumulators(foreign.KeyID(),
+			foreignAccs); err != nil {
+			t.Errorf("error setting accumulators of trusted issuer: %v", err)
+		}
 
 		testSrv := newTestSrv()
 		testSrv.addService(clSrv)
@@ -119,7 +180,67 @@ func TestEndToEnd_CL(t *testing.T) {
 		}
 
 		t.Run(tt.desc, func(t *testing.T) {
-			testEndToEndCL(t, conn, sessionKeyStore)
+			testEndToEndCL(t, conn, sessionKeyStore, "key1")
+		})
+
+		// several clients use the same server, each of them with
+		// its own registration key
+		t.Run(tt.desc+"MultipleClients", func(t *testing.T) {
+			runClients(t, *testNClients, *testConcurrent,
+				func(t *testing.T, i int) {
+					testEndToEndCL(t, conn, sessionKeyStore,
+						fmt.Sprintf("%s-cl-key%d", tt.desc, i))
+				})
+		})
+
+		t.Run(tt.desc+"RegisteredAttrs", func(t *testing.T) {
+			testRegisteredAttrsCL(t, conn, fmt.Sprintf("%s-cl-registered",
+				tt.desc))
+		})
+
+		t.Run(tt.desc+"ExpiredReservation", func(t *testing.T) {
+			testExpiredReservationCL(t, conn, fmt.Sprintf("%s-cl-expired",
+				tt.desc))
+		})
+
+		t.Run(tt.desc+"FailedRecord", func(t *testing.T) {
+			testFailedRecordCL(t, conn, records, fmt.Sprintf("%s-cl-failed",
+				tt.desc))
+		})
+
+		t.Run(tt.desc+"UpdateAuthorization", func(t *testing.T) {
+			testUpdateAuthorizationCL(t, conn, fmt.Sprintf("%s-cl-update",
+				tt.desc))
+		})
+
+		t.Run(tt.desc+"Revocation", func(t *testing.T) {
+			testRevocationCL(t, conn, clSrv, fmt.Sprintf("%s-cl-revoked",
+				tt.desc))
+		})
+
+		t.Run(tt.desc+"MultiCred", func(t *testing.T) {
+			testMultiCredCL(t, conn, sessionKeyStore, foreign, foreignAccs,
+				foreignSchema, fmt.Sprintf("%s-cl-multi", tt.desc))
+		})
+
+		t.Run(tt.desc+"ProofRequest", func(t *testing.T) {
+			testProofRequestCL(t, conn, sessionKeyStore,
+				fmt.Sprintf("%s-cl-request", tt.desc))
+		})
+
+		t.Run(tt.desc+"TamperedKey", func(t *testing.T) {
+			testTamperedKeyCL(t, conn, keys.Pub)
+		})
+
+		// keys of the server are rotated, so these have to run last
+		t.Run(tt.desc+"KeyRotation", func(t *testing.T) {
+			testKeyRotationCL(t, conn, clSrv, fmt.Sprintf("%s-cl-rotation",
+				tt.desc))
+		})
+
+		t.Run(tt.desc+"ConcurrentKeyRotation", func(t *testing.T) {
+			testConcurrentKeyRotationCL(t, conn, clSrv,
+				fmt.Sprintf("%s-cl-concurrent-rotation", tt.desc))
 		})
 
 		conn.Close()
@@ -127,18 +248,196 @@ func TestEndToEnd_CL(t *testing.T) {
 	}
 }
 
+// TestEndToEnd_CLVerifier checks that a server without keys of its own
+// verifies credentials of trusted issuers read from a directory, and
+// neither issues nor updates credentials.
+func TestEndToEnd_CLVerifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-cl-trusted")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	params := cl.GetDefaultParamSizes()
+	university, schema := newForeignIssuerCL(t, params)
+	college, _ := newForeignIssuerCL(t, params)
+	// updates of the accumulators published by the issuers
+	universityAccs := cl.NewFileAccumulatorStore(
+		path.Join(dir, "university.acc"))
+	collegeAccs := cl.NewFileAccumulatorStore(path.Join(dir, "college.acc"))
+	for name, org := range map[string]*cl.Org{
+		"university": university,
+		"college":    college,
+	} {
+		require.NoError(t, cl.WritePubKey(path.Join(dir, name+".pub"),
+			&cl.KeyPair{
+				Pub:    org.Keys.Pub,
+				Params: org.Params,
+				Schema: schema.CredStructure(),
+			}))
+		// no credentials were issued yet
+		require.NoError(t, ioutil.WriteFile(path.Join(dir, name+".acc"),
+			[]byte("[]"), 0644))
+	}
+	// the verifier only accepts master degrees of the university
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "university.yml"),
+		[]byte(`
+name: diploma
+version: 1
+attributes:
+  - name: degree
+    type: string
+    cond: equal
+  - name: link_secret
+    type: int64
+    disclosure: hidden
+`), 0644))
+
+	keyring, err := cl.ReadKeyring(dir)
+	require.NoError(t, err)
+	v := viper.New()
+	v.Set("cl_scope", clTestScope)
+	v.Set("cl_verifier_id", clTestVerifier)
+	v.Set("cl_allow_insecure_params", true)
+	v.Set("cl_reveal", []string{"degree"})
+	clSrv, err := cl.NewVerifierServer(keyring, v)
+	require.NoError(t, err)
+
+	dataStore := &testFetcher{}
+	dataStore.fillWith(map[string]interface{}{"degree": "MSc"})
+	sessionKeyStore := newTestStore()
+	clSrv.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
+	clSrv.SessStorer = sessionKeyStore
+	clSrv.DataFetcher = dataStore
+
+	testSrv := newTestSrv()
+	testSrv.addService(clSrv)
+	go testSrv.start()
+	defer testSrv.teardown()
+
+	conn, err := getTestConn()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := cl.NewClient(conn)
+	client.VerifierID = clTestVerifier
+	pubParams, err := client.GetPublicParams()
+	require.NoError(t, err)
+	assert.Nil(t, pubParams.PubKey)
+	require.Len(t, pubParams.TrustedIssuers, 2)
+	assert.Equal(t, "college", pubParams.TrustedIssuers[0].Name)
+
+	// the verifier holds no secret keys
+	issueStream, err := client.AnonCredsClient.Issue(context.Background())
+	require.NoError(t, err)
+	_, err = issueStream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	updateStream, err := client.AnonCredsClient.Update(context.Background())
+	require.NoError(t, err)
+	_, err = updateStream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// the key of the issuer is chosen by the key of the credential
+	cm, cred := issueForeignCredCL(t, university, universityAccs, schema,
+		"MSc", 24681357)
+	sessKey, err := client.ProveCredential(cm, cred, []string{"degree"})
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+	scopeNym, err := cm.ScopePseudonym(clTestScope)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(scopeNym.Bytes()),
+		sessionKeyStore.nym(*sessKey))
+
+	// degree is revealed without asking, as the condition over it
+	// cannot be proved otherwise
+	sessKey, err = client.ProveCredential(cm, cred, nil)
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+
+	bsc, bscCred := issueForeignCredCL(t, university, universityAccs, schema,
+		"BSc", 13572468)
+	_, err = client.ProveCredential(bsc, bscCred, []string{"degree"})
+	assert.Error(t, err)
+
+	ccm, ccred := issueForeignCredCL(t, college, collegeAccs, schema, "BSc",
+		24681357)
+	sessKey, err = client.ProveCredential(ccm, ccred, []string{"degree"})
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+
+	// the degree is only required from the first credential
+	sessKey, err = client.ProveCredentials([]*cl.CredPresentation{
+		{Manager: cm, Cred: cred},
+		{Manager: ccm, Cred: ccred},
+	})
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+	assert.Equal(t, "MSc", sessionKeyStore.session(*sessKey).Attrs["degree"])
+
+	// the degree of the session cannot be told apart if both
+	// credentials reveal it
+	_, err = client.ProveCredentials([]*cl.CredPresentation{
+		{Manager: cm, Cred: cred},
+		{Manager: ccm, Cred: ccred, RevealedAttrs: []string{"degree"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// credential of an issuer that is not trusted
+	untrusted, _ := newForeignIssuerCL(t, params)
+	ucm, ucred := issueForeignCredCL(t, untrusted,
+		cl.NewMockAccumulatorStore(), schema, "MSc", 24681357)
+	_, err = client.ProveCredential(ucm, ucred, []string{"degree"})
+	assert.Error(t, err)
+
+	// the witness is updated with the updates mirrored by the verifier
+	// once the university revokes a credential
+	revoker, err := cl.NewRevoker(university, universityAccs)
+	require.NoError(t, err)
+	require.NoError(t, revoker.Remove(bscCred.E))
+	sessKey, err = client.ProveCredential(cm, cred, []string{"degree"})
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+	_, err = client.ProveCredential(bsc, bscCred, []string{"degree"})
+	assert.Error(t, err)
+
+	// credentials are not accepted without the accumulator of the issuer
+	require.NoError(t, os.Remove(path.Join(dir, "college.acc")))
+	_, err = client.ProveCredential(ccm, ccred, []string{"degree"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// testTamperedKeyCL checks that clients refuse a public key with a
+// value outside of the subgroup generated by S.
+func testTamperedKeyCL(t *testing.T, conn *grpc.ClientConn, pk *cl.PubKey) {
+	z := pk.Z
+	pk.Z = new(big.Int).Sub(pk.N, big.NewInt(1))
+	defer func() { pk.Z = z }()
+
+	_, err := cl.NewClient(conn).GetPublicParams()
+	assert.Error(t, err)
+}
+
+// clTestScope is the scope of pseudonyms presented by clients.
+const clTestScope = "emmy-test"
+
+// clTestVerifier is the identity of the server, which proofs are bound
+// to.
+const clTestVerifier = "emmy-test-verifier"
+
+// clTestPurpose is the purpose of proofs that the server requests.
+const clTestPurpose = "emmy end-to-end tests"
+
 // TestCL requires a running server.
 func testEndToEndCL(t *testing.T, conn *grpc.ClientConn,
-	sessionKeyStore *testStorer) {
+	sessionKeyStore *testStorer, regKey string) {
 	client := cl.NewClient(conn)
+	client.VerifierID = clTestVerifier
 
 	params, err := client.GetPublicParams()
 	require.NoError(t, err)
 
 	rc := params.RawCred
-	err = rc.UpdateAttr("date_from", 1512643000)
+	err = rc.UpdateAttr("date_from", "2017-12-07")
 	assert.NoError(t, err)
-	err = rc.UpdateAttr("date_to", 1592643000)
+	err = rc.UpdateAttr("date_to", "2030-06-20")
 	assert.NoError(t, err)
 	err = rc.UpdateAttr("name", "Jack")
 	assert.NoError(t, err)
@@ -148,6 +447,9 @@ func testEndToEndCL(t *testing.T, conn *grpc.ClientConn,
 	assert.NoError(t, err)
 	err = rc.UpdateAttr("age", 50)
 	assert.NoError(t, err)
+	// the issuer never learns the value of a hidden attribute
+	err = rc.UpdateAttr("link_secret", 123456789)
+	assert.NoError(t, err)
 
 	acceptableCreds, err := client.GetAcceptableCreds()
 	require.NoError(t, err)
@@ -163,8 +465,14 @@ func testEndToEndCL(t *testing.T, conn *grpc.ClientConn,
 	cm, err := cl.NewCredManager(params.Config, pubKey, masterSecret, rc)
 	require.NoError(t, err)
 
-	regKey := "key1"
-	regKeyDB.Insert(regKey)
+	// the issuer registers values of Known attributes with the key
+	regKeyDB.InsertWithData(regKey, map[string]interface{}{
+		"date_from": "2017-12-07",
+		"date_to":   "2030-06-20",
+		"name":      "Jack",
+		"gender":    "M",
+		"graduated": "true",
+	})
 	cred, err := client.IssueCredential(cm, regKey)
 	require.NoError(t, err)
 
@@ -173,11 +481,48 @@ func testEndToEndCL(t *testing.T, conn *grpc.ClientConn,
 	cm, err = cl.RestoreCredManager(cm.GetContext(), masterSecret, rc)
 	require.NoError(t, err)
 
+	// hidden attributes can never be revealed
+	_, err = client.ProveCredential(cm, cred, []string{"link_secret"})
+	assert.Error(t, err)
+
+	// the client refuses to prove to an unexpected verifier
+	other := cl.NewClient(conn)
+	other.VerifierID = "other-verifier"
+	_, err = other.ProveCredential(cm, cred, revealedAttrs)
+	assert.Error(t, err)
+
 	sessKey, err := client.ProveCredential(cm, cred, revealedAttrs)
 	require.NoError(t, err)
 	assert.NotNil(t, sessKey, "possesion of a credential proof failed")
 	assert.True(t, sessionKeyStore.contains(*sessKey))
 
+	// the session holds values of revealed attributes, name only by
+	// its hash
+	sess := sessionKeyStore.session(*sessKey)
+	assert.Equal(t, "M", sess.Attrs["gender"])
+	assert.IsType(t, time.Time{}, sess.Attrs["date_from"])
+	assert.IsType(t, "", sess.Attrs["name"])
+	assert.NotEqual(t, "Jack", sess.Attrs["name"])
+
+	// the verifier identifies the client by its scope pseudonym
+	scopeNym, err := cm.ScopePseudonym(clTestScope)
+	require.NoError(t, err)
+	nym := hex.EncodeToString(scopeNym.Bytes())
+	assert.Equal(t, nym, sessionKeyStore.nym(*sessKey))
+
+	// conditions over date_from and date_to are proved without
+	// revealing the attributes
+	sessKey, err = client.ProveCredential(cm, cred, acceptableCreds["org2"])
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+	assert.Equal(t, nym, sessionKeyStore.nym(*sessKey))
+
+	// membership of graduated in the reference set is checked by the
+	// verifier when the attribute is revealed
+	sessKey, err = client.ProveCredential(cm, cred, acceptableCreds["org3"])
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+
 	// modify some attributes and get updated credential
 	err = rc.UpdateAttr("name", "Jim")
 	assert.NoError(t, err)
@@ -189,6 +534,593 @@ func testEndToEndCL(t *testing.T, conn *grpc.ClientConn,
 	require.NoError(t, err)
 	assert.NotNil(t, sessKey, "possesion of an updated credential proof failed")
 	assert.True(t, sessionKeyStore.contains(*sessKey))
+	assert.Equal(t, nym, sessionKeyStore.nym(*sessKey))
+}
+
+// testRegisteredAttrsCL checks that a credential is not issued when
+// Known attributes differ from the ones registered with the
+// registration key, and that a failed issuance does not use up the key.
+func testRegisteredAttrsCL(t *testing.T, conn *grpc.ClientConn,
+	regKey string) {
+	client := cl.NewClient(conn)
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "John"))
+	require.NoError(t, rc.UpdateAttr("gender", "M"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 20))
+	require.NoError(t, rc.UpdateAttr("link_secret", 111111111))
+
+	cm, err := cl.NewCredManager(params.Config, params.PubKey,
+		params.PubKey.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+
+	regAttrs := map[string]interface{}{
+		"date_from": "2017-12-07",
+		"date_to":   "2030-06-20",
+		"name":      "Jack",
+		"gender":    "M",
+		"graduated": "true",
+	}
+	regKeyDB.InsertWithData(regKey, regAttrs)
+	_, err = client.IssueCredential(cm, regKey)
+	assert.Error(t, err)
+
+	// the registration key is not used up by the failed issuance
+	require.NoError(t, rc.UpdateAttr("name", "Jack"))
+	jack, err := cl.NewCredManager(params.Config, params.PubKey,
+		params.PubKey.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+	_, err = client.IssueCredential(jack, regKey)
+	require.NoError(t, err)
+	_, err = client.IssueCredential(jack, regKey)
+	assert.Error(t, err)
+	require.NoError(t, rc.UpdateAttr("name", "John"))
+
+	// not all Known attributes are registered
+	delete(regAttrs, "gender")
+	regAttrs["name"] = "John"
+	regKeyDB.InsertWithData(regKey+"-partial", regAttrs)
+	_, err = client.IssueCredential(cm, regKey+"-partial")
+	assert.Error(t, err)
+}
+
+// testExpiredReservationCL checks that no credential is recorded when
+// the reservation of the registration key times out during issuance,
+// and that the key can then be used again.
+func testExpiredReservationCL(t *testing.T, conn *grpc.ClientConn,
+	regKey string) {
+	client := cl.NewClient(conn)
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "Eve"))
+	require.NoError(t, rc.UpdateAttr("gender", "F"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 35))
+	require.NoError(t, rc.UpdateAttr("link_secret", 555555555))
+
+	cm, err := cl.NewCredManager(params.Config, params.PubKey,
+		params.PubKey.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+	regKeyDB.Insert(regKey)
+
+	timeout := anauth.RegKeyReservationTimeout
+	anauth.RegKeyReservationTimeout = time.Millisecond
+	_, err = client.IssueCredential(cm, regKey)
+	anauth.RegKeyReservationTimeout = timeout
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	_, err = recDB.Load(cm.Nym)
+	assert.Error(t, err)
+
+	_, err = client.IssueCredential(cm, regKey)
+	require.NoError(t, err)
+	_, err = recDB.Load(cm.Nym)
+	assert.NoError(t, err)
+}
+
+// testFailedRecordCL checks that a credential whose receiver record
+// cannot be stored is withdrawn, and that the registration key can then
+// be used again.
+func testFailedRecordCL(t *testing.T, conn *grpc.ClientConn,
+	records *failingRecordManager, regKey string) {
+	client := cl.NewClient(conn)
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "Ann"))
+	require.NoError(t, rc.UpdateAttr("gender", "F"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 45))
+	require.NoError(t, rc.UpdateAttr("link_secret", 313131313))
+
+	cm, err := cl.NewCredManager(params.Config, params.PubKey,
+		params.PubKey.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+	regKeyDB.Insert(regKey)
+
+	atomic.StoreInt32(&records.fail, 1)
+	_, err = client.IssueCredential(cm, regKey)
+	atomic.StoreInt32(&records.fail, 0)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	_, err = recDB.Load(cm.Nym)
+	assert.Error(t, err)
+
+	cred, err := client.IssueCredential(cm, regKey)
+	require.NoError(t, err)
+	_, err = recDB.Load(cm.Nym)
+	assert.NoError(t, err)
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	assert.NoError(t, err)
+}
+
+// testUpdateAuthorizationCL checks that only the holder of a credential
+// can update it, and only with changes approved by the issuer.
+func testUpdateAuthorizationCL(t *testing.T, conn *grpc.ClientConn,
+	regKey string) {
+	client := cl.NewClient(conn)
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "Jane"))
+	require.NoError(t, rc.UpdateAttr("gender", "F"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 30))
+	require.NoError(t, rc.UpdateAttr("link_secret", 123123123))
+
+	masterSecret := params.PubKey.GenerateUserMasterSecret()
+	cm, err := cl.NewCredManager(params.Config, params.PubKey, masterSecret,
+		rc)
+	require.NoError(t, err)
+
+	regKeyDB.Insert(regKey)
+	_, err = client.IssueCredential(cm, regKey)
+	require.NoError(t, err)
+
+	// someone who knows the nym but not its opening
+	other, err := cl.NewCredManager(params.Config, params.PubKey,
+		params.PubKey.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+	other.Nym = cm.Nym
+	_, err = client.UpdateCredential(other, rc)
+	assert.Error(t, err)
+
+	// change that is not approved by the issuer
+	require.NoError(t, rc.UpdateAttr("gender", "M"))
+	_, err = client.UpdateCredential(cm, rc)
+	assert.Error(t, err)
+
+	require.NoError(t, rc.UpdateAttr("gender", "F"))
+	require.NoError(t, rc.UpdateAttr("name", "Joan"))
+	cred, err := client.UpdateCredential(cm, rc)
+	require.NoError(t, err)
+
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	assert.NoError(t, err)
+
+	// committed attribute is changed with a new commitment
+	require.NoError(t, rc.UpdateAttr("age", 31))
+	cred, err = client.UpdateCredential(cm, rc)
+	require.NoError(t, err)
+
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	assert.NoError(t, err)
+}
+
+// newForeignIssuerCL returns an issuer other than the server, with
+// the schema of its credentials.
+func newForeignIssuerCL(t *testing.T, params *pb.Params) (*cl.Org,
+	*cl.Schema) {
+	schema, err := cl.DecodeSchema([]byte(`
+name: diploma
+version: 1
+attributes:
+  - name: degree
+    type: string
+  - name: link_secret
+    type: int64
+    disclosure: hidden
+`))
+	require.NoError(t, err)
+
+	keys, err := cl.GenerateKeyPair(params, schema.Count)
+	require.NoError(t, err)
+	org, err := cl.NewOrgFromParams(params, keys)
+	require.NoError(t, err)
+
+	return org, schema
+}
+
+// issueForeignCredCL issues a credential of issuer org with the given
+// degree and link secret, without a server, adding it to the
+// accumulator with updates in accs.
+func issueForeignCredCL(t *testing.T, org *cl.Org, accs cl.AccumulatorStore,
+	schema *cl.Schema, degree string, linkSecret int) (*cl.CredManager,
+	*cl.Cred) {
+	rc, err := cl.NewRawCredFromStructure(schema.CredStructure())
+	require.NoError(t, err)
+	require.NoError(t, rc.UpdateAttr("degree", degree))
+	require.NoError(t, rc.UpdateAttr("link_secret", linkSecret))
+
+	cm, err := cl.NewCredManager(org.Params, org.Keys.Pub,
+		org.Keys.Pub.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+
+	issuer := org.NewCredIssuer()
+	cr, err := cm.GetCredRequest(issuer.GetNonce())
+	require.NoError(t, err)
+	res, err := issuer.IssueCred(cr)
+	require.NoError(t, err)
+	ok, err := cm.Verify(res.Cred, res.AProof)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	revoker, err := cl.NewRevoker(org, accs)
+	require.NoError(t, err)
+	res.Cred.Witness, err = revoker.Add(res.Cred.E)
+	require.NoError(t, err)
+
+	return cm, res.Cred
+}
+
+// testMultiCredCL checks that a credential of the server and
+// a credential of a trusted issuer are proved together only if they
+// share the link secret.
+func testMultiCredCL(t *testing.T, conn *grpc.ClientConn,
+	sessionKeyStore *testStorer, foreign *cl.Org,
+	foreignAccs cl.AccumulatorStore, foreignSchema *cl.Schema,
+	regKey string) {
+	client := cl.NewClient(conn)
+	client.VerifierID = clTestVerifier
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "Mary"))
+	require.NoError(t, rc.UpdateAttr("gender", "F"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 25))
+	require.NoError(t, rc.UpdateAttr("link_secret", 987654321))
+
+	cm, err := cl.NewCredManager(params.Config, params.PubKey,
+		params.PubKey.GenerateUserMasterSecret(), rc)
+	require.NoError(t, err)
+	regKeyDB.Insert(regKey)
+	cred, err := client.IssueCredential(cm, regKey)
+	require.NoError(t, err)
+
+	fcm, fcred := issueForeignCredCL(t, foreign, foreignAccs, foreignSchema,
+		"MSc", 987654321)
+	creds := []*cl.CredPresentation{
+		{Manager: cm, Cred: cred, RevealedAttrs: []string{"name"}},
+		{Manager: fcm, Cred: fcred, RevealedAttrs: []string{"degree"}},
+	}
+	sessKey, err := client.ProveCredentials(creds)
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+
+	// the scope pseudonym is the one of the first credential
+	scopeNym, err := cm.ScopePseudonym(clTestScope)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(scopeNym.Bytes()),
+		sessionKeyStore.nym(*sessKey))
+
+	// credential with another link secret
+	fcm, fcred = issueForeignCredCL(t, foreign, foreignAccs, foreignSchema,
+		"MSc", 123456789)
+	creds[1] = &cl.CredPresentation{Manager: fcm, Cred: fcred}
+	_, err = client.ProveCredentials(creds)
+	assert.Error(t, err)
+
+	// credential of an issuer that the server does not trust
+	untrusted, _ := newForeignIssuerCL(t, params.Config)
+	fcm, fcred = issueForeignCredCL(t, untrusted,
+		cl.NewMockAccumulatorStore(), foreignSchema, "MSc", 987654321)
+	creds[1] = &cl.CredPresentation{Manager: fcm, Cred: fcred}
+	_, err = client.ProveCredentials(creds)
+	assert.Error(t, err)
+}
+
+// testProofRequestCL checks that clients build proofs according to
+// the proof request of the server, once the holder consents to it.
+func testProofRequestCL(t *testing.T, conn *grpc.ClientConn,
+	sessionKeyStore *testStorer, regKey string) {
+	client := cl.NewClient(conn)
+	client.VerifierID = clTestVerifier
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	issue := func(dateTo, regKey string) (*cl.CredManager, *cl.Cred) {
+		rc := params.RawCred
+		require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+		require.NoError(t, rc.UpdateAttr("date_to", dateTo))
+		require.NoError(t, rc.UpdateAttr("name", "Anne"))
+		require.NoError(t, rc.UpdateAttr("gender", "F"))
+		require.NoError(t, rc.UpdateAttr("graduated", "pending"))
+		require.NoError(t, rc.UpdateAttr("age", 30))
+		require.NoError(t, rc.UpdateAttr("link_secret", 192837465))
+
+		cm, err := cl.NewCredManager(params.Config, params.PubKey,
+			params.PubKey.GenerateUserMasterSecret(), rc)
+		require.NoError(t, err)
+		regKeyDB.Insert(regKey)
+		cred, err := client.IssueCredential(cm, regKey)
+		require.NoError(t, err)
+
+		return cm, cred
+	}
+	cm, cred := issue("2030-06-20", regKey)
+
+	var req *cl.ProofRequest
+	client.Consent = func(r *cl.ProofRequest) error {
+		req = r
+		return nil
+	}
+	// gender is revealed as the server requests
+	sessKey, err := client.ProveCredential(cm, cred, nil)
+	require.NoError(t, err)
+	assert.True(t, sessionKeyStore.contains(*sessKey))
+
+	// the session holds the revealed attributes
+	sess := sessionKeyStore.session(*sessKey)
+	assert.Equal(t, anauth.SchemeCL, sess.Scheme)
+	assert.Equal(t, []string{params.PubKey.ID()}, sess.KeyIDs)
+	assert.Equal(t, map[string]interface{}{"gender": "F"}, sess.Attrs)
+
+	require.NotNil(t, req)
+	assert.Equal(t, clTestPurpose, req.Purpose)
+	assert.Equal(t, clTestVerifier, req.Verifier)
+	assert.Equal(t, clTestScope, req.Scope)
+	assert.Equal(t, []string{"gender"}, req.RevealedAttrs)
+	var attrs []string
+	for _, p := range req.Predicates {
+		attrs = append(attrs, p.Attr)
+	}
+	assert.ElementsMatch(t, []string{"date_from", "date_to", "graduated"},
+		attrs)
+	var keyIDs []string
+	for _, i := range req.AcceptedIssuers {
+		keyIDs = append(keyIDs, i.KeyID)
+	}
+	assert.Contains(t, keyIDs, params.PubKey.ID())
+
+	// the holder refuses the request
+	client.Consent = func(r *cl.ProofRequest) error {
+		return fmt.Errorf("not now")
+	}
+	_, err = client.ProveCredential(cm, cred, nil)
+	assert.Error(t, err)
+
+	// the holder is not asked for consent to a request that
+	// the credential does not satisfy
+	expired, expiredCred := issue("2017-12-08", regKey+"-expired")
+	client.Consent = func(r *cl.ProofRequest) error {
+		t.Error("consent asked for an unsatisfiable request")
+		return nil
+	}
+	_, err = client.ProveCredential(expired, expiredCred, nil)
+	assert.Error(t, err)
+}
+
+// testRevocationCL checks that a revoked credential can no longer be
+// proved nor updated.
+func testRevocationCL(t *testing.T, conn *grpc.ClientConn, srv *cl.Server,
+	regKey string) {
+	client := cl.NewClient(conn)
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "Joe"))
+	require.NoError(t, rc.UpdateAttr("gender", "M"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 40))
+	require.NoError(t, rc.UpdateAttr("link_secret", 987654321))
+
+	masterSecret := params.PubKey.GenerateUserMasterSecret()
+	cm, err := cl.NewCredManager(params.Config, params.PubKey, masterSecret,
+		rc)
+	require.NoError(t, err)
+
+	regKeyDB.Insert(regKey)
+	cred, err := client.IssueCredential(cm, regKey)
+	require.NoError(t, err)
+	require.NotNil(t, cred.Witness)
+
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	require.NoError(t, err)
+
+	require.NoError(t, srv.Revoke(cm.Nym))
+	assert.Error(t, srv.Revoke(cm.Nym))
+
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	assert.Equal(t, cl.ErrRevoked, err)
+	assert.Equal(t, cl.ErrRevoked, client.UpdateWitness(cm, cred))
+
+	_, err = client.UpdateCredential(cm, rc)
+	assert.Error(t, err)
+}
+
+// testKeyRotationCL checks that credentials issued with retired keys
+// are accepted until the keys expire, and that they are re-issued with
+// the active keys in updates.
+func testKeyRotationCL(t *testing.T, conn *grpc.ClientConn, srv *cl.Server,
+	regKey string) {
+	client := cl.NewClient(conn)
+
+	params, err := client.GetPublicParams()
+	require.NoError(t, err)
+	require.Empty(t, params.RetiredKeys)
+
+	rc := params.RawCred
+	require.NoError(t, rc.UpdateAttr("date_from", "2017-12-07"))
+	require.NoError(t, rc.UpdateAttr("date_to", "2030-06-20"))
+	require.NoError(t, rc.UpdateAttr("name", "Jane"))
+	require.NoError(t, rc.UpdateAttr("gender", "F"))
+	require.NoError(t, rc.UpdateAttr("graduated", "true"))
+	require.NoError(t, rc.UpdateAttr("age", 30))
+	require.NoError(t, rc.UpdateAttr("link_secret", 192837465))
+
+	masterSecret := params.PubKey.GenerateUserMasterSecret()
+	cm, err := cl.NewCredManager(params.Config, params.PubKey, masterSecret,
+		rc)
+	require.NoError(t, err)
+
+	regKeyDB.Insert(regKey)
+	cred, err := client.IssueCredential(cm, regKey)
+	require.NoError(t, err)
+
+	attrCount := cl.NewAttrCount(5, 1, 1)
+	keys, err := cl.GenerateNextKeyPair(params.Config, attrCount,
+		params.PubKey)
+	require.NoError(t, err)
+	require.NoError(t, srv.RotateKeys(keys, time.Hour,
+		cl.NewMockAccumulatorStore()))
+
+	rotated, err := client.GetPublicParams()
+	require.NoError(t, err)
+	assert.Equal(t, keys.Pub.ID(), rotated.PubKey.ID())
+	require.Len(t, rotated.RetiredKeys, 1)
+	assert.Equal(t, params.PubKey.ID(), rotated.RetiredKeys[0].PubKey.ID())
+
+	// the credential is accepted within the grace period
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	require.NoError(t, err)
+
+	// and is re-issued with the active keys in an update
+	cred, err = client.UpdateCredential(cm, rc)
+	require.NoError(t, err)
+	assert.Equal(t, keys.Pub.ID(), cm.PubKey.ID())
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	require.NoError(t, err)
+
+	// the keys that the credential was re-issued with expire
+	// immediately after they are retired
+	next, err := cl.GenerateNextKeyPair(params.Config, attrCount,
+		params.PubKey)
+	require.NoError(t, err)
+	require.NoError(t, srv.RotateKeys(next, -time.Second,
+		cl.NewMockAccumulatorStore()))
+
+	rotated, err = client.GetPublicParams()
+	require.NoError(t, err)
+	require.Len(t, rotated.RetiredKeys, 1)
+	assert.Equal(t, params.PubKey.ID(), rotated.RetiredKeys[0].PubKey.ID())
+
+	_, err = client.ProveCredential(cm, cred, []string{"name"})
+	assert.Error(t, err)
+	_, err = client.UpdateCredential(cm, rc)
+	assert.Error(t, err)
+	assert.Equal(t, keys.Pub.ID(), cm.PubKey.ID())
+}
+
+// testConcurrentKeyRotationCL checks that keys of the server can be
+// rotated while clients obtain public parameters and credentials. It is
+// meant to be run with -race.
+func testConcurrentKeyRotationCL(t *testing.T, conn *grpc.ClientConn,
+	srv *cl.Server, regKey string) {
+	params, err := cl.NewClient(conn).GetPublicParams()
+	require.NoError(t, err)
+
+	// keys are generated in advance, so that rotations overlap with
+	// issuance
+	attrCount := cl.NewAttrCount(5, 1, 1)
+	next := make([]*cl.KeyPair, 2)
+	for i := range next {
+		next[i], err = cl.GenerateNextKeyPair(params.Config, attrCount,
+			params.PubKey)
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// a credential requested for keys that were rotated
+			// meanwhile is not accepted by the client, which then
+			// asks again with the active keys
+			client := cl.NewClient(conn)
+			var err error
+			for attempt := 0; attempt < 5; attempt++ {
+				var params *cl.PubParams
+				if params, err = client.GetPublicParams(); err != nil {
+					continue
+				}
+				rc := params.RawCred
+				rc.UpdateAttr("date_from", "2017-12-07")
+				rc.UpdateAttr("date_to", "2030-06-20")
+				rc.UpdateAttr("name", "Max")
+				rc.UpdateAttr("gender", "M")
+				rc.UpdateAttr("graduated", "true")
+				rc.UpdateAttr("age", 25)
+				rc.UpdateAttr("link_secret", 246813579+i)
+
+				var cm *cl.CredManager
+				cm, err = cl.NewCredManager(params.Config, params.PubKey,
+					params.PubKey.GenerateUserMasterSecret(), rc)
+				if err != nil {
+					continue
+				}
+				key := fmt.Sprintf("%s-%d-%d", regKey, i, attempt)
+				regKeyDB.Insert(key)
+				if _, err = client.IssueCredential(cm, key); err == nil {
+					break
+				}
+			}
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	for _, keys := range next {
+		require.NoError(t, srv.RotateKeys(keys, time.Hour,
+			cl.NewMockAccumulatorStore()))
+	}
+	wg.Wait()
+
+	rotated, err := cl.NewClient(conn).GetPublicParams()
+	require.NoError(t, err)
+	assert.Equal(t, next[len(next)-1].Pub.ID(), rotated.PubKey.ID())
+}
+
+// failingRecordManager fails to store receiver records while fail
+// is set.
+type failingRecordManager struct {
+	cl.ReceiverRecordManager
+	fail int32
+}
+
+func (m *failingRecordManager) Store(nym *big.Int,
+	rec *cl.ReceiverRecord) error {
+	if atomic.LoadInt32(&m.fail) != 0 {
+		return fmt.Errorf("receiver record cannot be stored")
+	}
+	return m.ReceiverRecordManager.Store(nym, rec)
 }
 
 type testFetcher struct {
@@ -201,31 +1133,45 @@ func (f *testFetcher) FetchAttrData() (map[string]interface{}, error) {
 	return f.data, nil
 }
 
-type testSessStore interface {
-	anauth.SessStorer
-	contains(string) bool
-}
-
 type testStorer struct {
-	data []string
+	sessions map[string]*anauth.Session
+	mu       sync.Mutex
 }
+
 func newTestStore() *testStorer {
 	return &testStorer{
-		data: make([]string, 0),
+		sessions: make(map[string]*anauth.Session),
 	}
 }
-func (s *testStorer) Store(k string) error {
-	s.data = append(s.data, k)
+
+func (s *testStorer) Store(sess *anauth.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sess.Key] = sess
 	return nil
 }
 
-func (s *testStorer) contains(key string) bool {
-	for _, k := range s.data {
-		if key == k {
-			return true
-		}
+// session returns the session stored with key, or nil.
+func (s *testStorer) session(key string) *anauth.Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sessions[key]
+}
+
+// nym returns the scope pseudonym stored with key for clTestScope.
+func (s *testStorer) nym(key string) string {
+	sess := s.session(key)
+	if sess == nil || sess.Scope != clTestScope {
+		return ""
 	}
-	return false
+
+	return sess.Nym
+}
+
+func (s *testStorer) contains(key string) bool {
+	return s.session(key) != nil
 }
 
 func intsToBig(s ...int) []*big.Int {
@@ -18,6 +18,7 @@
 package test
 
 import (
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/emmyzkp/emmy/anauth/ecpsys"
 	"github.com/emmyzkp/emmy/anauth/psys"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEndToEnd_ECPsys(t *testing.T) {
@@ -55,6 +57,8 @@ func TestEndToEnd_ECPsys(t *testing.T) {
 		// FIXME
 		org.RegMgr = regKeyDB
 		org.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
+		sessionKeyStore := newTestStore()
+		org.SessStorer = sessionKeyStore
 
 		testSrv := newTestSrv()
 		testSrv.addService(ca)
@@ -67,7 +71,17 @@ func TestEndToEnd_ECPsys(t *testing.T) {
 		}
 
 		t.Run(tt.desc, func(t *testing.T) {
-			testEndToEndECPsys(t, conn, tt.curve, pk)
+			testEndToEndECPsys(t, conn, sessionKeyStore, tt.curve, pk, "ecKey")
+		})
+
+		// several clients use the same server, each of them with
+		// its own registration keys
+		t.Run(tt.desc+"MultipleClients", func(t *testing.T) {
+			runClients(t, *testNClients, *testConcurrent,
+				func(t *testing.T, i int) {
+					testEndToEndECPsys(t, conn, sessionKeyStore, tt.curve, pk,
+						fmt.Sprintf("%s-ecpsys-client%d-key", tt.desc, i))
+				})
 		})
 
 		conn.Close()
@@ -75,8 +89,12 @@ func TestEndToEnd_ECPsys(t *testing.T) {
 	}
 }
 
-func testEndToEndECPsys(t *testing.T, conn *grpc.ClientConn, c ec.Curve,
-	pk *ecpsys.PubKey) {
+// testEndToEndECPsys runs the protocols of the scheme against the server
+// at conn. Registration keys used by the client are prefixed with
+// regKeyPrefix.
+func testEndToEndECPsys(t *testing.T, conn *grpc.ClientConn,
+	sessionKeyStore *testStorer, c ec.Curve, pk *ecpsys.PubKey,
+	regKeyPrefix string) {
 
 	caClient := ecpsys.NewCAClient(c)
 
@@ -96,11 +114,11 @@ func testEndToEndECPsys(t *testing.T, conn *grpc.ClientConn, c ec.Curve,
 	_, err = c1.GenerateNym(userSecret, caCert, "029uywfh9udni")
 	assert.NotNil(t, err, "Should produce an error")
 
-	regKey := "ecKey1"
+	regKey := regKeyPrefix + "1"
 	regKeyDB.Insert(regKey)
 	nym1, err := c1.GenerateNym(userSecret, caCert, regKey)
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 
 	//nym generation should fail the second time with the same registration key
@@ -111,7 +129,7 @@ func testEndToEndECPsys(t *testing.T, conn *grpc.ClientConn, c ec.Curve,
 
 	cred, err := c1.ObtainCredential(userSecret, nym1, pk)
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 
 	// register with org2
@@ -123,17 +141,20 @@ func testEndToEndECPsys(t *testing.T, conn *grpc.ClientConn, c ec.Curve,
 	// using transferCredential to authenticate with the same organization and not
 	// transferring credentials to another organization
 	c2, _ := ecpsys.NewClient(conn, c)
-	regKey = "ecKey2"
+	regKey = regKeyPrefix + "2"
 	regKeyDB.Insert(regKey)
 	nym2, err := c2.GenerateNym(userSecret, caCert1, regKey)
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 
 	// Authentication should succeed
 	sessKey1, err := c2.TransferCredential(orgName, userSecret, nym2, cred)
-	assert.NotNil(t, sessKey1, "Should authenticate and obtain a valid (non-nil) session key")
+	require.NotNil(t, sessKey1, "Should authenticate and obtain a valid (non-nil) session key")
 	assert.Nil(t, err, "Should not produce an error")
+	sess := sessionKeyStore.session(*sessKey1)
+	require.NotNil(t, sess)
+	assert.Equal(t, anauth.SchemeECPsys, sess.Scheme)
 
 	// Authentication should fail because the user doesn't have the right secret
 	wrongUserSecret := big.NewInt(3952123123)
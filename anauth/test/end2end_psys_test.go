@@ -29,6 +29,7 @@ import (
 	"github.com/emmyzkp/emmy/anauth"
 	"github.com/emmyzkp/emmy/anauth/psys"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 )
 
@@ -53,6 +54,8 @@ func TestEndToEnd_Psys(t *testing.T) {
 		// FIXME
 		org.RegMgr = regKeyDB
 		org.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
+		sessionKeyStore := newTestStore()
+		org.SessStorer = sessionKeyStore
 
 		testSrv := newTestSrv()
 		testSrv.addService(ca)
@@ -65,7 +68,17 @@ func TestEndToEnd_Psys(t *testing.T) {
 		}
 
 		t.Run(fmt.Sprintf("qBitLen%d", tt), func(t *testing.T) {
-			testEndToEndPsys(t, conn, g, pk)
+			testEndToEndPsys(t, conn, sessionKeyStore, g, pk, "key")
+		})
+
+		// several clients use the same server, each of them with
+		// its own registration keys
+		t.Run(fmt.Sprintf("qBitLen%dMultipleClients", tt), func(t *testing.T) {
+			runClients(t, *testNClients, *testConcurrent,
+				func(t *testing.T, i int) {
+					testEndToEndPsys(t, conn, sessionKeyStore, g, pk,
+						fmt.Sprintf("qBitLen%d-psys-client%d-key", tt, i))
+				})
 		})
 
 		conn.Close()
@@ -73,8 +86,12 @@ func TestEndToEnd_Psys(t *testing.T) {
 	}
 }
 
-func testEndToEndPsys(t *testing.T, conn *grpc.ClientConn, g *schnorr.Group,
-	pk *psys.PubKey) {
+// testEndToEndPsys runs the protocols of the scheme against the server
+// at conn. Registration keys used by the client are prefixed with
+// regKeyPrefix.
+func testEndToEndPsys(t *testing.T, conn *grpc.ClientConn,
+	sessionKeyStore *testStorer, g *schnorr.Group, pk *psys.PubKey,
+	regKeyPrefix string) {
 
 	caClient := psys.NewCAClient(g)
 
@@ -94,7 +111,7 @@ func testEndToEndPsys(t *testing.T, conn *grpc.ClientConn, g *schnorr.Group,
 	_, err = c1.GenerateNym(userSecret, caCert, "029uywfh9udni")
 	assert.NotNil(t, err, "Should produce an error")
 
-	regKey := "key1"
+	regKey := regKeyPrefix + "1"
 	regKeyDB.Insert(regKey)
 	nym1, err := c1.GenerateNym(userSecret, caCert, regKey)
 	if err != nil {
@@ -123,7 +140,7 @@ func testEndToEndPsys(t *testing.T, conn *grpc.ClientConn, g *schnorr.Group,
 	// using transferCredential to authenticate with the same organization and not
 	// transferring credentials to another organization
 	c2, err := psys.NewClient(conn, g)
-	regKey = "key2"
+	regKey = regKeyPrefix + "2"
 	regKeyDB.Insert(regKey)
 	nym2, err := c2.GenerateNym(userSecret, caCert1, regKey)
 	if err != nil {
@@ -132,8 +149,11 @@ func testEndToEndPsys(t *testing.T, conn *grpc.ClientConn, g *schnorr.Group,
 
 	// Authentication should succeed
 	sessKey, err := c2.TransferCredential(orgName, userSecret, nym2, cred)
-	assert.NotNil(t, sessKey, "Should authenticate and obtain a valid (non-nil) session key")
+	require.NotNil(t, sessKey, "Should authenticate and obtain a valid (non-nil) session key")
 	assert.Nil(t, err, "Should not produce an error")
+	sess := sessionKeyStore.session(*sessKey)
+	require.NotNil(t, sess)
+	assert.Equal(t, anauth.SchemePsys, sess.Scheme)
 
 	// Authentication should fail because the user doesn't have the right secret
 	wrongUserSecret := big.NewInt(3952123123)
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/emmyzkp/crypto/ec"
+	"github.com/emmyzkp/crypto/schnorr"
+	"github.com/emmyzkp/emmy/anauth/cl"
+	"github.com/emmyzkp/emmy/anauth/ecpsys"
+	"github.com/emmyzkp/emmy/anauth/psys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passphrase() ([]byte, error) {
+	return []byte("passphrase"), nil
+}
+
+func TestKeyFiles_Psys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-psys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	g, err := schnorr.NewGroup(160)
+	require.NoError(t, err)
+	sk, pk := psys.GenerateKeyPair(g)
+	pubPath, secPath := path.Join(dir, "pubkey"), path.Join(dir, "seckey")
+	require.NoError(t, psys.WriteKeys(pubPath, secPath, g, sk, pk, nil))
+
+	readG, readSk, readPk, err := psys.ReadKeys(pubPath, secPath, nil)
+	require.NoError(t, err)
+	assert.Equal(t, g, readG)
+	assert.Equal(t, sk, readSk)
+	assert.Equal(t, pk, readPk)
+
+	// secret key of another key pair
+	otherSk, otherPk := psys.GenerateKeyPair(g)
+	otherPubPath := path.Join(dir, "other_pubkey")
+	otherSecPath := path.Join(dir, "other_seckey")
+	require.NoError(t, psys.WriteKeys(otherPubPath, otherSecPath, g, otherSk,
+		otherPk, nil))
+	_, _, _, err = psys.ReadKeys(pubPath, otherSecPath, nil)
+	assert.Error(t, err)
+
+	_, _, err = ecpsys.ReadPubKey(pubPath)
+	assert.Error(t, err, "psys key read as ecpsys key")
+}
+
+func TestKeyFiles_ECPsys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-ecpsys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sk, pk := ecpsys.GenerateKeyPair(ec.NewGroup(ec.P384))
+	pubPath, secPath := path.Join(dir, "pubkey"), path.Join(dir, "seckey")
+	require.NoError(t, ecpsys.WriteKeys(pubPath, secPath, ec.P384, sk, pk,
+		[]byte("passphrase")))
+
+	_, _, _, err = ecpsys.ReadKeys(pubPath, secPath, nil)
+	assert.Error(t, err, "encrypted key read without passphrase")
+
+	c, readSk, readPk, err := ecpsys.ReadKeys(pubPath, secPath, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, ec.P384, c)
+	assert.Equal(t, sk, readSk)
+	assert.Equal(t, pk, readPk)
+
+	// secret key of another key pair
+	otherSk, _ := ecpsys.GenerateKeyPair(ec.NewGroup(ec.P384))
+	otherPubPath := path.Join(dir, "other_pubkey")
+	otherSecPath := path.Join(dir, "other_seckey")
+	require.NoError(t, ecpsys.WriteKeys(otherPubPath, otherSecPath, ec.P384,
+		otherSk, pk, nil))
+	_, _, _, err = ecpsys.ReadKeys(pubPath, otherSecPath, nil)
+	assert.Error(t, err)
+}
+
+func TestKeyFiles_Legacy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-psys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sk, pk := ecpsys.GenerateKeyPair(ec.NewGroup(ec.P256))
+	pubPath, secPath := path.Join(dir, "pubkey"), path.Join(dir, "seckey")
+	require.NoError(t, cl.WriteGob(pubPath, pk))
+	require.NoError(t, cl.WriteGob(secPath, sk))
+
+	c, readSk, readPk, err := ecpsys.ReadKeys(pubPath, secPath, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ec.Curve(0), c)
+	assert.Equal(t, sk, readSk)
+	assert.Equal(t, pk, readPk)
+
+	g, err := schnorr.NewGroup(160)
+	require.NoError(t, err)
+	psysSk, psysPk := psys.GenerateKeyPair(g)
+	require.NoError(t, cl.WriteGob(pubPath, psysPk))
+	require.NoError(t, cl.WriteGob(secPath, psysSk))
+
+	readG, readPsysSk, readPsysPk, err := psys.ReadKeys(pubPath, secPath, nil)
+	require.NoError(t, err)
+	assert.Nil(t, readG)
+	assert.Equal(t, psysSk, readPsysSk)
+	assert.Equal(t, psysPk, readPsysPk)
+}
+
+func TestKeyFiles_CA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "emmy-psys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sk, pk, err := psys.GenerateCAKeyPair(ec.P256)
+	require.NoError(t, err)
+	pubPath, secPath := path.Join(dir, "ca_pubkey"), path.Join(dir, "ca_seckey")
+	require.NoError(t, psys.WriteCAKeys(pubPath, secPath, ec.P256, sk, pk,
+		[]byte("passphrase")))
+
+	info, err := os.Stat(secPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	c, readSk, readPk, err := psys.ReadCAKeys(pubPath, secPath, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, ec.P256, c)
+	assert.Equal(t, sk, readSk)
+	assert.Equal(t, pk, readPk)
+
+	_, _, _, err = psys.ReadCAKeys(pubPath, secPath,
+		func() ([]byte, error) { return []byte("wrong"), nil })
+	assert.Error(t, err)
+
+	// organization keys are not CA keys
+	_, _, err = psys.ReadPubKey(pubPath)
+	assert.Error(t, err)
+}
@@ -48,6 +48,18 @@ var testRedis = flag.Bool(
 	"whether to use a real redis server in integration test",
 )
 
+var testNClients = flag.Int(
+	"nclients",
+	5,
+	"how many clients to run in tests with several clients",
+)
+
+var testConcurrent = flag.Bool(
+	"concurrent",
+	true,
+	"whether to run clients concurrently in tests with several clients",
+)
+
 // getTestSecureConn establishes a connection to previously started server.
 func getTestSecureConn() (*grpc.ClientConn, error) {
 	testCert, err := ioutil.ReadFile("testdata/server.pem")
@@ -94,6 +106,25 @@ func (s *testSrv) addService(as anauth.Service) {
 	as.RegisterTo(s.Server)
 }
 
+// runClients runs nClients subtests of t, each executing f. If
+// concurrent is true, the subtests run in parallel, otherwise they
+// run one after another. runClients returns once all the
+// subtests are done.
+func runClients(t *testing.T, nClients int, concurrent bool,
+	f func(t *testing.T, i int)) {
+	t.Run("clients", func(t *testing.T) {
+		for i := 0; i < nClients; i++ {
+			i := i
+			t.Run(fmt.Sprintf("client%d", i), func(t *testing.T) {
+				if concurrent {
+					t.Parallel()
+				}
+				f(t, i)
+			})
+		}
+	})
+}
+
 // start starts testSrv
 func (s *testSrv) start() {
 	lis, err := net.Listen("tcp", testAddr)
@@ -108,10 +139,12 @@ func (s *testSrv) teardown() {
 	s.Server.Stop()
 }
 
-// testDb allows us to insert registration keys.
+// testDb allows us to insert registration keys, optionally
+// together with attribute values.
 type testDb interface {
 	Insert(key string)
-	CheckRegistrationKey(key string) (bool, error)
+	InsertWithData(key string, attrs map[string]interface{})
+	anauth.AttrRegManager
 }
 
 // testRedisClient wraps anauth.RedisClient and extends it
@@ -124,6 +157,11 @@ func (c *testRedisClient) Insert(key string) {
 	c.Client.Set(key, key, 0)
 }
 
+func (c *testRedisClient) InsertWithData(key string,
+	attrs map[string]interface{}) {
+	c.RegisterKey(key, attrs)
+}
+
 // TODO TestMain should determine, based on flags,
 // which anonymous authentication services it should test
 
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/emmyzkp/emmy/anauth"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// passphraseEnv is the environment variable that the passphrase of
+// secret key files can be provided with.
+const passphraseEnv = "EMMY_KEY_PASSPHRASE"
+
+// addPassphraseFlags adds flags that configure the passphrase of
+// secret key files to cmd and its subcommands.
+func addPassphraseFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("passphrase-file", "",
+		"Path to the file holding the passphrase of secret keys, "+
+			"instead of "+passphraseEnv+" or a prompt")
+}
+
+// passphraseGiven reports whether the passphrase of secret key files
+// is given to cmd with a file or an environment variable.
+func passphraseGiven(cmd *cobra.Command) bool {
+	file, _ := cmd.Flags().GetString("passphrase-file")
+	_, env := os.LookupEnv(passphraseEnv)
+
+	return file != "" || env
+}
+
+// readPassphrase returns the passphrase of secret key files. It is
+// read from the file given with flag --passphrase-file, from
+// environment variable EMMY_KEY_PASSPHRASE or, if neither is given,
+// from the terminal. When reading from the terminal, confirm asks for
+// the passphrase twice.
+func readPassphrase(cmd *cobra.Command, confirm bool) ([]byte, error) {
+	var passphrase []byte
+	if file, _ := cmd.Flags().GetString("passphrase-file"); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read passphrase: %v", err)
+		}
+		passphrase = bytes.TrimRight(data, "\r\n")
+	} else if env, ok := os.LookupEnv(passphraseEnv); ok {
+		passphrase = []byte(env)
+	} else {
+		var err error
+		if passphrase, err = promptPassphrase(confirm); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase is empty")
+	}
+
+	return passphrase, nil
+}
+
+// promptPassphrase reads the passphrase from the terminal, without
+// echoing it.
+func promptPassphrase(confirm bool) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+
+	fmt.Fprint(os.Stderr, "Passphrase for secret keys: ")
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read passphrase (use "+
+			"--passphrase-file or %s): %v", passphraseEnv, err)
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+	repeated, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read passphrase: %v", err)
+	}
+	if !bytes.Equal(passphrase, repeated) {
+		return nil, fmt.Errorf("passphrases do not match")
+	}
+
+	return passphrase, nil
+}
+
+// passphraseFunc returns a function that reads the passphrase of
+// secret key files for cmd (see readPassphrase), for reading keys that
+// may be encrypted. The passphrase is only read once, so that the user
+// is prompted once for all the keys of a command.
+func passphraseFunc(cmd *cobra.Command) anauth.PassphraseFunc {
+	var passphrase []byte
+	return func() ([]byte, error) {
+		if passphrase != nil {
+			return passphrase, nil
+		}
+
+		var err error
+		passphrase, err = readPassphrase(cmd, false)
+		return passphrase, err
+	}
+}
+
+// secKeyPassphrase returns the passphrase that secret keys generated by
+// cmd are encrypted with, or nil if they are not to be encrypted.
+// Keys are encrypted with flag --encrypt, or when the passphrase is
+// given with a file or an environment variable.
+func secKeyPassphrase(cmd *cobra.Command) ([]byte, error) {
+	encrypt, _ := cmd.Flags().GetBool("encrypt")
+	if !encrypt && !passphraseGiven(cmd) {
+		return nil, nil
+	}
+
+	return readPassphrase(cmd, true)
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2017 XLAB d.o.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/go-redis/redis"
+	"github.com/spf13/cobra"
+
+	"github.com/emmyzkp/emmy/anauth"
+	"github.com/emmyzkp/emmy/anauth/cl"
+)
+
+// clAccumulatorStore returns the store of the CL revocation
+// accumulator of public key pk, which keeps the log of updates
+// of the accumulator at redis key cl_accumulator:<id>, where id is
+// the identifier of pk.
+func clAccumulatorStore(c *redis.Client,
+	pk *cl.PubKey) *cl.RedisAccumulatorStore {
+	return cl.NewRedisAccumulatorStore(c, "cl_accumulator:"+pk.ID())
+}
+
+var revokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revokes credentials issued by emmy server",
+}
+
+var revokeCLCmd = &cobra.Command{
+	Use: "cl",
+	Short: "Revokes a credential issued with Camenisch-Lysyanskaya" +
+		" scheme to the given nym.",
+	Run: func(cmd *cobra.Command, args []string) {
+		nymStr, _ := cmd.Flags().GetString("nym")
+		nym, ok := new(big.Int).SetString(nymStr, 10)
+		if !ok {
+			fmt.Println("nym must be a decimal number")
+			os.Exit(1)
+		}
+
+		// flag is not bound to viper, as key db is bound to the flag
+		// of the server command
+		db, _ := cmd.Flags().GetString("db")
+		client := redis.NewClient(&redis.Options{
+			Addr: db,
+		})
+		if err := client.Ping().Err(); err != nil {
+			fmt.Println("cannot connect to redis:", err)
+			os.Exit(1)
+		}
+
+		recMgr := cl.NewRedisClient(client)
+		rec, err := recMgr.Load(nym)
+		if err != nil {
+			fmt.Println("cannot load receiver record:", err)
+			os.Exit(1)
+		}
+
+		keys, err := recordCLKeys(rec, passphraseFunc(cmd))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		params := keys.Params
+		if params == nil {
+			params = cl.GetLegacyParamSizes()
+		}
+		org, err := cl.NewOrgFromParams(params, keys)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		revoker, err := cl.NewRevoker(org,
+			clAccumulatorStore(client, keys.Pub))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := revoker.RevokeNym(recMgr, nym); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Successfully revoked credential")
+	},
+}
+
+// recordCLKeys returns the CL keys that the credential with receiver
+// record rec was issued with. Records without a key identifier belong
+// to the keys that were retired first, or to the active keys if no keys
+// were retired.
+func recordCLKeys(rec *cl.ReceiverRecord,
+	passphrase anauth.PassphraseFunc) (*cl.KeyPair, error) {
+	retired, err := readRetiredCLKeys(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldest *cl.KeyPair
+	for _, k := range retired {
+		if rec.KeyID == "" {
+			if oldest == nil || k.RetiredAt.Before(oldest.RetiredAt) {
+				oldest = k
+			}
+		} else if k.Pub.ID() == rec.KeyID {
+			return k, nil
+		}
+	}
+	if oldest != nil {
+		return oldest, nil
+	}
+
+	keys, err := readCLKeys(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if rec.KeyID != "" && keys.Pub.ID() != rec.KeyID {
+		return nil, fmt.Errorf("credential was issued with unknown key %s",
+			rec.KeyID)
+	}
+
+	return keys, nil
+}
+
+func init() {
+	rootCmd.AddCommand(revokeCmd)
+	revokeCmd.AddCommand(revokeCLCmd)
+
+	revokeCmd.PersistentFlags().StringP("db", "",
+		"localhost:6379",
+		"URI of redis database holding receiver records and the"+
+			" accumulator, in the form redisHost:redisPort")
+	revokeCLCmd.Flags().String("nym", "", "Nym of the credential holder")
+	addPassphraseFlags(revokeCmd)
+}
@@ -21,14 +21,22 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/go-redis/redis"
 
+	"github.com/emmyzkp/crypto/ec"
+	"github.com/emmyzkp/crypto/schnorr"
 	"github.com/emmyzkp/emmy/anauth"
 	"github.com/emmyzkp/emmy/anauth/cl"
+	pb "github.com/emmyzkp/emmy/anauth/cl/clpb"
+	"github.com/emmyzkp/emmy/anauth/ecpsys"
+	"github.com/emmyzkp/emmy/anauth/psys"
 	"github.com/emmyzkp/emmy/log"
 )
 
@@ -57,10 +65,35 @@ func init() {
 	genCLCmd.Flags().Int("known", 0, "Number of known attributes")
 	genCLCmd.Flags().Int("committed", 0, "Number of committed attributes")
 	genCLCmd.Flags().Int("hidden", 0, "Number of hidden attributes")
+	genCLCmd.Flags().String("params", cl.Profile2048,
+		fmt.Sprintf("Parameters profile, one of %v", cl.ParamsProfiles()))
+	genCLCmd.Flags().Bool("rotate", false,
+		"Replace the existing keypair with a new one, keeping the"+
+			" existing keypair as retired")
+
+	serverCLCmd.Flags().Bool("allow-insecure-params", false,
+		"Allow parameters that are insecure outside of tests")
+	serverCLVerifierCmd.Flags().String("trusted-issuers", "",
+		"Directory with public keys of trusted issuers (cl_trusted in"+
+			" emmy directory by default)")
+	serverCLVerifierCmd.Flags().Bool("allow-insecure-params", false,
+		"Allow parameters of trusted issuers that are insecure outside"+
+			" of tests")
+
+	genPsysCmd.Flags().Int("qbitlen", 256,
+		"Bit length of the order of the schnorr group")
+	genECPsysCmd.Flags().String("curve", psys.CurveName(ec.P256),
+		"Elliptic curve, one of P-224, P-256, P-384 and P-521")
+
+	genCmd.PersistentFlags().Bool("encrypt", false,
+		"Encrypt secret keys with a passphrase")
+	addPassphraseFlags(genCmd)
+	addPassphraseFlags(serverCmd)
 
 	// add subcommands tied to various anonymous authentication schemes
-	genCmd.AddCommand(genCLCmd)
-	serverCmd.AddCommand(serverCLCmd, serverPsysCmd, serverECPsysCmd)
+	genCmd.AddCommand(genCLCmd, genPsysCmd, genECPsysCmd)
+	serverCmd.AddCommand(serverCLCmd, serverCLVerifierCmd, serverPsysCmd,
+		serverECPsysCmd)
 
 	viper.BindPFlag("port", serverCmd.PersistentFlags().Lookup("port"))
 	viper.BindPFlag("db", serverCmd.PersistentFlags().Lookup("db"))
@@ -70,6 +103,10 @@ func init() {
 	viper.BindPFlag("cl_n_known", genCLCmd.Flags().Lookup("known"))
 	viper.BindPFlag("cl_n_committed", genCLCmd.Flags().Lookup("committed"))
 	viper.BindPFlag("cl_n_hidden", genCLCmd.Flags().Lookup("hidden"))
+	viper.BindPFlag("cl_allow_insecure_params",
+		serverCLCmd.Flags().Lookup("allow-insecure-params"))
+	viper.BindPFlag("cl_trusted_issuers",
+		serverCLVerifierCmd.Flags().Lookup("trusted-issuers"))
 
 	viper.SetEnvPrefix("EMMY")
 	viper.BindEnv("port", "EMMY_SERVER_PORT")
@@ -77,9 +114,19 @@ func init() {
 	viper.BindEnv("cert", "EMMY_TLS_CERT")
 	viper.BindEnv("key", "EMMY_TLS_KEY")
 	viper.BindEnv("cl_attrs_bitlen", "EMMY_CL_ATTRS_BITLEN")
+	viper.BindEnv("cl_params", "EMMY_CL_PARAMS")
+	viper.BindEnv("cl_allow_insecure_params", "EMMY_CL_ALLOW_INSECURE_PARAMS")
+	viper.BindEnv("cl_scope", "EMMY_CL_SCOPE")
+	viper.BindEnv("cl_verifier_id", "EMMY_CL_VERIFIER_ID")
+	viper.BindEnv("cl_schema", "EMMY_CL_SCHEMA")
 	viper.BindEnv("cl_n_known", "EMMY_CL_N_KNOWN")
 	viper.BindEnv("cl_n_committed", "EMMY_CL_N_COMMITTED")
 	viper.BindEnv("cl_n_hidden", "EMMY_CL_N_HIDDEN")
+	viper.BindEnv("cl_key_grace_period", "EMMY_CL_KEY_GRACE_PERIOD")
+	viper.BindEnv("cl_trusted_issuers", "EMMY_CL_TRUSTED_ISSUERS")
+	viper.BindEnv("session_ttl", "EMMY_SESSION_TTL")
+	viper.SetDefault("cl_key_grace_period", 30*24*time.Hour)
+	viper.SetDefault("session_ttl", 24*time.Hour)
 }
 
 var genCmd = &cobra.Command{
@@ -93,25 +140,76 @@ var genCLCmd = &cobra.Command{
 	Short:      "Generates and stores keypair for the scheme.",
 	SuggestFor: []string{"cl"},
 	Run: func(cmd *cobra.Command, args []string) {
-		keys, err := cl.GenerateKeyPair(cl.GetDefaultParamSizes(),
-			cl.NewAttrCount(
-				viper.GetInt("cl_n_known"),
-				viper.GetInt("cl_n_committed"),
-				viper.GetInt("cl_n_hidden"),
-			),
+		// flag is not bound to viper, as cl_params configures
+		// the server for keys stored without parameters
+		profile, _ := cmd.Flags().GetString("params")
+		params, err := cl.GetParamsProfile(profile, 0)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := cl.CheckParamsSecurity(params); err != nil {
+			fmt.Println("WARNING: generating keys for testing only:", err)
+		}
+
+		attrCount := cl.NewAttrCount(
+			viper.GetInt("cl_n_known"),
+			viper.GetInt("cl_n_committed"),
+			viper.GetInt("cl_n_hidden"),
 		)
+
+		// the schema of credentials is stored with the keys when
+		// a schema file or attributes are configured, attribute counts
+		// follow from it
+		var schema *pb.CredStructure
+		if viper.IsSet("attributes") || viper.GetString("cl_schema") != "" {
+			schema, attrCount, err = cl.ParseSchema(viper.GetViper())
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Printf("generating keys for the configured attributes:\n%s",
+				attrCount)
+		}
+
+		// rotated keys keep the parameters and the schema of
+		// the existing keys
+		rotate, _ := cmd.Flags().GetBool("rotate")
+		var keys, prev *cl.KeyPair
+		// keys generated for a schema are bound to it
+		switch {
+		case rotate:
+			keys, prev, err = nextCLKeys()
+		case schema != nil:
+			keys, err = cl.GenerateKeyPairForSchema(params, schema)
+		default:
+			keys, err = cl.GenerateKeyPair(params, attrCount)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		passphrase, err := secKeyPassphrase(cmd)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		err = cl.WriteGob(path.Join(emmyDir, "cl_seckey"), keys.Sec)
+		if rotate {
+			if err := retireCLKeys(prev); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+
+		err = cl.WriteSecKey(path.Join(emmyDir, "cl_seckey"), keys, passphrase)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		err = cl.WriteGob(path.Join(emmyDir, "cl_pubkey"), keys.Pub)
+		err = cl.WritePubKey(path.Join(emmyDir, "cl_pubkey"), keys)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -121,6 +219,92 @@ var genCLCmd = &cobra.Command{
 	},
 }
 
+var genPsysCmd = &cobra.Command{
+	Use: "psys",
+	Short: "Generates and stores keypairs of the organization and the CA" +
+		" for the pseudonym system scheme in modular arithmetic.",
+	Run: func(cmd *cobra.Command, args []string) {
+		qBitLen, _ := cmd.Flags().GetInt("qbitlen")
+		group, err := schnorr.NewGroup(qBitLen)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		caSk, caPk, err := psys.GenerateCAKeyPair(psys.CA_CURVE)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		sk, pk := psys.GenerateKeyPair(group)
+
+		passphrase, err := secKeyPassphrase(cmd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		err = psys.WriteCAKeys(path.Join(emmyDir, "psys_ca_pubkey"),
+			path.Join(emmyDir, "psys_ca_seckey"), psys.CA_CURVE, caSk, caPk,
+			passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		err = psys.WriteKeys(path.Join(emmyDir, "psys_pubkey"),
+			path.Join(emmyDir, "psys_seckey"), group, sk, pk, passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Successfully generated keypairs")
+	},
+}
+
+var genECPsysCmd = &cobra.Command{
+	Use: "ecpsys",
+	Short: "Generates and stores keypairs of the organization and the CA" +
+		" for the pseudonym system scheme in EC arithmetic.",
+	Run: func(cmd *cobra.Command, args []string) {
+		curveName, _ := cmd.Flags().GetString("curve")
+		curve, err := psys.CurveByName(curveName)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		caSk, caPk, err := psys.GenerateCAKeyPair(curve)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		sk, pk := ecpsys.GenerateKeyPair(ec.NewGroup(curve))
+
+		passphrase, err := secKeyPassphrase(cmd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		err = psys.WriteCAKeys(path.Join(emmyDir, "ecpsys_ca_pubkey"),
+			path.Join(emmyDir, "ecpsys_ca_seckey"), curve, caSk, caPk,
+			passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		err = ecpsys.WriteKeys(path.Join(emmyDir, "ecpsys_pubkey"),
+			path.Join(emmyDir, "ecpsys_seckey"), curve, sk, pk, passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Successfully generated keypairs")
+	},
+}
+
 // serverCmd represents the server command
 var serverCmd = &cobra.Command{
 	Use:   "server",
@@ -157,43 +341,117 @@ var serverCLCmd = &cobra.Command{
 	Short: "Configures the server to run Camenisch-Lysyanskaya scheme for" +
 		" anonymous authentication.",
 	Run: func(cmd *cobra.Command, args []string) {
-		var sk cl.SecKey
-		var pk cl.PubKey
-
-		err := cl.ReadGob(path.Join(emmyDir, "cl_seckey"), &sk)
+		keys, err := readCLKeys(passphraseFunc(cmd))
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		err = cl.ReadGob(path.Join(emmyDir, "cl_pubkey"), &pk)
+
+		redis := newRedisClient()
+
+		// receiver records are kept in redis, so that credentials
+		// can be revoked with 'emmy revoke cl'
+		clService, err := cl.NewServer(cl.NewRedisClient(redis.Client),
+			keys, viper.GetViper())
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		redis := anauth.NewRedisClient(redis.NewClient(&redis.Options{
-			Addr: viper.GetString("db"),
-		}))
-		if err := redis.Ping().Err(); err != nil {
-			fmt.Println("cannot connect to redis:", err)
-			os.Exit(1)
+		revocation := keys.Pub.SupportsRevocation()
+		if revocation {
+			err := clService.EnableRevocation(
+				clAccumulatorStore(redis.Client, keys.Pub))
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		} else {
+			fmt.Println("keys do not support revocation, " +
+				"revocation is disabled")
 		}
 
-		clService, err := cl.NewServer(
-			cl.NewMockRecordManager(), // TODO redis
-			&cl.KeyPair{
-				Sec: &sk,
-				Pub: &pk,
-			}, viper.GetViper())
+		// credentials issued with retired keys are accepted for
+		// the grace period after the keys were retired
+		retired, err := readRetiredCLKeys(passphraseFunc(cmd))
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
+		grace := viper.GetDuration("cl_key_grace_period")
+		for _, k := range retired {
+			expires := k.RetiredAt.Add(grace)
+			if time.Now().After(expires) {
+				continue
+			}
+			var store cl.AccumulatorStore
+			if revocation {
+				store = clAccumulatorStore(redis.Client, k.Pub)
+			}
+			if err := clService.AddRetiredKeys(k, expires, store); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Printf("accepting credentials issued with key %s until %s\n",
+				k.Pub.ID(), expires.Format(time.RFC3339))
+		}
+
+		// credentials of other issuers are accepted together with
+		// credentials of the server if trusted issuers are configured
+		if dir := viper.GetString("cl_trusted_issuers"); dir != "" {
+			keyring, err := cl.ReadKeyring(dir)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			keyring.SetGracePeriod(viper.GetDuration("cl_key_grace_period"))
+			clService.TrustedIssuers = keyring
+		}
 
 		// FIXME
 		clService.RegMgr = redis
 		clService.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
-		clService.SessStorer = anauth.NewRedisSessStorer(redis.Client)
+		clService.SessStorer = newSessStorer(redis)
+		clService.DataFetcher = cl.NewRedisDataFetcher(redis.Client)
+
+		srv.RegisterService(clService)
+	},
+}
+
+var serverCLVerifierCmd = &cobra.Command{
+	Use: "cl-verifier",
+	Short: "Configures the server to only verify Camenisch-Lysyanskaya" +
+		" credentials issued by trusted issuers.",
+	Long: `cl-verifier verifies credentials issued by the trusted issuers
+whose public keys are kept in the directory of trusted issuers, as
+<name>.pub files, with optional <name>.yml schema files specifying
+the conditions that are checked. It holds no secret keys, and does not
+issue nor update credentials.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		insecure, _ := cmd.Flags().GetBool("allow-insecure-params")
+		if insecure {
+			viper.Set("cl_allow_insecure_params", true)
+		}
+		dir := viper.GetString("cl_trusted_issuers")
+		if dir == "" {
+			dir = path.Join(emmyDir, "cl_trusted")
+		}
+		keyring, err := cl.ReadKeyring(dir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		keyring.SetGracePeriod(viper.GetDuration("cl_key_grace_period"))
+
+		clService, err := cl.NewVerifierServer(keyring, viper.GetViper())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		redis := newRedisClient()
+		clService.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
+		clService.SessStorer = newSessStorer(redis)
 		clService.DataFetcher = cl.NewRedisDataFetcher(redis.Client)
 
 		srv.RegisterService(clService)
@@ -205,7 +463,34 @@ var serverPsysCmd = &cobra.Command{
 	Short: "Configures the server to run pseudonym system scheme for" +
 		" anonymous authentication. Uses modular arithmetic.",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("running psys server")
+		passphrase := passphraseFunc(cmd)
+		_, caSk, caPk, err := psys.ReadCAKeys(
+			path.Join(emmyDir, "psys_ca_pubkey"),
+			path.Join(emmyDir, "psys_ca_seckey"), passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		group, sk, pk, err := psys.ReadKeys(path.Join(emmyDir, "psys_pubkey"),
+			path.Join(emmyDir, "psys_seckey"), passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if group == nil {
+			fmt.Println("keys are stored without their group, " +
+				"generate them again with 'emmy generate psys'")
+			os.Exit(1)
+		}
+
+		redis := newRedisClient()
+		org := psys.NewOrgServer(group, sk, pk, caPk)
+		org.RegMgr = redis
+		org.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
+		org.SessStorer = newSessStorer(redis)
+
+		srv.RegisterService(psys.NewCAServer(group, caSk, caPk))
+		srv.RegisterService(org)
 	},
 }
 
@@ -214,6 +499,154 @@ var serverECPsysCmd = &cobra.Command{
 	Short: "Configures the server to run pseudonym system scheme for" +
 		" anonymous authentication. Uses EC arithmetic.",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("running ecpsys server")
+		passphrase := passphraseFunc(cmd)
+		caCurve, caSk, caPk, err := psys.ReadCAKeys(
+			path.Join(emmyDir, "ecpsys_ca_pubkey"),
+			path.Join(emmyDir, "ecpsys_ca_seckey"), passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		curve, sk, pk, err := ecpsys.ReadKeys(
+			path.Join(emmyDir, "ecpsys_pubkey"),
+			path.Join(emmyDir, "ecpsys_seckey"), passphrase)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if curve == 0 {
+			curve = caCurve
+		}
+		if curve != caCurve {
+			fmt.Println("keys of the organization and the CA use " +
+				"different curves")
+			os.Exit(1)
+		}
+
+		redis := newRedisClient()
+		org := ecpsys.NewOrgServer(curve, sk, pk, caPk)
+		org.RegMgr = redis
+		org.SessMgr, _ = anauth.NewRandSessionKeyGen(32)
+		org.SessStorer = newSessStorer(redis)
+
+		srv.RegisterService(ecpsys.NewCAServer(caSk, caPk, curve))
+		srv.RegisterService(org)
 	},
 }
+
+// newRedisClient connects to the redis database holding registration
+// keys, exiting if it is not reachable.
+func newRedisClient() *anauth.RedisClient {
+	redis := anauth.NewRedisClient(redis.NewClient(&redis.Options{
+		Addr: viper.GetString("db"),
+	}))
+	if err := redis.Ping().Err(); err != nil {
+		fmt.Println("cannot connect to redis:", err)
+		os.Exit(1)
+	}
+
+	return redis
+}
+
+// newSessStorer returns a session storer keeping sessions in redis
+// for session_ttl.
+func newSessStorer(redis *anauth.RedisClient) *anauth.RedisSessStorer {
+	return anauth.NewRedisSessStorer(redis.Client,
+		viper.GetDuration("session_ttl"))
+}
+
+// readCLKeys reads the keypair for the CL scheme from emmy directory,
+// decrypting the secret key with passphrase if needed.
+// nextCLKeys reads the existing CL public key, and generates keys that
+// replace it, with the same parameters and attributes. It returns the
+// new keys and the existing ones.
+func nextCLKeys() (*cl.KeyPair, *cl.KeyPair, error) {
+	prev, err := cl.ReadPubKey(path.Join(emmyDir, "cl_pubkey"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if prev.Params == nil {
+		return nil, nil, fmt.Errorf("keys stored without parameters" +
+			" cannot be rotated")
+	}
+
+	attrCount := cl.NewAttrCount(len(prev.Pub.RsKnown),
+		len(prev.Pub.RsCommitted), len(prev.Pub.RsHidden))
+	keys, err := cl.GenerateNextKeyPair(prev.Params, attrCount, prev.Pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys.Schema = prev.Schema
+
+	return keys, prev, nil
+}
+
+// retireCLKeys keeps the existing CL keys prev in files cl_pubkey.<id>
+// and cl_seckey.<id>, where id is the identifier of the public key,
+// and marks them as retired.
+func retireCLKeys(prev *cl.KeyPair) error {
+	id := prev.Pub.ID()
+	prev.RetiredAt = time.Now()
+	err := cl.WritePubKey(path.Join(emmyDir, "cl_pubkey."+id), prev)
+	if err != nil {
+		return err
+	}
+	secKeyPath := path.Join(emmyDir, "cl_seckey")
+	if err := os.Rename(secKeyPath, secKeyPath+"."+id); err != nil {
+		return err
+	}
+	fmt.Println("Retired keypair", id)
+
+	return nil
+}
+
+func readCLKeys(passphrase anauth.PassphraseFunc) (*cl.KeyPair, error) {
+	keys, err := cl.ReadKeyPair(path.Join(emmyDir, "cl_pubkey"),
+		path.Join(emmyDir, "cl_seckey"), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	// legacy gob keys may have their parameters stored separately
+	paramsPath := path.Join(emmyDir, "cl_params")
+	if _, err := os.Stat(paramsPath); err == nil && keys.Params == nil {
+		keys.Params = new(pb.Params)
+		if err := cl.ReadGob(paramsPath, keys.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// readRetiredCLKeys reads the retired CL keys (see rotateCLKeys),
+// together with their secret keys if present.
+func readRetiredCLKeys(passphrase anauth.PassphraseFunc) ([]*cl.KeyPair,
+	error) {
+	pubKeyPaths, err := filepath.Glob(path.Join(emmyDir, "cl_pubkey.*"))
+	if err != nil {
+		return nil, err
+	}
+
+	retired := make([]*cl.KeyPair, 0, len(pubKeyPaths))
+	for _, p := range pubKeyPaths {
+		id := strings.TrimPrefix(path.Base(p), "cl_pubkey.")
+		secKeyPath := path.Join(emmyDir, "cl_seckey."+id)
+
+		var keys *cl.KeyPair
+		if _, err := os.Stat(secKeyPath); err == nil {
+			keys, err = cl.ReadKeyPair(p, secKeyPath, passphrase)
+		} else {
+			keys, err = cl.ReadPubKey(p)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if keys.RetiredAt.IsZero() {
+			return nil, fmt.Errorf("key in %s is not retired", p)
+		}
+		retired = append(retired, keys)
+	}
+
+	return retired, nil
+}
@@ -14,7 +14,7 @@ require (
 	github.com/spf13/cobra v0.0.3
 	github.com/spf13/viper v1.3.1
 	github.com/stretchr/testify v1.2.2
-	golang.org/x/crypto v0.0.0-20190325154230-a5d413f7728c // indirect
+	golang.org/x/crypto v0.0.0-20190325154230-a5d413f7728c
 	golang.org/x/exp v0.0.0-20190321205749-f0864edee7f3 // indirect
 	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
 	golang.org/x/mobile v0.0.0-20190319155245-9487ef54b94a // indirect
@@ -22,6 +22,7 @@ require (
 	golang.org/x/sys v0.0.0-20190322080309-f49334f85ddc // indirect
 	golang.org/x/tools v0.0.0-20190325223049-1d95b17f1b04 // indirect
 	google.golang.org/grpc v1.19.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 replace golang.org/x/text v0.3.0 => github.com/golang/text v0.3.0
@@ -17,37 +17,156 @@
 
 package mock
 
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/emmyzkp/emmy/anauth"
+)
+
 // RegKeyDB mocks storage of registration keys. It is a
-// slice that will hold the keys.
+// slice that will hold the keys. It is safe for concurrent use.
 type RegKeyDB struct {
-	data []string
+	data         []string
+	attrs        map[string]map[string]interface{}
+	reservations map[string]reservation
+	mu           sync.Mutex
+}
+
+// reservation of a registration key.
+type reservation struct {
+	token   string
+	expires time.Time
 }
 
 // insert inserts a registration key to RegKeyDB,
 // if it's not already present.
 func (m *RegKeyDB) Insert(key string) {
-	alreadyPresent := false
-	for _, k := range m.data {
-		if k == key {
-			alreadyPresent = true
-			break
+	m.InsertWithData(key, nil)
+}
+
+// InsertWithData inserts a registration key to RegKeyDB together
+// with the values of attributes in attrs, if the key is not
+// already present.
+func (m *RegKeyDB) InsertWithData(key string, attrs map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index(key) >= 0 {
+		return
+	}
+	m.data = append(m.data, key)
+	if attrs != nil {
+		if m.attrs == nil {
+			m.attrs = make(map[string]map[string]interface{})
 		}
+		m.attrs[key] = attrs
 	}
-	if !alreadyPresent {
-		m.data = append(m.data, key)
+}
+
+// index returns the index of key in the slice, or -1 if the key is
+// not present.
+func (m *RegKeyDB) index(key string) int {
+	for i, k := range m.data {
+		if k == key {
+			return i
+		}
 	}
+
+	return -1
 }
 
 // CheckRegistrationKey checks for the presence of registration
 // key key, removing it and returning success if it was present.
 // If the key is not present in the slice, it returns false.
 func (m *RegKeyDB) CheckRegistrationKey(key string) (bool, error) {
-	for i, regKey := range m.data {
-		if key == regKey {
-			m.data = append(m.data[:i], m.data[i+1:]...) // remove i
-			return true, nil
-		}
+	token, ok, err := m.ReserveRegistrationKey(key, time.Minute)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	return true, m.CommitRegistrationKey(key, token)
+}
+
+// ReserveRegistrationKey reserves registration key key for timeout, if
+// it is present and not reserved already.
+func (m *RegKeyDB) ReserveRegistrationKey(key string,
+	timeout time.Duration) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index(key) < 0 {
+		return "", false, nil
+	}
+	if r, ok := m.reservations[key]; ok && time.Now().Before(r.expires) {
+		return "", false, nil
+	}
+
+	token, err := anauth.NewReservationToken()
+	if err != nil {
+		return "", false, err
+	}
+	if m.reservations == nil {
+		m.reservations = make(map[string]reservation)
+	}
+	m.reservations[key] = reservation{
+		token:   token,
+		expires: time.Now().Add(timeout),
+	}
+
+	return token, true, nil
+}
+
+// holds reports whether the reservation of key made with token is
+// still valid.
+func (m *RegKeyDB) holds(key, token string) bool {
+	r, ok := m.reservations[key]
+	return ok && r.token == token && time.Now().Before(r.expires)
+}
+
+// CommitRegistrationKey removes registration key key reserved with
+// token.
+func (m *RegKeyDB) CommitRegistrationKey(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.holds(key, token) {
+		return anauth.ErrReservationNotHeld
+	}
+	delete(m.reservations, key)
+	if i := m.index(key); i >= 0 {
+		m.data = append(m.data[:i], m.data[i+1:]...) // remove i
+	}
+	delete(m.attrs, key)
+
+	return nil
+}
+
+// ReleaseRegistrationKey releases the reservation of registration key
+// key made with token.
+func (m *RegKeyDB) ReleaseRegistrationKey(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.holds(key, token) {
+		return anauth.ErrReservationNotHeld
+	}
+	delete(m.reservations, key)
+
+	return nil
+}
+
+// RegistrationKeyData returns the attribute values inserted with
+// registration key key.
+func (m *RegKeyDB) RegistrationKeyData(key string) (map[string]interface{},
+	error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index(key) < 0 {
+		return nil, fmt.Errorf("registration key %s is not present", key)
 	}
 
-	return false, nil
+	return m.attrs[key], nil
 }